@@ -3,10 +3,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"time"
 
+	"github.com/kramirez/documentos/internal/carga"
 	"github.com/kramirez/documentos/internal/documento"
 	"github.com/kramirez/documentos/pkg/bootstrap"
 	"github.com/kramirez/documentos/pkg/handler"
@@ -36,13 +39,49 @@ func main() {
 	solicitudesClient := httpclient.NewSolicitudClient(solicitudesServiceURL)
 	logger.Printf("Cliente de solicitudes configurado: %s", solicitudesServiceURL)
 
+	//Inicializar almacenamiento de archivos
+	store, err := bootstrap.InitStorage()
+	if err != nil {
+		log.Fatal("Error al inicializar el almacenamiento", err)
+	}
+
+	//Inicializar antivirus
+	sc, err := bootstrap.InitScanner()
+	if err != nil {
+		log.Fatal("Error al inicializar el antivirus", err)
+	}
+
+	//Cargar configuración de negocio
+	config, err := bootstrap.InitConfig()
+	if err != nil {
+		log.Fatal("Error al cargar la configuración", err)
+	}
+
 	//Inicializar capas
 	repo := documento.NewRepository(db)
-	service := documento.NewService(repo, logger, solicitudesClient)
+	escaneador := documento.NewEscaneador(repo, store, sc, logger)
+	escaneador.Iniciar(context.Background(), 2, 10*time.Minute)
+	service := documento.NewService(repo, logger, solicitudesClient, store, escaneador, config)
 	endpoint := documento.NewEndpoint(service)
 
+	//Purgar periódicamente los documentos eliminados hace más que el período de retención
+	purgador := documento.NewPurgador(repo, store, logger, bootstrap.InitPurgaConfig())
+	purgador.Iniciar(context.Background(), 24*time.Hour)
+
+	//Avisar diariamente los documentos próximos a vencer
+	avisador := documento.NewAvisadorVencimientos(repo, bootstrap.InitNotificador(logger), logger, bootstrap.InitVencimientosConfig())
+	avisador.Iniciar(context.Background(), 24*time.Hour)
+
+	//Inicializar cargas reanudables
+	cargaService, err := carga.NewService(carga.NewRepository(db), logger, service, solicitudesClient, bootstrap.InitCargaConfig(config.Politica))
+	if err != nil {
+		log.Fatal("Error al inicializar las cargas reanudables", err)
+	}
+	cargaService.IniciarLimpieza(context.Background(), time.Hour)
+	cargaEndpoint := carga.NewEndpoint(cargaService, config.URLPublica)
+
 	//Configurar rutas
-	router := handler.SetupRoutes(endpoint)
+	router := handler.SetupRoutes(endpoint, cargaEndpoint)
 
 	//Obtener puerto del servicio
 	port := os.Getenv("SERVICE_PORT")
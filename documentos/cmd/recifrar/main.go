@@ -0,0 +1,67 @@
+// Re-envuelve las claves de datos de todos los archivos con la clave maestra actual y cifra
+// los que se guardaron antes de activar el cifrado.
+// Para ejecutar: go run cmd/recifrar/main.go
+
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/kramirez/documentos/internal/documento"
+	"github.com/kramirez/documentos/pkg/bootstrap"
+	"github.com/kramirez/documentos/pkg/storage"
+)
+
+func main() {
+	//Iniciar Logger
+	logger := bootstrap.InitLogger()
+
+	//Cargar variables de entorno
+	bootstrap.InitEnv()
+
+	//Conectar a la base de datos
+	db, err := bootstrap.DBConnection()
+	if err != nil {
+		log.Fatal("Error al conectar a la base de datos", err)
+	}
+
+	//Inicializar almacenamiento de archivos
+	store, err := bootstrap.InitStorage()
+	if err != nil {
+		log.Fatal("Error al inicializar el almacenamiento", err)
+	}
+	cifrado, ok := store.(*storage.CifradoStorage)
+	if !ok {
+		log.Fatal("El cifrado no está configurado, defina CIFRADO_ARCHIVO_CLAVES o CIFRADO_CLAVE_MAESTRA")
+	}
+
+	ctx := context.Background()
+	claves, err := documento.NewRepository(db).GetClavesAlmacenamiento(ctx)
+	if err != nil {
+		log.Fatal("Error al obtener las claves de almacenamiento", err)
+	}
+
+	var recifrados, sinCambios, faltantes, errores int
+	for _, clave := range claves {
+		cambio, err := cifrado.Recifrar(ctx, clave)
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			logger.Printf("Advertencia: el archivo %s no existe en el almacenamiento", clave)
+			faltantes++
+		case err != nil:
+			logger.Printf("Error al re-cifrar el archivo %s: %v", clave, err)
+			errores++
+		case cambio:
+			recifrados++
+		default:
+			sinCambios++
+		}
+	}
+
+	logger.Printf("Re-cifrado terminado: %d archivos actualizados, %d ya usaban la clave actual, %d no encontrados, %d con errores", recifrados, sinCambios, faltantes, errores)
+	if errores > 0 {
+		log.Fatal("El re-cifrado terminó con errores, puede volver a ejecutarse de forma segura")
+	}
+}
@@ -0,0 +1,39 @@
+package carga
+
+import (
+	"time"
+)
+
+// Carga es una subida reanudable del protocolo tus. Los bytes recibidos se guardan en un archivo
+// parcial y, al completarse, se convierten en un Documento de la solicitud indicada en los metadatos.
+type Carga struct {
+	ID             string    `gorm:"type:char(32);primaryKey" json:"id"`
+	Tamano         int64     `gorm:"not null" json:"tamano"`
+	Desplazamiento int64     `gorm:"not null;default:0" json:"desplazamiento"`
+	NombreArchivo  string    `gorm:"type:varchar(255);not null" json:"nombre_archivo"`
+	Extension      string    `gorm:"type:varchar(5);not null" json:"extension"`
+	SolicitudID    uint      `gorm:"not null" json:"solicitud_id"`
+	Categoria      string    `gorm:"type:varchar(50)" json:"categoria,omitempty"`
+	UsuarioID      *uint     `json:"usuario_id,omitempty"`
+	DocumentoID    *uint     `json:"documento_id,omitempty"` // presente cuando la carga ya fue finalizada
+	ExpiraEl       time.Time `gorm:"index" json:"expira_el"`
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName especifica el nombre de la tabla de cargas
+func (Carga) TableName() string {
+	return "cargas"
+}
+
+// Completa indica si ya se recibieron todos los bytes de la carga
+func (c *Carga) Completa() bool {
+	return c.Desplazamiento == c.Tamano
+}
+
+// CrearReq representa la petición de creación de una carga (POST con Upload-Length y Upload-Metadata)
+type CrearReq struct {
+	Tamano    int64
+	Metadatos map[string]string
+	UsuarioID *uint
+}
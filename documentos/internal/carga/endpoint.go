@@ -0,0 +1,190 @@
+package carga
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kramirez/documentos/internal/documento"
+)
+
+const (
+	versionTus   = "1.0.0"
+	extensionTus = "creation,expiration,termination"
+)
+
+type Endpoint struct {
+	service    Service
+	urlPublica string
+}
+
+// NewEndpoint crea los manejadores del protocolo tus. urlPublica se usa para construir el Location de cada carga.
+func NewEndpoint(service Service, urlPublica string) *Endpoint {
+	return &Endpoint{service: service, urlPublica: urlPublica}
+}
+
+// VerificarVersion responde 412 si el cliente no usa la versión del protocolo tus soportada
+func (e *Endpoint) VerificarVersion(c *gin.Context) {
+	c.Header("Tus-Resumable", versionTus)
+	if c.Request.Method == http.MethodOptions {
+		return
+	}
+	if c.GetHeader("Tus-Resumable") != versionTus {
+		c.Header("Tus-Version", versionTus)
+		c.AbortWithStatus(http.StatusPreconditionFailed)
+	}
+}
+
+// Opciones maneja OPTIONS /documentos/cargas
+func (e *Endpoint) Opciones(c *gin.Context) {
+	c.Header("Tus-Version", versionTus)
+	c.Header("Tus-Extension", extensionTus)
+	c.Header("Tus-Max-Size", strconv.FormatInt(e.service.TamanoMaximo(), 10))
+	c.Status(http.StatusNoContent)
+}
+
+// Crear maneja POST /documentos/cargas
+func (e *Endpoint) Crear(c *gin.Context) {
+	tamano, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || tamano < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload-Length inválido"})
+		return
+	}
+
+	metadatos, err := parseMetadatos(c.GetHeader("Upload-Metadata"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	carga, err := e.service.Crear(c.Request.Context(), CrearReq{
+		Tamano:    tamano,
+		Metadatos: metadatos,
+		UsuarioID: usuarioID(c),
+	})
+	if err != nil {
+		e.responderError(c, err)
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("%s/documentos/cargas/%s", e.urlPublica, carga.ID))
+	c.Header("Upload-Expires", carga.ExpiraEl.UTC().Format(http.TimeFormat))
+	c.Status(http.StatusCreated)
+}
+
+// Estado maneja HEAD /documentos/cargas/:id
+func (e *Endpoint) Estado(c *gin.Context) {
+	carga, err := e.service.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, ErrNoEncontrada) {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	escribirCabeceras(c, carga)
+	c.Status(http.StatusOK)
+}
+
+// Escribir maneja PATCH /documentos/cargas/:id
+func (e *Endpoint) Escribir(c *gin.Context) {
+	if c.ContentType() != "application/offset+octet-stream" {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type debe ser application/offset+octet-stream"})
+		return
+	}
+	desplazamiento, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || desplazamiento < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload-Offset inválido"})
+		return
+	}
+
+	carga, err := e.service.Escribir(c.Request.Context(), c.Param("id"), desplazamiento, c.Request.Body)
+	if carga != nil {
+		escribirCabeceras(c, carga)
+	}
+	if err != nil {
+		e.responderError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Eliminar maneja DELETE /documentos/cargas/:id
+func (e *Endpoint) Eliminar(c *gin.Context) {
+	if err := e.service.Eliminar(c.Request.Context(), c.Param("id")); err != nil {
+		e.responderError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// escribirCabeceras informa el avance de la carga y, si ya fue finalizada, el documento creado
+func escribirCabeceras(c *gin.Context, carga *Carga) {
+	c.Header("Upload-Offset", strconv.FormatInt(carga.Desplazamiento, 10))
+	c.Header("Upload-Length", strconv.FormatInt(carga.Tamano, 10))
+	c.Header("Upload-Expires", carga.ExpiraEl.UTC().Format(http.TimeFormat))
+	if carga.DocumentoID != nil {
+		c.Header("X-Documento-ID", strconv.FormatUint(uint64(*carga.DocumentoID), 10))
+	}
+}
+
+func (e *Endpoint) responderError(c *gin.Context, err error) {
+	var errMetadatos *ErrMetadatos
+	var errValidacion *documento.ErrorValidacionArchivo
+	var errCuota *documento.ErrorCuota
+	switch {
+	case errors.As(err, &errValidacion):
+		c.JSON(errValidacion.StatusHTTP(), errValidacion)
+	case errors.As(err, &errCuota):
+		c.JSON(errCuota.StatusHTTP(), errCuota)
+	case errors.As(err, &errMetadatos):
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMetadatos.Mensaje})
+	case errors.Is(err, ErrNoEncontrada):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Carga no encontrada o expirada"})
+	case errors.Is(err, ErrDesplazamientoInvalido):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrEnUso):
+		c.JSON(http.StatusLocked, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrTamanoExcedido):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("El archivo supera el tamaño máximo de %d bytes", e.service.TamanoMaximo())})
+	case errors.Is(err, ErrSolicitudNoEncontrada):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// parseMetadatos decodifica Upload-Metadata: pares "clave valor_base64" separados por comas
+func parseMetadatos(cabecera string) (map[string]string, error) {
+	metadatos := make(map[string]string)
+	for _, par := range strings.Split(cabecera, ",") {
+		par = strings.TrimSpace(par)
+		if par == "" {
+			continue
+		}
+		clave, valor, _ := strings.Cut(par, " ")
+		decodificado, err := base64.StdEncoding.DecodeString(strings.TrimSpace(valor))
+		if err != nil {
+			return nil, fmt.Errorf("Upload-Metadata inválido en la clave %s", clave)
+		}
+		metadatos[clave] = string(decodificado)
+	}
+	return metadatos, nil
+}
+
+// usuarioID obtiene el usuario que realiza la acción desde la cabecera X-Usuario-ID
+func usuarioID(c *gin.Context) *uint {
+	id, err := strconv.ParseUint(c.GetHeader("X-Usuario-ID"), 10, 32)
+	if err != nil || id == 0 {
+		return nil
+	}
+	uid := uint(id)
+	return &uid
+}
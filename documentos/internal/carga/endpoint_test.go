@@ -0,0 +1,329 @@
+package carga
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kramirez/documentos/internal/documento"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupEndpoint(t *testing.T, repo *mockRepository, documentos *mockDocumentos) (*gin.Engine, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	s, dir := setupService(t, repo, documentos)
+	ep := NewEndpoint(s, "http://documentos.local")
+
+	r := gin.New()
+	cargas := r.Group("/documentos/cargas", ep.VerificarVersion)
+	cargas.OPTIONS("", ep.Opciones)
+	cargas.POST("", ep.Crear)
+	cargas.HEAD("/:id", ep.Estado)
+	cargas.PATCH("/:id", ep.Escribir)
+	cargas.DELETE("/:id", ep.Eliminar)
+	return r, dir
+}
+
+// peticionTus crea una petición con la cabecera Tus-Resumable y las cabeceras indicadas
+func peticionTus(method, url string, body []byte, cabeceras map[string]string) *http.Request {
+	req := httptest.NewRequest(method, url, bytes.NewReader(body))
+	req.Header.Set("Tus-Resumable", versionTus)
+	for clave, valor := range cabeceras {
+		req.Header.Set(clave, valor)
+	}
+	return req
+}
+
+func b64(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+func TestEndpoint_VerificarVersion(t *testing.T) {
+	t.Run("debe retornar 412 sin la cabecera Tus-Resumable", func(t *testing.T) {
+		// Arrange
+		r, _ := setupEndpoint(t, new(mockRepository), new(mockDocumentos))
+		req := httptest.NewRequest(http.MethodHead, "/documentos/cargas/"+idCarga, nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		assert.Equal(t, versionTus, w.Header().Get("Tus-Version"))
+	})
+
+	t.Run("debe responder OPTIONS sin la cabecera Tus-Resumable", func(t *testing.T) {
+		// Arrange
+		r, _ := setupEndpoint(t, new(mockRepository), new(mockDocumentos))
+		req := httptest.NewRequest(http.MethodOptions, "/documentos/cargas", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, extensionTus, w.Header().Get("Tus-Extension"))
+		assert.Equal(t, "1048576", w.Header().Get("Tus-Max-Size"))
+	})
+}
+
+func TestEndpoint_Crear(t *testing.T) {
+	t.Run("debe retornar 201 con la ubicación de la carga", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		documentos := new(mockDocumentos)
+		documentos.On("VerificarCuota", mock.Anything, uint(1), mock.Anything, int64(48)).Return(nil)
+		repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+		r, _ := setupEndpoint(t, repo, documentos)
+		req := peticionTus(http.MethodPost, "/documentos/cargas", nil, map[string]string{
+			"Upload-Length":   "48",
+			"Upload-Metadata": "filename " + b64("contrato.pdf") + ",solicitud_id " + b64("1"),
+		})
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.True(t, strings.HasPrefix(w.Header().Get("Location"), "http://documentos.local/documentos/cargas/"))
+		assert.NotEmpty(t, w.Header().Get("Upload-Expires"))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe retornar 400 si Upload-Length no es válido", func(t *testing.T) {
+		// Arrange
+		r, _ := setupEndpoint(t, new(mockRepository), new(mockDocumentos))
+		req := peticionTus(http.MethodPost, "/documentos/cargas", nil, map[string]string{"Upload-Length": "-1"})
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("debe retornar 400 si Upload-Metadata no está en base64", func(t *testing.T) {
+		// Arrange
+		r, _ := setupEndpoint(t, new(mockRepository), new(mockDocumentos))
+		req := peticionTus(http.MethodPost, "/documentos/cargas", nil, map[string]string{
+			"Upload-Length":   "10",
+			"Upload-Metadata": "filename no-es-base64!",
+		})
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "filename")
+	})
+
+	t.Run("debe retornar 413 si supera el tamaño máximo", func(t *testing.T) {
+		// Arrange
+		r, _ := setupEndpoint(t, new(mockRepository), new(mockDocumentos))
+		req := peticionTus(http.MethodPost, "/documentos/cargas", nil, map[string]string{
+			"Upload-Length":   "99999999",
+			"Upload-Metadata": "filename " + b64("contrato.pdf") + ",solicitud_id " + b64("1"),
+		})
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+
+	t.Run("debe retornar 415 si la extensión no está permitida", func(t *testing.T) {
+		// Arrange
+		r, _ := setupEndpoint(t, new(mockRepository), new(mockDocumentos))
+		req := peticionTus(http.MethodPost, "/documentos/cargas", nil, map[string]string{
+			"Upload-Length":   "10",
+			"Upload-Metadata": "filename " + b64("virus.exe") + ",solicitud_id " + b64("1"),
+		})
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	})
+}
+
+func TestEndpoint_Estado(t *testing.T) {
+	t.Run("debe informar el desplazamiento y el documento creado", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		documentoID := uint(42)
+		repo.On("GetByID", mock.Anything, idCarga).Return(&Carga{
+			ID: idCarga, Tamano: 100, Desplazamiento: 100, DocumentoID: &documentoID, ExpiraEl: time.Now().Add(time.Hour),
+		}, nil)
+		r, _ := setupEndpoint(t, repo, new(mockDocumentos))
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, peticionTus(http.MethodHead, "/documentos/cargas/"+idCarga, nil, nil))
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "100", w.Header().Get("Upload-Offset"))
+		assert.Equal(t, "100", w.Header().Get("Upload-Length"))
+		assert.Equal(t, "42", w.Header().Get("X-Documento-ID"))
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	})
+
+	t.Run("debe informar el desplazamiento de una carga a medias", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", mock.Anything, idCarga).Return(&Carga{
+			ID: idCarga, Tamano: 100, Desplazamiento: 30, ExpiraEl: time.Now().Add(time.Hour),
+		}, nil)
+		r, _ := setupEndpoint(t, repo, new(mockDocumentos))
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, peticionTus(http.MethodHead, "/documentos/cargas/"+idCarga, nil, nil))
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "30", w.Header().Get("Upload-Offset"))
+		assert.Empty(t, w.Header().Get("X-Documento-ID"))
+	})
+
+	t.Run("debe retornar 404 si la carga expiró", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", mock.Anything, idCarga).Return(&Carga{ID: idCarga, ExpiraEl: time.Now().Add(-time.Minute)}, nil)
+		r, _ := setupEndpoint(t, repo, new(mockDocumentos))
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, peticionTus(http.MethodHead, "/documentos/cargas/"+idCarga, nil, nil))
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestEndpoint_Escribir(t *testing.T) {
+	contenido := []byte("%PDF-1.4\ncontenido del contrato\n%%EOF")
+	cabeceras := func(desplazamiento string) map[string]string {
+		return map[string]string{"Content-Type": "application/offset+octet-stream", "Upload-Offset": desplazamiento}
+	}
+
+	t.Run("debe retornar 204 con el nuevo desplazamiento", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, dir := setupEndpoint(t, repo, new(mockDocumentos))
+		repo.On("GetByID", mock.Anything, idCarga).Return(cargaPendiente(t, dir, int64(len(contenido)), nil), nil)
+		repo.On("UpdateDesplazamiento", mock.Anything, mock.Anything).Return(nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, peticionTus(http.MethodPatch, "/documentos/cargas/"+idCarga, contenido[:10], cabeceras("0")))
+
+		// Assert
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "10", w.Header().Get("Upload-Offset"))
+	})
+
+	t.Run("debe retornar 409 con el desplazamiento actual si no coincide", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, dir := setupEndpoint(t, repo, new(mockDocumentos))
+		repo.On("GetByID", mock.Anything, idCarga).Return(cargaPendiente(t, dir, int64(len(contenido)), contenido[:10]), nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, peticionTus(http.MethodPatch, "/documentos/cargas/"+idCarga, contenido, cabeceras("0")))
+
+		// Assert
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, "10", w.Header().Get("Upload-Offset"))
+		repo.AssertNotCalled(t, "UpdateDesplazamiento", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe informar el documento creado al completar la carga", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		documentos := new(mockDocumentos)
+		r, dir := setupEndpoint(t, repo, documentos)
+		repo.On("GetByID", mock.Anything, idCarga).Return(cargaPendiente(t, dir, int64(len(contenido)), contenido[:10]), nil)
+		repo.On("UpdateDesplazamiento", mock.Anything, mock.Anything).Return(nil)
+		documentos.On("GetByCargaID", mock.Anything, idCarga).Return(nil, gorm.ErrRecordNotFound)
+		documentos.On("Create", mock.Anything, mock.Anything).Return(&documento.DocumentoResponse{ID: 7}, nil)
+		repo.On("Finalizar", mock.Anything, idCarga, uint(7)).Return(nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, peticionTus(http.MethodPatch, "/documentos/cargas/"+idCarga, contenido[10:], cabeceras("10")))
+
+		// Assert
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "7", w.Header().Get("X-Documento-ID"))
+		assert.Equal(t, contenido, documentos.contenido)
+	})
+
+	t.Run("debe retornar 415 con otro Content-Type", func(t *testing.T) {
+		// Arrange
+		r, _ := setupEndpoint(t, new(mockRepository), new(mockDocumentos))
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, peticionTus(http.MethodPatch, "/documentos/cargas/"+idCarga, contenido, map[string]string{
+			"Content-Type": "application/pdf", "Upload-Offset": "0",
+		}))
+
+		// Assert
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	})
+
+	t.Run("debe retornar 400 sin Upload-Offset", func(t *testing.T) {
+		// Arrange
+		r, _ := setupEndpoint(t, new(mockRepository), new(mockDocumentos))
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, peticionTus(http.MethodPatch, "/documentos/cargas/"+idCarga, contenido, cabeceras("")))
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestEndpoint_Eliminar(t *testing.T) {
+	t.Run("debe eliminar la carga y su archivo parcial", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, dir := setupEndpoint(t, repo, new(mockDocumentos))
+		repo.On("GetByID", mock.Anything, idCarga).Return(cargaPendiente(t, dir, 10, []byte("abc")), nil)
+		repo.On("Delete", mock.Anything, idCarga).Return(nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, peticionTus(http.MethodDelete, "/documentos/cargas/"+idCarga, nil, nil))
+
+		// Assert
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		_, err := os.Stat(filepath.Join(dir, idCarga))
+		require.True(t, os.IsNotExist(err))
+		repo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,85 @@
+package carga
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/kramirez/documentos/internal/documento"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockRepository struct {
+	mock.Mock
+}
+
+func (m *mockRepository) Create(ctx context.Context, carga *Carga) error {
+	args := m.Called(ctx, carga)
+	return args.Error(0)
+}
+
+func (m *mockRepository) GetByID(ctx context.Context, id string) (*Carga, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Carga), args.Error(1)
+}
+
+func (m *mockRepository) UpdateDesplazamiento(ctx context.Context, carga *Carga) error {
+	args := m.Called(ctx, carga)
+	return args.Error(0)
+}
+
+func (m *mockRepository) Finalizar(ctx context.Context, id string, documentoID uint) error {
+	args := m.Called(ctx, id, documentoID)
+	return args.Error(0)
+}
+
+func (m *mockRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *mockRepository) GetExpiradas(ctx context.Context, ahora time.Time) ([]Carga, error) {
+	args := m.Called(ctx, ahora)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Carga), args.Error(1)
+}
+
+// mockDocumentos implementa solo las operaciones de documento.Service que usan las cargas;
+// llamar a cualquier otra falla porque la interfaz embebida es nil
+type mockDocumentos struct {
+	documento.Service
+	mock.Mock
+
+	// contenido recibido en la última llamada a Create
+	contenido []byte
+}
+
+func (m *mockDocumentos) Create(ctx context.Context, req documento.CreateReq) (*documento.DocumentoResponse, error) {
+	if req.Archivo != nil {
+		m.contenido, _ = io.ReadAll(req.Archivo)
+		req.Archivo = nil
+	}
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*documento.DocumentoResponse), args.Error(1)
+}
+
+func (m *mockDocumentos) GetByCargaID(ctx context.Context, cargaID string) (*documento.Documento, error) {
+	args := m.Called(ctx, cargaID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*documento.Documento), args.Error(1)
+}
+
+func (m *mockDocumentos) VerificarCuota(ctx context.Context, solicitudID uint, usuarioID *uint, tamano int64) error {
+	args := m.Called(ctx, solicitudID, usuarioID, tamano)
+	return args.Error(0)
+}
@@ -0,0 +1,59 @@
+package carga
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Create(ctx context.Context, carga *Carga) error
+	GetByID(ctx context.Context, id string) (*Carga, error)
+	UpdateDesplazamiento(ctx context.Context, carga *Carga) error
+	Finalizar(ctx context.Context, id string, documentoID uint) error
+	Delete(ctx context.Context, id string) error
+	GetExpiradas(ctx context.Context, ahora time.Time) ([]Carga, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, carga *Carga) error {
+	return r.db.WithContext(ctx).Create(carga).Error
+}
+
+func (r *repository) GetByID(ctx context.Context, id string) (*Carga, error) {
+	var carga Carga
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&carga).Error; err != nil {
+		return nil, err
+	}
+	return &carga, nil
+}
+
+// UpdateDesplazamiento guarda los bytes recibidos y extiende la expiración de la carga
+func (r *repository) UpdateDesplazamiento(ctx context.Context, carga *Carga) error {
+	return r.db.WithContext(ctx).Model(&Carga{}).Where("id = ?", carga.ID).Updates(map[string]interface{}{
+		"desplazamiento": carga.Desplazamiento,
+		"expira_el":      carga.ExpiraEl,
+	}).Error
+}
+
+func (r *repository) Finalizar(ctx context.Context, id string, documentoID uint) error {
+	return r.db.WithContext(ctx).Model(&Carga{}).Where("id = ?", id).Update("documento_id", documentoID).Error
+}
+
+func (r *repository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&Carga{}).Error
+}
+
+func (r *repository) GetExpiradas(ctx context.Context, ahora time.Time) ([]Carga, error) {
+	var cargas []Carga
+	err := r.db.WithContext(ctx).Where("expira_el < ?", ahora).Find(&cargas).Error
+	return cargas, err
+}
@@ -0,0 +1,75 @@
+package carga
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+
+	return gormDB, mock
+}
+
+func TestRepository_UpdateDesplazamiento(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+	expira := time.Now().Add(time.Hour)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `cargas` SET `desplazamiento`=\\?,`expira_el`=\\?,`updated_at`=\\? WHERE id = \\?").
+		WithArgs(int64(20), expira, sqlmock.AnyArg(), idCarga).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.UpdateDesplazamiento(context.Background(), &Carga{ID: idCarga, Desplazamiento: 20, ExpiraEl: expira})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Finalizar(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `cargas` SET `documento_id`=\\?,`updated_at`=\\? WHERE id = \\?").
+		WithArgs(uint(42), sqlmock.AnyArg(), idCarga).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.Finalizar(context.Background(), idCarga, 42)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_GetExpiradas(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+	ahora := time.Now()
+
+	mock.ExpectQuery("SELECT \\* FROM `cargas` WHERE expira_el < \\?").
+		WithArgs(ahora).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tamano", "desplazamiento"}).
+			AddRow(idCarga, 100, 30))
+
+	result, err := repo.GetExpiradas(context.Background(), ahora)
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	assert.Equal(t, idCarga, result[0].ID)
+	assert.Equal(t, int64(30), result[0].Desplazamiento)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,446 @@
+package carga
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kramirez/documentos/internal/documento"
+	"github.com/kramirez/documentos/pkg/httpclient"
+	"gorm.io/gorm"
+)
+
+type Service interface {
+	Crear(ctx context.Context, req CrearReq) (*Carga, error)
+	Get(ctx context.Context, id string) (*Carga, error)
+	Escribir(ctx context.Context, id string, desplazamiento int64, r io.Reader) (*Carga, error)
+	Eliminar(ctx context.Context, id string) error
+	LimpiarExpiradas(ctx context.Context) (int, error)
+	IniciarLimpieza(ctx context.Context, intervalo time.Duration)
+	TamanoMaximo() int64
+}
+
+var (
+	// ErrNoEncontrada indica que la carga no existe o ya expiró
+	ErrNoEncontrada = errors.New("carga no encontrada")
+	// ErrDesplazamientoInvalido indica que Upload-Offset no coincide con los bytes ya recibidos
+	ErrDesplazamientoInvalido = errors.New("el desplazamiento no coincide con el de la carga")
+	// ErrEnUso indica que otra petición está escribiendo en la misma carga
+	ErrEnUso = errors.New("la carga está recibiendo datos en otra petición")
+	// ErrTamanoExcedido indica que el tamaño declarado supera el máximo permitido
+	ErrTamanoExcedido = errors.New("el tamaño de la carga supera el máximo permitido")
+	// ErrSolicitudNoEncontrada indica que la solicitud de los metadatos no existe
+	ErrSolicitudNoEncontrada = errors.New("la solicitud indicada no existe")
+)
+
+// ErrMetadatos indica que faltan datos requeridos en Upload-Metadata
+type ErrMetadatos struct {
+	Mensaje string
+}
+
+func (e *ErrMetadatos) Error() string {
+	return e.Mensaje
+}
+
+// Config contiene la configuración de las cargas reanudables
+type Config struct {
+	// Directorio donde se guardan los archivos parciales
+	Directorio   string
+	TamanoMaximo int64
+	// Expiracion es el tiempo sin recibir datos tras el cual una carga se considera abandonada
+	Expiracion time.Duration
+	Politica   documento.PoliticaArchivos
+}
+
+type service struct {
+	repo            Repository
+	logger          *log.Logger
+	documentos      documento.Service
+	solicitudClient *httpclient.SolicitudClient
+	config          Config
+
+	mu          sync.Mutex
+	enEscritura map[string]bool
+}
+
+func NewService(repo Repository, logger *log.Logger, documentos documento.Service, solicitudClient *httpclient.SolicitudClient, config Config) (Service, error) {
+	if err := os.MkdirAll(config.Directorio, 0o755); err != nil {
+		return nil, fmt.Errorf("no se pudo crear el directorio de cargas: %v", err)
+	}
+	return &service{
+		repo:            repo,
+		logger:          logger,
+		documentos:      documentos,
+		solicitudClient: solicitudClient,
+		config:          config,
+		enEscritura:     make(map[string]bool),
+	}, nil
+}
+
+func (s *service) TamanoMaximo() int64 {
+	return s.config.TamanoMaximo
+}
+
+// ruta retorna la ubicación del archivo parcial de una carga
+func (s *service) ruta(id string) string {
+	return filepath.Join(s.config.Directorio, id)
+}
+
+func (s *service) Crear(ctx context.Context, req CrearReq) (*Carga, error) {
+	if req.Tamano > s.config.TamanoMaximo {
+		return nil, ErrTamanoExcedido
+	}
+
+	carga, err := cargaDesdeMetadatos(req.Metadatos)
+	if err != nil {
+		return nil, err
+	}
+
+	// Se valida antes de recibir los datos para no aceptar una subida que no podrá finalizarse
+	if err := s.config.Politica.ValidarExtension(carga.Extension, carga.Categoria); err != nil {
+		return nil, err
+	}
+	existe, err := s.solicitudClient.ValidarSolicitud(carga.SolicitudID)
+	if err != nil {
+		s.logger.Printf("Error al validar la solicitud ID=%d: %v", carga.SolicitudID, err)
+		return nil, fmt.Errorf("no se pudo validar la solicitud: %v", err)
+	}
+	if !existe {
+		return nil, ErrSolicitudNoEncontrada
+	}
+	// La cuota se vuelve a verificar al finalizar, pero así se rechaza antes de recibir los datos
+	if err := s.documentos.VerificarCuota(ctx, carga.SolicitudID, req.UsuarioID, req.Tamano); err != nil {
+		return nil, err
+	}
+
+	carga.ID, err = nuevoID()
+	if err != nil {
+		return nil, err
+	}
+	carga.Tamano = req.Tamano
+	carga.UsuarioID = req.UsuarioID
+	carga.ExpiraEl = time.Now().Add(s.config.Expiracion)
+
+	archivo, err := os.OpenFile(s.ruta(carga.ID), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("error al crear el archivo de la carga: %v", err)
+	}
+	archivo.Close()
+
+	if err := s.repo.Create(ctx, carga); err != nil {
+		os.Remove(s.ruta(carga.ID))
+		s.logger.Printf("Error al registrar la carga: %v", err)
+		return nil, err
+	}
+
+	s.logger.Printf("Carga %s creada: %d bytes para la solicitud ID=%d", carga.ID, carga.Tamano, carga.SolicitudID)
+	return carga, nil
+}
+
+// cargaDesdeMetadatos obtiene nombre, extensión, solicitud y categoría de Upload-Metadata.
+// Se aceptan las claves filename o name que envían los clientes tus habituales.
+func cargaDesdeMetadatos(metadatos map[string]string) (*Carga, error) {
+	nombre := metadatos["filename"]
+	if nombre == "" {
+		nombre = metadatos["name"]
+	}
+
+	carga := &Carga{
+		NombreArchivo: metadatos["nombre_archivo"],
+		Extension:     strings.ToLower(strings.TrimPrefix(metadatos["extension"], ".")),
+		Categoria:     metadatos["categoria"],
+	}
+	if carga.Extension == "" {
+		carga.Extension = strings.ToLower(strings.TrimPrefix(filepath.Ext(nombre), "."))
+	}
+	if carga.NombreArchivo == "" {
+		carga.NombreArchivo = strings.TrimSuffix(filepath.Base(nombre), filepath.Ext(nombre))
+	}
+	if carga.Extension == "" || carga.NombreArchivo == "" || carga.NombreArchivo == "." {
+		return nil, &ErrMetadatos{Mensaje: "No se pudo determinar el nombre o la extensión del archivo, envíe filename en Upload-Metadata"}
+	}
+	if len(carga.Extension) > 5 {
+		return nil, &ErrMetadatos{Mensaje: "La extensión no puede superar los 5 caracteres"}
+	}
+
+	solicitudID, err := strconv.ParseUint(metadatos["solicitud_id"], 10, 32)
+	if err != nil || solicitudID == 0 {
+		return nil, &ErrMetadatos{Mensaje: "solicitud_id es requerido en Upload-Metadata"}
+	}
+	carga.SolicitudID = uint(solicitudID)
+	return carga, nil
+}
+
+func nuevoID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error al generar el identificador de la carga: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// idValido evita que un identificador manipulado se use para acceder a otras rutas del disco
+func idValido(id string) bool {
+	if len(id) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
+func (s *service) Get(ctx context.Context, id string) (*Carga, error) {
+	if !idValido(id) {
+		return nil, ErrNoEncontrada
+	}
+	carga, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNoEncontrada
+		}
+		return nil, err
+	}
+	if time.Now().After(carga.ExpiraEl) {
+		return nil, ErrNoEncontrada
+	}
+	return carga, nil
+}
+
+// bloquear impide que dos peticiones PATCH escriban a la vez en la misma carga. El bloqueo es en
+// memoria y solo cubre esta instancia: con varias instancias las peticiones de una carga deben llegar
+// a la misma, que es la que tiene el archivo parcial. Aun así, el índice único de documentos.carga_id
+// impide que una carga termine convertida en dos documentos.
+func (s *service) bloquear(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.enEscritura[id] {
+		return false
+	}
+	s.enEscritura[id] = true
+	return true
+}
+
+func (s *service) desbloquear(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.enEscritura, id)
+}
+
+// Escribir agrega los bytes recibidos a partir del desplazamiento indicado. Si la conexión se corta
+// se conserva lo recibido para que el cliente pueda continuar. Al completarse se crea el Documento.
+func (s *service) Escribir(ctx context.Context, id string, desplazamiento int64, r io.Reader) (*Carga, error) {
+	if !s.bloquear(id) {
+		return nil, ErrEnUso
+	}
+	defer s.desbloquear(id)
+
+	carga, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if desplazamiento != carga.Desplazamiento {
+		return carga, ErrDesplazamientoInvalido
+	}
+
+	if !carga.Completa() {
+		escritos, errEscritura := s.agregar(carga, r)
+		if escritos > 0 {
+			carga.Desplazamiento += escritos
+			carga.ExpiraEl = time.Now().Add(s.config.Expiracion)
+			// Si el cliente cortó la conexión el contexto ya está cancelado, pero lo recibido debe quedar registrado
+			if err := s.repo.UpdateDesplazamiento(context.WithoutCancel(ctx), carga); err != nil {
+				s.logger.Printf("Error al actualizar la carga %s: %v", id, err)
+				return nil, err
+			}
+		}
+		if errEscritura != nil {
+			s.logger.Printf("Carga %s interrumpida en %d de %d bytes: %v", id, carga.Desplazamiento, carga.Tamano, errEscritura)
+			return carga, errEscritura
+		}
+	}
+
+	if carga.Completa() && carga.DocumentoID == nil {
+		if err := s.finalizar(ctx, carga); err != nil {
+			return carga, err
+		}
+	}
+	return carga, nil
+}
+
+// agregar escribe al final del archivo parcial como máximo los bytes que faltan para completar la carga
+func (s *service) agregar(carga *Carga, r io.Reader) (int64, error) {
+	archivo, err := os.OpenFile(s.ruta(carga.ID), os.O_WRONLY, 0o600)
+	if err != nil {
+		return 0, fmt.Errorf("error al abrir el archivo de la carga: %v", err)
+	}
+	defer archivo.Close()
+
+	// Un intento anterior interrumpido pudo dejar bytes sin registrar al final del archivo
+	if err := archivo.Truncate(carga.Desplazamiento); err != nil {
+		return 0, fmt.Errorf("error al preparar el archivo de la carga: %v", err)
+	}
+	if _, err := archivo.Seek(carga.Desplazamiento, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("error al preparar el archivo de la carga: %v", err)
+	}
+
+	escritos, err := io.Copy(archivo, io.LimitReader(r, carga.Tamano-carga.Desplazamiento))
+	if err != nil {
+		return escritos, err
+	}
+	if err := archivo.Sync(); err != nil {
+		return 0, fmt.Errorf("error al guardar el archivo de la carga: %v", err)
+	}
+	return escritos, nil
+}
+
+// finalizar convierte la carga completa en un Documento. Si el archivo no pasa la validación
+// la carga se descarta; ante otros errores se conserva para que el cliente pueda reintentar.
+// El documento guarda el ID de la carga, así un reintento después de que se creó el documento
+// pero no se registró la finalización reutiliza ese documento en vez de crear otro.
+func (s *service) finalizar(ctx context.Context, carga *Carga) error {
+	documentoID, err := s.documentoDeCarga(ctx, carga.ID)
+	if err != nil {
+		return err
+	}
+	if documentoID == 0 {
+		documentoID, err = s.crearDocumento(ctx, carga)
+		if err != nil {
+			return err
+		}
+	}
+
+	carga.DocumentoID = &documentoID
+	if err := s.repo.Finalizar(ctx, carga.ID, documentoID); err != nil {
+		s.logger.Printf("Error al registrar la finalización de la carga %s: %v", carga.ID, err)
+		return err
+	}
+	// El contenido ya está en el Storage; el registro se mantiene hasta expirar para responder HEAD
+	if err := os.Remove(s.ruta(carga.ID)); err != nil && !os.IsNotExist(err) {
+		s.logger.Printf("Advertencia: No se pudo eliminar el archivo de la carga %s: %v", carga.ID, err)
+	}
+
+	s.logger.Printf("Carga %s finalizada como documento ID=%d", carga.ID, documentoID)
+	return nil
+}
+
+// documentoDeCarga retorna el ID del documento ya creado para la carga, o 0 si aún no existe
+func (s *service) documentoDeCarga(ctx context.Context, cargaID string) (uint, error) {
+	doc, err := s.documentos.GetByCargaID(ctx, cargaID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		s.logger.Printf("Error al buscar el documento de la carga %s: %v", cargaID, err)
+		return 0, err
+	}
+	return doc.ID, nil
+}
+
+// crearDocumento crea el documento con el contenido de la carga
+func (s *service) crearDocumento(ctx context.Context, carga *Carga) (uint, error) {
+	archivo, err := os.Open(s.ruta(carga.ID))
+	if err != nil {
+		return 0, fmt.Errorf("error al abrir el archivo de la carga: %v", err)
+	}
+	defer archivo.Close()
+
+	doc, err := s.documentos.Create(ctx, documento.CreateReq{
+		Extension:     carga.Extension,
+		NombreArchivo: carga.NombreArchivo,
+		SolicitudID:   carga.SolicitudID,
+		Categoria:     carga.Categoria,
+		Archivo:       archivo,
+		UsuarioID:     carga.UsuarioID,
+		CargaID:       carga.ID,
+	})
+	if err != nil {
+		// Otra instancia pudo crear el documento de la misma carga al mismo tiempo
+		if documentoID, errBusqueda := s.documentoDeCarga(ctx, carga.ID); errBusqueda == nil && documentoID != 0 {
+			return documentoID, nil
+		}
+		var errValidacion *documento.ErrorValidacionArchivo
+		if errors.As(err, &errValidacion) {
+			s.descartar(ctx, carga.ID)
+		}
+		s.logger.Printf("Error al finalizar la carga %s: %v", carga.ID, err)
+		return 0, err
+	}
+	return doc.ID, nil
+}
+
+func (s *service) Eliminar(ctx context.Context, id string) error {
+	if !s.bloquear(id) {
+		return ErrEnUso
+	}
+	defer s.desbloquear(id)
+
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+	return s.descartar(ctx, id)
+}
+
+// descartar elimina el registro y el archivo parcial de una carga
+func (s *service) descartar(ctx context.Context, id string) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		s.logger.Printf("Error al eliminar la carga %s: %v", id, err)
+		return err
+	}
+	if err := os.Remove(s.ruta(id)); err != nil && !os.IsNotExist(err) {
+		s.logger.Printf("Advertencia: No se pudo eliminar el archivo de la carga %s: %v", id, err)
+	}
+	return nil
+}
+
+// LimpiarExpiradas elimina las cargas abandonadas y sus archivos parciales
+func (s *service) LimpiarExpiradas(ctx context.Context) (int, error) {
+	expiradas, err := s.repo.GetExpiradas(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	eliminadas := 0
+	for _, carga := range expiradas {
+		if !s.bloquear(carga.ID) {
+			continue
+		}
+		err := s.descartar(ctx, carga.ID)
+		s.desbloquear(carga.ID)
+		if err == nil {
+			eliminadas++
+		}
+	}
+	return eliminadas, nil
+}
+
+// IniciarLimpieza elimina periódicamente las cargas expiradas hasta que se cancele el contexto
+func (s *service) IniciarLimpieza(ctx context.Context, intervalo time.Duration) {
+	go func() {
+		ticker := time.NewTicker(intervalo)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				eliminadas, err := s.LimpiarExpiradas(ctx)
+				if err != nil {
+					s.logger.Printf("Error al limpiar las cargas expiradas: %v", err)
+					continue
+				}
+				if eliminadas > 0 {
+					s.logger.Printf("Se eliminaron %d cargas expiradas", eliminadas)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,511 @@
+package carga
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kramirez/documentos/internal/documento"
+	"github.com/kramirez/documentos/pkg/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+const idCarga = "0123456789abcdef0123456789abcdef"
+
+// nuevoServidorSolicitudes simula el servicio de solicitudes: solo la solicitud 1 existe
+func nuevoServidorSolicitudes(t *testing.T) *httpclient.SolicitudClient {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/solicitudes/1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(httpclient.SolicitudResponse{ID: 1, Titulo: "Analista"})
+	}))
+	t.Cleanup(server.Close)
+	return httpclient.NewSolicitudClient(server.URL)
+}
+
+// setupService crea el servicio con un directorio temporal para los archivos parciales
+func setupService(t *testing.T, repo *mockRepository, documentos *mockDocumentos) (*service, string) {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "cargas")
+	s, err := NewService(repo, log.New(io.Discard, "", 0), documentos, nuevoServidorSolicitudes(t), Config{
+		Directorio:   dir,
+		TamanoMaximo: 1 << 20,
+		Expiracion:   time.Hour,
+	})
+	require.NoError(t, err)
+	return s.(*service), dir
+}
+
+// cargaPendiente crea el archivo parcial con los bytes ya recibidos y retorna su registro
+func cargaPendiente(t *testing.T, dir string, tamano int64, recibido []byte) *Carga {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, idCarga), recibido, 0o600))
+	return &Carga{
+		ID:             idCarga,
+		Tamano:         tamano,
+		Desplazamiento: int64(len(recibido)),
+		NombreArchivo:  "contrato",
+		Extension:      "pdf",
+		SolicitudID:    1,
+		Categoria:      "contrato",
+		ExpiraEl:       time.Now().Add(time.Hour),
+	}
+}
+
+func TestService_Crear(t *testing.T) {
+	ctx := context.Background()
+	metadatos := map[string]string{"filename": "Contrato.PDF", "solicitud_id": "1", "categoria": "contrato"}
+
+	t.Run("debe registrar la carga y crear su archivo parcial vacío", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		documentos := new(mockDocumentos)
+		usuario := uint(3)
+		documentos.On("VerificarCuota", ctx, uint(1), &usuario, int64(100)).Return(nil)
+		repo.On("Create", ctx, mock.AnythingOfType("*carga.Carga")).Return(nil)
+		s, dir := setupService(t, repo, documentos)
+
+		// Act
+		carga, err := s.Crear(ctx, CrearReq{Tamano: 100, Metadatos: metadatos, UsuarioID: &usuario})
+
+		// Assert
+		require.NoError(t, err)
+		assert.True(t, idValido(carga.ID))
+		assert.Equal(t, "Contrato", carga.NombreArchivo)
+		assert.Equal(t, "pdf", carga.Extension)
+		assert.Equal(t, "contrato", carga.Categoria)
+		assert.Equal(t, uint(1), carga.SolicitudID)
+		assert.Equal(t, int64(100), carga.Tamano)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), carga.ExpiraEl, time.Minute)
+		info, err := os.Stat(filepath.Join(dir, carga.ID))
+		require.NoError(t, err)
+		assert.Zero(t, info.Size())
+		repo.AssertExpectations(t)
+		documentos.AssertExpectations(t)
+	})
+
+	t.Run("debe rechazar un tamaño mayor al máximo", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _ := setupService(t, repo, new(mockDocumentos))
+
+		// Act
+		_, err := s.Crear(ctx, CrearReq{Tamano: 2 << 20, Metadatos: metadatos})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrTamanoExcedido)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe exigir solicitud_id en los metadatos", func(t *testing.T) {
+		// Arrange
+		s, _ := setupService(t, new(mockRepository), new(mockDocumentos))
+
+		// Act
+		_, err := s.Crear(ctx, CrearReq{Tamano: 10, Metadatos: map[string]string{"filename": "cv.pdf"}})
+
+		// Assert
+		var errMetadatos *ErrMetadatos
+		assert.ErrorAs(t, err, &errMetadatos)
+	})
+
+	t.Run("debe rechazar una extensión no permitida antes de recibir los datos", func(t *testing.T) {
+		// Arrange
+		s, _ := setupService(t, new(mockRepository), new(mockDocumentos))
+
+		// Act
+		_, err := s.Crear(ctx, CrearReq{Tamano: 10, Metadatos: map[string]string{"filename": "virus.exe", "solicitud_id": "1"}})
+
+		// Assert
+		var errValidacion *documento.ErrorValidacionArchivo
+		assert.ErrorAs(t, err, &errValidacion)
+	})
+
+	t.Run("debe rechazar una solicitud inexistente", func(t *testing.T) {
+		// Arrange
+		s, _ := setupService(t, new(mockRepository), new(mockDocumentos))
+
+		// Act
+		_, err := s.Crear(ctx, CrearReq{Tamano: 10, Metadatos: map[string]string{"filename": "cv.pdf", "solicitud_id": "9"}})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrSolicitudNoEncontrada)
+	})
+
+	t.Run("debe rechazar la carga si supera la cuota", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		documentos := new(mockDocumentos)
+		errCuota := &documento.ErrorCuota{}
+		documentos.On("VerificarCuota", ctx, uint(1), (*uint)(nil), int64(10)).Return(errCuota)
+		s, dir := setupService(t, repo, documentos)
+
+		// Act
+		_, err := s.Crear(ctx, CrearReq{Tamano: 10, Metadatos: metadatos})
+
+		// Assert
+		assert.ErrorIs(t, err, errCuota)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		archivos, _ := os.ReadDir(dir)
+		assert.Empty(t, archivos)
+	})
+}
+
+func TestService_Get(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("debe rechazar un identificador que no es hexadecimal sin consultar la base de datos", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _ := setupService(t, repo, new(mockDocumentos))
+
+		// Act
+		_, err := s.Get(ctx, "../../etc/passwd")
+
+		// Assert
+		assert.ErrorIs(t, err, ErrNoEncontrada)
+		repo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe tratar una carga expirada como inexistente", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, idCarga).Return(&Carga{ID: idCarga, ExpiraEl: time.Now().Add(-time.Minute)}, nil)
+		s, _ := setupService(t, repo, new(mockDocumentos))
+
+		// Act
+		_, err := s.Get(ctx, idCarga)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrNoEncontrada)
+	})
+
+	t.Run("debe retornar ErrNoEncontrada si no existe el registro", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, idCarga).Return(nil, gorm.ErrRecordNotFound)
+		s, _ := setupService(t, repo, new(mockDocumentos))
+
+		// Act
+		_, err := s.Get(ctx, idCarga)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrNoEncontrada)
+	})
+}
+
+func TestService_Escribir(t *testing.T) {
+	ctx := context.Background()
+	contenido := []byte("%PDF-1.4\ncontenido del contrato\n%%EOF")
+
+	t.Run("debe agregar el bloque recibido y registrar el nuevo desplazamiento", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, dir := setupService(t, repo, new(mockDocumentos))
+		carga := cargaPendiente(t, dir, int64(len(contenido)), contenido[:10])
+		repo.On("GetByID", ctx, idCarga).Return(carga, nil)
+		repo.On("UpdateDesplazamiento", mock.Anything, mock.MatchedBy(func(c *Carga) bool {
+			return c.Desplazamiento == 20
+		})).Return(nil)
+
+		// Act
+		resultado, err := s.Escribir(ctx, idCarga, 10, bytes.NewReader(contenido[10:20]))
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, int64(20), resultado.Desplazamiento)
+		assert.Nil(t, resultado.DocumentoID)
+		parcial, err := os.ReadFile(filepath.Join(dir, idCarga))
+		require.NoError(t, err)
+		assert.Equal(t, contenido[:20], parcial)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe rechazar un desplazamiento distinto al registrado", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, dir := setupService(t, repo, new(mockDocumentos))
+		carga := cargaPendiente(t, dir, int64(len(contenido)), contenido[:10])
+		repo.On("GetByID", ctx, idCarga).Return(carga, nil)
+
+		// Act
+		resultado, err := s.Escribir(ctx, idCarga, 5, bytes.NewReader(contenido[5:]))
+
+		// Assert
+		assert.ErrorIs(t, err, ErrDesplazamientoInvalido)
+		assert.Equal(t, int64(10), resultado.Desplazamiento)
+		parcial, _ := os.ReadFile(filepath.Join(dir, idCarga))
+		assert.Equal(t, contenido[:10], parcial)
+		repo.AssertNotCalled(t, "UpdateDesplazamiento", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe conservar lo recibido si la conexión se corta", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, dir := setupService(t, repo, new(mockDocumentos))
+		carga := cargaPendiente(t, dir, int64(len(contenido)), nil)
+		repo.On("GetByID", ctx, idCarga).Return(carga, nil)
+		repo.On("UpdateDesplazamiento", mock.Anything, mock.Anything).Return(nil)
+		cortado := io.MultiReader(bytes.NewReader(contenido[:7]), &lectorCortado{})
+
+		// Act
+		resultado, err := s.Escribir(ctx, idCarga, 0, cortado)
+
+		// Assert
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+		assert.Equal(t, int64(7), resultado.Desplazamiento)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe descartar los bytes sobrantes de un intento interrumpido", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, dir := setupService(t, repo, new(mockDocumentos))
+		carga := cargaPendiente(t, dir, int64(len(contenido)), contenido[:10])
+		carga.Desplazamiento = 5 // los últimos 5 bytes del archivo no alcanzaron a registrarse
+		repo.On("GetByID", ctx, idCarga).Return(carga, nil)
+		repo.On("UpdateDesplazamiento", mock.Anything, mock.Anything).Return(nil)
+
+		// Act
+		_, err := s.Escribir(ctx, idCarga, 5, strings.NewReader("XXXXX"))
+
+		// Assert
+		require.NoError(t, err)
+		parcial, _ := os.ReadFile(filepath.Join(dir, idCarga))
+		assert.Equal(t, append(append([]byte{}, contenido[:5]...), "XXXXX"...), parcial)
+	})
+
+	t.Run("debe crear el documento al recibir el último bloque", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		documentos := new(mockDocumentos)
+		s, dir := setupService(t, repo, documentos)
+		carga := cargaPendiente(t, dir, int64(len(contenido)), contenido[:20])
+		repo.On("GetByID", ctx, idCarga).Return(carga, nil)
+		repo.On("UpdateDesplazamiento", mock.Anything, mock.Anything).Return(nil)
+		documentos.On("GetByCargaID", ctx, idCarga).Return(nil, gorm.ErrRecordNotFound)
+		documentos.On("Create", ctx, documento.CreateReq{
+			Extension:     "pdf",
+			NombreArchivo: "contrato",
+			SolicitudID:   1,
+			Categoria:     "contrato",
+			CargaID:       idCarga,
+		}).Return(&documento.DocumentoResponse{ID: 42}, nil)
+		repo.On("Finalizar", ctx, idCarga, uint(42)).Return(nil)
+
+		// Act: el cliente envía más bytes de los declarados
+		resultado, err := s.Escribir(ctx, idCarga, 20, bytes.NewReader(append(contenido[20:], "extra"...)))
+
+		// Assert
+		require.NoError(t, err)
+		assert.True(t, resultado.Completa())
+		require.NotNil(t, resultado.DocumentoID)
+		assert.Equal(t, uint(42), *resultado.DocumentoID)
+		assert.Equal(t, contenido, documentos.contenido)
+		_, err = os.Stat(filepath.Join(dir, idCarga))
+		assert.True(t, os.IsNotExist(err), "el archivo parcial debe eliminarse")
+		repo.AssertExpectations(t)
+		documentos.AssertExpectations(t)
+	})
+
+	t.Run("debe descartar la carga si el archivo no pasa la validación", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		documentos := new(mockDocumentos)
+		s, dir := setupService(t, repo, documentos)
+		carga := cargaPendiente(t, dir, int64(len(contenido)), contenido)
+		repo.On("GetByID", ctx, idCarga).Return(carga, nil)
+		errValidacion := &documento.ErrorValidacionArchivo{Codigo: documento.CodigoContenidoNoCoincide}
+		documentos.On("GetByCargaID", ctx, idCarga).Return(nil, gorm.ErrRecordNotFound)
+		documentos.On("Create", ctx, mock.Anything).Return(nil, errValidacion)
+		repo.On("Delete", ctx, idCarga).Return(nil)
+
+		// Act
+		_, err := s.Escribir(ctx, idCarga, int64(len(contenido)), bytes.NewReader(nil))
+
+		// Assert
+		assert.ErrorIs(t, err, errValidacion)
+		_, err = os.Stat(filepath.Join(dir, idCarga))
+		assert.True(t, os.IsNotExist(err))
+		repo.AssertNotCalled(t, "Finalizar", mock.Anything, mock.Anything, mock.Anything)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe conservar la carga completa si la creación falla por otro motivo", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		documentos := new(mockDocumentos)
+		s, dir := setupService(t, repo, documentos)
+		carga := cargaPendiente(t, dir, int64(len(contenido)), contenido)
+		repo.On("GetByID", ctx, idCarga).Return(carga, nil)
+		documentos.On("GetByCargaID", ctx, idCarga).Return(nil, gorm.ErrRecordNotFound)
+		documentos.On("Create", ctx, mock.Anything).Return(nil, errors.New("almacenamiento no disponible"))
+
+		// Act
+		_, err := s.Escribir(ctx, idCarga, int64(len(contenido)), bytes.NewReader(nil))
+
+		// Assert
+		assert.Error(t, err)
+		_, err = os.Stat(filepath.Join(dir, idCarga))
+		assert.NoError(t, err, "el archivo se conserva para reintentar")
+		repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe reutilizar el documento creado en un intento que no alcanzó a registrar la finalización", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		documentos := new(mockDocumentos)
+		s, dir := setupService(t, repo, documentos)
+		carga := cargaPendiente(t, dir, int64(len(contenido)), contenido)
+		repo.On("GetByID", ctx, idCarga).Return(carga, nil)
+		documentos.On("GetByCargaID", ctx, idCarga).Return(&documento.Documento{ID: 42}, nil)
+		repo.On("Finalizar", ctx, idCarga, uint(42)).Return(nil)
+
+		// Act
+		resultado, err := s.Escribir(ctx, idCarga, int64(len(contenido)), bytes.NewReader(nil))
+
+		// Assert
+		require.NoError(t, err)
+		require.NotNil(t, resultado.DocumentoID)
+		assert.Equal(t, uint(42), *resultado.DocumentoID)
+		documentos.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe usar el documento que creó otra petición para la misma carga", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		documentos := new(mockDocumentos)
+		s, dir := setupService(t, repo, documentos)
+		carga := cargaPendiente(t, dir, int64(len(contenido)), contenido)
+		repo.On("GetByID", ctx, idCarga).Return(carga, nil)
+		documentos.On("GetByCargaID", ctx, idCarga).Return(nil, gorm.ErrRecordNotFound).Once()
+		documentos.On("Create", ctx, mock.Anything).Return(nil, errors.New("Duplicate entry for key 'idx_documentos_carga_id'"))
+		documentos.On("GetByCargaID", ctx, idCarga).Return(&documento.Documento{ID: 42}, nil)
+		repo.On("Finalizar", ctx, idCarga, uint(42)).Return(nil)
+
+		// Act
+		resultado, err := s.Escribir(ctx, idCarga, int64(len(contenido)), bytes.NewReader(nil))
+
+		// Assert
+		require.NoError(t, err)
+		require.NotNil(t, resultado.DocumentoID)
+		assert.Equal(t, uint(42), *resultado.DocumentoID)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("no debe volver a crear el documento de una carga finalizada", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		documentos := new(mockDocumentos)
+		s, _ := setupService(t, repo, documentos)
+		documentoID := uint(42)
+		repo.On("GetByID", ctx, idCarga).Return(&Carga{
+			ID: idCarga, Tamano: 5, Desplazamiento: 5, DocumentoID: &documentoID, ExpiraEl: time.Now().Add(time.Hour),
+		}, nil)
+
+		// Act
+		resultado, err := s.Escribir(ctx, idCarga, 5, bytes.NewReader(nil))
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, &documentoID, resultado.DocumentoID)
+		documentos.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe rechazar una escritura concurrente en la misma carga", func(t *testing.T) {
+		// Arrange
+		s, _ := setupService(t, new(mockRepository), new(mockDocumentos))
+		require.True(t, s.bloquear(idCarga))
+		defer s.desbloquear(idCarga)
+
+		// Act
+		_, err := s.Escribir(ctx, idCarga, 0, bytes.NewReader(nil))
+
+		// Assert
+		assert.ErrorIs(t, err, ErrEnUso)
+	})
+}
+
+func TestService_LimpiarExpiradas(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("debe eliminar las cargas expiradas y sus archivos parciales", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, dir := setupService(t, repo, new(mockDocumentos))
+		otra := "fedcba9876543210fedcba9876543210"
+		cargaPendiente(t, dir, 10, []byte("abc"))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, otra), []byte("def"), 0o600))
+		repo.On("GetExpiradas", ctx, mock.AnythingOfType("time.Time")).Return([]Carga{{ID: idCarga}, {ID: otra}}, nil)
+		repo.On("Delete", ctx, idCarga).Return(nil)
+		repo.On("Delete", ctx, otra).Return(nil)
+
+		// Act
+		eliminadas, err := s.LimpiarExpiradas(ctx)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, 2, eliminadas)
+		archivos, _ := os.ReadDir(dir)
+		assert.Empty(t, archivos)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe omitir las cargas que están recibiendo datos", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, dir := setupService(t, repo, new(mockDocumentos))
+		cargaPendiente(t, dir, 10, []byte("abc"))
+		repo.On("GetExpiradas", ctx, mock.AnythingOfType("time.Time")).Return([]Carga{{ID: idCarga}}, nil)
+		require.True(t, s.bloquear(idCarga))
+
+		// Act
+		eliminadas, err := s.LimpiarExpiradas(ctx)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Zero(t, eliminadas)
+		repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+		_, err = os.Stat(filepath.Join(dir, idCarga))
+		assert.NoError(t, err)
+	})
+
+	t.Run("no debe contar las cargas que no se pudieron eliminar", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _ := setupService(t, repo, new(mockDocumentos))
+		repo.On("GetExpiradas", ctx, mock.AnythingOfType("time.Time")).Return([]Carga{{ID: idCarga}}, nil)
+		repo.On("Delete", ctx, idCarga).Return(errors.New("db error"))
+
+		// Act
+		eliminadas, err := s.LimpiarExpiradas(ctx)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Zero(t, eliminadas)
+	})
+}
+
+// lectorCortado simula una conexión que se interrumpe
+type lectorCortado struct{}
+
+func (*lectorCortado) Read([]byte) (int, error) {
+	return 0, io.ErrUnexpectedEOF
+}
@@ -0,0 +1,91 @@
+package documento
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	resultadosBusquedaPorDefecto = 20
+	maxResultadosBusqueda        = 100
+
+	// maxFragmentos es la cantidad de extractos que se devuelven por documento
+	maxFragmentos = 3
+	// contextoFragmento es la cantidad de caracteres que se muestran a cada lado del término encontrado
+	contextoFragmento = 80
+)
+
+// terminosBusqueda separa la consulta en palabras en minúsculas, ignorando las de un solo carácter
+func terminosBusqueda(consulta string) [][]rune {
+	var terminos [][]rune
+	palabras := strings.FieldsFunc(strings.ToLower(consulta), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for _, palabra := range palabras {
+		if runas := []rune(palabra); len(runas) > 1 {
+			terminos = append(terminos, runas)
+		}
+	}
+	return terminos
+}
+
+// fragmentos devuelve extractos del texto alrededor de las apariciones de los términos buscados.
+// Si ningún término aparece literalmente (MySQL también encuentra variantes) se devuelve el inicio del texto.
+func fragmentos(texto, consulta string) []string {
+	runas := []rune(texto)
+	if len(runas) == 0 {
+		return []string{}
+	}
+
+	// Se compara en minúsculas runa por runa para conservar las posiciones del texto original
+	minusculas := make([]rune, len(runas))
+	for i, r := range runas {
+		minusculas[i] = unicode.ToLower(r)
+	}
+
+	terminos := terminosBusqueda(consulta)
+	resultado := make([]string, 0, maxFragmentos)
+	fin := 0
+	for i := 0; i < len(minusculas) && len(resultado) < maxFragmentos; i++ {
+		for _, termino := range terminos {
+			if !coincideEn(minusculas, i, termino) {
+				continue
+			}
+			desde := max(i-contextoFragmento, fin)
+			hasta := min(i+len(termino)+contextoFragmento, len(runas))
+			resultado = append(resultado, recortar(runas, desde, hasta))
+			fin = hasta
+			i = hasta - 1
+			break
+		}
+	}
+
+	if len(resultado) == 0 {
+		resultado = append(resultado, recortar(runas, 0, min(2*contextoFragmento, len(runas))))
+	}
+	return resultado
+}
+
+func coincideEn(texto []rune, pos int, termino []rune) bool {
+	if pos+len(termino) > len(texto) {
+		return false
+	}
+	for j, r := range termino {
+		if texto[pos+j] != r {
+			return false
+		}
+	}
+	return true
+}
+
+// recortar extrae texto[desde:hasta] marcando con puntos suspensivos si el texto continúa
+func recortar(texto []rune, desde, hasta int) string {
+	fragmento := strings.TrimSpace(string(texto[desde:hasta]))
+	if desde > 0 {
+		fragmento = "…" + fragmento
+	}
+	if hasta < len(texto) {
+		fragmento += "…"
+	}
+	return fragmento
+}
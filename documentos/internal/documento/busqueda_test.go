@@ -0,0 +1,77 @@
+package documento
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFragmentos(t *testing.T) {
+	t.Run("debe mostrar el texto alrededor del término sin distinguir mayúsculas", func(t *testing.T) {
+		// Arrange
+		texto := strings.Repeat("a ", 100) + "Experiencia en GOLANG y MySQL" + strings.Repeat(" b", 100)
+
+		// Act
+		resultado := fragmentos(texto, "golang")
+
+		// Assert
+		assert.Len(t, resultado, 1)
+		assert.Contains(t, resultado[0], "GOLANG")
+		assert.True(t, strings.HasPrefix(resultado[0], "…"))
+		assert.True(t, strings.HasSuffix(resultado[0], "…"))
+	})
+
+	t.Run("debe respetar los caracteres acentuados", func(t *testing.T) {
+		// Act
+		resultado := fragmentos("Título en Administración Pública", "administración")
+
+		// Assert
+		assert.Equal(t, []string{"Título en Administración Pública"}, resultado)
+	})
+
+	t.Run("debe retornar como máximo tres fragmentos sin solaparlos", func(t *testing.T) {
+		// Arrange
+		texto := strings.Repeat("go "+strings.Repeat("x", 200)+" ", 5)
+
+		// Act
+		resultado := fragmentos(texto, "go")
+
+		// Assert
+		assert.Len(t, resultado, maxFragmentos)
+	})
+
+	t.Run("debe retornar el inicio del texto si el término no aparece literalmente", func(t *testing.T) {
+		// Arrange
+		texto := strings.Repeat("palabra ", 50)
+
+		// Act
+		resultado := fragmentos(texto, "palabras")
+
+		// Assert
+		assert.Len(t, resultado, 1)
+		assert.True(t, strings.HasPrefix(resultado[0], "palabra"))
+		assert.LessOrEqual(t, len([]rune(resultado[0])), 2*contextoFragmento+1)
+	})
+
+	t.Run("debe retornar una lista vacía sin texto", func(t *testing.T) {
+		// Act
+		resultado := fragmentos("", "go")
+
+		// Assert
+		assert.NotNil(t, resultado)
+		assert.Empty(t, resultado)
+	})
+}
+
+func TestTerminosBusqueda(t *testing.T) {
+	// Act
+	terminos := terminosBusqueda("Analista, C# y SQL-Server 2024")
+
+	// Assert
+	var palabras []string
+	for _, termino := range terminos {
+		palabras = append(palabras, string(termino))
+	}
+	assert.Equal(t, []string{"analista", "sql", "server", "2024"}, palabras)
+}
@@ -0,0 +1,26 @@
+package documento
+
+// Categoria es una entrada del catálogo de tipos de documento que puede tener una solicitud,
+// por ejemplo la descripción del cargo o la aprobación de presupuesto
+type Categoria struct {
+	Codigo string `json:"codigo"`
+	Nombre string `json:"nombre"`
+}
+
+// CategoriaResponse describe una categoría del catálogo con las extensiones que acepta
+type CategoriaResponse struct {
+	Categoria
+	Extensiones []string `json:"extensiones"`
+}
+
+// Catalogo retorna las categorías configuradas junto con sus extensiones permitidas
+func (p PoliticaArchivos) Catalogo() []CategoriaResponse {
+	catalogo := make([]CategoriaResponse, len(p.Categorias))
+	for i, categoria := range p.Categorias {
+		catalogo[i] = CategoriaResponse{
+			Categoria:   categoria,
+			Extensiones: p.permitidas(categoria.Codigo),
+		}
+	}
+	return catalogo
+}
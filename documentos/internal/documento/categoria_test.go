@@ -0,0 +1,79 @@
+package documento
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// politicaConCategorias es un catálogo con una categoría restringida a PDF y otra sin restricciones
+var politicaConCategorias = PoliticaArchivos{
+	Permitidas:   []string{"pdf", "docx", "png"},
+	PorCategoria: map[string][]string{"descripcion_cargo": {"pdf", "docx", "odt"}},
+	Categorias: []Categoria{
+		{Codigo: "descripcion_cargo", Nombre: "Descripción del cargo"},
+		{Codigo: "otro", Nombre: "Otro"},
+	},
+}
+
+func TestPoliticaArchivos_ValidarCategoria(t *testing.T) {
+	t.Run("debe aceptar una categoría del catálogo o ninguna", func(t *testing.T) {
+		// Act & Assert
+		assert.NoError(t, politicaConCategorias.ValidarCategoria("descripcion_cargo"))
+		assert.NoError(t, politicaConCategorias.ValidarCategoria(""))
+	})
+
+	t.Run("debe rechazar con 422 una categoría que no está en el catálogo", func(t *testing.T) {
+		// Act
+		err := politicaConCategorias.ValidarCategoria("nda")
+
+		// Assert
+		var errValidacion *ErrorValidacionArchivo
+		require.ErrorAs(t, err, &errValidacion)
+		assert.Equal(t, CodigoCategoriaInvalida, errValidacion.Codigo)
+		assert.Equal(t, []string{"descripcion_cargo", "otro"}, errValidacion.Categorias)
+		assert.Equal(t, http.StatusUnprocessableEntity, errValidacion.StatusHTTP())
+	})
+
+	t.Run("debe aceptar cualquier categoría sin catálogo configurado", func(t *testing.T) {
+		// Act
+		err := PoliticaArchivos{}.ValidarCategoria("nda")
+
+		// Assert
+		assert.NoError(t, err)
+	})
+
+	t.Run("debe validar la categoría antes que la extensión", func(t *testing.T) {
+		// Act
+		err := politicaConCategorias.ValidarExtension("pdf", "nda")
+
+		// Assert
+		var errValidacion *ErrorValidacionArchivo
+		require.ErrorAs(t, err, &errValidacion)
+		assert.Equal(t, CodigoCategoriaInvalida, errValidacion.Codigo)
+	})
+}
+
+func TestPoliticaArchivos_Catalogo(t *testing.T) {
+	t.Run("debe informar las extensiones que acepta cada categoría", func(t *testing.T) {
+		// Act
+		catalogo := politicaConCategorias.Catalogo()
+
+		// Assert
+		assert.Equal(t, []CategoriaResponse{
+			{Categoria: Categoria{Codigo: "descripcion_cargo", Nombre: "Descripción del cargo"}, Extensiones: []string{"docx", "pdf"}},
+			{Categoria: Categoria{Codigo: "otro", Nombre: "Otro"}, Extensiones: []string{"docx", "pdf", "png"}},
+		}, catalogo)
+	})
+
+	t.Run("debe retornar un catálogo vacío si no hay categorías", func(t *testing.T) {
+		// Act
+		catalogo := PoliticaArchivos{}.Catalogo()
+
+		// Assert
+		assert.NotNil(t, catalogo)
+		assert.Empty(t, catalogo)
+	})
+}
@@ -0,0 +1,84 @@
+package documento
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Ámbitos y límites de las cuotas de almacenamiento
+const (
+	AmbitoSolicitud = "solicitud"
+	AmbitoUsuario   = "usuario"
+
+	LimiteArchivos = "archivos"
+	LimiteBytes    = "bytes"
+)
+
+// Cuota limita los documentos de un ámbito. Un valor 0 significa sin límite.
+type Cuota struct {
+	MaxArchivos int64
+	MaxBytes    int64
+}
+
+// Cuotas agrupa los límites de almacenamiento por solicitud y por usuario que sube los documentos
+type Cuotas struct {
+	PorSolicitud Cuota
+	PorUsuario   Cuota
+}
+
+// Uso es lo que ocupan los documentos vigentes de una solicitud o de un usuario
+type Uso struct {
+	Archivos int64 `json:"archivos"`
+	Bytes    int64 `json:"bytes"`
+	// Límites configurados; se omiten cuando no hay límite
+	MaxArchivos int64 `json:"max_archivos,omitempty"`
+	MaxBytes    int64 `json:"max_bytes,omitempty"`
+}
+
+// ErrorCuota indica que un documento superaría la cuota de almacenamiento
+type ErrorCuota struct {
+	Mensaje string `json:"error"`
+	Ambito  string `json:"ambito"`
+	Limite  string `json:"limite"`
+	Maximo  int64  `json:"maximo"`
+	Uso     int64  `json:"uso"`
+}
+
+func (e *ErrorCuota) Error() string {
+	return e.Mensaje
+}
+
+// StatusHTTP retorna 413 porque la petición excede el espacio disponible
+func (e *ErrorCuota) StatusHTTP() int {
+	return http.StatusRequestEntityTooLarge
+}
+
+// verificar comprueba si agregar archivos y bytes al uso actual supera la cuota
+func (c Cuota) verificar(ambito string, uso Uso, archivos, bytes int64) error {
+	if c.MaxArchivos > 0 && archivos > 0 && uso.Archivos+archivos > c.MaxArchivos {
+		return &ErrorCuota{
+			Mensaje: fmt.Sprintf("Se alcanzó el máximo de %d archivos por %s", c.MaxArchivos, ambito),
+			Ambito:  ambito,
+			Limite:  LimiteArchivos,
+			Maximo:  c.MaxArchivos,
+			Uso:     uso.Archivos,
+		}
+	}
+	if c.MaxBytes > 0 && bytes > 0 && uso.Bytes+bytes > c.MaxBytes {
+		return &ErrorCuota{
+			Mensaje: fmt.Sprintf("El archivo supera el espacio disponible por %s: %d de %d bytes usados", ambito, uso.Bytes, c.MaxBytes),
+			Ambito:  ambito,
+			Limite:  LimiteBytes,
+			Maximo:  c.MaxBytes,
+			Uso:     uso.Bytes,
+		}
+	}
+	return nil
+}
+
+// conLimites agrega al uso los límites configurados para informarlos al cliente
+func (c Cuota) conLimites(uso Uso) *Uso {
+	uso.MaxArchivos = c.MaxArchivos
+	uso.MaxBytes = c.MaxBytes
+	return &uso
+}
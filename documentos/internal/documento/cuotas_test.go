@@ -0,0 +1,63 @@
+package documento
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCuota_Verificar(t *testing.T) {
+	cuota := Cuota{MaxArchivos: 3, MaxBytes: 1000}
+
+	t.Run("debe permitir lo que cabe exactamente en la cuota", func(t *testing.T) {
+		// Act
+		err := cuota.verificar(AmbitoSolicitud, Uso{Archivos: 2, Bytes: 600}, 1, 400)
+
+		// Assert
+		assert.NoError(t, err)
+	})
+
+	t.Run("debe rechazar un archivo más del máximo", func(t *testing.T) {
+		// Act
+		err := cuota.verificar(AmbitoSolicitud, Uso{Archivos: 3, Bytes: 100}, 1, 10)
+
+		// Assert
+		var errCuota *ErrorCuota
+		require.ErrorAs(t, err, &errCuota)
+		assert.Equal(t, AmbitoSolicitud, errCuota.Ambito)
+		assert.Equal(t, LimiteArchivos, errCuota.Limite)
+		assert.Equal(t, int64(3), errCuota.Maximo)
+		assert.Equal(t, int64(3), errCuota.Uso)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, errCuota.StatusHTTP())
+	})
+
+	t.Run("debe rechazar los bytes que superan el máximo", func(t *testing.T) {
+		// Act
+		err := cuota.verificar(AmbitoUsuario, Uso{Archivos: 1, Bytes: 900}, 1, 101)
+
+		// Assert
+		var errCuota *ErrorCuota
+		require.ErrorAs(t, err, &errCuota)
+		assert.Equal(t, AmbitoUsuario, errCuota.Ambito)
+		assert.Equal(t, LimiteBytes, errCuota.Limite)
+		assert.Equal(t, int64(900), errCuota.Uso)
+	})
+
+	t.Run("debe permitir reducir el tamaño aunque la cuota ya esté excedida", func(t *testing.T) {
+		// Act
+		err := cuota.verificar(AmbitoSolicitud, Uso{Archivos: 5, Bytes: 2000}, 0, -500)
+
+		// Assert
+		assert.NoError(t, err)
+	})
+
+	t.Run("no debe limitar una cuota en cero", func(t *testing.T) {
+		// Act
+		err := Cuota{}.verificar(AmbitoSolicitud, Uso{Archivos: 1000, Bytes: 1 << 40}, 1, 1<<30)
+
+		// Assert
+		assert.NoError(t, err)
+	})
+}
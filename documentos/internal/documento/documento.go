@@ -1,6 +1,7 @@
 package documento
 
 import (
+	"io"
 	"time"
 
 	"gorm.io/gorm"
@@ -10,20 +11,60 @@ type Documento struct {
 	ID            uint           `gorm:"primaryKey;autoIncrement" json:"id"`
 	Extension     string         `gorm:"type:varchar(5);not null" json:"extension"`
 	NombreArchivo string         `gorm:"type:varchar(255);not null" json:"nombre_archivo"`
+	Tamano        int64          `gorm:"not null;default:0" json:"tamano"`
+	TipoMime      string         `gorm:"type:varchar(100)" json:"tipo_mime"`
+	Version       int            `gorm:"not null;default:1" json:"version"`
 	CreatedAt     time.Time      `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt     time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
 	// Cuando se elimine una solicitud (soft delete), los documentos asociados también se marcarán como eliminados
-	SolicitudID uint `gorm:"not null" json:"-"`
+	SolicitudID uint `gorm:"not null;index" json:"-"`
+	// Clave con la que se guardó el contenido en el Storage (vacía si el documento no tiene contenido)
+	ClaveAlmacenamiento string `gorm:"type:varchar(255)" json:"-"`
+	// Usuario que subió el documento
+	UsuarioID *uint `gorm:"index" json:"usuario_id,omitempty"`
+	// Carga reanudable de la que proviene el documento; el índice único impide crear dos documentos por carga
+	CargaID *string `gorm:"type:char(32);uniqueIndex" json:"-"`
+	// SHA-256 del contenido, identifica el Blob compartido con otros documentos
+	Checksum string `gorm:"type:char(64);index" json:"checksum,omitempty"`
+	// Categoría del documento, determina las extensiones permitidas
+	Categoria string `gorm:"type:varchar(50);index" json:"categoria,omitempty"`
+	// Resultado del análisis antivirus del contenido actual
+	EstadoEscaneo  string `gorm:"type:varchar(20);index" json:"estado_escaneo,omitempty"`
+	DetalleEscaneo string `gorm:"type:varchar(255)" json:"detalle_escaneo,omitempty"`
+	// Fecha hasta la que el documento es válido, por ejemplo un certificado de antecedentes
+	VenceEl *time.Time `gorm:"type:date;index" json:"vence_el,omitempty"`
+	// Cuándo se avisó que el documento está por vencer; se reinicia al cambiar VenceEl
+	AvisoVencimientoEl *time.Time `json:"-"`
+	// Etiquetas y metadatos definidos por el usuario, no forman parte de las versiones
+	Etiquetas []Etiqueta `gorm:"foreignKey:DocumentoID" json:"-"`
+	Metadatos []Metadato `gorm:"foreignKey:DocumentoID" json:"-"`
 }
 
+// Estados del análisis antivirus de un documento
+const (
+	EstadoPendienteEscaneo = "pendiente_escaneo"
+	EstadoLimpio           = "limpio"
+	EstadoEnCuarentena     = "en_cuarentena"
+)
+
 // DocumentoResponse es la estructura de respuesta para los documentos
 type DocumentoResponse struct {
-	ID            uint      `json:"id"`
-	Extension     string    `json:"extension"`
-	NombreArchivo string    `json:"nombre_archivo"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID            uint       `json:"id"`
+	Extension     string     `json:"extension"`
+	NombreArchivo string     `json:"nombre_archivo"`
+	Tamano        int64      `json:"tamano"`
+	TipoMime      string     `json:"tipo_mime"`
+	Checksum      string     `json:"checksum,omitempty"`
+	Categoria     string     `json:"categoria,omitempty"`
+	Etiquetas     []string   `json:"etiquetas,omitempty"`
+	Metadatos     []Metadato `json:"metadatos,omitempty"`
+	EstadoEscaneo string     `json:"estado_escaneo,omitempty"`
+	VenceEl       *time.Time `json:"vence_el,omitempty"`
+	URLMiniatura  string     `json:"url_miniatura,omitempty"`
+	Version       int        `json:"version"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
 	Solicitud     struct {
 		ID     uint   `json:"solicitud_id"`
 		Titulo string `json:"titulo"`
@@ -31,6 +72,118 @@ type DocumentoResponse struct {
 	} `json:"solicitud"`
 }
 
+// Version es una revisión inmutable de un documento. Cada versión guarda el estado completo
+// del documento en ese momento; el registro de Documento refleja siempre la versión actual.
+type Version struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	DocumentoID   uint      `gorm:"not null;uniqueIndex:idx_documento_version" json:"documento_id"`
+	Numero        int       `gorm:"not null;uniqueIndex:idx_documento_version" json:"numero"`
+	Extension     string    `gorm:"type:varchar(5);not null" json:"extension"`
+	NombreArchivo string    `gorm:"type:varchar(255);not null" json:"nombre_archivo"`
+	Tamano        int64     `gorm:"not null;default:0" json:"tamano"`
+	TipoMime      string    `gorm:"type:varchar(100)" json:"tipo_mime"`
+	Cambios       string    `gorm:"type:varchar(255)" json:"cambios"`
+	UsuarioID     *uint     `json:"usuario_id,omitempty"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+	// Clave del archivo de esta versión en el Storage
+	ClaveAlmacenamiento string `gorm:"type:varchar(255)" json:"-"`
+	Checksum            string `gorm:"type:char(64);index" json:"checksum,omitempty"`
+}
+
+// versionDesdeDocumento crea una versión con el estado actual del documento
+func versionDesdeDocumento(d *Documento, cambios string, usuarioID *uint) *Version {
+	return &Version{
+		DocumentoID:         d.ID,
+		Numero:              d.Version,
+		Extension:           d.Extension,
+		NombreArchivo:       d.NombreArchivo,
+		Tamano:              d.Tamano,
+		TipoMime:            d.TipoMime,
+		ClaveAlmacenamiento: d.ClaveAlmacenamiento,
+		Checksum:            d.Checksum,
+		Cambios:             cambios,
+		UsuarioID:           usuarioID,
+	}
+}
+
+// TableName especifica el nombre de la tabla de versiones
+func (Version) TableName() string {
+	return "documento_versiones"
+}
+
+// Blob es un archivo guardado una única vez en el Storage, identificado por su SHA-256.
+// Referencias cuenta las versiones de documentos que apuntan a él.
+type Blob struct {
+	Checksum            string    `gorm:"type:char(64);primaryKey" json:"checksum"`
+	ClaveAlmacenamiento string    `gorm:"type:varchar(255);not null" json:"-"`
+	Tamano              int64     `gorm:"not null" json:"tamano"`
+	Referencias         int       `gorm:"not null;default:0" json:"-"`
+	EstadoEscaneo       string    `gorm:"type:varchar(20)" json:"estado_escaneo"`
+	DetalleEscaneo      string    `gorm:"type:varchar(255)" json:"-"`
+	ClaveMiniatura      string    `gorm:"type:varchar(255)" json:"-"` // vacía si aún no se ha generado
+	CreatedAt           time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt           time.Time `gorm:"autoUpdateTime" json:"-"`
+}
+
+// TableName especifica el nombre de la tabla de blobs
+func (Blob) TableName() string {
+	return "blobs"
+}
+
+// TextoBlob guarda el texto extraído del contenido de un Blob para la búsqueda de texto completo.
+// Un texto vacío indica que el contenido ya fue procesado pero no tiene texto extraíble.
+type TextoBlob struct {
+	Checksum  string    `gorm:"type:char(64);primaryKey"`
+	Contenido string    `gorm:"type:longtext;index:idx_blob_textos_contenido,class:FULLTEXT"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName especifica el nombre de la tabla de textos extraídos
+func (TextoBlob) TableName() string {
+	return "blob_textos"
+}
+
+// Coincidencia es un documento encontrado por la búsqueda junto con el texto de su contenido
+type Coincidencia struct {
+	Documento
+	Texto string
+}
+
+// ResultadoBusqueda es un documento encontrado con los fragmentos del texto que coinciden
+type ResultadoBusqueda struct {
+	DocumentoResponse
+	Fragmentos []string `json:"fragmentos"`
+}
+
+// EnlaceUsado registra el consumo de un enlace firmado de un solo uso
+type EnlaceUsado struct {
+	Nonce       string    `gorm:"type:char(32);primaryKey"`
+	DocumentoID uint      `gorm:"not null;index"`
+	ExpiraEl    time.Time `gorm:"index"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName especifica el nombre de la tabla de enlaces usados
+func (EnlaceUsado) TableName() string {
+	return "enlaces_usados"
+}
+
+// Contenido representa el contenido binario de un documento listo para ser enviado
+type Contenido struct {
+	Documento *Documento
+	Archivo   io.ReadSeekCloser
+	// URL de descarga directa desde el almacenamiento; si está presente Archivo es nil
+	URL string
+}
+
+// NombreDescarga retorna el nombre con el que se descarga el archivo
+func (d *Documento) NombreDescarga() string {
+	if d.Extension == "" {
+		return d.NombreArchivo
+	}
+	return d.NombreArchivo + "." + d.Extension
+}
+
 // TableName especifica el nombre de la tabla
 func (Documento) TableName() string {
 	return "documentos"
@@ -38,15 +191,41 @@ func (Documento) TableName() string {
 
 //CreateReq representa la petición para crear un documento
 type CreateReq struct {
-	Extension     string `json:"extension" binding:"required"`
-	NombreArchivo string `json:"nombre_archivo" binding:"required"`
-	SolicitudID   uint   `json:"solicitud_id" binding:"required"`
+	Extension     string     `json:"extension" binding:"required"`
+	NombreArchivo string     `json:"nombre_archivo" binding:"required"`
+	SolicitudID   uint       `json:"solicitud_id" binding:"required"`
+	Categoria     string     `json:"categoria,omitempty"`
+	Etiquetas     []string   `json:"etiquetas,omitempty"`
+	Metadatos     []Metadato `json:"metadatos,omitempty"`
+	// Fecha de vencimiento con formato YYYY-MM-DD
+	VenceEl string `json:"vence_el,omitempty"`
+	// Checksum de un archivo ya existente en el servidor, para no volver a subirlo
+	Checksum string `json:"checksum,omitempty"`
+	// Contenido del archivo, solo presente en cargas multipart
+	Archivo   io.Reader `json:"-"`
+	UsuarioID *uint     `json:"-"`
+	// Identificador de la carga reanudable que origina el documento, si la hay
+	CargaID string `json:"-"`
 }
 
 //UpdateReq representa la petición para actualizar un documento
 type UpdateReq struct {
 	Extension     *string `json:"extension"`
 	NombreArchivo *string `json:"nombre_archivo"`
+	Categoria     *string `json:"categoria"`
+	// Etiquetas y Metadatos reemplazan los existentes cuando se envían
+	Etiquetas *[]string   `json:"etiquetas"`
+	Metadatos *[]Metadato `json:"metadatos"`
+	// VenceEl con formato YYYY-MM-DD; vacío quita la fecha de vencimiento
+	VenceEl   *string `json:"vence_el"`
+	UsuarioID *uint   `json:"-"`
+}
+
+// ContenidoReq representa la petición para reemplazar el archivo de un documento
+type ContenidoReq struct {
+	Archivo   io.Reader
+	Extension string
+	UsuarioID *uint
 }
 
 //GetAllReq representa los filtros para obtener documentos
@@ -54,6 +233,62 @@ type GetAllReq struct {
 	Extension     string
 	NombreArchivo string
 	SolicitudID   uint // para ver los documentos de una solicitud en particular
-	Limit         int
-	Page          int
+	// Etiquetas que debe tener el documento, todas ellas
+	Etiquetas []string
+	// Metadatos por clave que deben tener exactamente el valor indicado
+	Metadatos map[string]string
+	Limit     int
+	Page      int
+}
+
+// BuscarReq representa los parámetros de la búsqueda de texto completo
+type BuscarReq struct {
+	Q           string
+	SolicitudID uint
+	Limit       int
+}
+
+// EnlaceReq representa la petición para generar un enlace de descarga firmado
+type EnlaceReq struct {
+	// Vigencia del enlace; si no se envía se usa la vigencia por defecto
+	ExpiraEnSegundos int   `json:"expira_en_segundos"`
+	UnSoloUso        bool  `json:"un_solo_uso"`
+	UsuarioID        *uint `json:"-"`
+}
+
+// EnlaceResponse es un enlace de descarga firmado que no requiere acceso a la API
+type EnlaceResponse struct {
+	URL       string    `json:"url"`
+	Version   int       `json:"version"`
+	ExpiraEl  time.Time `json:"expira_el"`
+	UnSoloUso bool      `json:"un_solo_uso"`
+}
+
+// EnlaceFirmado son los parámetros recibidos en la URL pública de descarga
+type EnlaceFirmado struct {
+	DocumentoID uint
+	Version     int
+	Expira      int64 // segundos Unix
+	Nonce       string
+	Firma       string
+}
+
+// DocumentoEliminado es un documento en la papelera (soft delete)
+type DocumentoEliminado struct {
+	DocumentoResponse
+	EliminadoEl time.Time `json:"eliminado_el"`
+	// Indica si la solicitud del documento sigue existiendo, requisito para restaurarlo
+	SolicitudVigente bool `json:"solicitud_vigente"`
+}
+
+// UsoReq indica de qué solicitud y/o usuario se consulta el uso de almacenamiento
+type UsoReq struct {
+	SolicitudID uint
+	UsuarioID   *uint
+}
+
+// UsoResponse es el uso de almacenamiento de la solicitud y del usuario consultados
+type UsoResponse struct {
+	Solicitud *Uso `json:"solicitud,omitempty"`
+	Usuario   *Uso `json:"usuario,omitempty"`
 }
@@ -2,13 +2,27 @@ package documento
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kramirez/documentos/pkg/miniatura"
+	"github.com/kramirez/documentos/pkg/storage"
+	"gorm.io/gorm"
 )
 
+// maxUploadSize es el tamaño máximo aceptado para el cuerpo de una carga multipart
+const maxUploadSize = 50 << 20
+
+// maxDiasPorVencer es el período máximo que se puede consultar en GET /documentos/por-vencer
+const maxDiasPorVencer = 3650
+
 type Endpoint struct {
 	service Service
 }
@@ -19,6 +33,12 @@ func NewEndpoint(service Service) *Endpoint {
 
 // Create maneja POST /documentos
 func (e *Endpoint) Create(c *gin.Context) {
+	// Las cargas con archivo llegan como multipart/form-data
+	if c.ContentType() == "multipart/form-data" {
+		e.createMultipart(c)
+		return
+	}
+
 	var req CreateReq
 	// Configurar decoder para rechazar campos desconocidos
 	decoder := json.NewDecoder(c.Request.Body)
@@ -34,9 +54,189 @@ func (e *Endpoint) Create(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Todos los campos son requeridos"})
 		return
 	}
+	req.Extension = strings.ToLower(strings.TrimPrefix(req.Extension, "."))
+	if req.Checksum != "" && !checksumValido(req.Checksum) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Checksum inválido, debe ser un SHA-256 en hexadecimal"})
+		return
+	}
+	req.UsuarioID = usuarioID(c)
+
+	documento, err := e.service.Create(c.Request.Context(), req)
+	if err != nil {
+		if errors.Is(err, ErrBlobNoEncontrado) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if responderErrorValidacion(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, documento)
+}
+
+// responderErrorValidacion responde con 415/422 si el error es de validación de archivo,
+// con 413 si el documento supera una cuota de almacenamiento y con 400 si las etiquetas,
+// los metadatos o la fecha de vencimiento no son válidos
+func responderErrorValidacion(c *gin.Context, err error) bool {
+	var errValidacion *ErrorValidacionArchivo
+	var errCuota *ErrorCuota
+	switch {
+	case errors.As(err, &errValidacion):
+		c.JSON(errValidacion.StatusHTTP(), errValidacion)
+	case errors.As(err, &errCuota):
+		c.JSON(errCuota.StatusHTTP(), errCuota)
+	case errors.Is(err, ErrEtiquetadoInvalido), errors.Is(err, ErrVencimientoInvalido):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
+
+// checksumValido verifica que el valor sea un SHA-256 en hexadecimal
+func checksumValido(checksum string) bool {
+	if len(checksum) != 64 {
+		return false
+	}
+	for _, r := range checksum {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetBlob maneja GET /documentos/checksum/:checksum?solicitud_id=
+// Solo se informan los archivos de documentos de la solicitud o del usuario de la cabecera X-Usuario-ID.
+func (e *Endpoint) GetBlob(c *gin.Context) {
+	checksum := strings.ToLower(c.Param("checksum"))
+	if !checksumValido(checksum) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Checksum inválido, debe ser un SHA-256 en hexadecimal"})
+		return
+	}
+	var solicitudID uint
+	if valor := c.Query("solicitud_id"); valor != "" {
+		sid, err := strconv.ParseUint(valor, 10, 32)
+		if err != nil || sid == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID de solicitud inválido"})
+			return
+		}
+		solicitudID = uint(sid)
+	}
+	usuario := usuarioID(c)
+	if solicitudID == 0 && usuario == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Se requiere solicitud_id o la cabecera X-Usuario-ID"})
+		return
+	}
+
+	blob, err := e.service.GetBlob(c.Request.Context(), checksum, solicitudID, usuario)
+	if err != nil {
+		if errors.Is(err, ErrBlobNoEncontrado) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, blob)
+}
+
+// archivoMultipart es el archivo recibido en el campo "archivo" de una carga multipart
+type archivoMultipart struct {
+	file          multipart.File
+	nombreArchivo string
+	extension     string
+}
+
+// leerArchivoMultipart lee el campo "archivo" de la petición. Si retorna false ya respondió con el error.
+func leerArchivoMultipart(c *gin.Context) (*archivoMultipart, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadSize)
+
+	fileHeader, err := c.FormFile("archivo")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("El archivo supera el tamaño máximo de %d bytes", maxUploadSize)})
+			return nil, false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El campo 'archivo' es requerido"})
+		return nil, false
+	}
+
+	// Extensión y nombre se toman del archivo si no se envían explícitamente
+	extension := strings.ToLower(strings.TrimPrefix(c.PostForm("extension"), "."))
+	if extension == "" {
+		extension = strings.ToLower(strings.TrimPrefix(filepath.Ext(fileHeader.Filename), "."))
+	}
+	nombreArchivo := c.PostForm("nombre_archivo")
+	if nombreArchivo == "" {
+		nombreArchivo = strings.TrimSuffix(filepath.Base(fileHeader.Filename), filepath.Ext(fileHeader.Filename))
+	}
+	if extension == "" || nombreArchivo == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo determinar el nombre o la extensión del archivo"})
+		return nil, false
+	}
+	if len(extension) > 5 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La extensión no puede superar los 5 caracteres"})
+		return nil, false
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el archivo"})
+		return nil, false
+	}
+
+	return &archivoMultipart{
+		file:          file,
+		nombreArchivo: nombreArchivo,
+		extension:     extension,
+	}, true
+}
+
+// createMultipart maneja POST /documentos con el archivo en el campo "archivo"
+func (e *Endpoint) createMultipart(c *gin.Context) {
+	archivo, ok := leerArchivoMultipart(c)
+	if !ok {
+		return
+	}
+	defer archivo.file.Close()
+
+	solicitudID, err := strconv.ParseUint(c.PostForm("solicitud_id"), 10, 32)
+	if err != nil || solicitudID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de solicitud inválido"})
+		return
+	}
+
+	req := CreateReq{
+		Extension:     archivo.extension,
+		NombreArchivo: archivo.nombreArchivo,
+		SolicitudID:   uint(solicitudID),
+		Categoria:     c.PostForm("categoria"),
+		VenceEl:       c.PostForm("vence_el"),
+		Archivo:       archivo.file,
+		UsuarioID:     usuarioID(c),
+	}
+
+	// Las etiquetas pueden enviarse en varios campos o separadas por comas
+	for _, etiquetas := range c.PostFormArray("etiquetas") {
+		req.Etiquetas = append(req.Etiquetas, strings.Split(etiquetas, ",")...)
+	}
+	// Los metadatos se envían como un arreglo JSON en el campo "metadatos"
+	if metadatos := c.PostForm("metadatos"); metadatos != "" {
+		if err := json.Unmarshal([]byte(metadatos), &req.Metadatos); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El campo 'metadatos' debe ser un arreglo JSON de objetos con clave, tipo y valor"})
+			return
+		}
+	}
 
 	documento, err := e.service.Create(c.Request.Context(), req)
 	if err != nil {
+		if responderErrorValidacion(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -50,6 +250,19 @@ func (e *Endpoint) GetAll(c *gin.Context) {
 		NombreArchivo: c.Query("nombre_archivo"),
 	}
 
+	// Filtros por etiqueta (?etiqueta=a&etiqueta=b) y por metadato (?metadatos[autor]=valor)
+	for _, etiqueta := range c.QueryArray("etiqueta") {
+		if etiqueta = strings.ToLower(strings.TrimSpace(etiqueta)); etiqueta != "" {
+			filters.Etiquetas = append(filters.Etiquetas, etiqueta)
+		}
+	}
+	if metadatos := c.QueryMap("metadatos"); len(metadatos) > 0 {
+		filters.Metadatos = make(map[string]string, len(metadatos))
+		for clave, valor := range metadatos {
+			filters.Metadatos[strings.ToLower(clave)] = valor
+		}
+	}
+
 	// Convertir solicitud_id
 	if solicitudID := c.Query("solicitud_id"); solicitudID != "" {
 		if sid, err := strconv.Atoi(solicitudID); err == nil {
@@ -77,6 +290,153 @@ func (e *Endpoint) GetAll(c *gin.Context) {
 	c.JSON(http.StatusOK, documentos)
 }
 
+// Buscar maneja GET /documentos/buscar?q=
+func (e *Endpoint) Buscar(c *gin.Context) {
+	req := BuscarReq{Q: strings.TrimSpace(c.Query("q"))}
+	if req.Q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El parámetro q es requerido"})
+		return
+	}
+
+	if solicitudID := c.Query("solicitud_id"); solicitudID != "" {
+		if sid, err := strconv.Atoi(solicitudID); err == nil {
+			req.SolicitudID = uint(sid)
+		}
+	}
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			req.Limit = l
+		}
+	}
+
+	resultados, err := e.service.Buscar(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resultados)
+}
+
+// GetPorVencer maneja GET /documentos/por-vencer?dias=30
+func (e *Endpoint) GetPorVencer(c *gin.Context) {
+	req := PorVencerReq{Dias: 30}
+	if dias := c.Query("dias"); dias != "" {
+		d, err := strconv.Atoi(dias)
+		if err != nil || d < 0 || d > maxDiasPorVencer {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("El parámetro dias debe ser un número entero entre 0 y %d", maxDiasPorVencer)})
+			return
+		}
+		req.Dias = d
+	}
+	if solicitudID := c.Query("solicitud_id"); solicitudID != "" {
+		if sid, err := strconv.Atoi(solicitudID); err == nil {
+			req.SolicitudID = uint(sid)
+		}
+	}
+	req.IncluirVencidos = c.Query("vencidos") == "true"
+
+	documentos, err := e.service.GetPorVencer(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, documentos)
+}
+
+// GetPapelera maneja GET /documentos/papelera
+func (e *Endpoint) GetPapelera(c *gin.Context) {
+	var filters GetAllReq
+	if solicitudID := c.Query("solicitud_id"); solicitudID != "" {
+		if sid, err := strconv.Atoi(solicitudID); err == nil {
+			filters.SolicitudID = uint(sid)
+		}
+	}
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			filters.Limit = l
+		}
+	}
+	if page := c.Query("page"); page != "" {
+		if p, err := strconv.Atoi(page); err == nil {
+			filters.Page = p
+		}
+	}
+
+	documentos, err := e.service.GetPapelera(c.Request.Context(), filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, documentos)
+}
+
+// Restaurar maneja POST /documentos/:id/restaurar
+func (e *Endpoint) Restaurar(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	documento, err := e.service.Restaurar(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Documento no encontrado en la papelera"})
+			return
+		}
+		if errors.Is(err, ErrSolicitudEliminada) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if responderErrorValidacion(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, documento)
+}
+
+// GetCategorias maneja GET /documentos/categorias
+func (e *Endpoint) GetCategorias(c *gin.Context) {
+	c.JSON(http.StatusOK, e.service.GetCategorias(c.Request.Context()))
+}
+
+// GetUso maneja GET /documentos/uso?solicitud_id=&usuario_id=
+// Sin usuario_id se informa el uso del usuario de la cabecera X-Usuario-ID, si viene.
+func (e *Endpoint) GetUso(c *gin.Context) {
+	var req UsoReq
+	if solicitudID := c.Query("solicitud_id"); solicitudID != "" {
+		sid, err := strconv.ParseUint(solicitudID, 10, 32)
+		if err != nil || sid == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID de solicitud inválido"})
+			return
+		}
+		req.SolicitudID = uint(sid)
+	}
+	req.UsuarioID = usuarioID(c)
+	if usuario := c.Query("usuario_id"); usuario != "" {
+		uid, err := strconv.ParseUint(usuario, 10, 32)
+		if err != nil || uid == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID de usuario inválido"})
+			return
+		}
+		id := uint(uid)
+		req.UsuarioID = &id
+	}
+	if req.SolicitudID == 0 && req.UsuarioID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Se requiere solicitud_id o usuario_id"})
+		return
+	}
+
+	uso, err := e.service.GetUso(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, uso)
+}
+
 // GetByID maneja GET /documentos/:id
 func (e *Endpoint) GetByID(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -93,6 +453,325 @@ func (e *Endpoint) GetByID(c *gin.Context) {
 	c.JSON(http.StatusOK, documento)
 }
 
+// GetContenido maneja GET /documentos/:id/contenido
+func (e *Endpoint) GetContenido(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	contenido, err := e.service.GetContenido(c.Request.Context(), uint(id))
+	if err != nil {
+		if esNoEncontrado(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Contenido del documento no encontrado"})
+			return
+		}
+		if responderErrorEscaneo(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	servirContenido(c, contenido)
+}
+
+// GetMiniatura maneja GET /documentos/:id/miniatura
+func (e *Endpoint) GetMiniatura(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	contenido, err := e.service.GetMiniatura(c.Request.Context(), uint(id))
+	if err != nil {
+		if esNoEncontrado(err) || errors.Is(err, ErrSinMiniatura) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Miniatura del documento no encontrada"})
+			return
+		}
+		if responderErrorEscaneo(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer contenido.Archivo.Close()
+
+	doc := contenido.Documento
+	c.Header("Content-Type", miniatura.TipoMime)
+	c.Header("ETag", `"`+doc.Checksum+`-miniatura"`)
+	c.Header("Cache-Control", "private, max-age=86400")
+	http.ServeContent(c.Writer, c.Request, "", doc.UpdatedAt, contenido.Archivo)
+}
+
+// servirContenido envía el archivo con sus cabeceras de descarga y soporte de Range
+func servirContenido(c *gin.Context, contenido *Contenido) {
+	defer contenido.Archivo.Close()
+
+	doc := contenido.Documento
+
+	// Con ?inline=true el navegador puede mostrar el archivo en lugar de descargarlo
+	disposition := "attachment"
+	if c.Query("inline") == "true" {
+		disposition = "inline"
+	}
+
+	tipoMime := doc.TipoMime
+	if tipoMime == "" {
+		tipoMime = "application/octet-stream"
+	}
+
+	c.Header("Content-Type", tipoMime)
+	c.Header("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": doc.NombreDescarga()}))
+	// El checksum identifica el contenido exacto, por lo que sirve como ETag fuerte
+	if doc.Checksum != "" {
+		c.Header("ETag", `"`+doc.Checksum+`"`)
+	} else {
+		c.Header("ETag", fmt.Sprintf(`"%d-%d-%d-%x"`, doc.ID, doc.Version, doc.Tamano, uint64(doc.UpdatedAt.UnixNano())))
+	}
+
+	// ServeContent resuelve Range, If-Range, If-None-Match e If-Modified-Since
+	http.ServeContent(c.Writer, c.Request, doc.NombreDescarga(), doc.UpdatedAt, contenido.Archivo)
+}
+
+// esNoEncontrado indica si el error corresponde a un documento, versión o archivo inexistente
+func esNoEncontrado(err error) bool {
+	return errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, ErrSinContenido) || errors.Is(err, storage.ErrNotFound)
+}
+
+// responderErrorEscaneo responde 409 si el contenido aún no se analiza y 403 si está en cuarentena
+func responderErrorEscaneo(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, ErrEscaneoPendiente):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "estado_escaneo": EstadoPendienteEscaneo})
+	case errors.Is(err, ErrEnCuarentena):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "estado_escaneo": EstadoEnCuarentena})
+	default:
+		return false
+	}
+	return true
+}
+
+// usuarioID obtiene el usuario que realiza la operación desde el header X-Usuario-ID
+func usuarioID(c *gin.Context) *uint {
+	id, err := strconv.ParseUint(c.GetHeader("X-Usuario-ID"), 10, 32)
+	if err != nil || id == 0 {
+		return nil
+	}
+	uid := uint(id)
+	return &uid
+}
+
+// ReemplazarContenido maneja PUT /documentos/:id/contenido
+func (e *Endpoint) ReemplazarContenido(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	archivo, ok := leerArchivoMultipart(c)
+	if !ok {
+		return
+	}
+	defer archivo.file.Close()
+
+	req := ContenidoReq{
+		Archivo:   archivo.file,
+		Extension: archivo.extension,
+		UsuarioID: usuarioID(c),
+	}
+
+	documento, err := e.service.ReemplazarContenido(c.Request.Context(), uint(id), req)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Documento no encontrado"})
+			return
+		}
+		if responderErrorValidacion(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, documento)
+}
+
+// GetVersiones maneja GET /documentos/:id/versiones
+func (e *Endpoint) GetVersiones(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	versiones, err := e.service.GetVersiones(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Documento no encontrado"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, versiones)
+}
+
+// parseVersion obtiene el ID del documento y el número de versión de la ruta
+func parseVersion(c *gin.Context) (uint, int, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return 0, 0, false
+	}
+	numero, err := strconv.Atoi(c.Param("version"))
+	if err != nil || numero < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Número de versión inválido"})
+		return 0, 0, false
+	}
+	return uint(id), numero, true
+}
+
+// GetVersion maneja GET /documentos/:id/versiones/:version
+func (e *Endpoint) GetVersion(c *gin.Context) {
+	id, numero, ok := parseVersion(c)
+	if !ok {
+		return
+	}
+
+	version, err := e.service.GetVersion(c.Request.Context(), id, numero)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Versión no encontrada"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, version)
+}
+
+// GetVersionContenido maneja GET /documentos/:id/versiones/:version/contenido
+func (e *Endpoint) GetVersionContenido(c *gin.Context) {
+	id, numero, ok := parseVersion(c)
+	if !ok {
+		return
+	}
+
+	contenido, err := e.service.GetVersionContenido(c.Request.Context(), id, numero)
+	if err != nil {
+		if esNoEncontrado(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Contenido de la versión no encontrado"})
+			return
+		}
+		if responderErrorEscaneo(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	servirContenido(c, contenido)
+}
+
+// RestaurarVersion maneja POST /documentos/:id/versiones/:version/restaurar
+func (e *Endpoint) RestaurarVersion(c *gin.Context) {
+	id, numero, ok := parseVersion(c)
+	if !ok {
+		return
+	}
+
+	documento, err := e.service.RestaurarVersion(c.Request.Context(), id, numero, usuarioID(c))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Versión no encontrada"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, documento)
+}
+
+// CrearEnlace maneja POST /documentos/:id/enlaces
+func (e *Endpoint) CrearEnlace(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req EnlaceReq
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.ExpiraEnSegundos < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expira_en_segundos debe ser positivo"})
+		return
+	}
+	req.UsuarioID = usuarioID(c)
+
+	enlace, err := e.service.CrearEnlace(c.Request.Context(), uint(id), req)
+	if err != nil {
+		if esNoEncontrado(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Contenido del documento no encontrado"})
+			return
+		}
+		if responderErrorEscaneo(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, enlace)
+}
+
+// GetContenidoEnlace maneja GET /publico/documentos/:id/contenido, la descarga mediante un enlace firmado
+func (e *Endpoint) GetContenidoEnlace(c *gin.Context) {
+	id, errID := strconv.ParseUint(c.Param("id"), 10, 32)
+	version, errVersion := strconv.Atoi(c.Query("v"))
+	expira, errExpira := strconv.ParseInt(c.Query("exp"), 10, 64)
+	if errID != nil || errVersion != nil || errExpira != nil || c.Query("firma") == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": ErrEnlaceInvalido.Error()})
+		return
+	}
+
+	contenido, err := e.service.GetContenidoEnlace(c.Request.Context(), EnlaceFirmado{
+		DocumentoID: uint(id),
+		Version:     version,
+		Expira:      expira,
+		Nonce:       c.Query("n"),
+		Firma:       c.Query("firma"),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrEnlaceInvalido):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrEnlaceExpirado), errors.Is(err, ErrEnlaceUsado):
+			c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+		case esNoEncontrado(err):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Contenido del documento no encontrado"})
+		default:
+			if !responderErrorEscaneo(c, err) {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+		}
+		return
+	}
+
+	// El enlace puede compartirse fuera del sistema, por lo que la respuesta no debe quedar en cachés intermedias
+	c.Header("Cache-Control", "private, no-store")
+	if contenido.URL != "" {
+		c.Redirect(http.StatusFound, contenido.URL)
+		return
+	}
+	servirContenido(c, contenido)
+}
+
 // Update maneja PATCH /documentos/:id
 func (e *Endpoint) Update(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -121,6 +800,10 @@ func (e *Endpoint) Update(c *gin.Context) {
 	allowedFields := map[string]bool{
 		"extension":      true,
 		"nombre_archivo": true,
+		"categoria":      true,
+		"etiquetas":      true,
+		"metadatos":      true,
+		"vence_el":       true,
 	}
 
 	// Validar que no haya campos desconocidos
@@ -132,7 +815,7 @@ func (e *Endpoint) Update(c *gin.Context) {
 	}
 
 	// Convertir a UpdateReq
-	var req UpdateReq
+	req := UpdateReq{UsuarioID: usuarioID(c)}
 	if rawReq["extension"] != nil {
 		if ext, ok := rawReq["extension"].(string); ok {
 			req.Extension = &ext
@@ -143,14 +826,60 @@ func (e *Endpoint) Update(c *gin.Context) {
 			req.NombreArchivo = &nombre
 		}
 	}
+	if rawReq["categoria"] != nil {
+		if categoria, ok := rawReq["categoria"].(string); ok {
+			req.Categoria = &categoria
+		}
+	}
+	// Un vencimiento nulo o vacío quita la fecha de vencimiento
+	if valor, ok := rawReq["vence_el"]; ok {
+		venceEl := ""
+		if !convertirCampo(valor, &venceEl) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El campo 'vence_el' debe ser una fecha con formato YYYY-MM-DD"})
+			return
+		}
+		req.VenceEl = &venceEl
+	}
+	// Etiquetas y metadatos se convierten a su tipo; un arreglo vacío los elimina todos
+	if valor, ok := rawReq["etiquetas"]; ok {
+		etiquetas := []string{}
+		if !convertirCampo(valor, &etiquetas) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El campo 'etiquetas' debe ser un arreglo de textos"})
+			return
+		}
+		req.Etiquetas = &etiquetas
+	}
+	if valor, ok := rawReq["metadatos"]; ok {
+		metadatos := []Metadato{}
+		if !convertirCampo(valor, &metadatos) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El campo 'metadatos' debe ser un arreglo de objetos con clave, tipo y valor"})
+			return
+		}
+		req.Metadatos = &metadatos
+	}
 
 	if err := e.service.Update(c.Request.Context(), uint(id), req); err != nil {
+		if responderErrorValidacion(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Documento actualizado exitosamente"})
 }
 
+// convertirCampo convierte un campo de un cuerpo JSON genérico al tipo de destino
+func convertirCampo(valor interface{}, destino interface{}) bool {
+	if valor == nil {
+		return true
+	}
+	datos, err := json.Marshal(valor)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(datos, destino) == nil
+}
+
 // Delete maneja DELETE /documentos/:id
 func (e *Endpoint) Delete(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -166,6 +895,36 @@ func (e *Endpoint) Delete(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Documento eliminado exitosamente"})
 }
 
+// GetZipSolicitud maneja GET /documentos/solicitud/:solicitud_id/zip
+func (e *Endpoint) GetZipSolicitud(c *gin.Context) {
+	solicitudID, err := strconv.ParseUint(c.Param("solicitud_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de solicitud inválido"})
+		return
+	}
+
+	documentos, err := e.service.GetDocumentosZip(c.Request.Context(), uint(solicitudID))
+	if err != nil {
+		if errors.Is(err, ErrSolicitudSinDocumentos) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// El ZIP se genera mientras se envía, por lo que no se conoce su tamaño y un error
+	// a mitad de la descarga solo puede cortar la respuesta
+	nombre := fmt.Sprintf("solicitud_%d_documentos.zip", solicitudID)
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": nombre}))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+	if err := e.service.EscribirZip(c.Request.Context(), documentos, c.Writer); err != nil {
+		c.Error(err)
+	}
+}
+
 // DeleteBySolicitudID maneja DELETE /documentos/solicitud/:solicitud_id
 func (e *Endpoint) DeleteBySolicitudID(c *gin.Context) {
 	solicitudID, err := strconv.ParseUint(c.Param("solicitud_id"), 10, 32)
@@ -0,0 +1,1438 @@
+package documento
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kramirez/documentos/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// setupEndpoint registra las rutas de documentos sobre el servicio real con el repositorio simulado
+func setupEndpoint(t *testing.T, repo *mockRepository, config Config) (*gin.Engine, *storage.LocalStorage) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	s, store, cola := setupService(t, repo, config)
+	cola.On("Encolar", mock.Anything).Maybe()
+	ep := NewEndpoint(s)
+
+	r := gin.New()
+	documentos := r.Group("/documentos")
+	documentos.POST("", ep.Create)
+	documentos.GET("", ep.GetAll)
+	documentos.GET("/buscar", ep.Buscar)
+	documentos.GET("/categorias", ep.GetCategorias)
+	documentos.GET("/uso", ep.GetUso)
+	documentos.GET("/papelera", ep.GetPapelera)
+	documentos.GET("/por-vencer", ep.GetPorVencer)
+	documentos.GET("/checksum/:checksum", ep.GetBlob)
+	documentos.GET("/:id/contenido", ep.GetContenido)
+	documentos.PUT("/:id/contenido", ep.ReemplazarContenido)
+	documentos.GET("/:id/miniatura", ep.GetMiniatura)
+	documentos.GET("/:id/versiones", ep.GetVersiones)
+	documentos.GET("/:id/versiones/:version", ep.GetVersion)
+	documentos.GET("/:id/versiones/:version/contenido", ep.GetVersionContenido)
+	documentos.POST("/:id/versiones/:version/restaurar", ep.RestaurarVersion)
+	documentos.POST("/:id/restaurar", ep.Restaurar)
+	documentos.PATCH("/:id", ep.Update)
+	documentos.POST("/:id/enlaces", ep.CrearEnlace)
+	documentos.GET("/solicitud/:solicitud_id/zip", ep.GetZipSolicitud)
+	r.GET("/publico/documentos/:id/contenido", ep.GetContenidoEnlace)
+	return r, store
+}
+
+// peticionMultipart crea un POST multipart con el archivo en el campo "archivo" y los campos indicados
+func peticionMultipart(t *testing.T, url, nombre string, contenido []byte, campos map[string]string) *http.Request {
+	t.Helper()
+	var cuerpo bytes.Buffer
+	writer := multipart.NewWriter(&cuerpo)
+	for clave, valor := range campos {
+		require.NoError(t, writer.WriteField(clave, valor))
+	}
+	if nombre != "" {
+		parte, err := writer.CreateFormFile("archivo", nombre)
+		require.NoError(t, err)
+		_, err = parte.Write(contenido)
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, url, &cuerpo)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestEndpoint_CreateMultipart(t *testing.T) {
+	checksum := checksumDe(contenidoPDF)
+
+	t.Run("debe guardar el archivo subido y registrar el documento", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, store := setupEndpoint(t, repo, Config{})
+		repo.On("GetBlob", mock.Anything, checksum).Return(nil, gorm.ErrRecordNotFound)
+		repo.On("Create", mock.Anything, mock.MatchedBy(func(d *Documento) bool {
+			return d.NombreArchivo == "informe final" && d.Extension == "pdf" && d.SolicitudID == 1 &&
+				d.Tamano == int64(len(contenidoPDF)) && *d.UsuarioID == 3
+		})).Return(nil).Run(func(args mock.Arguments) {
+			args.Get(1).(*Documento).ID = 10
+		})
+		req := peticionMultipart(t, "/documentos", "informe final.PDF", contenidoPDF, map[string]string{"solicitud_id": "1"})
+		req.Header.Set("X-Usuario-ID", "3")
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var documento DocumentoResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &documento))
+		assert.Equal(t, uint(10), documento.ID)
+		assert.Equal(t, checksum, documento.Checksum)
+		archivo, err := store.Open(context.Background(), storage.ContentKey(checksum))
+		require.NoError(t, err)
+		archivo.Close()
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe usar el nombre y la extensión enviados en el formulario", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		repo.On("GetBlob", mock.Anything, checksum).Return(nil, gorm.ErrRecordNotFound)
+		repo.On("Create", mock.Anything, mock.MatchedBy(func(d *Documento) bool {
+			return d.NombreArchivo == "cv" && d.Extension == "pdf"
+		})).Return(nil)
+		req := peticionMultipart(t, "/documentos", "scan001", contenidoPDF, map[string]string{
+			"solicitud_id": "1", "nombre_archivo": "cv", "extension": ".PDF",
+		})
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		repo.AssertExpectations(t)
+	})
+
+	casos := []struct {
+		nombre    string
+		archivo   string
+		contenido []byte
+		campos    map[string]string
+		esperado  int
+	}{
+		{"sin el campo archivo", "", nil, map[string]string{"solicitud_id": "1"}, http.StatusBadRequest},
+		{"sin solicitud_id", "cv.pdf", contenidoPDF, nil, http.StatusBadRequest},
+		{"con un archivo sin extensión", "cv", contenidoPDF, map[string]string{"solicitud_id": "1"}, http.StatusBadRequest},
+		{"con una extensión de más de 5 caracteres", "cv.backup", contenidoPDF, map[string]string{"solicitud_id": "1"}, http.StatusBadRequest},
+		{"con metadatos que no son JSON", "cv.pdf", contenidoPDF, map[string]string{"solicitud_id": "1", "metadatos": "{"}, http.StatusBadRequest},
+		{"con un archivo que supera el tamaño máximo", "cv.pdf", make([]byte, maxUploadSize+1), map[string]string{"solicitud_id": "1"}, http.StatusRequestEntityTooLarge},
+	}
+	for _, caso := range casos {
+		t.Run("debe rechazar la carga "+caso.nombre, func(t *testing.T) {
+			// Arrange
+			repo := new(mockRepository)
+			r, _ := setupEndpoint(t, repo, Config{})
+			req := peticionMultipart(t, "/documentos", caso.archivo, caso.contenido, caso.campos)
+			w := httptest.NewRecorder()
+
+			// Act
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, caso.esperado, w.Code, w.Body.String())
+			repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestEndpoint_GetBlob(t *testing.T) {
+	checksum := checksumDe(contenidoPDF)
+
+	t.Run("debe retornar 400 sin solicitud_id ni usuario", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		req := httptest.NewRequest(http.MethodGet, "/documentos/checksum/"+checksum, nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		repo.AssertNotCalled(t, "GetBlob", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe retornar 400 con un checksum inválido", func(t *testing.T) {
+		// Arrange
+		r, _ := setupEndpoint(t, new(mockRepository), Config{})
+		req := httptest.NewRequest(http.MethodGet, "/documentos/checksum/abc?solicitud_id=1", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("debe retornar 404 si el archivo no es accesible", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		repo.On("GetBlob", mock.Anything, checksum).Return(&Blob{Checksum: checksum}, nil)
+		repo.On("BlobAccesible", mock.Anything, checksum, uint(2), (*uint)(nil)).Return(false, nil)
+		req := httptest.NewRequest(http.MethodGet, "/documentos/checksum/"+checksum+"?solicitud_id=2", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("debe retornar el archivo del usuario de la cabecera", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		usuario := uint(3)
+		repo.On("GetBlob", mock.Anything, checksum).Return(&Blob{Checksum: checksum, Tamano: 10, EstadoEscaneo: EstadoLimpio}, nil)
+		repo.On("BlobAccesible", mock.Anything, checksum, uint(0), &usuario).Return(true, nil)
+		req := httptest.NewRequest(http.MethodGet, "/documentos/checksum/"+checksum, nil)
+		req.Header.Set("X-Usuario-ID", "3")
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code)
+		var blob Blob
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &blob))
+		assert.Equal(t, int64(10), blob.Tamano)
+	})
+}
+
+// documentoConContenido guarda el contenido en el almacenamiento y retorna el documento limpio que lo referencia
+func documentoConContenido(t *testing.T, store storage.Storage, id uint, contenido []byte) *Documento {
+	t.Helper()
+	return &Documento{
+		ID:                  id,
+		NombreArchivo:       "informe final",
+		Extension:           "pdf",
+		TipoMime:            "application/pdf",
+		Tamano:              int64(len(contenido)),
+		Checksum:            checksumDe(contenido),
+		ClaveAlmacenamiento: guardarEnStorage(t, store, contenido),
+		EstadoEscaneo:       EstadoLimpio,
+		UpdatedAt:           time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestEndpoint_GetContenido(t *testing.T) {
+	t.Run("debe descargar el archivo completo con sus cabeceras", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, store := setupEndpoint(t, repo, Config{})
+		documento := documentoConContenido(t, store, 1, contenidoPDF)
+		repo.On("GetByID", mock.Anything, uint(1)).Return(documento, nil)
+		req := httptest.NewRequest(http.MethodGet, "/documentos/1/contenido", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, contenidoPDF, w.Body.Bytes())
+		assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="informe final.pdf"`, w.Header().Get("Content-Disposition"))
+		assert.Equal(t, `"`+documento.Checksum+`"`, w.Header().Get("ETag"))
+		assert.Equal(t, "bytes", w.Header().Get("Accept-Ranges"))
+	})
+
+	t.Run("debe mostrar el archivo en el navegador con inline=true", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, store := setupEndpoint(t, repo, Config{})
+		repo.On("GetByID", mock.Anything, uint(1)).Return(documentoConContenido(t, store, 1, contenidoPDF), nil)
+		req := httptest.NewRequest(http.MethodGet, "/documentos/1/contenido?inline=true", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `inline; filename="informe final.pdf"`, w.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("debe responder 206 con el rango solicitado", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, store := setupEndpoint(t, repo, Config{})
+		repo.On("GetByID", mock.Anything, uint(1)).Return(documentoConContenido(t, store, 1, contenidoPDF), nil)
+		req := httptest.NewRequest(http.MethodGet, "/documentos/1/contenido", nil)
+		req.Header.Set("Range", "bytes=5-7")
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusPartialContent, w.Code)
+		assert.Equal(t, contenidoPDF[5:8], w.Body.Bytes())
+		assert.Equal(t, fmt.Sprintf("bytes 5-7/%d", len(contenidoPDF)), w.Header().Get("Content-Range"))
+	})
+
+	t.Run("debe responder 416 con un rango fuera del archivo", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, store := setupEndpoint(t, repo, Config{})
+		repo.On("GetByID", mock.Anything, uint(1)).Return(documentoConContenido(t, store, 1, contenidoPDF), nil)
+		req := httptest.NewRequest(http.MethodGet, "/documentos/1/contenido", nil)
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", len(contenidoPDF)+10))
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, w.Code)
+	})
+
+	t.Run("debe responder 304 si el cliente ya tiene el contenido", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, store := setupEndpoint(t, repo, Config{})
+		documento := documentoConContenido(t, store, 1, contenidoPDF)
+		repo.On("GetByID", mock.Anything, uint(1)).Return(documento, nil)
+		req := httptest.NewRequest(http.MethodGet, "/documentos/1/contenido", nil)
+		req.Header.Set("If-None-Match", `"`+documento.Checksum+`"`)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.Bytes())
+	})
+
+	t.Run("debe retornar 404 si el documento no tiene contenido", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		repo.On("GetByID", mock.Anything, uint(1)).Return(&Documento{ID: 1}, nil)
+		req := httptest.NewRequest(http.MethodGet, "/documentos/1/contenido", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("debe retornar 404 si el archivo no está en el almacenamiento", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		repo.On("GetByID", mock.Anything, uint(1)).Return(&Documento{ID: 1, ClaveAlmacenamiento: "blobs/ab/abc", EstadoEscaneo: EstadoLimpio}, nil)
+		req := httptest.NewRequest(http.MethodGet, "/documentos/1/contenido", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("debe retornar 404 si el documento no existe", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		repo.On("GetByID", mock.Anything, uint(9)).Return(nil, gorm.ErrRecordNotFound)
+		req := httptest.NewRequest(http.MethodGet, "/documentos/9/contenido", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("debe retornar 400 con un ID inválido", func(t *testing.T) {
+		// Arrange
+		r, _ := setupEndpoint(t, new(mockRepository), Config{})
+		req := httptest.NewRequest(http.MethodGet, "/documentos/abc/contenido", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestEndpoint_ReemplazarContenido(t *testing.T) {
+	checksum := checksumDe(contenidoPDF)
+
+	t.Run("debe retornar el documento con su nueva versión", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		repo.On("GetByID", mock.Anything, uint(1)).Return(&Documento{ID: 1, SolicitudID: 1, NombreArchivo: "cv", Extension: "pdf", Version: 1}, nil).Once()
+		repo.On("GetBlob", mock.Anything, checksum).Return(nil, gorm.ErrRecordNotFound)
+		repo.On("ApplyVersion", mock.Anything, uint(1), mock.MatchedBy(func(v *Version) bool {
+			return v.Checksum == checksum && *v.UsuarioID == 3
+		})).Return(nil)
+		repo.On("GetByID", mock.Anything, uint(1)).Return(&Documento{ID: 1, SolicitudID: 1, NombreArchivo: "cv", Extension: "pdf", Version: 2, Checksum: checksum}, nil).Once()
+		req := peticionMultipart(t, "/documentos/1/contenido", "cv.pdf", contenidoPDF, nil)
+		req.Method = http.MethodPut
+		req.Header.Set("X-Usuario-ID", "3")
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var documento DocumentoResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &documento))
+		assert.Equal(t, 2, documento.Version)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe retornar 404 si el documento no existe", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		repo.On("GetByID", mock.Anything, uint(9)).Return(nil, gorm.ErrRecordNotFound)
+		req := peticionMultipart(t, "/documentos/9/contenido", "cv.pdf", contenidoPDF, nil)
+		req.Method = http.MethodPut
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestEndpoint_GetVersiones(t *testing.T) {
+	t.Run("debe retornar el historial de versiones", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		repo.On("GetByID", mock.Anything, uint(1)).Return(&Documento{ID: 1, Version: 2}, nil)
+		repo.On("GetVersiones", mock.Anything, uint(1)).Return([]Version{{Numero: 2}, {Numero: 1}}, nil)
+		req := httptest.NewRequest(http.MethodGet, "/documentos/1/versiones", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code)
+		var versiones []Version
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &versiones))
+		assert.Len(t, versiones, 2)
+	})
+
+	t.Run("debe retornar 404 si el documento no existe", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		repo.On("GetByID", mock.Anything, uint(9)).Return(nil, gorm.ErrRecordNotFound)
+		req := httptest.NewRequest(http.MethodGet, "/documentos/9/versiones", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestEndpoint_GetVersion(t *testing.T) {
+	for _, url := range []string{"/documentos/1/versiones/0", "/documentos/1/versiones/abc", "/documentos/abc/versiones/1"} {
+		t.Run("debe retornar 400 con la ruta "+url, func(t *testing.T) {
+			// Arrange
+			r, _ := setupEndpoint(t, new(mockRepository), Config{})
+			req := httptest.NewRequest(http.MethodGet, url, nil)
+			w := httptest.NewRecorder()
+
+			// Act
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+
+	t.Run("debe retornar 404 si la versión no existe", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		repo.On("GetByID", mock.Anything, uint(1)).Return(&Documento{ID: 1, Version: 2}, nil)
+		repo.On("GetVersion", mock.Anything, uint(1), 5).Return(nil, gorm.ErrRecordNotFound)
+		req := httptest.NewRequest(http.MethodGet, "/documentos/1/versiones/5", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestEndpoint_GetVersionContenido(t *testing.T) {
+	t.Run("debe descargar el contenido de una versión anterior", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, store := setupEndpoint(t, repo, Config{})
+		anterior := []byte("contenido de la versión 1")
+		checksum := checksumDe(anterior)
+		repo.On("GetByID", mock.Anything, uint(1)).Return(&Documento{ID: 1, Version: 2}, nil)
+		repo.On("GetVersion", mock.Anything, uint(1), 1).Return(&Version{
+			Numero: 1, NombreArchivo: "cv", Extension: "txt", TipoMime: "text/plain",
+			Checksum: checksum, ClaveAlmacenamiento: guardarEnStorage(t, store, anterior),
+		}, nil)
+		repo.On("GetBlob", mock.Anything, checksum).Return(&Blob{Checksum: checksum, EstadoEscaneo: EstadoLimpio}, nil)
+		req := httptest.NewRequest(http.MethodGet, "/documentos/1/versiones/1/contenido", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, anterior, w.Body.Bytes())
+		assert.Equal(t, "attachment; filename=cv.txt", w.Header().Get("Content-Disposition"))
+	})
+}
+
+func TestEndpoint_RestaurarVersion(t *testing.T) {
+	t.Run("debe retornar 404 si la versión no existe", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		repo.On("GetByID", mock.Anything, uint(1)).Return(&Documento{ID: 1, Version: 2}, nil)
+		repo.On("GetVersion", mock.Anything, uint(1), 7).Return(nil, gorm.ErrRecordNotFound)
+		req := httptest.NewRequest(http.MethodPost, "/documentos/1/versiones/7/restaurar", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		repo.AssertNotCalled(t, "ApplyVersion", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestEndpoint_Create_PoliticaArchivos(t *testing.T) {
+	exe := []byte("MZ\x90\x00\x03\x00\x00\x00\x04\x00\x00\x00\xff\xff\x00\x00")
+
+	t.Run("debe retornar 422 si el contenido no corresponde a la extensión", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, store := setupEndpoint(t, repo, Config{})
+		req := peticionMultipart(t, "/documentos", "cv.pdf", exe, map[string]string{"solicitud_id": "1"})
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		var errValidacion ErrorValidacionArchivo
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errValidacion))
+		assert.Equal(t, CodigoContenidoNoCoincide, errValidacion.Codigo)
+		_, err := store.Open(context.Background(), storage.ContentKey(checksumDe(exe)))
+		assert.ErrorIs(t, err, storage.ErrNotFound, "el archivo rechazado no se guarda")
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe retornar 415 con una extensión no permitida", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{Politica: PoliticaArchivos{Permitidas: []string{"pdf"}}})
+		req := peticionMultipart(t, "/documentos", "foto.png", []byte("\x89PNG\r\n\x1a\n"), map[string]string{"solicitud_id": "1"})
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		require.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+		var errValidacion ErrorValidacionArchivo
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errValidacion))
+		assert.Equal(t, CodigoTipoNoPermitido, errValidacion.Codigo)
+		assert.Equal(t, []string{"pdf"}, errValidacion.Permitidas)
+	})
+
+	t.Run("debe validar el contenido existente al crear desde un checksum", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, store := setupEndpoint(t, repo, Config{})
+		checksum := checksumDe(exe)
+		repo.On("GetBlob", mock.Anything, checksum).Return(&Blob{Checksum: checksum, ClaveAlmacenamiento: guardarEnStorage(t, store, exe)}, nil)
+		repo.On("BlobAccesible", mock.Anything, checksum, uint(1), (*uint)(nil)).Return(true, nil)
+		cuerpo := `{"extension":"pdf","nombre_archivo":"cv","solicitud_id":1,"checksum":"` + checksum + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/documentos", strings.NewReader(cuerpo))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestEndpoint_Buscar(t *testing.T) {
+	t.Run("debe retornar 400 sin el parámetro q", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		req := httptest.NewRequest(http.MethodGet, "/documentos/buscar?q=%20", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		repo.AssertNotCalled(t, "Buscar", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe buscar en los documentos de la solicitud", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		repo.On("Buscar", mock.Anything, BuscarReq{Q: "analista de datos", SolicitudID: 1, Limit: 5}).Return([]Coincidencia{
+			{Documento: Documento{ID: 1, SolicitudID: 1}, Texto: "Analista de datos"},
+		}, nil)
+		req := httptest.NewRequest(http.MethodGet, "/documentos/buscar?q=analista+de+datos&solicitud_id=1&limit=5", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code)
+		var resultados []ResultadoBusqueda
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resultados))
+		require.Len(t, resultados, 1)
+		assert.Equal(t, []string{"Analista de datos"}, resultados[0].Fragmentos)
+	})
+}
+
+func TestEndpoint_GetMiniatura(t *testing.T) {
+	t.Run("debe retornar la miniatura en JPEG con caché privada", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, store := setupEndpoint(t, repo, Config{})
+		_, err := store.Save(context.Background(), claveMiniatura("abc"), strings.NewReader("jpeg"))
+		require.NoError(t, err)
+		repo.On("GetByID", mock.Anything, uint(1)).Return(&Documento{ID: 1, TipoMime: "image/png", Checksum: "abc", ClaveAlmacenamiento: "blobs/ab/abc", EstadoEscaneo: EstadoLimpio}, nil)
+		repo.On("GetBlob", mock.Anything, "abc").Return(&Blob{Checksum: "abc", ClaveMiniatura: claveMiniatura("abc")}, nil)
+		req := httptest.NewRequest(http.MethodGet, "/documentos/1/miniatura", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "jpeg", w.Body.String())
+		assert.Equal(t, "image/jpeg", w.Header().Get("Content-Type"))
+		assert.Equal(t, `"abc-miniatura"`, w.Header().Get("ETag"))
+		assert.Equal(t, "private, max-age=86400", w.Header().Get("Cache-Control"))
+	})
+
+	t.Run("debe retornar 404 si el documento no admite miniatura", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		repo.On("GetByID", mock.Anything, uint(1)).Return(&Documento{ID: 1, TipoMime: "text/plain", Checksum: "abc", ClaveAlmacenamiento: "blobs/ab/abc", EstadoEscaneo: EstadoLimpio}, nil)
+		req := httptest.NewRequest(http.MethodGet, "/documentos/1/miniatura", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("debe retornar 409 mientras el contenido no se analiza", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		repo.On("GetByID", mock.Anything, uint(1)).Return(&Documento{ID: 1, TipoMime: "image/png", Checksum: "abc", ClaveAlmacenamiento: "blobs/ab/abc", EstadoEscaneo: EstadoPendienteEscaneo}, nil)
+		req := httptest.NewRequest(http.MethodGet, "/documentos/1/miniatura", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
+
+func TestEndpoint_CrearEnlace(t *testing.T) {
+	config := Config{URLPublica: "http://localhost:8083", ClaveEnlaces: []byte("clave-secreta")}
+
+	t.Run("debe crear un enlace con la vigencia por defecto sin cuerpo", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, config)
+		repo.On("GetByID", mock.Anything, uint(1)).Return(&Documento{ID: 1, Version: 1, ClaveAlmacenamiento: "blobs/abc", EstadoEscaneo: EstadoLimpio}, nil)
+		req := httptest.NewRequest(http.MethodPost, "/documentos/1/enlaces", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var enlace EnlaceResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &enlace))
+		assert.Contains(t, enlace.URL, "/publico/documentos/1/contenido?")
+		assert.False(t, enlace.UnSoloUso)
+	})
+
+	t.Run("debe crear un enlace de un solo uso", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, config)
+		repo.On("GetByID", mock.Anything, uint(1)).Return(&Documento{ID: 1, Version: 1, ClaveAlmacenamiento: "blobs/abc", EstadoEscaneo: EstadoLimpio}, nil)
+		req := httptest.NewRequest(http.MethodPost, "/documentos/1/enlaces", strings.NewReader(`{"expira_en_segundos": 600, "un_solo_uso": true}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Usuario-ID", "4")
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var enlace EnlaceResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &enlace))
+		assert.True(t, enlace.UnSoloUso)
+		assert.Contains(t, enlace.URL, "n=")
+		assert.WithinDuration(t, time.Now().Add(10*time.Minute), enlace.ExpiraEl, 5*time.Second)
+	})
+
+	t.Run("debe retornar 400 con una vigencia negativa", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, config)
+		req := httptest.NewRequest(http.MethodPost, "/documentos/1/enlaces", strings.NewReader(`{"expira_en_segundos": -1}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		repo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe retornar 404 si el documento no existe", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, config)
+		repo.On("GetByID", mock.Anything, uint(9)).Return(nil, gorm.ErrRecordNotFound)
+		req := httptest.NewRequest(http.MethodPost, "/documentos/9/enlaces", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("debe retornar 409 si el documento aún no se analiza", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, config)
+		repo.On("GetByID", mock.Anything, uint(1)).Return(&Documento{ID: 1, ClaveAlmacenamiento: "blobs/abc", EstadoEscaneo: EstadoPendienteEscaneo}, nil)
+		req := httptest.NewRequest(http.MethodPost, "/documentos/1/enlaces", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
+
+func TestEndpoint_GetContenidoEnlace(t *testing.T) {
+	config := Config{URLPublica: "http://localhost:8083", ClaveEnlaces: []byte("clave-secreta")}
+	contenido := []byte("contenido compartido")
+
+	// crearEnlace genera el enlace mediante la API y retorna su ruta relativa
+	crearEnlace := func(t *testing.T, r http.Handler, cuerpo string) string {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/documentos/1/enlaces", strings.NewReader(cuerpo))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusCreated, w.Code)
+		var enlace EnlaceResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &enlace))
+		return strings.TrimPrefix(enlace.URL, config.URLPublica)
+	}
+	// esperarDocumento prepara el documento 1 con su contenido en la versión 1
+	esperarDocumento := func(t *testing.T, repo *mockRepository, store storage.Storage) {
+		doc := documentoConContenido(t, store, 1, contenido)
+		doc.Version = 1
+		repo.On("GetByID", mock.Anything, uint(1)).Return(doc, nil)
+		repo.On("GetVersion", mock.Anything, uint(1), 1).Return(&Version{
+			Numero: 1, NombreArchivo: doc.NombreArchivo, Extension: doc.Extension, TipoMime: doc.TipoMime,
+			Checksum: doc.Checksum, ClaveAlmacenamiento: doc.ClaveAlmacenamiento,
+		}, nil)
+		repo.On("GetBlob", mock.Anything, doc.Checksum).Return(&Blob{Checksum: doc.Checksum, EstadoEscaneo: EstadoLimpio}, nil)
+	}
+
+	t.Run("debe descargar el contenido con un enlace válido", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, store := setupEndpoint(t, repo, config)
+		esperarDocumento(t, repo, store)
+		req := httptest.NewRequest(http.MethodGet, crearEnlace(t, r, `{}`), nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, contenido, w.Body.Bytes())
+		assert.Equal(t, "private, no-store", w.Header().Get("Cache-Control"))
+		assert.Equal(t, `attachment; filename="informe final.pdf"`, w.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("debe retornar 410 al reutilizar un enlace de un solo uso", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, store := setupEndpoint(t, repo, config)
+		esperarDocumento(t, repo, store)
+		repo.On("ConsumirEnlace", mock.Anything, mock.Anything).Return(nil).Once()
+		repo.On("ConsumirEnlace", mock.Anything, mock.Anything).Return(ErrEnlaceUsado)
+		ruta := crearEnlace(t, r, `{"un_solo_uso": true}`)
+
+		// Act
+		primera := httptest.NewRecorder()
+		r.ServeHTTP(primera, httptest.NewRequest(http.MethodGet, ruta, nil))
+		segunda := httptest.NewRecorder()
+		r.ServeHTTP(segunda, httptest.NewRequest(http.MethodGet, ruta, nil))
+
+		// Assert
+		assert.Equal(t, http.StatusOK, primera.Code)
+		assert.Equal(t, http.StatusGone, segunda.Code)
+	})
+
+	t.Run("debe retornar 403 si se modifica la versión del enlace", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, store := setupEndpoint(t, repo, config)
+		esperarDocumento(t, repo, store)
+		ruta := strings.Replace(crearEnlace(t, r, `{}`), "v=1", "v=2", 1)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, ruta, nil))
+
+		// Assert
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("debe retornar 403 si faltan parámetros", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, config)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/publico/documentos/1/contenido?v=1", nil))
+
+		// Assert
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		repo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe retornar 410 si el enlace expiró", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, config)
+		s, _, _ := setupService(t, repo, config)
+		enlace := EnlaceFirmado{DocumentoID: 1, Version: 1, Expira: time.Now().Add(-time.Minute).Unix()}
+		firma := s.firmante.Firmar(partesEnlace(enlace)...)
+		ruta := fmt.Sprintf("/publico/documentos/1/contenido?v=1&exp=%d&firma=%s", enlace.Expira, firma)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, ruta, nil))
+
+		// Assert
+		assert.Equal(t, http.StatusGone, w.Code)
+	})
+}
+
+func TestEndpoint_GetZipSolicitud(t *testing.T) {
+	t.Run("debe descargar el ZIP con los documentos de la solicitud", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, store := setupEndpoint(t, repo, Config{})
+		contenido := []byte("contenido del informe")
+		repo.On("GetAll", mock.Anything, GetAllReq{SolicitudID: 1}).Return([]Documento{*documentoConContenido(t, store, 1, contenido)}, nil)
+		req := httptest.NewRequest(http.MethodGet, "/documentos/solicitud/1/zip", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+		assert.Equal(t, "attachment; filename=solicitud_1_documentos.zip", w.Header().Get("Content-Disposition"))
+		nombres, entradas := leerZip(t, w.Body.Bytes())
+		assert.Equal(t, []string{"informe final.pdf", nombreManifiesto}, nombres)
+		assert.Equal(t, contenido, entradas["informe final.pdf"])
+	})
+
+	t.Run("debe retornar 404 si la solicitud no tiene documentos", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		repo.On("GetAll", mock.Anything, GetAllReq{SolicitudID: 2}).Return([]Documento{}, nil)
+		req := httptest.NewRequest(http.MethodGet, "/documentos/solicitud/2/zip", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("debe retornar 400 con un ID de solicitud inválido", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		req := httptest.NewRequest(http.MethodGet, "/documentos/solicitud/abc/zip", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestEndpoint_Create_Cuotas(t *testing.T) {
+	t.Run("debe retornar 413 con el detalle de la cuota excedida", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{Cuotas: Cuotas{PorUsuario: Cuota{MaxArchivos: 5}}})
+		repo.On("GetUsoUsuario", mock.Anything, uint(4)).Return(Uso{Archivos: 5}, nil)
+		req := peticionMultipart(t, "/documentos", "cv.pdf", contenidoPDF, map[string]string{"solicitud_id": "1"})
+		req.Header.Set("X-Usuario-ID", "4")
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		var errCuota ErrorCuota
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errCuota))
+		assert.Equal(t, AmbitoUsuario, errCuota.Ambito)
+		assert.Equal(t, LimiteArchivos, errCuota.Limite)
+		assert.Equal(t, int64(5), errCuota.Maximo)
+		assert.NotEmpty(t, errCuota.Mensaje)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestEndpoint_GetUso(t *testing.T) {
+	t.Run("debe informar el uso de la solicitud y del usuario del encabezado", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{Cuotas: Cuotas{PorSolicitud: Cuota{MaxBytes: 1000}}})
+		repo.On("GetUsoSolicitud", mock.Anything, uint(1)).Return(Uso{Archivos: 2, Bytes: 300}, nil)
+		repo.On("GetUsoUsuario", mock.Anything, uint(4)).Return(Uso{Archivos: 1, Bytes: 100}, nil)
+		req := httptest.NewRequest(http.MethodGet, "/documentos/uso?solicitud_id=1", nil)
+		req.Header.Set("X-Usuario-ID", "4")
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{
+			"solicitud": {"archivos": 2, "bytes": 300, "max_bytes": 1000},
+			"usuario": {"archivos": 1, "bytes": 100}
+		}`, w.Body.String())
+	})
+
+	t.Run("debe priorizar el usuario indicado en la consulta", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		repo.On("GetUsoUsuario", mock.Anything, uint(7)).Return(Uso{Archivos: 3}, nil)
+		req := httptest.NewRequest(http.MethodGet, "/documentos/uso?usuario_id=7", nil)
+		req.Header.Set("X-Usuario-ID", "4")
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		repo.AssertNotCalled(t, "GetUsoUsuario", mock.Anything, uint(4))
+	})
+
+	t.Run("debe retornar 400 sin solicitud ni usuario", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		req := httptest.NewRequest(http.MethodGet, "/documentos/uso", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("debe retornar 400 con un ID de solicitud inválido", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		req := httptest.NewRequest(http.MethodGet, "/documentos/uso?solicitud_id=0", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestEndpoint_GetPapelera(t *testing.T) {
+	t.Run("debe listar los documentos eliminados con los filtros de la consulta", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		eliminadoEl := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+		repo.On("GetEliminados", mock.Anything, GetAllReq{SolicitudID: 1, Limit: 5, Page: 2}).Return([]Documento{
+			{ID: 1, SolicitudID: 1, NombreArchivo: "cv", DeletedAt: gorm.DeletedAt{Time: eliminadoEl, Valid: true}},
+		}, nil)
+		req := httptest.NewRequest(http.MethodGet, "/documentos/papelera?solicitud_id=1&limit=5&page=2", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		var eliminados []DocumentoEliminado
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &eliminados))
+		require.Len(t, eliminados, 1)
+		assert.True(t, eliminados[0].SolicitudVigente)
+		assert.True(t, eliminadoEl.Equal(eliminados[0].EliminadoEl))
+	})
+
+	t.Run("debe retornar 500 si falla la consulta", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		repo.On("GetEliminados", mock.Anything, GetAllReq{}).Return(nil, assert.AnError)
+		req := httptest.NewRequest(http.MethodGet, "/documentos/papelera", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestEndpoint_Restaurar(t *testing.T) {
+	t.Run("debe restaurar el documento", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		repo.On("GetEliminadoByID", mock.Anything, uint(1)).Return(&Documento{ID: 1, SolicitudID: 1, EstadoEscaneo: EstadoLimpio}, nil)
+		repo.On("Restaurar", mock.Anything, uint(1)).Return(nil)
+		repo.On("GetByID", mock.Anything, uint(1)).Return(&Documento{ID: 1, SolicitudID: 1}, nil)
+		req := httptest.NewRequest(http.MethodPost, "/documentos/1/restaurar", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe retornar 404 si el documento no está en la papelera", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		repo.On("GetEliminadoByID", mock.Anything, uint(5)).Return(nil, gorm.ErrRecordNotFound)
+		req := httptest.NewRequest(http.MethodPost, "/documentos/5/restaurar", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("debe retornar 409 si la solicitud fue eliminada", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		repo.On("GetEliminadoByID", mock.Anything, uint(2)).Return(&Documento{ID: 2, SolicitudID: 9}, nil)
+		req := httptest.NewRequest(http.MethodPost, "/documentos/2/restaurar", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("debe retornar 413 si el documento supera la cuota", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{Cuotas: Cuotas{PorSolicitud: Cuota{MaxBytes: 100}}})
+		repo.On("GetEliminadoByID", mock.Anything, uint(1)).Return(&Documento{ID: 1, SolicitudID: 1, Tamano: 50}, nil)
+		repo.On("GetUsoSolicitud", mock.Anything, uint(1)).Return(Uso{Archivos: 1, Bytes: 60}, nil)
+		req := httptest.NewRequest(http.MethodPost, "/documentos/1/restaurar", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+}
+
+func TestEndpoint_GetCategorias(t *testing.T) {
+	t.Run("debe listar el catálogo con las extensiones de cada categoría", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{Politica: politicaConCategorias})
+		req := httptest.NewRequest(http.MethodGet, "/documentos/categorias", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `[
+			{"codigo": "descripcion_cargo", "nombre": "Descripción del cargo", "extensiones": ["docx", "pdf"]},
+			{"codigo": "otro", "nombre": "Otro", "extensiones": ["docx", "pdf", "png"]}
+		]`, w.Body.String())
+	})
+}
+
+func TestEndpoint_Categoria(t *testing.T) {
+	t.Run("debe crear un documento con la categoría del formulario", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{Politica: politicaConCategorias})
+		repo.On("GetBlob", mock.Anything, checksumDe(contenidoPDF)).Return(nil, gorm.ErrRecordNotFound)
+		repo.On("Create", mock.Anything, mock.MatchedBy(func(d *Documento) bool {
+			return d.Categoria == "descripcion_cargo"
+		})).Return(nil)
+		req := peticionMultipart(t, "/documentos", "cargo.pdf", contenidoPDF, map[string]string{"solicitud_id": "1", "categoria": "descripcion_cargo"})
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe retornar 422 con una categoría fuera del catálogo", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{Politica: politicaConCategorias})
+		repo.On("GetByID", mock.Anything, uint(1)).Return(&Documento{ID: 1, Extension: "pdf"}, nil)
+		req := httptest.NewRequest(http.MethodPatch, "/documentos/1", strings.NewReader(`{"categoria": "nda"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		var errValidacion ErrorValidacionArchivo
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errValidacion))
+		assert.Equal(t, CodigoCategoriaInvalida, errValidacion.Codigo)
+		assert.Equal(t, []string{"descripcion_cargo", "otro"}, errValidacion.Categorias)
+	})
+}
+
+func TestEndpoint_Etiquetado(t *testing.T) {
+	t.Run("debe crear el documento con las etiquetas y metadatos del formulario", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		repo.On("GetBlob", mock.Anything, checksumDe(contenidoPDF)).Return(nil, gorm.ErrRecordNotFound)
+		repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+		req := peticionMultipart(t, "/documentos", "cv.pdf", contenidoPDF, map[string]string{
+			"solicitud_id": "1",
+			"etiquetas":    "confidencial, Urgente",
+			"metadatos":    `[{"clave": "autor", "valor": "Karla"}, {"clave": "monto", "tipo": "numero", "valor": "10.0"}]`,
+		})
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var documento DocumentoResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &documento))
+		assert.Equal(t, []string{"confidencial", "urgente"}, documento.Etiquetas)
+		assert.Equal(t, []Metadato{{Clave: "autor", Tipo: TipoTexto, Valor: "Karla"}, {Clave: "monto", Tipo: TipoNumero, Valor: "10"}}, documento.Metadatos)
+	})
+
+	t.Run("debe retornar 400 si los metadatos del formulario no son JSON", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		req := peticionMultipart(t, "/documentos", "cv.pdf", contenidoPDF, map[string]string{"solicitud_id": "1", "metadatos": "autor=Karla"})
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe reemplazar las etiquetas y metadatos al actualizar", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		repo.On("GetByID", mock.Anything, uint(1)).Return(&Documento{ID: 1}, nil)
+		repo.On("ReemplazarEtiquetas", mock.Anything, uint(1), []Etiqueta{{Nombre: "urgente"}}).Return(nil)
+		repo.On("ReemplazarMetadatos", mock.Anything, uint(1), []Metadato{}).Return(nil)
+		req := httptest.NewRequest(http.MethodPatch, "/documentos/1", strings.NewReader(`{"etiquetas": ["urgente"], "metadatos": []}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe retornar 400 con etiquetas o metadatos inválidos al actualizar", func(t *testing.T) {
+		casos := map[string]string{
+			"etiquetas no son textos":    `{"etiquetas": [1, 2]}`,
+			"metadatos no son objetos":   `{"metadatos": "autor"}`,
+			"metadato con tipo inválido": `{"metadatos": [{"clave": "monto", "tipo": "numero", "valor": "mil"}]}`,
+		}
+		for nombre, cuerpo := range casos {
+			// Arrange
+			repo := new(mockRepository)
+			r, _ := setupEndpoint(t, repo, Config{})
+			repo.On("GetByID", mock.Anything, uint(1)).Return(&Documento{ID: 1}, nil)
+			req := httptest.NewRequest(http.MethodPatch, "/documentos/1", strings.NewReader(cuerpo))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			// Act
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, http.StatusBadRequest, w.Code, nombre)
+			repo.AssertNotCalled(t, "ReemplazarMetadatos", mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("debe filtrar por etiquetas y metadatos de la consulta", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		repo.On("GetAll", mock.Anything, GetAllReq{
+			Etiquetas: []string{"urgente", "confidencial"},
+			Metadatos: map[string]string{"autor": "Karla"},
+		}).Return([]Documento{{ID: 1, SolicitudID: 1, Etiquetas: []Etiqueta{{Nombre: "confidencial"}, {Nombre: "urgente"}}}}, nil)
+		req := httptest.NewRequest(http.MethodGet, "/documentos?etiqueta=Urgente&etiqueta=confidencial&etiqueta=%20&metadatos[Autor]=Karla", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code)
+		var documentos []DocumentoResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &documentos))
+		require.Len(t, documentos, 1)
+		assert.Equal(t, []string{"confidencial", "urgente"}, documentos[0].Etiquetas)
+	})
+}
+
+func TestEndpoint_GetPorVencer(t *testing.T) {
+	t.Run("debe consultar los próximos 30 días por defecto", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		desde := hoy()
+		repo.On("GetPorVencer", mock.Anything, &desde, desde.AddDate(0, 0, 30), uint(0)).Return([]Documento{
+			{ID: 1, SolicitudID: 1, VenceEl: enDias(5)},
+		}, nil)
+		req := httptest.NewRequest(http.MethodGet, "/documentos/por-vencer", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code)
+		var documentos []DocumentoPorVencer
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &documentos))
+		require.Len(t, documentos, 1)
+		assert.Equal(t, 5, documentos[0].DiasRestantes)
+	})
+
+	t.Run("debe aplicar los filtros de la consulta", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		repo.On("GetPorVencer", mock.Anything, (*time.Time)(nil), hoy().AddDate(0, 0, 7), uint(1)).Return([]Documento{}, nil)
+		req := httptest.NewRequest(http.MethodGet, "/documentos/por-vencer?dias=7&solicitud_id=1&vencidos=true", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `[]`, w.Body.String())
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe retornar 400 con una cantidad de días inválida", func(t *testing.T) {
+		for _, dias := range []string{"-1", "abc", "3651"} {
+			// Arrange
+			repo := new(mockRepository)
+			r, _ := setupEndpoint(t, repo, Config{})
+			req := httptest.NewRequest(http.MethodGet, "/documentos/por-vencer?dias="+dias, nil)
+			w := httptest.NewRecorder()
+
+			// Act
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, http.StatusBadRequest, w.Code, dias)
+		}
+	})
+}
+
+func TestEndpoint_Vencimientos(t *testing.T) {
+	t.Run("debe retornar 400 al crear con una fecha de vencimiento inválida", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		req := peticionMultipart(t, "/documentos", "nda.pdf", contenidoPDF, map[string]string{"solicitud_id": "1", "vence_el": "31/12/2026"})
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe quitar el vencimiento al actualizar con null", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		repo.On("GetByID", mock.Anything, uint(1)).Return(&Documento{ID: 1, VenceEl: enDias(10)}, nil)
+		repo.On("UpdateVenceEl", mock.Anything, uint(1), (*time.Time)(nil)).Return(nil)
+		req := httptest.NewRequest(http.MethodPatch, "/documentos/1", strings.NewReader(`{"vence_el": null}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe retornar 400 si el vencimiento no es un texto", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		r, _ := setupEndpoint(t, repo, Config{})
+		req := httptest.NewRequest(http.MethodPatch, "/documentos/1", strings.NewReader(`{"vence_el": 20261231}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
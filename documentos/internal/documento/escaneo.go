@@ -0,0 +1,305 @@
+package documento
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/kramirez/documentos/pkg/extractor"
+	"github.com/kramirez/documentos/pkg/miniatura"
+	"github.com/kramirez/documentos/pkg/scanner"
+	"github.com/kramirez/documentos/pkg/storage"
+	"gorm.io/gorm"
+)
+
+// intentosEscaneo es la cantidad de veces que se reintenta un análisis cuando el antivirus falla
+const intentosEscaneo = 3
+
+// ColaEscaneo recibe los documentos que deben ser analizados por el antivirus
+type ColaEscaneo interface {
+	Encolar(documentoID uint)
+}
+
+// Escaneador analiza en segundo plano el contenido de los documentos subidos y,
+// una vez declarado limpio, extrae su texto para la búsqueda y genera su miniatura
+type Escaneador struct {
+	repo    Repository
+	storage storage.Storage
+	scanner scanner.Scanner
+	logger  *log.Logger
+	cola    chan uint
+
+	// encolados son los documentos que están en la cola o en proceso, para no analizarlos dos veces
+	mu        sync.Mutex
+	encolados map[uint]bool
+}
+
+func NewEscaneador(repo Repository, store storage.Storage, sc scanner.Scanner, logger *log.Logger) *Escaneador {
+	return &Escaneador{
+		repo:      repo,
+		storage:   store,
+		scanner:   sc,
+		logger:    logger,
+		cola:      make(chan uint, 1000),
+		encolados: make(map[uint]bool),
+	}
+}
+
+// Iniciar lanza los workers de análisis y revisa al iniciar y luego periódicamente los documentos pendientes,
+// de modo que se retomen los que no cupieron en la cola o cuyo análisis falló
+func (e *Escaneador) Iniciar(ctx context.Context, workers int, intervalo time.Duration) {
+	for i := 0; i < workers; i++ {
+		go e.worker(ctx)
+	}
+
+	go func() {
+		e.encolarPendientes(ctx)
+		ticker := time.NewTicker(intervalo)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				e.encolarPendientes(ctx)
+			}
+		}
+	}()
+}
+
+// encolarPendientes encola los documentos pendientes de escaneo o de indexación,
+// esperando a que haya espacio en la cola en vez de descartarlos
+func (e *Escaneador) encolarPendientes(ctx context.Context) {
+	pendientes, err := e.repo.GetPendientesEscaneo(ctx)
+	if err != nil {
+		e.logger.Printf("Error al obtener documentos pendientes de escaneo: %v", err)
+		return
+	}
+	if len(pendientes) > 0 {
+		e.logger.Printf("Se encolan %d documentos pendientes de escaneo", len(pendientes))
+	}
+	for _, doc := range pendientes {
+		if !e.encolarEsperando(ctx, doc.ID) {
+			return
+		}
+	}
+
+	sinIndexar, err := e.repo.GetPendientesIndexacion(ctx)
+	if err != nil {
+		e.logger.Printf("Error al obtener documentos pendientes de indexación: %v", err)
+		return
+	}
+	// Basta con un documento por contenido, el texto se comparte entre documentos con el mismo checksum
+	encolados := make(map[string]bool)
+	for _, doc := range sinIndexar {
+		if !encolados[doc.Checksum] {
+			encolados[doc.Checksum] = true
+			if !e.encolarEsperando(ctx, doc.ID) {
+				return
+			}
+		}
+	}
+	if len(encolados) > 0 {
+		e.logger.Printf("Se encolan %d documentos pendientes de indexación", len(encolados))
+	}
+}
+
+// Encolar agrega un documento a la cola de análisis sin bloquear.
+// Si la cola está llena el documento queda pendiente y se encola en la próxima revisión periódica.
+func (e *Escaneador) Encolar(documentoID uint) {
+	if !e.reservar(documentoID) {
+		return
+	}
+	select {
+	case e.cola <- documentoID:
+	default:
+		e.liberar(documentoID)
+		e.logger.Printf("Advertencia: Cola de escaneo llena, el documento ID=%d queda pendiente hasta la próxima revisión", documentoID)
+	}
+}
+
+// encolarEsperando agrega un documento a la cola bloqueando hasta que haya espacio;
+// retorna false si el contexto se canceló antes
+func (e *Escaneador) encolarEsperando(ctx context.Context, documentoID uint) bool {
+	if !e.reservar(documentoID) {
+		return true
+	}
+	select {
+	case e.cola <- documentoID:
+		return true
+	case <-ctx.Done():
+		e.liberar(documentoID)
+		return false
+	}
+}
+
+// reservar marca el documento como encolado; retorna false si ya está en la cola o en proceso
+func (e *Escaneador) reservar(documentoID uint) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.encolados[documentoID] {
+		return false
+	}
+	e.encolados[documentoID] = true
+	return true
+}
+
+func (e *Escaneador) liberar(documentoID uint) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.encolados, documentoID)
+}
+
+func (e *Escaneador) worker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-e.cola:
+			e.procesar(ctx, id)
+			e.liberar(id)
+		}
+	}
+}
+
+func (e *Escaneador) procesar(ctx context.Context, id uint) {
+	documento, err := e.repo.GetByID(ctx, id)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			e.logger.Printf("Error al obtener el documento ID=%d para escaneo: %v", id, err)
+		}
+		return
+	}
+	if documento.ClaveAlmacenamiento == "" || documento.EstadoEscaneo == EstadoEnCuarentena {
+		return
+	}
+	if documento.EstadoEscaneo == EstadoLimpio {
+		e.procesarLimpio(ctx, documento)
+		return
+	}
+
+	// Si el mismo contenido ya fue analizado para otro documento se reutiliza el resultado
+	if documento.Checksum != "" {
+		if blob, err := e.repo.GetBlob(ctx, documento.Checksum); err == nil && blob.EstadoEscaneo != EstadoPendienteEscaneo && blob.EstadoEscaneo != "" {
+			if err := e.repo.UpdateEstadoEscaneo(ctx, documento, blob.EstadoEscaneo, blob.DetalleEscaneo); err != nil {
+				e.logger.Printf("Error al actualizar el estado de escaneo del documento ID=%d: %v", id, err)
+				return
+			}
+			if blob.EstadoEscaneo == EstadoLimpio {
+				e.procesarLimpio(ctx, documento)
+			}
+			return
+		}
+	}
+
+	var resultado scanner.Resultado
+	for intento := 1; intento <= intentosEscaneo; intento++ {
+		resultado, err = e.escanear(ctx, documento.ClaveAlmacenamiento)
+		if err == nil {
+			break
+		}
+		e.logger.Printf("Error al escanear el documento ID=%d (intento %d de %d): %v", id, intento, intentosEscaneo, err)
+		if intento < intentosEscaneo {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Duration(intento) * 5 * time.Second):
+			}
+		}
+	}
+	if err != nil {
+		// El documento sigue pendiente y se volverá a intentar en la próxima revisión
+		return
+	}
+
+	estado := EstadoLimpio
+	if resultado.Infectado {
+		estado = EstadoEnCuarentena
+		e.logger.Printf("Advertencia: Documento ID=%d en cuarentena, amenaza detectada: %s", id, resultado.Firma)
+	}
+	if err := e.repo.UpdateEstadoEscaneo(ctx, documento, estado, resultado.Firma); err != nil {
+		e.logger.Printf("Error al actualizar el estado de escaneo del documento ID=%d: %v", id, err)
+		return
+	}
+	e.logger.Printf("Documento ID=%d escaneado: %s", id, estado)
+
+	if estado == EstadoLimpio {
+		e.procesarLimpio(ctx, documento)
+	}
+}
+
+// procesarLimpio prepara el contenido ya declarado limpio para la búsqueda y la previsualización
+func (e *Escaneador) procesarLimpio(ctx context.Context, documento *Documento) {
+	e.indexar(ctx, documento)
+	e.crearMiniatura(ctx, documento)
+}
+
+// crearMiniatura genera la miniatura de imágenes y PDF si el contenido aún no la tiene
+func (e *Escaneador) crearMiniatura(ctx context.Context, documento *Documento) {
+	if documento.Checksum == "" || !miniatura.Soporta(documento.TipoMime) {
+		return
+	}
+	blob, err := e.repo.GetBlob(ctx, documento.Checksum)
+	if err != nil {
+		e.logger.Printf("Error al obtener el contenido del documento ID=%d para la miniatura: %v", documento.ID, err)
+		return
+	}
+	if blob.ClaveMiniatura != "" {
+		return
+	}
+
+	if err := generarMiniatura(ctx, e.repo, e.storage, blob, documento.TipoMime); err != nil {
+		e.logger.Printf("Advertencia: No se pudo generar la miniatura del documento ID=%d: %v", documento.ID, err)
+		return
+	}
+	e.logger.Printf("Miniatura del documento ID=%d generada", documento.ID)
+}
+
+// indexar extrae el texto del contenido del documento y lo guarda para la búsqueda.
+// El texto se asocia al checksum, por lo que cada contenido se procesa una sola vez.
+func (e *Escaneador) indexar(ctx context.Context, documento *Documento) {
+	if documento.Checksum == "" {
+		return
+	}
+	existe, err := e.repo.ExisteTexto(ctx, documento.Checksum)
+	if err != nil {
+		e.logger.Printf("Error al verificar el texto indexado del documento ID=%d: %v", documento.ID, err)
+		return
+	}
+	if existe {
+		return
+	}
+
+	texto := &TextoBlob{Checksum: documento.Checksum}
+	if extractor.Soporta(documento.TipoMime) {
+		archivo, err := e.storage.Open(ctx, documento.ClaveAlmacenamiento)
+		if err != nil {
+			e.logger.Printf("Error al abrir el contenido del documento ID=%d para indexar: %v", documento.ID, err)
+			return
+		}
+		defer archivo.Close()
+
+		// Si el archivo no se puede interpretar se guarda sin texto para no reintentarlo
+		texto.Contenido, err = extractor.Extraer(archivo, documento.TipoMime)
+		if err != nil {
+			e.logger.Printf("Advertencia: No se pudo extraer el texto del documento ID=%d: %v", documento.ID, err)
+		}
+	}
+
+	if err := e.repo.GuardarTexto(ctx, texto); err != nil {
+		e.logger.Printf("Error al guardar el texto del documento ID=%d: %v", documento.ID, err)
+		return
+	}
+	e.logger.Printf("Documento ID=%d indexado para búsqueda (%d bytes de texto)", documento.ID, len(texto.Contenido))
+}
+
+func (e *Escaneador) escanear(ctx context.Context, clave string) (scanner.Resultado, error) {
+	archivo, err := e.storage.Open(ctx, clave)
+	if err != nil {
+		return scanner.Resultado{}, err
+	}
+	defer archivo.Close()
+	return e.scanner.Scan(ctx, archivo)
+}
@@ -0,0 +1,230 @@
+package documento
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/kramirez/documentos/pkg/scanner"
+	"github.com/kramirez/documentos/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// eicar es la firma de prueba que scanner.Fake detecta como infectada
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// setupEscaneador crea el escaneador sobre un almacenamiento local con el contenido indicado
+// y retorna el documento pendiente de escaneo que lo referencia
+func setupEscaneador(t *testing.T, repo *mockRepository, sc scanner.Scanner, contenido []byte) (*Escaneador, *Documento) {
+	t.Helper()
+	store, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	documento := &Documento{
+		ID:                  7,
+		TipoMime:            "text/plain",
+		Checksum:            checksumDe(contenido),
+		ClaveAlmacenamiento: guardarEnStorage(t, store, contenido),
+		EstadoEscaneo:       EstadoPendienteEscaneo,
+	}
+	return NewEscaneador(repo, store, sc, log.New(io.Discard, "", 0)), documento
+}
+
+func TestEscaneador_Procesar(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("debe marcar limpio e indexar un archivo sin amenazas", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		escaneador, documento := setupEscaneador(t, repo, scanner.NewFake(), []byte("experiencia en Go y MySQL"))
+		repo.On("GetByID", ctx, documento.ID).Return(documento, nil)
+		repo.On("GetBlob", ctx, documento.Checksum).Return(&Blob{Checksum: documento.Checksum, EstadoEscaneo: EstadoPendienteEscaneo}, nil)
+		repo.On("UpdateEstadoEscaneo", ctx, documento, EstadoLimpio, "").Return(nil)
+		repo.On("ExisteTexto", ctx, documento.Checksum).Return(false, nil)
+		repo.On("GuardarTexto", ctx, mock.MatchedBy(func(texto *TextoBlob) bool {
+			return texto.Checksum == documento.Checksum && texto.Contenido == "experiencia en Go y MySQL"
+		})).Return(nil)
+
+		// Act
+		escaneador.procesar(ctx, documento.ID)
+
+		// Assert
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe generar la miniatura de una imagen limpia", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		escaneador, documento := setupEscaneador(t, repo, scanner.NewFake(), imagenPNG(t))
+		documento.TipoMime = "image/png"
+		blob := &Blob{Checksum: documento.Checksum, ClaveAlmacenamiento: documento.ClaveAlmacenamiento, EstadoEscaneo: EstadoPendienteEscaneo}
+		repo.On("GetByID", ctx, documento.ID).Return(documento, nil)
+		repo.On("GetBlob", ctx, documento.Checksum).Return(blob, nil)
+		repo.On("UpdateEstadoEscaneo", ctx, documento, EstadoLimpio, "").Return(nil)
+		repo.On("ExisteTexto", ctx, documento.Checksum).Return(false, nil)
+		repo.On("GuardarTexto", ctx, mock.Anything).Return(nil)
+		repo.On("UpdateMiniatura", ctx, documento.Checksum, claveMiniatura(documento.Checksum)).Return(nil)
+
+		// Act
+		escaneador.procesar(ctx, documento.ID)
+
+		// Assert
+		repo.AssertExpectations(t)
+		miniatura, err := escaneador.storage.Open(ctx, claveMiniatura(documento.Checksum))
+		require.NoError(t, err)
+		miniatura.Close()
+	})
+
+	t.Run("debe poner en cuarentena un archivo infectado sin indexarlo", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		escaneador, documento := setupEscaneador(t, repo, scanner.NewFake(), []byte(eicar))
+		repo.On("GetByID", ctx, documento.ID).Return(documento, nil)
+		repo.On("GetBlob", ctx, documento.Checksum).Return(&Blob{Checksum: documento.Checksum, EstadoEscaneo: EstadoPendienteEscaneo}, nil)
+		repo.On("UpdateEstadoEscaneo", ctx, documento, EstadoEnCuarentena, "Eicar-Test-Signature").Return(nil)
+
+		// Act
+		escaneador.procesar(ctx, documento.ID)
+
+		// Assert
+		repo.AssertExpectations(t)
+		repo.AssertNotCalled(t, "ExisteTexto", mock.Anything, mock.Anything)
+		repo.AssertNotCalled(t, "GuardarTexto", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe dejar pendiente el documento si falla el antivirus", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		escaneador, documento := setupEscaneador(t, repo, &scanner.Fake{Err: errors.New("clamd no disponible")}, []byte("contenido"))
+		repo.On("GetByID", mock.Anything, documento.ID).Return(documento, nil)
+		repo.On("GetBlob", mock.Anything, documento.Checksum).Return(nil, gorm.ErrRecordNotFound)
+		// Con el contexto cancelado no se espera entre reintentos
+		ctxCancelado, cancel := context.WithCancel(ctx)
+		cancel()
+
+		// Act
+		escaneador.procesar(ctxCancelado, documento.ID)
+
+		// Assert
+		repo.AssertNotCalled(t, "UpdateEstadoEscaneo", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe reutilizar el resultado de un contenido ya analizado", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		escaneador, documento := setupEscaneador(t, repo, &scanner.Fake{Err: errors.New("no debe usarse")}, []byte(eicar))
+		repo.On("GetByID", ctx, documento.ID).Return(documento, nil)
+		repo.On("GetBlob", ctx, documento.Checksum).Return(&Blob{Checksum: documento.Checksum, EstadoEscaneo: EstadoEnCuarentena, DetalleEscaneo: "Eicar-Test-Signature"}, nil)
+		repo.On("UpdateEstadoEscaneo", ctx, documento, EstadoEnCuarentena, "Eicar-Test-Signature").Return(nil)
+
+		// Act
+		escaneador.procesar(ctx, documento.ID)
+
+		// Assert
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("no debe volver a analizar un documento en cuarentena", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		escaneador, documento := setupEscaneador(t, repo, scanner.NewFake(), []byte(eicar))
+		documento.EstadoEscaneo = EstadoEnCuarentena
+		repo.On("GetByID", ctx, documento.ID).Return(documento, nil)
+
+		// Act
+		escaneador.procesar(ctx, documento.ID)
+
+		// Assert
+		repo.AssertNotCalled(t, "GetBlob", mock.Anything, mock.Anything)
+		repo.AssertNotCalled(t, "UpdateEstadoEscaneo", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestEscaneador_Iniciar(t *testing.T) {
+	t.Run("debe analizar los documentos que quedaron pendientes", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		escaneador, documento := setupEscaneador(t, repo, scanner.NewFake(), []byte(eicar))
+		analizado := make(chan struct{})
+		repo.On("GetPendientesEscaneo", mock.Anything).Return([]Documento{*documento}, nil)
+		repo.On("GetPendientesIndexacion", mock.Anything).Return([]Documento{}, nil)
+		repo.On("GetByID", mock.Anything, documento.ID).Return(documento, nil)
+		repo.On("GetBlob", mock.Anything, documento.Checksum).Return(nil, gorm.ErrRecordNotFound)
+		repo.On("UpdateEstadoEscaneo", mock.Anything, documento, EstadoEnCuarentena, "Eicar-Test-Signature").
+			Return(nil).
+			Run(func(mock.Arguments) { close(analizado) })
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// Act
+		escaneador.Iniciar(ctx, 1, time.Hour)
+
+		// Assert
+		select {
+		case <-analizado:
+		case <-time.After(time.Second):
+			t.Fatal("el documento pendiente no se analizó")
+		}
+	})
+
+	t.Run("debe encolar en la revisión periódica los documentos que quedaron pendientes después", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		escaneador, documento := setupEscaneador(t, repo, scanner.NewFake(), []byte("contenido limpio"))
+		analizado := make(chan struct{})
+		repo.On("GetPendientesEscaneo", mock.Anything).Return([]Documento{}, nil).Once()
+		repo.On("GetPendientesEscaneo", mock.Anything).Return([]Documento{*documento}, nil)
+		repo.On("GetPendientesIndexacion", mock.Anything).Return([]Documento{}, nil)
+		repo.On("GetByID", mock.Anything, documento.ID).Return(documento, nil)
+		repo.On("GetBlob", mock.Anything, documento.Checksum).Return(nil, gorm.ErrRecordNotFound)
+		repo.On("UpdateEstadoEscaneo", mock.Anything, documento, EstadoLimpio, "").
+			Return(nil).
+			Run(func(mock.Arguments) { close(analizado) })
+		repo.On("ExisteTexto", mock.Anything, documento.Checksum).Return(true, nil)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// Act
+		escaneador.Iniciar(ctx, 1, 10*time.Millisecond)
+
+		// Assert
+		select {
+		case <-analizado:
+		case <-time.After(time.Second):
+			t.Fatal("el documento pendiente no se analizó en la revisión periódica")
+		}
+	})
+}
+
+func TestEscaneador_Encolar(t *testing.T) {
+	t.Run("no debe encolar dos veces un documento que ya está en la cola", func(t *testing.T) {
+		// Arrange
+		escaneador, _ := setupEscaneador(t, new(mockRepository), scanner.NewFake(), []byte("contenido"))
+
+		// Act
+		escaneador.Encolar(7)
+		escaneador.Encolar(7)
+
+		// Assert
+		assert.Len(t, escaneador.cola, 1)
+	})
+
+	t.Run("debe dejar el documento pendiente sin bloquear si la cola está llena", func(t *testing.T) {
+		// Arrange
+		escaneador, _ := setupEscaneador(t, new(mockRepository), scanner.NewFake(), []byte("contenido"))
+		escaneador.cola = make(chan uint, 1)
+		escaneador.Encolar(1)
+
+		// Act
+		escaneador.Encolar(7)
+
+		// Assert
+		assert.Equal(t, uint(1), <-escaneador.cola)
+		assert.False(t, escaneador.encolados[7], "la próxima revisión debe poder encolarlo")
+	})
+}
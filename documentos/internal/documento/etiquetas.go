@@ -0,0 +1,155 @@
+package documento
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Tipos de valor de los metadatos de un documento
+const (
+	TipoTexto    = "texto"
+	TipoNumero   = "numero"
+	TipoFecha    = "fecha"
+	TipoBooleano = "booleano"
+)
+
+const (
+	maxEtiquetas      = 20
+	maxMetadatos      = 20
+	largoMaximoNombre = 50
+	largoMaximoValor  = 255
+)
+
+// ErrEtiquetadoInvalido indica que las etiquetas o los metadatos enviados no son válidos
+var ErrEtiquetadoInvalido = errors.New("etiquetas o metadatos inválidos")
+
+// claveMetadatoValida restringe las claves a identificadores simples, para poder filtrarlas en la URL
+var claveMetadatoValida = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// Etiqueta es una marca libre de un documento, por ejemplo "confidencial" o "versión cliente"
+type Etiqueta struct {
+	DocumentoID uint   `gorm:"primaryKey" json:"-"`
+	Nombre      string `gorm:"primaryKey;type:varchar(50);index" json:"nombre"`
+}
+
+// TableName especifica el nombre de la tabla de etiquetas
+func (Etiqueta) TableName() string {
+	return "documento_etiquetas"
+}
+
+// Metadato es un dato con tipo asociado a un documento, por ejemplo la fecha de emisión o el autor.
+// El valor se guarda normalizado según su tipo para que los filtros comparen de forma consistente.
+type Metadato struct {
+	DocumentoID uint   `gorm:"primaryKey" json:"-"`
+	Clave       string `gorm:"primaryKey;type:varchar(50)" json:"clave"`
+	Tipo        string `gorm:"type:varchar(10);not null" json:"tipo"`
+	Valor       string `gorm:"type:varchar(255);not null;index" json:"valor"`
+}
+
+// TableName especifica el nombre de la tabla de metadatos
+func (Metadato) TableName() string {
+	return "documento_metadatos"
+}
+
+// nombresEtiquetas retorna los nombres de las etiquetas para la respuesta
+func nombresEtiquetas(etiquetas []Etiqueta) []string {
+	if len(etiquetas) == 0 {
+		return nil
+	}
+	nombres := make([]string, len(etiquetas))
+	for i, etiqueta := range etiquetas {
+		nombres[i] = etiqueta.Nombre
+	}
+	return nombres
+}
+
+// normalizarEtiquetas quita espacios, pasa a minúsculas y elimina duplicados
+func normalizarEtiquetas(nombres []string) ([]Etiqueta, error) {
+	etiquetas := []Etiqueta{}
+	vistas := make(map[string]bool)
+	for _, nombre := range nombres {
+		nombre = strings.ToLower(strings.TrimSpace(nombre))
+		if nombre == "" || vistas[nombre] {
+			continue
+		}
+		if utf8.RuneCountInString(nombre) > largoMaximoNombre {
+			return nil, fmt.Errorf("%w: la etiqueta '%s' supera los %d caracteres", ErrEtiquetadoInvalido, nombre, largoMaximoNombre)
+		}
+		vistas[nombre] = true
+		etiquetas = append(etiquetas, Etiqueta{Nombre: nombre})
+	}
+	if len(etiquetas) > maxEtiquetas {
+		return nil, fmt.Errorf("%w: se permiten hasta %d etiquetas por documento", ErrEtiquetadoInvalido, maxEtiquetas)
+	}
+	return etiquetas, nil
+}
+
+// normalizarMetadatos valida cada clave y convierte el valor a la forma canónica de su tipo.
+// Un metadato sin tipo se considera texto.
+func normalizarMetadatos(metadatos []Metadato) ([]Metadato, error) {
+	if len(metadatos) > maxMetadatos {
+		return nil, fmt.Errorf("%w: se permiten hasta %d metadatos por documento", ErrEtiquetadoInvalido, maxMetadatos)
+	}
+
+	normalizados := make([]Metadato, 0, len(metadatos))
+	vistas := make(map[string]bool)
+	for _, metadato := range metadatos {
+		clave := strings.ToLower(strings.TrimSpace(metadato.Clave))
+		if len(clave) > largoMaximoNombre || !claveMetadatoValida.MatchString(clave) {
+			return nil, fmt.Errorf("%w: la clave '%s' debe tener hasta %d letras minúsculas, números o guiones bajos", ErrEtiquetadoInvalido, metadato.Clave, largoMaximoNombre)
+		}
+		if vistas[clave] {
+			return nil, fmt.Errorf("%w: la clave '%s' está repetida", ErrEtiquetadoInvalido, clave)
+		}
+		vistas[clave] = true
+
+		tipo := metadato.Tipo
+		if tipo == "" {
+			tipo = TipoTexto
+		}
+		valor, err := normalizarValor(tipo, strings.TrimSpace(metadato.Valor))
+		if err != nil {
+			return nil, fmt.Errorf("%w: el metadato '%s' %v", ErrEtiquetadoInvalido, clave, err)
+		}
+		normalizados = append(normalizados, Metadato{Clave: clave, Tipo: tipo, Valor: valor})
+	}
+	return normalizados, nil
+}
+
+func normalizarValor(tipo, valor string) (string, error) {
+	switch tipo {
+	case TipoTexto:
+		if valor == "" {
+			return "", fmt.Errorf("no puede estar vacío")
+		}
+		if utf8.RuneCountInString(valor) > largoMaximoValor {
+			return "", fmt.Errorf("supera los %d caracteres", largoMaximoValor)
+		}
+		return valor, nil
+	case TipoNumero:
+		numero, err := strconv.ParseFloat(valor, 64)
+		if err != nil {
+			return "", fmt.Errorf("debe ser un número")
+		}
+		return strconv.FormatFloat(numero, 'f', -1, 64), nil
+	case TipoFecha:
+		fecha, err := time.Parse("2006-01-02", valor)
+		if err != nil {
+			return "", fmt.Errorf("debe ser una fecha con formato YYYY-MM-DD")
+		}
+		return fecha.Format("2006-01-02"), nil
+	case TipoBooleano:
+		booleano, err := strconv.ParseBool(valor)
+		if err != nil {
+			return "", fmt.Errorf("debe ser true o false")
+		}
+		return strconv.FormatBool(booleano), nil
+	default:
+		return "", fmt.Errorf("tiene un tipo no soportado '%s', use %s, %s, %s o %s", tipo, TipoTexto, TipoNumero, TipoFecha, TipoBooleano)
+	}
+}
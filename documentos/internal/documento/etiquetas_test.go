@@ -0,0 +1,100 @@
+package documento
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizarEtiquetas(t *testing.T) {
+	t.Run("debe quitar espacios, pasar a minúsculas y eliminar duplicados", func(t *testing.T) {
+		// Act
+		etiquetas, err := normalizarEtiquetas([]string{" Confidencial", "confidencial", "", "Versión Cliente "})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, []Etiqueta{{Nombre: "confidencial"}, {Nombre: "versión cliente"}}, etiquetas)
+	})
+
+	t.Run("debe retornar una lista vacía sin etiquetas", func(t *testing.T) {
+		// Act
+		etiquetas, err := normalizarEtiquetas(nil)
+
+		// Assert
+		require.NoError(t, err)
+		assert.NotNil(t, etiquetas)
+		assert.Empty(t, etiquetas)
+	})
+
+	t.Run("debe rechazar una etiqueta demasiado larga", func(t *testing.T) {
+		// Act
+		_, err := normalizarEtiquetas([]string{strings.Repeat("ñ", largoMaximoNombre+1)})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrEtiquetadoInvalido)
+	})
+
+	t.Run("debe rechazar más etiquetas que el máximo", func(t *testing.T) {
+		// Arrange
+		nombres := make([]string, maxEtiquetas+1)
+		for i := range nombres {
+			nombres[i] = strings.Repeat("a", i+1)
+		}
+
+		// Act
+		_, err := normalizarEtiquetas(nombres)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrEtiquetadoInvalido)
+	})
+}
+
+func TestNormalizarMetadatos(t *testing.T) {
+	t.Run("debe normalizar la clave y el valor según su tipo", func(t *testing.T) {
+		// Act
+		metadatos, err := normalizarMetadatos([]Metadato{
+			{Clave: " Autor ", Valor: " Karla "},
+			{Clave: "monto", Tipo: TipoNumero, Valor: "1500.50"},
+			{Clave: "emision", Tipo: TipoFecha, Valor: "2026-03-01"},
+			{Clave: "firmado", Tipo: TipoBooleano, Valor: "1"},
+		})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, []Metadato{
+			{Clave: "autor", Tipo: TipoTexto, Valor: "Karla"},
+			{Clave: "monto", Tipo: TipoNumero, Valor: "1500.5"},
+			{Clave: "emision", Tipo: TipoFecha, Valor: "2026-03-01"},
+			{Clave: "firmado", Tipo: TipoBooleano, Valor: "true"},
+		}, metadatos)
+	})
+
+	t.Run("debe rechazar metadatos inválidos", func(t *testing.T) {
+		casos := map[string][]Metadato{
+			"clave con espacios": {{Clave: "fecha de emision", Valor: "x"}},
+			"clave repetida":     {{Clave: "autor", Valor: "a"}, {Clave: "AUTOR", Valor: "b"}},
+			"texto vacío":        {{Clave: "autor", Valor: "  "}},
+			"número inválido":    {{Clave: "monto", Tipo: TipoNumero, Valor: "mil"}},
+			"fecha inválida":     {{Clave: "emision", Tipo: TipoFecha, Valor: "01/03/2026"}},
+			"booleano inválido":  {{Clave: "firmado", Tipo: TipoBooleano, Valor: "quizás"}},
+			"tipo desconocido":   {{Clave: "autor", Tipo: "lista", Valor: "a"}},
+		}
+		for nombre, metadatos := range casos {
+			// Act
+			_, err := normalizarMetadatos(metadatos)
+
+			// Assert
+			assert.ErrorIs(t, err, ErrEtiquetadoInvalido, nombre)
+		}
+	})
+
+	t.Run("debe rechazar más metadatos que el máximo", func(t *testing.T) {
+		// Act
+		_, err := normalizarMetadatos(make([]Metadato, maxMetadatos+1))
+
+		// Assert
+		assert.ErrorIs(t, err, ErrEtiquetadoInvalido)
+	})
+}
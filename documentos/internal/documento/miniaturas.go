@@ -0,0 +1,40 @@
+package documento
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/kramirez/documentos/pkg/miniatura"
+	"github.com/kramirez/documentos/pkg/storage"
+)
+
+// claveMiniatura retorna la clave en el Storage de la miniatura de un contenido
+func claveMiniatura(checksum string) string {
+	return fmt.Sprintf("miniaturas/%s/%s.jpg", checksum[:2], checksum)
+}
+
+// generarMiniatura crea la miniatura del contenido de un blob, la guarda en el Storage y la
+// registra en el blob. Al depender solo del contenido, se comparte entre documentos con el mismo checksum.
+func generarMiniatura(ctx context.Context, repo Repository, store storage.Storage, blob *Blob, tipoMime string) error {
+	archivo, err := store.Open(ctx, blob.ClaveAlmacenamiento)
+	if err != nil {
+		return fmt.Errorf("error al abrir el contenido: %v", err)
+	}
+	defer archivo.Close()
+
+	imagen, err := miniatura.Generar(archivo, tipoMime)
+	if err != nil {
+		return err
+	}
+
+	clave := claveMiniatura(blob.Checksum)
+	if _, err := store.Save(ctx, clave, bytes.NewReader(imagen)); err != nil {
+		return fmt.Errorf("error al guardar la miniatura: %v", err)
+	}
+	if err := repo.UpdateMiniatura(ctx, blob.Checksum, clave); err != nil {
+		return fmt.Errorf("error al registrar la miniatura: %v", err)
+	}
+	blob.ClaveMiniatura = clave
+	return nil
+}
@@ -0,0 +1,244 @@
+package documento
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type mockRepository struct {
+	mock.Mock
+}
+
+func (m *mockRepository) Create(ctx context.Context, documento *Documento) error {
+	args := m.Called(ctx, documento)
+	return args.Error(0)
+}
+
+func (m *mockRepository) GetAll(ctx context.Context, filters GetAllReq) ([]Documento, error) {
+	args := m.Called(ctx, filters)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Documento), args.Error(1)
+}
+
+func (m *mockRepository) GetByID(ctx context.Context, id uint) (*Documento, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Documento), args.Error(1)
+}
+
+func (m *mockRepository) GetByCargaID(ctx context.Context, cargaID string) (*Documento, error) {
+	args := m.Called(ctx, cargaID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Documento), args.Error(1)
+}
+
+func (m *mockRepository) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *mockRepository) DeleteBySolicitudID(ctx context.Context, solicitudID uint) error {
+	args := m.Called(ctx, solicitudID)
+	return args.Error(0)
+}
+
+func (m *mockRepository) GetVersiones(ctx context.Context, documentoID uint) ([]Version, error) {
+	args := m.Called(ctx, documentoID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Version), args.Error(1)
+}
+
+func (m *mockRepository) GetVersion(ctx context.Context, documentoID uint, numero int) (*Version, error) {
+	args := m.Called(ctx, documentoID, numero)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Version), args.Error(1)
+}
+
+func (m *mockRepository) ApplyVersion(ctx context.Context, documentoID uint, version *Version) error {
+	args := m.Called(ctx, documentoID, version)
+	return args.Error(0)
+}
+
+func (m *mockRepository) GetBlob(ctx context.Context, checksum string) (*Blob, error) {
+	args := m.Called(ctx, checksum)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Blob), args.Error(1)
+}
+
+func (m *mockRepository) BlobAccesible(ctx context.Context, checksum string, solicitudID uint, usuarioID *uint) (bool, error) {
+	args := m.Called(ctx, checksum, solicitudID, usuarioID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockRepository) GetPendientesEscaneo(ctx context.Context) ([]Documento, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Documento), args.Error(1)
+}
+
+func (m *mockRepository) UpdateEstadoEscaneo(ctx context.Context, documento *Documento, estado, detalle string) error {
+	args := m.Called(ctx, documento, estado, detalle)
+	return args.Error(0)
+}
+
+func (m *mockRepository) Buscar(ctx context.Context, req BuscarReq) ([]Coincidencia, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Coincidencia), args.Error(1)
+}
+
+func (m *mockRepository) ExisteTexto(ctx context.Context, checksum string) (bool, error) {
+	args := m.Called(ctx, checksum)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockRepository) GuardarTexto(ctx context.Context, texto *TextoBlob) error {
+	args := m.Called(ctx, texto)
+	return args.Error(0)
+}
+
+func (m *mockRepository) GetPendientesIndexacion(ctx context.Context) ([]Documento, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Documento), args.Error(1)
+}
+
+func (m *mockRepository) UpdateMiniatura(ctx context.Context, checksum, clave string) error {
+	args := m.Called(ctx, checksum, clave)
+	return args.Error(0)
+}
+
+func (m *mockRepository) UpdateCategoria(ctx context.Context, id uint, categoria string) error {
+	args := m.Called(ctx, id, categoria)
+	return args.Error(0)
+}
+
+func (m *mockRepository) UpdateVenceEl(ctx context.Context, id uint, venceEl *time.Time) error {
+	args := m.Called(ctx, id, venceEl)
+	return args.Error(0)
+}
+
+func (m *mockRepository) GetPorVencer(ctx context.Context, desde *time.Time, hasta time.Time, solicitudID uint) ([]Documento, error) {
+	args := m.Called(ctx, desde, hasta, solicitudID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Documento), args.Error(1)
+}
+
+func (m *mockRepository) GetPendientesAvisoVencimiento(ctx context.Context, hasta time.Time) ([]Documento, error) {
+	args := m.Called(ctx, hasta)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Documento), args.Error(1)
+}
+
+func (m *mockRepository) MarcarAvisoVencimiento(ctx context.Context, ids []uint, fecha time.Time) error {
+	args := m.Called(ctx, ids, fecha)
+	return args.Error(0)
+}
+
+func (m *mockRepository) ReemplazarEtiquetas(ctx context.Context, id uint, etiquetas []Etiqueta) error {
+	args := m.Called(ctx, id, etiquetas)
+	return args.Error(0)
+}
+
+func (m *mockRepository) ReemplazarMetadatos(ctx context.Context, id uint, metadatos []Metadato) error {
+	args := m.Called(ctx, id, metadatos)
+	return args.Error(0)
+}
+
+func (m *mockRepository) ConsumirEnlace(ctx context.Context, enlace *EnlaceUsado) error {
+	args := m.Called(ctx, enlace)
+	return args.Error(0)
+}
+
+func (m *mockRepository) GetUsoSolicitud(ctx context.Context, solicitudID uint) (Uso, error) {
+	args := m.Called(ctx, solicitudID)
+	return args.Get(0).(Uso), args.Error(1)
+}
+
+func (m *mockRepository) GetUsoUsuario(ctx context.Context, usuarioID uint) (Uso, error) {
+	args := m.Called(ctx, usuarioID)
+	return args.Get(0).(Uso), args.Error(1)
+}
+
+func (m *mockRepository) GetEliminados(ctx context.Context, filters GetAllReq) ([]Documento, error) {
+	args := m.Called(ctx, filters)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Documento), args.Error(1)
+}
+
+func (m *mockRepository) GetEliminadoByID(ctx context.Context, id uint) (*Documento, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Documento), args.Error(1)
+}
+
+func (m *mockRepository) Restaurar(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *mockRepository) PurgarEliminados(ctx context.Context, eliminadosAntesDe time.Time, desdeID uint, limite int, simular bool) (*ResultadoPurga, error) {
+	args := m.Called(ctx, eliminadosAntesDe, desdeID, limite, simular)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ResultadoPurga), args.Error(1)
+}
+
+func (m *mockRepository) GetClavesAlmacenamiento(ctx context.Context) ([]string, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+type mockColaEscaneo struct {
+	mock.Mock
+}
+
+func (m *mockColaEscaneo) Encolar(documentoID uint) {
+	m.Called(documentoID)
+}
+
+type mockNotificador struct {
+	mock.Mock
+}
+
+func (m *mockNotificador) Notificar(ctx context.Context, aviso AvisoVencimiento) error {
+	args := m.Called(ctx, aviso)
+	return args.Error(0)
+}
+
+// Transaction ejecuta fn con el mismo mock, las operaciones dentro de la transacción se esperan como cualquier otra
+func (m *mockRepository) Transaction(ctx context.Context, fn func(repo Repository) error) error {
+	return fn(m)
+}
@@ -0,0 +1,173 @@
+package documento
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// tiposPorExtension indica los tipos MIME (detectados por contenido) aceptados para cada extensión conocida
+var tiposPorExtension = map[string][]string{
+	"pdf":  {"application/pdf"},
+	"doc":  {"application/msword", "application/x-ole-storage"},
+	"docx": {"application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+	"xls":  {"application/vnd.ms-excel", "application/x-ole-storage"},
+	"xlsx": {"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+	"ppt":  {"application/vnd.ms-powerpoint", "application/x-ole-storage"},
+	"pptx": {"application/vnd.openxmlformats-officedocument.presentationml.presentation"},
+	"odt":  {"application/vnd.oasis.opendocument.text"},
+	"ods":  {"application/vnd.oasis.opendocument.spreadsheet"},
+	"txt":  {"text/plain"},
+	"csv":  {"text/csv", "text/plain"},
+	"jpg":  {"image/jpeg"},
+	"jpeg": {"image/jpeg"},
+	"png":  {"image/png"},
+}
+
+// Códigos de error de validación de archivos
+const (
+	CodigoTipoNoPermitido     = "tipo_no_permitido"
+	CodigoContenidoNoCoincide = "contenido_no_coincide"
+	CodigoCategoriaInvalida   = "categoria_invalida"
+)
+
+// ErrorValidacionArchivo describe un archivo rechazado por la política de tipos permitidos
+type ErrorValidacionArchivo struct {
+	Codigo        string   `json:"codigo"`
+	Mensaje       string   `json:"error"`
+	Extension     string   `json:"extension"`
+	TipoDetectado string   `json:"tipo_detectado,omitempty"`
+	Permitidas    []string `json:"permitidas,omitempty"`
+	// Categorias del catálogo, informadas cuando la categoría no existe
+	Categorias []string `json:"categorias,omitempty"`
+}
+
+func (e *ErrorValidacionArchivo) Error() string {
+	return e.Mensaje
+}
+
+// StatusHTTP retorna 415 para tipos no permitidos y 422 cuando el contenido no coincide con la extensión
+func (e *ErrorValidacionArchivo) StatusHTTP() int {
+	if e.Codigo == CodigoTipoNoPermitido {
+		return http.StatusUnsupportedMediaType
+	}
+	return http.StatusUnprocessableEntity
+}
+
+// PoliticaArchivos define qué extensiones se aceptan, de forma global y por categoría de documento
+type PoliticaArchivos struct {
+	// Permitidas son las extensiones aceptadas globalmente. Si está vacía se aceptan las extensiones conocidas.
+	Permitidas []string
+	// PorCategoria restringe aún más las extensiones para una categoría de documento
+	PorCategoria map[string][]string
+	// Categorias es el catálogo de categorías de documento. Si está vacío se acepta cualquier categoría.
+	Categorias []Categoria
+}
+
+// permitidas retorna las extensiones aceptadas para una categoría
+func (p PoliticaArchivos) permitidas(categoria string) []string {
+	globales := p.Permitidas
+	if len(globales) == 0 {
+		for ext := range tiposPorExtension {
+			globales = append(globales, ext)
+		}
+	}
+
+	porCategoria, ok := p.PorCategoria[categoria]
+	if categoria == "" || !ok {
+		sort.Strings(globales)
+		return globales
+	}
+
+	var resultado []string
+	for _, ext := range porCategoria {
+		if contiene(globales, ext) {
+			resultado = append(resultado, ext)
+		}
+	}
+	sort.Strings(resultado)
+	return resultado
+}
+
+// ValidarCategoria verifica que la categoría pertenezca al catálogo
+func (p PoliticaArchivos) ValidarCategoria(categoria string) error {
+	if categoria == "" || len(p.Categorias) == 0 {
+		return nil
+	}
+	codigos := make([]string, len(p.Categorias))
+	for i, cat := range p.Categorias {
+		if cat.Codigo == categoria {
+			return nil
+		}
+		codigos[i] = cat.Codigo
+	}
+	return &ErrorValidacionArchivo{
+		Codigo:     CodigoCategoriaInvalida,
+		Mensaje:    fmt.Sprintf("La categoría '%s' no existe en el catálogo", categoria),
+		Categorias: codigos,
+	}
+}
+
+// ValidarExtension verifica que la categoría exista y que la extensión esté permitida para ella
+func (p PoliticaArchivos) ValidarExtension(extension, categoria string) error {
+	if err := p.ValidarCategoria(categoria); err != nil {
+		err.(*ErrorValidacionArchivo).Extension = extension
+		return err
+	}
+	permitidas := p.permitidas(categoria)
+	if contiene(permitidas, extension) {
+		return nil
+	}
+
+	mensaje := fmt.Sprintf("La extensión '%s' no está permitida", extension)
+	if categoria != "" {
+		mensaje = fmt.Sprintf("La extensión '%s' no está permitida para la categoría '%s'", extension, categoria)
+	}
+	return &ErrorValidacionArchivo{
+		Codigo:     CodigoTipoNoPermitido,
+		Mensaje:    mensaje,
+		Extension:  extension,
+		Permitidas: permitidas,
+	}
+}
+
+// Validar verifica la extensión y que el tipo detectado en el contenido corresponda a ella
+func (p PoliticaArchivos) Validar(extension, categoria string, detectado *mimetype.MIME) error {
+	if err := p.ValidarExtension(extension, categoria); err != nil {
+		if e, ok := err.(*ErrorValidacionArchivo); ok {
+			e.TipoDetectado = detectado.String()
+		}
+		return err
+	}
+
+	// Extensiones permitidas por configuración pero sin tipos conocidos no se pueden verificar
+	esperados, ok := tiposPorExtension[extension]
+	if !ok {
+		return nil
+	}
+	for m := detectado; m != nil; m = m.Parent() {
+		for _, esperado := range esperados {
+			if m.Is(esperado) {
+				return nil
+			}
+		}
+	}
+
+	return &ErrorValidacionArchivo{
+		Codigo:        CodigoContenidoNoCoincide,
+		Mensaje:       fmt.Sprintf("El contenido del archivo (%s) no corresponde a la extensión '%s'", detectado.String(), extension),
+		Extension:     extension,
+		TipoDetectado: detectado.String(),
+	}
+}
+
+func contiene(lista []string, valor string) bool {
+	for _, v := range lista {
+		if v == valor {
+			return true
+		}
+	}
+	return false
+}
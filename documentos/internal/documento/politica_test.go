@@ -0,0 +1,117 @@
+package documento
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoliticaArchivos_Validar(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x02\x00\x00\x00")
+	exe := []byte("MZ\x90\x00\x03\x00\x00\x00\x04\x00\x00\x00\xff\xff\x00\x00")
+
+	t.Run("debe aceptar un contenido que corresponde a su extensión", func(t *testing.T) {
+		casos := []struct {
+			extension string
+			contenido []byte
+		}{
+			{"pdf", contenidoPDF},
+			{"png", png},
+			{"txt", []byte("experiencia en Go")},
+			{"csv", []byte("nombre,email\nana,ana@correo.cl\n")},
+		}
+		for _, caso := range casos {
+			// Act
+			err := PoliticaArchivos{}.Validar(caso.extension, "", mimetype.Detect(caso.contenido))
+
+			// Assert
+			assert.NoError(t, err, caso.extension)
+		}
+	})
+
+	t.Run("debe rechazar con 422 un contenido que no corresponde a la extensión", func(t *testing.T) {
+		// Act
+		err := PoliticaArchivos{}.Validar("pdf", "", mimetype.Detect(exe))
+
+		// Assert
+		var errValidacion *ErrorValidacionArchivo
+		require.ErrorAs(t, err, &errValidacion)
+		assert.Equal(t, CodigoContenidoNoCoincide, errValidacion.Codigo)
+		assert.Equal(t, "application/vnd.microsoft.portable-executable", errValidacion.TipoDetectado)
+		assert.Equal(t, http.StatusUnprocessableEntity, errValidacion.StatusHTTP())
+	})
+
+	t.Run("debe rechazar con 415 una extensión desconocida", func(t *testing.T) {
+		// Act
+		err := PoliticaArchivos{}.Validar("exe", "", mimetype.Detect(exe))
+
+		// Assert
+		var errValidacion *ErrorValidacionArchivo
+		require.ErrorAs(t, err, &errValidacion)
+		assert.Equal(t, CodigoTipoNoPermitido, errValidacion.Codigo)
+		assert.Equal(t, http.StatusUnsupportedMediaType, errValidacion.StatusHTTP())
+		assert.Contains(t, errValidacion.Permitidas, "pdf")
+		assert.NotEmpty(t, errValidacion.TipoDetectado)
+	})
+
+	t.Run("debe limitar las extensiones a las configuradas", func(t *testing.T) {
+		// Arrange
+		politica := PoliticaArchivos{Permitidas: []string{"pdf"}}
+
+		// Act
+		err := politica.Validar("png", "", mimetype.Detect(png))
+
+		// Assert
+		var errValidacion *ErrorValidacionArchivo
+		require.ErrorAs(t, err, &errValidacion)
+		assert.Equal(t, []string{"pdf"}, errValidacion.Permitidas)
+	})
+
+	t.Run("debe aceptar sin verificar el contenido una extensión configurada sin tipos conocidos", func(t *testing.T) {
+		// Arrange
+		politica := PoliticaArchivos{Permitidas: []string{"pdf", "rtf"}}
+
+		// Act
+		err := politica.Validar("rtf", "", mimetype.Detect([]byte("{\\rtf1 hola}")))
+
+		// Assert
+		assert.NoError(t, err)
+	})
+}
+
+func TestPoliticaArchivos_ValidarExtension(t *testing.T) {
+	politica := PoliticaArchivos{
+		Permitidas:   []string{"pdf", "docx", "jpg", "png"},
+		PorCategoria: map[string][]string{"foto": {"jpg", "png", "gif"}},
+	}
+
+	t.Run("debe restringir las extensiones de una categoría a las permitidas globalmente", func(t *testing.T) {
+		// Act
+		err := politica.ValidarExtension("pdf", "foto")
+
+		// Assert
+		var errValidacion *ErrorValidacionArchivo
+		require.ErrorAs(t, err, &errValidacion)
+		assert.Equal(t, []string{"jpg", "png"}, errValidacion.Permitidas)
+		assert.Contains(t, errValidacion.Mensaje, "categoría 'foto'")
+	})
+
+	t.Run("debe aceptar una extensión de la categoría", func(t *testing.T) {
+		// Act
+		err := politica.ValidarExtension("png", "foto")
+
+		// Assert
+		assert.NoError(t, err)
+	})
+
+	t.Run("debe usar las extensiones globales en una categoría sin restricciones", func(t *testing.T) {
+		// Act
+		err := politica.ValidarExtension("docx", "cv")
+
+		// Assert
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,199 @@
+package documento
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/kramirez/documentos/pkg/storage"
+	"gorm.io/gorm"
+)
+
+// documentosPorLote es la cantidad de documentos que se purgan en cada transacción
+const documentosPorLote = 100
+
+// ConfigPurga define cuánto tiempo se conservan los documentos eliminados antes de borrarlos definitivamente
+type ConfigPurga struct {
+	// Retencion es el tiempo desde el soft delete tras el cual se purga el documento; 0 desactiva la purga
+	Retencion time.Duration
+	// Simular genera el reporte de lo que se purgaría sin eliminar nada
+	Simular bool
+}
+
+// ResultadoPurga es lo que se eliminó (o se eliminaría, al simular) en un lote de la base de datos.
+// Los archivos del Storage se eliminan después, fuera de la transacción.
+type ResultadoPurga struct {
+	Documentos []Documento
+	Versiones  int
+	// Blobs que quedaron sin referencias
+	Blobs []Blob
+	// Claves de archivos guardados antes de la deduplicación, que no pertenecen a un Blob
+	ClavesSinBlob []string
+}
+
+// DocumentoPurgado describe un documento en el reporte de purga
+type DocumentoPurgado struct {
+	ID            uint      `json:"id"`
+	SolicitudID   uint      `json:"solicitud_id"`
+	NombreArchivo string    `json:"nombre_archivo"`
+	Checksum      string    `json:"checksum,omitempty"`
+	EliminadoEl   time.Time `json:"eliminado_el"`
+}
+
+// ReportePurga resume una ejecución de la purga y se exporta como JSON al Storage
+type ReportePurga struct {
+	Inicio            time.Time          `json:"inicio"`
+	Fin               time.Time          `json:"fin"`
+	Simulada          bool               `json:"simulada"`
+	EliminadosAntesDe time.Time          `json:"eliminados_antes_de"`
+	Documentos        []DocumentoPurgado `json:"documentos"`
+	Versiones         int                `json:"versiones"`
+	Archivos          int                `json:"archivos"`
+	BytesLiberados    int64              `json:"bytes_liberados"`
+	Errores           []string           `json:"errores,omitempty"`
+}
+
+// Purgador elimina definitivamente los documentos que llevan más del período de retención
+// en soft delete, junto con sus versiones y los archivos que ya no usa ningún otro documento
+type Purgador struct {
+	repo    Repository
+	storage storage.Storage
+	logger  *log.Logger
+	config  ConfigPurga
+}
+
+func NewPurgador(repo Repository, store storage.Storage, logger *log.Logger, config ConfigPurga) *Purgador {
+	return &Purgador{
+		repo:    repo,
+		storage: store,
+		logger:  logger,
+		config:  config,
+	}
+}
+
+// Iniciar ejecuta la purga periódicamente hasta que se cancele el contexto
+func (p *Purgador) Iniciar(ctx context.Context, intervalo time.Duration) {
+	if p.config.Retencion <= 0 {
+		p.logger.Println("Purga de documentos eliminados desactivada")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(intervalo)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := p.Purgar(ctx); err != nil {
+					p.logger.Printf("Error al purgar los documentos eliminados: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Purgar elimina los documentos en soft delete desde antes del período de retención y exporta el reporte
+func (p *Purgador) Purgar(ctx context.Context) (*ReportePurga, error) {
+	reporte := &ReportePurga{
+		Inicio:            time.Now(),
+		Simulada:          p.config.Simular,
+		EliminadosAntesDe: time.Now().Add(-p.config.Retencion),
+		Documentos:        []DocumentoPurgado{},
+	}
+
+	var desdeID uint
+	for {
+		lote, err := p.repo.PurgarEliminados(ctx, reporte.EliminadosAntesDe, desdeID, documentosPorLote, p.config.Simular)
+		if err != nil {
+			reporte.Errores = append(reporte.Errores, err.Error())
+			break
+		}
+		if len(lote.Documentos) == 0 {
+			break
+		}
+		desdeID = lote.Documentos[len(lote.Documentos)-1].ID
+		p.registrar(reporte, lote)
+		if !p.config.Simular {
+			p.eliminarArchivos(ctx, reporte, lote)
+		}
+	}
+	reporte.Fin = time.Now()
+
+	if len(reporte.Documentos) > 0 || len(reporte.Errores) > 0 {
+		p.exportar(ctx, reporte)
+	}
+	accion := "Purgados"
+	if reporte.Simulada {
+		accion = "Purga simulada:"
+	}
+	p.logger.Printf("%s %d documentos, %d versiones y %d archivos (%d bytes)", accion, len(reporte.Documentos), reporte.Versiones, reporte.Archivos, reporte.BytesLiberados)
+
+	if len(reporte.Errores) > 0 {
+		return reporte, fmt.Errorf("la purga terminó con %d errores", len(reporte.Errores))
+	}
+	return reporte, nil
+}
+
+func (p *Purgador) registrar(reporte *ReportePurga, lote *ResultadoPurga) {
+	for _, doc := range lote.Documentos {
+		reporte.Documentos = append(reporte.Documentos, DocumentoPurgado{
+			ID:            doc.ID,
+			SolicitudID:   doc.SolicitudID,
+			NombreArchivo: doc.NombreDescarga(),
+			Checksum:      doc.Checksum,
+			EliminadoEl:   doc.DeletedAt.Time,
+		})
+	}
+	reporte.Versiones += lote.Versiones
+	reporte.Archivos += len(lote.Blobs) + len(lote.ClavesSinBlob)
+	for _, blob := range lote.Blobs {
+		reporte.BytesLiberados += blob.Tamano
+	}
+}
+
+// eliminarArchivos borra del Storage el contenido y las miniaturas que quedaron sin referencias
+func (p *Purgador) eliminarArchivos(ctx context.Context, reporte *ReportePurga, lote *ResultadoPurga) {
+	claves := lote.ClavesSinBlob
+	for _, blob := range lote.Blobs {
+		// Una subida del mismo contenido posterior a la purga vuelve a registrar el blob y reutiliza el archivo
+		if _, err := p.repo.GetBlob(ctx, blob.Checksum); !errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		claves = append(claves, blob.ClaveAlmacenamiento)
+		if blob.ClaveMiniatura != "" {
+			claves = append(claves, blob.ClaveMiniatura)
+		}
+	}
+
+	for _, clave := range claves {
+		if err := p.storage.Delete(ctx, clave); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			p.logger.Printf("Advertencia: No se pudo eliminar el archivo %s: %v", clave, err)
+			reporte.Errores = append(reporte.Errores, fmt.Sprintf("%s: %v", clave, err))
+		}
+	}
+}
+
+// exportar guarda el reporte como JSON en el Storage bajo reportes/purga/
+func (p *Purgador) exportar(ctx context.Context, reporte *ReportePurga) {
+	contenido, err := json.MarshalIndent(reporte, "", "  ")
+	if err != nil {
+		p.logger.Printf("Error al generar el reporte de purga: %v", err)
+		return
+	}
+	clave := "reportes/purga/" + reporte.Inicio.UTC().Format("20060102T150405Z")
+	if reporte.Simulada {
+		clave += "-simulada"
+	}
+	clave += ".json"
+	if _, err := p.storage.Save(ctx, clave, bytes.NewReader(contenido)); err != nil {
+		p.logger.Printf("Error al exportar el reporte de purga: %v", err)
+		return
+	}
+	p.logger.Printf("Reporte de purga exportado en %s", clave)
+}
@@ -0,0 +1,212 @@
+package documento
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kramirez/documentos/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// setupPurgador crea el purgador sobre un almacenamiento local con los archivos indicados
+func setupPurgador(t *testing.T, repo *mockRepository, config ConfigPurga, claves ...string) (*Purgador, string) {
+	t.Helper()
+	dir := t.TempDir()
+	store, err := storage.NewLocalStorage(dir)
+	require.NoError(t, err)
+	for _, clave := range claves {
+		_, err := store.Save(context.Background(), clave, bytes.NewReader([]byte(clave)))
+		require.NoError(t, err)
+	}
+	return NewPurgador(repo, store, log.New(io.Discard, "", 0), config), dir
+}
+
+func existeArchivo(dir, clave string) bool {
+	_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(clave)))
+	return err == nil
+}
+
+// reporteExportado lee el único reporte de purga guardado en el almacenamiento
+func reporteExportado(t *testing.T, dir string) (string, *ReportePurga) {
+	t.Helper()
+	archivos, err := os.ReadDir(filepath.Join(dir, "reportes", "purga"))
+	require.NoError(t, err)
+	require.Len(t, archivos, 1)
+	contenido, err := os.ReadFile(filepath.Join(dir, "reportes", "purga", archivos[0].Name()))
+	require.NoError(t, err)
+	var reporte ReportePurga
+	require.NoError(t, json.Unmarshal(contenido, &reporte))
+	return archivos[0].Name(), &reporte
+}
+
+func TestPurgador_Purgar(t *testing.T) {
+	ctx := context.Background()
+	retencion := 30 * 24 * time.Hour
+	eliminado := gorm.DeletedAt{Time: time.Now().Add(-40 * 24 * time.Hour), Valid: true}
+
+	t.Run("debe eliminar los archivos sin referencias y exportar el reporte", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		purgador, dir := setupPurgador(t, repo, ConfigPurga{Retencion: retencion},
+			"blobs/abc", "miniaturas/abc.jpg", "solicitudes/5/antiguo.pdf", "blobs/compartido")
+		repo.On("PurgarEliminados", ctx, mock.AnythingOfType("time.Time"), uint(0), documentosPorLote, false).Return(&ResultadoPurga{
+			Documentos:    []Documento{{ID: 1, SolicitudID: 5, NombreArchivo: "cv", Extension: "pdf", Checksum: "abc", DeletedAt: eliminado}, {ID: 2, SolicitudID: 5}},
+			Versiones:     3,
+			Blobs:         []Blob{{Checksum: "abc", ClaveAlmacenamiento: "blobs/abc", ClaveMiniatura: "miniaturas/abc.jpg", Tamano: 1024}},
+			ClavesSinBlob: []string{"solicitudes/5/antiguo.pdf"},
+		}, nil).Once()
+		repo.On("PurgarEliminados", ctx, mock.AnythingOfType("time.Time"), uint(2), documentosPorLote, false).Return(&ResultadoPurga{}, nil).Once()
+		repo.On("GetBlob", ctx, "abc").Return(nil, gorm.ErrRecordNotFound)
+
+		// Act
+		reporte, err := purgador.Purgar(ctx)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Len(t, reporte.Documentos, 2)
+		assert.Equal(t, "cv.pdf", reporte.Documentos[0].NombreArchivo)
+		assert.Equal(t, 3, reporte.Versiones)
+		assert.Equal(t, 2, reporte.Archivos)
+		assert.Equal(t, int64(1024), reporte.BytesLiberados)
+		assert.WithinDuration(t, time.Now().Add(-retencion), reporte.EliminadosAntesDe, time.Minute)
+		assert.False(t, existeArchivo(dir, "blobs/abc"))
+		assert.False(t, existeArchivo(dir, "miniaturas/abc.jpg"))
+		assert.False(t, existeArchivo(dir, "solicitudes/5/antiguo.pdf"))
+		assert.True(t, existeArchivo(dir, "blobs/compartido"), "un blob que no quedó sin referencias se conserva")
+
+		nombre, exportado := reporteExportado(t, dir)
+		assert.NotContains(t, nombre, "simulada")
+		assert.Len(t, exportado.Documentos, 2)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe conservar el archivo de un blob registrado de nuevo tras la purga", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		purgador, dir := setupPurgador(t, repo, ConfigPurga{Retencion: retencion}, "blobs/abc")
+		repo.On("PurgarEliminados", ctx, mock.Anything, uint(0), documentosPorLote, false).Return(&ResultadoPurga{
+			Documentos: []Documento{{ID: 1}},
+			Blobs:      []Blob{{Checksum: "abc", ClaveAlmacenamiento: "blobs/abc"}},
+		}, nil).Once()
+		repo.On("PurgarEliminados", ctx, mock.Anything, uint(1), documentosPorLote, false).Return(&ResultadoPurga{}, nil).Once()
+		repo.On("GetBlob", ctx, "abc").Return(&Blob{Checksum: "abc", Referencias: 1}, nil)
+
+		// Act
+		_, err := purgador.Purgar(ctx)
+
+		// Assert
+		require.NoError(t, err)
+		assert.True(t, existeArchivo(dir, "blobs/abc"))
+	})
+
+	t.Run("no debe eliminar archivos al simular", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		purgador, dir := setupPurgador(t, repo, ConfigPurga{Retencion: retencion, Simular: true}, "blobs/abc", "solicitudes/5/antiguo.pdf")
+		repo.On("PurgarEliminados", ctx, mock.Anything, uint(0), documentosPorLote, true).Return(&ResultadoPurga{
+			Documentos:    []Documento{{ID: 1}},
+			Blobs:         []Blob{{Checksum: "abc", ClaveAlmacenamiento: "blobs/abc", Tamano: 10}},
+			ClavesSinBlob: []string{"solicitudes/5/antiguo.pdf"},
+		}, nil).Once()
+		repo.On("PurgarEliminados", ctx, mock.Anything, uint(1), documentosPorLote, true).Return(&ResultadoPurga{}, nil).Once()
+
+		// Act
+		reporte, err := purgador.Purgar(ctx)
+
+		// Assert
+		require.NoError(t, err)
+		assert.True(t, reporte.Simulada)
+		assert.Equal(t, 2, reporte.Archivos)
+		assert.True(t, existeArchivo(dir, "blobs/abc"))
+		assert.True(t, existeArchivo(dir, "solicitudes/5/antiguo.pdf"))
+		nombre, _ := reporteExportado(t, dir)
+		assert.Contains(t, nombre, "-simulada.json")
+		repo.AssertNotCalled(t, "GetBlob", mock.Anything, mock.Anything)
+	})
+
+	t.Run("no debe exportar un reporte si no hay nada que purgar", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		purgador, dir := setupPurgador(t, repo, ConfigPurga{Retencion: retencion})
+		repo.On("PurgarEliminados", ctx, mock.Anything, uint(0), documentosPorLote, false).Return(&ResultadoPurga{}, nil)
+
+		// Act
+		reporte, err := purgador.Purgar(ctx)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Empty(t, reporte.Documentos)
+		_, err = os.Stat(filepath.Join(dir, "reportes"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("debe registrar el error en el reporte si falla un lote", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		purgador, dir := setupPurgador(t, repo, ConfigPurga{Retencion: retencion})
+		repo.On("PurgarEliminados", ctx, mock.Anything, uint(0), documentosPorLote, false).Return(nil, assert.AnError)
+
+		// Act
+		reporte, err := purgador.Purgar(ctx)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Equal(t, []string{assert.AnError.Error()}, reporte.Errores)
+		_, exportado := reporteExportado(t, dir)
+		assert.Equal(t, reporte.Errores, exportado.Errores)
+	})
+}
+
+func TestPurgador_Iniciar(t *testing.T) {
+	t.Run("no debe ejecutar la purga si la retención es cero", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		purgador, _ := setupPurgador(t, repo, ConfigPurga{})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// Act
+		purgador.Iniciar(ctx, time.Millisecond)
+		time.Sleep(20 * time.Millisecond)
+
+		// Assert
+		repo.AssertNotCalled(t, "PurgarEliminados", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe purgar periódicamente hasta que se cancele el contexto", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		purgador, _ := setupPurgador(t, repo, ConfigPurga{Retencion: time.Hour})
+		ejecutada := make(chan struct{}, 1)
+		repo.On("PurgarEliminados", mock.Anything, mock.Anything, uint(0), documentosPorLote, false).
+			Return(&ResultadoPurga{}, nil).
+			Run(func(mock.Arguments) {
+				select {
+				case ejecutada <- struct{}{}:
+				default:
+				}
+			})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// Act
+		purgador.Iniciar(ctx, time.Millisecond)
+
+		// Assert
+		select {
+		case <-ejecutada:
+		case <-time.After(time.Second):
+			t.Fatal("la purga no se ejecutó")
+		}
+	})
+}
@@ -2,21 +2,54 @@ package documento
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository interface {
 	Create(ctx context.Context, documento *Documento) error
 	GetAll(ctx context.Context, filters GetAllReq) ([]Documento, error)
 	GetByID(ctx context.Context, id uint) (*Documento, error)
-	Update(ctx context.Context, id uint, req UpdateReq) error
+	GetByCargaID(ctx context.Context, cargaID string) (*Documento, error)
 	Delete(ctx context.Context, id uint) error
 	DeleteBySolicitudID(ctx context.Context, solicitudID uint) error
+	GetVersiones(ctx context.Context, documentoID uint) ([]Version, error)
+	GetVersion(ctx context.Context, documentoID uint, numero int) (*Version, error)
+	ApplyVersion(ctx context.Context, documentoID uint, version *Version) error
+	GetBlob(ctx context.Context, checksum string) (*Blob, error)
+	BlobAccesible(ctx context.Context, checksum string, solicitudID uint, usuarioID *uint) (bool, error)
+	GetPendientesEscaneo(ctx context.Context) ([]Documento, error)
+	UpdateEstadoEscaneo(ctx context.Context, documento *Documento, estado, detalle string) error
+	Buscar(ctx context.Context, req BuscarReq) ([]Coincidencia, error)
+	ExisteTexto(ctx context.Context, checksum string) (bool, error)
+	GuardarTexto(ctx context.Context, texto *TextoBlob) error
+	GetPendientesIndexacion(ctx context.Context) ([]Documento, error)
+	UpdateMiniatura(ctx context.Context, checksum, clave string) error
+	UpdateCategoria(ctx context.Context, id uint, categoria string) error
+	UpdateVenceEl(ctx context.Context, id uint, venceEl *time.Time) error
+	GetPorVencer(ctx context.Context, desde *time.Time, hasta time.Time, solicitudID uint) ([]Documento, error)
+	GetPendientesAvisoVencimiento(ctx context.Context, hasta time.Time) ([]Documento, error)
+	MarcarAvisoVencimiento(ctx context.Context, ids []uint, fecha time.Time) error
+	ReemplazarEtiquetas(ctx context.Context, id uint, etiquetas []Etiqueta) error
+	ReemplazarMetadatos(ctx context.Context, id uint, metadatos []Metadato) error
+	ConsumirEnlace(ctx context.Context, enlace *EnlaceUsado) error
+	GetUsoSolicitud(ctx context.Context, solicitudID uint) (Uso, error)
+	GetUsoUsuario(ctx context.Context, usuarioID uint) (Uso, error)
+	GetEliminados(ctx context.Context, filters GetAllReq) ([]Documento, error)
+	GetEliminadoByID(ctx context.Context, id uint) (*Documento, error)
+	Restaurar(ctx context.Context, id uint) error
+	PurgarEliminados(ctx context.Context, eliminadosAntesDe time.Time, desdeID uint, limite int, simular bool) (*ResultadoPurga, error)
+	GetClavesAlmacenamiento(ctx context.Context) ([]string, error)
+	Transaction(ctx context.Context, fn func(repo Repository) error) error
 }
 
 type repository struct {
 	db *gorm.DB
+	// enTransaccion indica que el repositorio opera dentro de Transaction
+	enTransaccion bool
 }
 
 func NewRepository(db *gorm.DB) Repository {
@@ -24,7 +57,18 @@ func NewRepository(db *gorm.DB) Repository {
 }
 
 func (r *repository) Create(ctx context.Context, documento *Documento) error {
-	return r.db.WithContext(ctx).Create(documento).Error
+	// El documento y su primera versión se crean en la misma transacción
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		documento.Version = 1
+		if err := tx.Create(documento).Error; err != nil {
+			return err
+		}
+		version := versionDesdeDocumento(documento, "creación", documento.UsuarioID)
+		if err := tx.Create(version).Error; err != nil {
+			return err
+		}
+		return agregarReferencia(tx, version)
+	})
 }
 
 func (r *repository) GetAll(ctx context.Context, filters GetAllReq) ([]Documento, error) {
@@ -42,6 +86,12 @@ func (r *repository) GetAll(ctx context.Context, filters GetAllReq) ([]Documento
 	if filters.SolicitudID > 0 {
 		query = query.Where("solicitud_id = ?", filters.SolicitudID)
 	}
+	for _, etiqueta := range filters.Etiquetas {
+		query = query.Where("EXISTS (SELECT 1 FROM documento_etiquetas e WHERE e.documento_id = documentos.id AND e.nombre = ?)", etiqueta)
+	}
+	for clave, valor := range filters.Metadatos {
+		query = query.Where("EXISTS (SELECT 1 FROM documento_metadatos m WHERE m.documento_id = documentos.id AND m.clave = ? AND m.valor = ?)", clave, valor)
+	}
 
 	//Paginacion
 	if filters.Limit > 0 {
@@ -52,29 +102,34 @@ func (r *repository) GetAll(ctx context.Context, filters GetAllReq) ([]Documento
 		query = query.Offset(offset)
 	}
 
-	err := query.Find(&documentos).Error
+	err := conEtiquetado(query).Find(&documentos).Error
 	return documentos, err
 }
 
+// conEtiquetado carga las etiquetas y los metadatos de los documentos consultados
+func conEtiquetado(query *gorm.DB) *gorm.DB {
+	return query.Preload("Etiquetas", func(db *gorm.DB) *gorm.DB {
+		return db.Order("nombre")
+	}).Preload("Metadatos", func(db *gorm.DB) *gorm.DB {
+		return db.Order("clave")
+	})
+}
+
 func (r *repository) GetByID(ctx context.Context, id uint) (*Documento, error) {
 	var documento Documento
-	err := r.db.WithContext(ctx).First(&documento, id).Error
+	err := conEtiquetado(r.db.WithContext(ctx)).First(&documento, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &documento, nil
 }
 
-func (r *repository) Update(ctx context.Context, id uint, req UpdateReq) error {
-	updates := make(map[string]interface{})
-
-	if req.Extension != nil {
-		updates["extension"] = *req.Extension
-	}
-	if req.NombreArchivo != nil {
-		updates["nombre_archivo"] = *req.NombreArchivo
+func (r *repository) GetByCargaID(ctx context.Context, cargaID string) (*Documento, error) {
+	var documento Documento
+	if err := r.db.WithContext(ctx).Where("carga_id = ?", cargaID).First(&documento).Error; err != nil {
+		return nil, err
 	}
-	return r.db.WithContext(ctx).Model(&Documento{}).Where("id = ?", id).Updates(updates).Error
+	return &documento, nil
 }
 
 func (r *repository) Delete(ctx context.Context, id uint) error {
@@ -86,3 +141,494 @@ func (r *repository) DeleteBySolicitudID(ctx context.Context, solicitudID uint)
 	// Soft delete de todos los documentos asociados a una solicitud
 	return r.db.WithContext(ctx).Where("solicitud_id = ?", solicitudID).Delete(&Documento{}).Error
 }
+
+func (r *repository) GetVersiones(ctx context.Context, documentoID uint) ([]Version, error) {
+	var versiones []Version
+	err := r.db.WithContext(ctx).
+		Where("documento_id = ?", documentoID).
+		Order("numero DESC").
+		Find(&versiones).Error
+	return versiones, err
+}
+
+func (r *repository) GetVersion(ctx context.Context, documentoID uint, numero int) (*Version, error) {
+	var version Version
+	err := r.db.WithContext(ctx).
+		Where("documento_id = ? AND numero = ?", documentoID, numero).
+		First(&version).Error
+	if err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// ApplyVersion registra una nueva versión y actualiza el documento para que la refleje.
+// El número de versión se asigna aquí, bloqueando el documento para evitar números duplicados.
+func (r *repository) ApplyVersion(ctx context.Context, documentoID uint, version *Version) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var actual Documento
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&actual, documentoID).Error; err != nil {
+			return err
+		}
+
+		// Documentos creados antes del historial de versiones no tienen su estado inicial registrado
+		var existentes int64
+		if err := tx.Model(&Version{}).Where("documento_id = ?", documentoID).Count(&existentes).Error; err != nil {
+			return err
+		}
+		if existentes == 0 {
+			if err := tx.Create(versionDesdeDocumento(&actual, "versión inicial", nil)).Error; err != nil {
+				return err
+			}
+		}
+
+		version.ID = 0
+		version.DocumentoID = documentoID
+		version.Numero = actual.Version + 1
+		if err := tx.Create(version).Error; err != nil {
+			return err
+		}
+		if err := agregarReferencia(tx, version); err != nil {
+			return err
+		}
+
+		// El estado del análisis antivirus acompaña al contenido de la versión
+		estado, detalle := EstadoPendienteEscaneo, ""
+		if version.Checksum != "" {
+			var blob Blob
+			if err := tx.Where("checksum = ?", version.Checksum).First(&blob).Error; err != nil {
+				return err
+			}
+			estado, detalle = blob.EstadoEscaneo, blob.DetalleEscaneo
+		} else if version.ClaveAlmacenamiento == actual.ClaveAlmacenamiento {
+			estado, detalle = actual.EstadoEscaneo, actual.DetalleEscaneo
+		}
+
+		return tx.Model(&Documento{}).Where("id = ?", documentoID).Updates(map[string]interface{}{
+			"extension":            version.Extension,
+			"nombre_archivo":       version.NombreArchivo,
+			"tamano":               version.Tamano,
+			"tipo_mime":            version.TipoMime,
+			"clave_almacenamiento": version.ClaveAlmacenamiento,
+			"checksum":             version.Checksum,
+			"estado_escaneo":       estado,
+			"detalle_escaneo":      detalle,
+			"version":              version.Numero,
+		}).Error
+	})
+}
+
+func (r *repository) GetBlob(ctx context.Context, checksum string) (*Blob, error) {
+	var blob Blob
+	err := r.db.WithContext(ctx).Where("checksum = ?", checksum).First(&blob).Error
+	if err != nil {
+		return nil, err
+	}
+	return &blob, nil
+}
+
+// BlobAccesible indica si el contenido con el checksum pertenece a un documento vigente, en su versión
+// actual o en una anterior, de la solicitud indicada o subido por el usuario indicado
+func (r *repository) BlobAccesible(ctx context.Context, checksum string, solicitudID uint, usuarioID *uint) (bool, error) {
+	query := r.db.WithContext(ctx).Model(&Documento{}).
+		Where("checksum = ? OR id IN (SELECT documento_id FROM documento_versiones WHERE checksum = ?)", checksum, checksum)
+	if usuarioID != nil {
+		query = query.Where("solicitud_id = ? OR usuario_id = ?", solicitudID, *usuarioID)
+	} else {
+		query = query.Where("solicitud_id = ?", solicitudID)
+	}
+
+	var cantidad int64
+	if err := query.Count(&cantidad).Error; err != nil {
+		return false, err
+	}
+	return cantidad > 0, nil
+}
+
+// agregarReferencia suma una referencia al blob de la versión, creándolo si aún no existe
+func agregarReferencia(tx *gorm.DB, version *Version) error {
+	if version.Checksum == "" {
+		return nil
+	}
+	blob := &Blob{
+		Checksum:            version.Checksum,
+		ClaveAlmacenamiento: version.ClaveAlmacenamiento,
+		Tamano:              version.Tamano,
+		Referencias:         1,
+		EstadoEscaneo:       EstadoPendienteEscaneo,
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "checksum"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"referencias": gorm.Expr("referencias + 1")}),
+	}).Create(blob).Error
+}
+
+// GetPendientesEscaneo retorna los documentos con contenido que aún no han sido analizados
+func (r *repository) GetPendientesEscaneo(ctx context.Context) ([]Documento, error) {
+	var documentos []Documento
+	err := r.db.WithContext(ctx).
+		Where("clave_almacenamiento <> ''").
+		Where("estado_escaneo IS NULL OR estado_escaneo IN ?", []string{"", EstadoPendienteEscaneo}).
+		Find(&documentos).Error
+	return documentos, err
+}
+
+// UpdateEstadoEscaneo registra el resultado del análisis. Si el contenido es un blob compartido,
+// el resultado se aplica al blob y a todos los documentos cuya versión actual lo usa.
+func (r *repository) UpdateEstadoEscaneo(ctx context.Context, documento *Documento, estado, detalle string) error {
+	updates := map[string]interface{}{
+		"estado_escaneo":  estado,
+		"detalle_escaneo": detalle,
+	}
+
+	if documento.Checksum == "" {
+		return r.db.WithContext(ctx).Model(&Documento{}).Where("id = ?", documento.ID).Updates(updates).Error
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Blob{}).Where("checksum = ?", documento.Checksum).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.Model(&Documento{}).Where("checksum = ?", documento.Checksum).Updates(updates).Error
+	})
+}
+
+// Buscar usa el índice FULLTEXT de MySQL sobre el texto extraído, ordenando por relevancia.
+// Solo se consideran documentos cuyo contenido fue declarado limpio por el antivirus.
+func (r *repository) Buscar(ctx context.Context, req BuscarReq) ([]Coincidencia, error) {
+	const match = "MATCH(blob_textos.contenido) AGAINST (? IN NATURAL LANGUAGE MODE)"
+
+	var coincidencias []Coincidencia
+	query := r.db.WithContext(ctx).Model(&Documento{}).
+		Select("documentos.*, blob_textos.contenido AS texto").
+		Joins("JOIN blob_textos ON blob_textos.checksum = documentos.checksum").
+		Where("documentos.estado_escaneo = ?", EstadoLimpio).
+		Where(match, req.Q)
+	if req.SolicitudID > 0 {
+		query = query.Where("documentos.solicitud_id = ?", req.SolicitudID)
+	}
+
+	err := query.
+		Clauses(clause.OrderBy{Expression: clause.Expr{SQL: match + " DESC", Vars: []interface{}{req.Q}}}).
+		Limit(req.Limit).
+		Scan(&coincidencias).Error
+	return coincidencias, err
+}
+
+func (r *repository) ExisteTexto(ctx context.Context, checksum string) (bool, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Model(&TextoBlob{}).Where("checksum = ?", checksum).Count(&total).Error
+	return total > 0, err
+}
+
+func (r *repository) GuardarTexto(ctx context.Context, texto *TextoBlob) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(texto).Error
+}
+
+// GetPendientesIndexacion obtiene los documentos limpios cuyo contenido aún no tiene texto indexado
+func (r *repository) GetPendientesIndexacion(ctx context.Context) ([]Documento, error) {
+	var documentos []Documento
+	err := r.db.WithContext(ctx).
+		Where("estado_escaneo = ? AND checksum <> ''", EstadoLimpio).
+		Where("checksum NOT IN (?)", r.db.Model(&TextoBlob{}).Select("checksum")).
+		Find(&documentos).Error
+	return documentos, err
+}
+
+func (r *repository) UpdateMiniatura(ctx context.Context, checksum, clave string) error {
+	return r.db.WithContext(ctx).Model(&Blob{}).Where("checksum = ?", checksum).Update("clave_miniatura", clave).Error
+}
+
+func (r *repository) UpdateCategoria(ctx context.Context, id uint, categoria string) error {
+	return r.db.WithContext(ctx).Model(&Documento{}).Where("id = ?", id).Update("categoria", categoria).Error
+}
+
+// UpdateVenceEl cambia la fecha de vencimiento; el documento vuelve a quedar pendiente de aviso
+func (r *repository) UpdateVenceEl(ctx context.Context, id uint, venceEl *time.Time) error {
+	return r.db.WithContext(ctx).Model(&Documento{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"vence_el":             venceEl,
+		"aviso_vencimiento_el": nil,
+	}).Error
+}
+
+// GetPorVencer obtiene los documentos que vencen hasta la fecha indicada, ordenados por vencimiento.
+// Sin fecha desde también se incluyen los que ya vencieron.
+func (r *repository) GetPorVencer(ctx context.Context, desde *time.Time, hasta time.Time, solicitudID uint) ([]Documento, error) {
+	var documentos []Documento
+	query := r.db.WithContext(ctx).Where("vence_el IS NOT NULL AND vence_el <= ?", hasta)
+	if desde != nil {
+		query = query.Where("vence_el >= ?", *desde)
+	}
+	if solicitudID > 0 {
+		query = query.Where("solicitud_id = ?", solicitudID)
+	}
+	err := conEtiquetado(query).Order("vence_el, id").Find(&documentos).Error
+	return documentos, err
+}
+
+// GetPendientesAvisoVencimiento obtiene los documentos que vencen hasta la fecha indicada y aún no han sido avisados
+func (r *repository) GetPendientesAvisoVencimiento(ctx context.Context, hasta time.Time) ([]Documento, error) {
+	var documentos []Documento
+	err := r.db.WithContext(ctx).
+		Where("vence_el IS NOT NULL AND vence_el <= ? AND aviso_vencimiento_el IS NULL", hasta).
+		Order("solicitud_id, vence_el, id").
+		Find(&documentos).Error
+	return documentos, err
+}
+
+// MarcarAvisoVencimiento registra que se avisó el vencimiento de los documentos
+func (r *repository) MarcarAvisoVencimiento(ctx context.Context, ids []uint, fecha time.Time) error {
+	return r.db.WithContext(ctx).Model(&Documento{}).Where("id IN ?", ids).Update("aviso_vencimiento_el", fecha).Error
+}
+
+// ReemplazarEtiquetas deja al documento solo con las etiquetas indicadas
+func (r *repository) ReemplazarEtiquetas(ctx context.Context, id uint, etiquetas []Etiqueta) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("documento_id = ?", id).Delete(&Etiqueta{}).Error; err != nil {
+			return err
+		}
+		if len(etiquetas) == 0 {
+			return nil
+		}
+		for i := range etiquetas {
+			etiquetas[i].DocumentoID = id
+		}
+		return tx.Create(&etiquetas).Error
+	})
+}
+
+// ReemplazarMetadatos deja al documento solo con los metadatos indicados
+func (r *repository) ReemplazarMetadatos(ctx context.Context, id uint, metadatos []Metadato) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("documento_id = ?", id).Delete(&Metadato{}).Error; err != nil {
+			return err
+		}
+		if len(metadatos) == 0 {
+			return nil
+		}
+		for i := range metadatos {
+			metadatos[i].DocumentoID = id
+		}
+		return tx.Create(&metadatos).Error
+	})
+}
+
+// ConsumirEnlace registra el uso de un enlace de un solo uso. La clave primaria garantiza que,
+// aun con peticiones simultáneas, solo la primera lo consuma.
+func (r *repository) ConsumirEnlace(ctx context.Context, enlace *EnlaceUsado) error {
+	resultado := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(enlace)
+	if resultado.Error != nil {
+		return resultado.Error
+	}
+	if resultado.RowsAffected == 0 {
+		return ErrEnlaceUsado
+	}
+	return nil
+}
+
+func (r *repository) GetUsoSolicitud(ctx context.Context, solicitudID uint) (Uso, error) {
+	return r.uso(ctx, "solicitud_id = ?", solicitudID)
+}
+
+func (r *repository) GetUsoUsuario(ctx context.Context, usuarioID uint) (Uso, error) {
+	return r.uso(ctx, "usuario_id = ?", usuarioID)
+}
+
+// uso suma los documentos vigentes que cumplen la condición. Cada documento cuenta su tamaño
+// aunque comparta el archivo con otro, ya que la cuota es por lo que sube cada uno.
+func (r *repository) uso(ctx context.Context, condicion string, valor uint) (Uso, error) {
+	var uso Uso
+	query := r.db.WithContext(ctx).Model(&Documento{})
+	// Dentro de una transacción se bloquean los documentos contados hasta su fin, para que otra
+	// petición no agregue documentos entre la verificación de la cuota y la escritura
+	if r.enTransaccion {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	err := query.
+		Select("COUNT(*) AS archivos, COALESCE(SUM(tamano), 0) AS bytes").
+		Where(condicion, valor).
+		Scan(&uso).Error
+	return uso, err
+}
+
+// GetEliminados lista los documentos en soft delete, los eliminados más recientemente primero
+func (r *repository) GetEliminados(ctx context.Context, filters GetAllReq) ([]Documento, error) {
+	var documentos []Documento
+	query := r.db.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL")
+	if filters.SolicitudID > 0 {
+		query = query.Where("solicitud_id = ?", filters.SolicitudID)
+	}
+	if filters.Limit > 0 {
+		query = query.Limit(filters.Limit)
+	}
+	if filters.Page > 0 {
+		query = query.Offset((filters.Page - 1) * filters.Limit)
+	}
+	err := conEtiquetado(query).Order("deleted_at DESC").Find(&documentos).Error
+	return documentos, err
+}
+
+func (r *repository) GetEliminadoByID(ctx context.Context, id uint) (*Documento, error) {
+	var documento Documento
+	err := conEtiquetado(r.db.WithContext(ctx).Unscoped()).Where("deleted_at IS NOT NULL").First(&documento, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &documento, nil
+}
+
+// Restaurar quita la marca de eliminación del documento
+func (r *repository) Restaurar(ctx context.Context, id uint) error {
+	resultado := r.db.WithContext(ctx).Unscoped().Model(&Documento{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if resultado.Error != nil {
+		return resultado.Error
+	}
+	if resultado.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// errSimulacion revierte la transacción de una purga simulada
+var errSimulacion = errors.New("purga simulada")
+
+// PurgarEliminados elimina definitivamente hasta limite documentos en soft delete desde antes de
+// eliminadosAntesDe, con sus versiones y enlaces usados, y descuenta sus referencias a los blobs.
+// Los blobs que quedan sin referencias se eliminan junto con su texto indexado. Al simular, la
+// transacción se revierte y solo se retorna lo que se habría eliminado.
+func (r *repository) PurgarEliminados(ctx context.Context, eliminadosAntesDe time.Time, desdeID uint, limite int, simular bool) (*ResultadoPurga, error) {
+	resultado := &ResultadoPurga{}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ? AND id > ?", eliminadosAntesDe, desdeID).
+			Order("id").Limit(limite).
+			Find(&resultado.Documentos).Error; err != nil {
+			return err
+		}
+		if len(resultado.Documentos) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, 0, len(resultado.Documentos))
+		for _, doc := range resultado.Documentos {
+			ids = append(ids, doc.ID)
+		}
+		var versiones []Version
+		if err := tx.Where("documento_id IN ?", ids).Find(&versiones).Error; err != nil {
+			return err
+		}
+		resultado.Versiones = len(versiones)
+
+		// Cada versión con checksum es una referencia a su blob
+		referencias := make(map[string]int)
+		clavesSinBlob := make(map[string]bool)
+		for _, version := range versiones {
+			if version.Checksum != "" {
+				referencias[version.Checksum]++
+			} else if version.ClaveAlmacenamiento != "" {
+				clavesSinBlob[version.ClaveAlmacenamiento] = true
+			}
+		}
+		for _, doc := range resultado.Documentos {
+			if doc.Checksum == "" && doc.ClaveAlmacenamiento != "" {
+				clavesSinBlob[doc.ClaveAlmacenamiento] = true
+			}
+		}
+		for clave := range clavesSinBlob {
+			resultado.ClavesSinBlob = append(resultado.ClavesSinBlob, clave)
+		}
+
+		if len(referencias) > 0 {
+			checksums := make([]string, 0, len(referencias))
+			for checksum, cantidad := range referencias {
+				checksums = append(checksums, checksum)
+				if err := tx.Model(&Blob{}).Where("checksum = ?", checksum).
+					Update("referencias", gorm.Expr("referencias - ?", cantidad)).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Where("checksum IN ? AND referencias <= 0", checksums).Find(&resultado.Blobs).Error; err != nil {
+				return err
+			}
+		}
+		if len(resultado.Blobs) > 0 {
+			liberados := make([]string, 0, len(resultado.Blobs))
+			for _, blob := range resultado.Blobs {
+				liberados = append(liberados, blob.Checksum)
+			}
+			if err := tx.Where("checksum IN ?", liberados).Delete(&TextoBlob{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("checksum IN ?", liberados).Delete(&Blob{}).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("documento_id IN ?", ids).Delete(&EnlaceUsado{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("documento_id IN ?", ids).Delete(&Etiqueta{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("documento_id IN ?", ids).Delete(&Metadato{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("documento_id IN ?", ids).Delete(&Version{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("id IN ?", ids).Delete(&Documento{}).Error; err != nil {
+			return err
+		}
+
+		if simular {
+			return errSimulacion
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errSimulacion) {
+		return nil, err
+	}
+	return resultado, nil
+}
+
+// GetClavesAlmacenamiento retorna todas las claves del Storage que referencia la base de datos:
+// contenido y miniaturas de los blobs, y archivos de documentos y versiones anteriores a la
+// deduplicación, incluidos los que están en la papelera
+func (r *repository) GetClavesAlmacenamiento(ctx context.Context) ([]string, error) {
+	db := r.db.WithContext(ctx)
+	fuentes := []struct {
+		query   *gorm.DB
+		columna string
+	}{
+		{db.Model(&Blob{}), "clave_almacenamiento"},
+		{db.Model(&Blob{}), "clave_miniatura"},
+		{db.Unscoped().Model(&Documento{}), "clave_almacenamiento"},
+		{db.Model(&Version{}), "clave_almacenamiento"},
+	}
+
+	var claves []string
+	vistas := make(map[string]bool)
+	for _, fuente := range fuentes {
+		var resultado []string
+		if err := fuente.query.Where(fuente.columna+" <> ''").Distinct().Pluck(fuente.columna, &resultado).Error; err != nil {
+			return nil, err
+		}
+		for _, clave := range resultado {
+			if !vistas[clave] {
+				vistas[clave] = true
+				claves = append(claves, clave)
+			}
+		}
+	}
+	return claves, nil
+}
+
+// Transaction ejecuta fn con un repositorio cuyas operaciones forman parte de una misma transacción,
+// que se confirma si fn no retorna error
+func (r *repository) Transaction(ctx context.Context, fn func(repo Repository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&repository{db: tx, enTransaccion: true})
+	})
+}
@@ -0,0 +1,687 @@
+package documento
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+
+	return gormDB, mock
+}
+
+// esperarPurga registra las consultas de un lote de purga de los documentos 1 y 2: el documento 1
+// tiene dos versiones con el blob "abc" y el 2 un archivo guardado antes de la deduplicación
+func esperarPurga(mock sqlmock.Sqlmock, blobsLiberados *sqlmock.Rows) {
+	eliminado := time.Now().Add(-48 * time.Hour)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `documentos` WHERE deleted_at IS NOT NULL AND deleted_at < \\? AND id > \\? ORDER BY id LIMIT \\? FOR UPDATE").
+		WithArgs(sqlmock.AnyArg(), 0, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "solicitud_id", "checksum", "clave_almacenamiento", "deleted_at"}).
+			AddRow(1, 5, "abc", "blobs/abc", eliminado).
+			AddRow(2, 5, "", "solicitudes/5/antiguo.pdf", eliminado))
+	mock.ExpectQuery("SELECT \\* FROM `documento_versiones` WHERE documento_id IN \\(\\?,\\?\\)").
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "documento_id", "numero", "checksum", "clave_almacenamiento"}).
+			AddRow(10, 1, 1, "abc", "blobs/abc").
+			AddRow(11, 1, 2, "abc", "blobs/abc").
+			AddRow(12, 2, 1, "", "solicitudes/5/antiguo.pdf"))
+	mock.ExpectExec("UPDATE `blobs` SET `referencias`=referencias - \\?,`updated_at`=\\? WHERE checksum = \\?").
+		WithArgs(2, sqlmock.AnyArg(), "abc").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT \\* FROM `blobs` WHERE checksum IN \\(\\?\\) AND referencias <= 0").
+		WithArgs("abc").
+		WillReturnRows(blobsLiberados)
+}
+
+// esperarEliminacionDocumentos registra el borrado de los datos de los documentos 1 y 2
+func esperarEliminacionDocumentos(mock sqlmock.Sqlmock) {
+	for _, tabla := range []string{"enlaces_usados", "documento_etiquetas", "documento_metadatos", "documento_versiones", "documentos"} {
+		mock.ExpectExec("DELETE FROM `"+tabla+"` WHERE .*IN \\(\\?,\\?\\)").
+			WithArgs(1, 2).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+	}
+}
+
+func TestRepository_PurgarEliminados(t *testing.T) {
+	ctx := context.Background()
+	antesDe := time.Now().Add(-24 * time.Hour)
+
+	t.Run("debe conservar el blob que aún referencia otro documento", func(t *testing.T) {
+		// Arrange
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+		esperarPurga(mock, sqlmock.NewRows([]string{"checksum", "referencias"}))
+		esperarEliminacionDocumentos(mock)
+		mock.ExpectCommit()
+
+		// Act
+		resultado, err := repo.PurgarEliminados(ctx, antesDe, 0, 100, false)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Len(t, resultado.Documentos, 2)
+		assert.Equal(t, 3, resultado.Versiones)
+		assert.Empty(t, resultado.Blobs)
+		assert.Equal(t, []string{"solicitudes/5/antiguo.pdf"}, resultado.ClavesSinBlob)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("debe eliminar el blob y su texto cuando queda sin referencias", func(t *testing.T) {
+		// Arrange
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+		esperarPurga(mock, sqlmock.NewRows([]string{"checksum", "clave_almacenamiento", "tamano", "referencias"}).
+			AddRow("abc", "blobs/abc", 1024, 0))
+		mock.ExpectExec("DELETE FROM `blob_textos` WHERE checksum IN \\(\\?\\)").
+			WithArgs("abc").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM `blobs` WHERE checksum IN \\(\\?\\)").
+			WithArgs("abc").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		esperarEliminacionDocumentos(mock)
+		mock.ExpectCommit()
+
+		// Act
+		resultado, err := repo.PurgarEliminados(ctx, antesDe, 0, 100, false)
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, resultado.Blobs, 1)
+		assert.Equal(t, "blobs/abc", resultado.Blobs[0].ClaveAlmacenamiento)
+		assert.Equal(t, int64(1024), resultado.Blobs[0].Tamano)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("debe revertir la transacción al simular", func(t *testing.T) {
+		// Arrange
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+		esperarPurga(mock, sqlmock.NewRows([]string{"checksum", "clave_almacenamiento", "tamano", "referencias"}).
+			AddRow("abc", "blobs/abc", 1024, 0))
+		mock.ExpectExec("DELETE FROM `blob_textos`").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM `blobs`").WillReturnResult(sqlmock.NewResult(0, 1))
+		esperarEliminacionDocumentos(mock)
+		mock.ExpectRollback()
+
+		// Act
+		resultado, err := repo.PurgarEliminados(ctx, antesDe, 0, 100, true)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Len(t, resultado.Documentos, 2)
+		assert.Len(t, resultado.Blobs, 1)
+		assert.NoError(t, mock.ExpectationsWereMet(), "la simulación no debe confirmar la transacción")
+	})
+
+	t.Run("no debe modificar nada si no hay documentos por purgar", func(t *testing.T) {
+		// Arrange
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT \\* FROM `documentos` WHERE deleted_at IS NOT NULL AND deleted_at < \\? AND id > \\?").
+			WithArgs(sqlmock.AnyArg(), 7, 100).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectCommit()
+
+		// Act
+		resultado, err := repo.PurgarEliminados(ctx, antesDe, 7, 100, false)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Empty(t, resultado.Documentos)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("debe revertir y retornar el error si falla una eliminación", func(t *testing.T) {
+		// Arrange
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+		esperarPurga(mock, sqlmock.NewRows([]string{"checksum"}))
+		mock.ExpectExec("DELETE FROM `enlaces_usados`").WillReturnError(assert.AnError)
+		mock.ExpectRollback()
+
+		// Act
+		resultado, err := repo.PurgarEliminados(ctx, antesDe, 0, 100, false)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, resultado)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRepository_Create(t *testing.T) {
+	t.Run("debe crear el documento con su primera versión y sumar una referencia al blob", func(t *testing.T) {
+		// Arrange
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `documentos`").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO `documento_versiones`").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO `blobs` .* ON DUPLICATE KEY UPDATE `referencias`=referencias \\+ 1").
+			WithArgs("abc", "blobs/abc", int64(10), 1, EstadoPendienteEscaneo, "", "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		documento := &Documento{SolicitudID: 1, NombreArchivo: "cv", Extension: "pdf", Checksum: "abc", ClaveAlmacenamiento: "blobs/abc", Tamano: 10}
+
+		// Act
+		err := repo.Create(context.Background(), documento)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), documento.ID)
+		assert.Equal(t, 1, documento.Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("no debe registrar un blob si el documento no tiene contenido", func(t *testing.T) {
+		// Arrange
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `documentos`").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO `documento_versiones`").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		// Act
+		err := repo.Create(context.Background(), &Documento{SolicitudID: 1, NombreArchivo: "nota", Extension: "txt"})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("debe revertir el documento si falla la referencia al blob", func(t *testing.T) {
+		// Arrange
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `documentos`").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO `documento_versiones`").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO `blobs`").WillReturnError(assert.AnError)
+		mock.ExpectRollback()
+
+		// Act
+		err := repo.Create(context.Background(), &Documento{SolicitudID: 1, Checksum: "abc", ClaveAlmacenamiento: "blobs/abc"})
+
+		// Assert
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRepository_ApplyVersion(t *testing.T) {
+	t.Run("debe sumar una referencia al blob de la nueva versión y copiar su estado de escaneo", func(t *testing.T) {
+		// Arrange
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT \\* FROM `documentos` WHERE `documentos`.`id` = \\? AND `documentos`.`deleted_at` IS NULL ORDER BY `documentos`.`id` LIMIT \\? FOR UPDATE").
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "version", "checksum"}).AddRow(1, 1, "abc"))
+		mock.ExpectQuery("SELECT count\\(\\*\\) FROM `documento_versiones` WHERE documento_id = \\?").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectExec("INSERT INTO `documento_versiones`").WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectExec("INSERT INTO `blobs` .* ON DUPLICATE KEY UPDATE `referencias`=referencias \\+ 1").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectQuery("SELECT \\* FROM `blobs` WHERE checksum = \\?").
+			WithArgs("def", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"checksum", "estado_escaneo"}).AddRow("def", EstadoLimpio))
+		mock.ExpectExec("UPDATE `documentos` SET .*`checksum`=\\?.*`estado_escaneo`=\\?.*`version`=\\?").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		version := &Version{Checksum: "def", ClaveAlmacenamiento: "blobs/def", Tamano: 20}
+
+		// Act
+		err := repo.ApplyVersion(context.Background(), 1, version)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 2, version.Numero)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("debe registrar la versión inicial de un documento sin historial", func(t *testing.T) {
+		// Arrange
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT \\* FROM `documentos` WHERE `documentos`.`id` = \\? AND `documentos`.`deleted_at` IS NULL ORDER BY `documentos`.`id` LIMIT \\? FOR UPDATE").
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "version", "nombre_archivo", "clave_almacenamiento", "estado_escaneo"}).
+				AddRow(1, 1, "cv", "solicitudes/1/cv.pdf", EstadoLimpio))
+		mock.ExpectQuery("SELECT count\\(\\*\\) FROM `documento_versiones` WHERE documento_id = \\?").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectExec("INSERT INTO `documento_versiones`").
+			WithArgs(uint(1), 1, "", "cv", int64(0), "", "versión inicial", nil, sqlmock.AnyArg(), "solicitudes/1/cv.pdf", "").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO `documento_versiones`").
+			WithArgs(uint(1), 2, "", "cv renombrado", int64(0), "", "nombre_archivo", nil, sqlmock.AnyArg(), "solicitudes/1/cv.pdf", "").
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		// El contenido no cambia, por lo que conserva el resultado del análisis
+		mock.ExpectExec("UPDATE `documentos` SET .*`estado_escaneo`=\\?.*`version`=\\?").
+			WithArgs("", "solicitudes/1/cv.pdf", "", EstadoLimpio, "", "cv renombrado", int64(0), "", 2, sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		version := &Version{NombreArchivo: "cv renombrado", ClaveAlmacenamiento: "solicitudes/1/cv.pdf", Cambios: "nombre_archivo"}
+
+		// Act
+		err := repo.ApplyVersion(context.Background(), 1, version)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 2, version.Numero)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("debe revertir la transacción si falla el registro de la versión", func(t *testing.T) {
+		// Arrange
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT \\* FROM `documentos`").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "version"}).AddRow(1, 2))
+		mock.ExpectQuery("SELECT count\\(\\*\\) FROM `documento_versiones`").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		mock.ExpectExec("INSERT INTO `documento_versiones`").WillReturnError(assert.AnError)
+		mock.ExpectRollback()
+
+		// Act
+		err := repo.ApplyVersion(context.Background(), 1, &Version{NombreArchivo: "cv"})
+
+		// Assert
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRepository_GetByCargaID(t *testing.T) {
+	// Arrange
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+	idCarga := "0123456789abcdef0123456789abcdef"
+	mock.ExpectQuery("SELECT \\* FROM `documentos` WHERE carga_id = \\? AND `documentos`.`deleted_at` IS NULL ORDER BY `documentos`.`id` LIMIT \\?").
+		WithArgs(idCarga, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "carga_id"}).AddRow(42, idCarga))
+
+	// Act
+	documento, err := repo.GetByCargaID(context.Background(), idCarga)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, uint(42), documento.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_GetVersiones(t *testing.T) {
+	// Arrange
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+	mock.ExpectQuery("SELECT \\* FROM `documento_versiones` WHERE documento_id = \\? ORDER BY numero DESC").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "documento_id", "numero"}).AddRow(2, 1, 2).AddRow(1, 1, 1))
+
+	// Act
+	versiones, err := repo.GetVersiones(context.Background(), 1)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, versiones, 2)
+	assert.Equal(t, 2, versiones[0].Numero)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Delete(t *testing.T) {
+	t.Run("debe marcar el documento como eliminado sin descontar referencias hasta la purga", func(t *testing.T) {
+		// Arrange
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE `documentos` SET `deleted_at`=\\? WHERE `documentos`.`id` = \\? AND `documentos`.`deleted_at` IS NULL").
+			WithArgs(sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		// Act
+		err := repo.Delete(context.Background(), 1)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRepository_BlobAccesible(t *testing.T) {
+	consulta := "SELECT count\\(\\*\\) FROM `documentos` WHERE \\(checksum = \\? OR id IN \\(SELECT documento_id FROM documento_versiones WHERE checksum = \\?\\)\\) "
+
+	t.Run("debe buscar en los documentos de la solicitud o del usuario", func(t *testing.T) {
+		// Arrange
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+		usuario := uint(3)
+		mock.ExpectQuery(consulta+"AND \\(solicitud_id = \\? OR usuario_id = \\?\\) AND `documentos`.`deleted_at` IS NULL").
+			WithArgs("abc", "abc", 1, 3).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		// Act
+		accesible, err := repo.BlobAccesible(context.Background(), "abc", 1, &usuario)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.True(t, accesible)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("debe buscar solo en la solicitud si no hay usuario", func(t *testing.T) {
+		// Arrange
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+		mock.ExpectQuery(consulta+"AND solicitud_id = \\? AND `documentos`.`deleted_at` IS NULL").
+			WithArgs("abc", "abc", 2).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		// Act
+		accesible, err := repo.BlobAccesible(context.Background(), "abc", 2, nil)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.False(t, accesible)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRepository_Buscar(t *testing.T) {
+	// Arrange
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+	mock.ExpectQuery("SELECT documentos.\\*, blob_textos.contenido AS texto FROM `documentos` JOIN blob_textos ON blob_textos.checksum = documentos.checksum "+
+		"WHERE documentos.estado_escaneo = \\? AND MATCH\\(blob_textos.contenido\\) AGAINST \\(\\? IN NATURAL LANGUAGE MODE\\) AND documentos.solicitud_id = \\? "+
+		"AND `documentos`.`deleted_at` IS NULL ORDER BY MATCH\\(blob_textos.contenido\\) AGAINST \\(\\? IN NATURAL LANGUAGE MODE\\) DESC LIMIT \\?").
+		WithArgs(EstadoLimpio, "golang", 1, "golang", 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "solicitud_id", "texto"}).AddRow(1, 1, "experiencia en golang"))
+
+	// Act
+	coincidencias, err := repo.Buscar(context.Background(), BuscarReq{Q: "golang", SolicitudID: 1, Limit: 20})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, coincidencias, 1)
+	assert.Equal(t, "experiencia en golang", coincidencias[0].Texto)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_ConsumirEnlace(t *testing.T) {
+	ctx := context.Background()
+	enlace := func() *EnlaceUsado {
+		return &EnlaceUsado{Nonce: "0123456789abcdef0123456789abcdef", DocumentoID: 1, ExpiraEl: time.Now().Add(time.Hour)}
+	}
+
+	t.Run("debe registrar el primer uso del enlace", func(t *testing.T) {
+		// Arrange
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `enlaces_usados`").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		// Act
+		err := repo.ConsumirEnlace(ctx, enlace())
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("debe fallar si el enlace ya fue usado", func(t *testing.T) {
+		// Arrange
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `enlaces_usados`").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		// Act
+		err := repo.ConsumirEnlace(ctx, enlace())
+
+		// Assert
+		assert.ErrorIs(t, err, ErrEnlaceUsado)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRepository_GetUsoSolicitud(t *testing.T) {
+	t.Run("debe sumar los documentos vigentes de la solicitud", func(t *testing.T) {
+		// Arrange
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+		ctx := context.Background()
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) AS archivos, COALESCE\\(SUM\\(tamano\\), 0\\) AS bytes FROM `documentos` WHERE solicitud_id = \\? AND `documentos`.`deleted_at` IS NULL").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"archivos", "bytes"}).AddRow(3, 4096))
+
+		// Act
+		uso, err := repo.GetUsoSolicitud(ctx, 1)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, Uso{Archivos: 3, Bytes: 4096}, uso)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRepository_Transaction(t *testing.T) {
+	t.Run("debe bloquear los documentos contados para la cuota hasta el final de la transacción", func(t *testing.T) {
+		// Arrange
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+		ctx := context.Background()
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) AS archivos, COALESCE\\(SUM\\(tamano\\), 0\\) AS bytes FROM `documentos` WHERE solicitud_id = \\? AND `documentos`.`deleted_at` IS NULL FOR UPDATE").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"archivos", "bytes"}).AddRow(3, 4096))
+		mock.ExpectCommit()
+
+		// Act
+		err := repo.Transaction(ctx, func(tx Repository) error {
+			_, err := tx.GetUsoSolicitud(ctx, 1)
+			return err
+		})
+
+		// Assert
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("debe revertir la transacción si la función falla", func(t *testing.T) {
+		// Arrange
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		// Act
+		err := repo.Transaction(context.Background(), func(Repository) error {
+			return assert.AnError
+		})
+
+		// Assert
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRepository_Restaurar(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("debe quitar la marca de eliminación", func(t *testing.T) {
+		// Arrange
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE `documentos` SET `deleted_at`=\\?,`updated_at`=\\? WHERE id = \\? AND deleted_at IS NOT NULL").
+			WithArgs(nil, sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		// Act
+		err := repo.Restaurar(ctx, 1)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("debe fallar si el documento no está eliminado", func(t *testing.T) {
+		// Arrange
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE `documentos`").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		// Act
+		err := repo.Restaurar(ctx, 1)
+
+		// Assert
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+func TestRepository_GetAll_Etiquetado(t *testing.T) {
+	t.Run("debe exigir cada etiqueta y metadato indicado", func(t *testing.T) {
+		// Arrange
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+		ctx := context.Background()
+		mock.ExpectQuery("SELECT \\* FROM `documentos` WHERE "+
+			"\\(EXISTS \\(SELECT 1 FROM documento_etiquetas e WHERE e.documento_id = documentos.id AND e.nombre = \\?\\)\\) AND "+
+			"\\(EXISTS \\(SELECT 1 FROM documento_etiquetas e WHERE e.documento_id = documentos.id AND e.nombre = \\?\\)\\) AND "+
+			"\\(EXISTS \\(SELECT 1 FROM documento_metadatos m WHERE m.documento_id = documentos.id AND m.clave = \\? AND m.valor = \\?\\)\\) AND "+
+			"`documentos`.`deleted_at` IS NULL").
+			WithArgs("urgente", "confidencial", "autor", "Karla").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery("SELECT \\* FROM `documento_etiquetas` WHERE `documento_etiquetas`.`documento_id` = \\? ORDER BY nombre").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"documento_id", "nombre"}).AddRow(1, "confidencial").AddRow(1, "urgente"))
+		mock.ExpectQuery("SELECT \\* FROM `documento_metadatos` WHERE `documento_metadatos`.`documento_id` = \\? ORDER BY clave").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"documento_id", "clave", "tipo", "valor"}).AddRow(1, "autor", TipoTexto, "Karla"))
+
+		// Act
+		documentos, err := repo.GetAll(ctx, GetAllReq{
+			Etiquetas: []string{"urgente", "confidencial"},
+			Metadatos: map[string]string{"autor": "Karla"},
+		})
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, documentos, 1)
+		assert.Equal(t, []Etiqueta{{DocumentoID: 1, Nombre: "confidencial"}, {DocumentoID: 1, Nombre: "urgente"}}, documentos[0].Etiquetas)
+		assert.Equal(t, []Metadato{{DocumentoID: 1, Clave: "autor", Tipo: TipoTexto, Valor: "Karla"}}, documentos[0].Metadatos)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRepository_ReemplazarEtiquetas(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("debe borrar las etiquetas anteriores e insertar las nuevas", func(t *testing.T) {
+		// Arrange
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM `documento_etiquetas` WHERE documento_id = \\?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec("INSERT INTO `documento_etiquetas` \\(`documento_id`,`nombre`\\) VALUES \\(\\?,\\?\\),\\(\\?,\\?\\)").
+			WithArgs(1, "confidencial", 1, "urgente").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		// Act
+		err := repo.ReemplazarEtiquetas(ctx, 1, []Etiqueta{{Nombre: "confidencial"}, {Nombre: "urgente"}})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("debe dejar el documento sin etiquetas con una lista vacía", func(t *testing.T) {
+		// Arrange
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM `documento_etiquetas` WHERE documento_id = \\?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		// Act
+		err := repo.ReemplazarEtiquetas(ctx, 1, []Etiqueta{})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRepository_GetPendientesAvisoVencimiento(t *testing.T) {
+	t.Run("debe obtener los documentos por vencer aún no avisados", func(t *testing.T) {
+		// Arrange
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+		ctx := context.Background()
+		hasta := time.Date(2026, 3, 8, 0, 0, 0, 0, time.Local)
+		mock.ExpectQuery("SELECT \\* FROM `documentos` WHERE \\(vence_el IS NOT NULL AND vence_el <= \\? AND aviso_vencimiento_el IS NULL\\) AND `documentos`.`deleted_at` IS NULL ORDER BY solicitud_id, vence_el, id").
+			WithArgs(hasta).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "solicitud_id", "vence_el"}).AddRow(1, 1, hasta))
+
+		// Act
+		documentos, err := repo.GetPendientesAvisoVencimiento(ctx, hasta)
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, documentos, 1)
+		assert.True(t, hasta.Equal(*documentos[0].VenceEl))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRepository_UpdateVenceEl(t *testing.T) {
+	t.Run("debe reiniciar el aviso al cambiar el vencimiento", func(t *testing.T) {
+		// Arrange
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+		ctx := context.Background()
+		venceEl := time.Date(2026, 12, 31, 0, 0, 0, 0, time.Local)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE `documentos` SET `aviso_vencimiento_el`=\\?,`vence_el`=\\?,`updated_at`=\\? WHERE id = \\? AND `documentos`.`deleted_at` IS NULL").
+			WithArgs(nil, venceEl, sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		// Act
+		err := repo.UpdateVenceEl(ctx, 1, &venceEl)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -1,33 +1,177 @@
 package documento
 
 import (
+	"archive/zip"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/kramirez/documentos/pkg/firma"
 	"github.com/kramirez/documentos/pkg/httpclient"
+	"github.com/kramirez/documentos/pkg/miniatura"
+	"github.com/kramirez/documentos/pkg/storage"
+	"gorm.io/gorm"
 )
 
 type Service interface {
 	Create(ctx context.Context, req CreateReq) (*DocumentoResponse, error)
 	GetAll(ctx context.Context, filter GetAllReq) ([]DocumentoResponse, error)
 	GetByID(ctx context.Context, id uint) (*DocumentoResponse, error)
+	GetByCargaID(ctx context.Context, cargaID string) (*Documento, error)
+	GetContenido(ctx context.Context, id uint) (*Contenido, error)
+	GetMiniatura(ctx context.Context, id uint) (*Contenido, error)
 	Update(ctx context.Context, id uint, req UpdateReq) error
 	Delete(ctx context.Context, id uint) error
 	DeleteBySolicitudID(ctx context.Context, solicitudID uint) error
+	ReemplazarContenido(ctx context.Context, id uint, req ContenidoReq) (*DocumentoResponse, error)
+	GetVersiones(ctx context.Context, id uint) ([]Version, error)
+	GetVersion(ctx context.Context, id uint, numero int) (*Version, error)
+	GetVersionContenido(ctx context.Context, id uint, numero int) (*Contenido, error)
+	RestaurarVersion(ctx context.Context, id uint, numero int, usuarioID *uint) (*DocumentoResponse, error)
+	GetBlob(ctx context.Context, checksum string, solicitudID uint, usuarioID *uint) (*Blob, error)
+	Buscar(ctx context.Context, req BuscarReq) ([]ResultadoBusqueda, error)
+	CrearEnlace(ctx context.Context, id uint, req EnlaceReq) (*EnlaceResponse, error)
+	GetContenidoEnlace(ctx context.Context, enlace EnlaceFirmado) (*Contenido, error)
+	GetDocumentosZip(ctx context.Context, solicitudID uint) ([]Documento, error)
+	EscribirZip(ctx context.Context, documentos []Documento, w io.Writer) error
+	GetPapelera(ctx context.Context, filter GetAllReq) ([]DocumentoEliminado, error)
+	Restaurar(ctx context.Context, id uint) (*DocumentoResponse, error)
+	GetUso(ctx context.Context, req UsoReq) (*UsoResponse, error)
+	VerificarCuota(ctx context.Context, solicitudID uint, usuarioID *uint, tamano int64) error
+	GetCategorias(ctx context.Context) []CategoriaResponse
+	GetPorVencer(ctx context.Context, req PorVencerReq) ([]DocumentoPorVencer, error)
 }
 
+var (
+	// ErrSinContenido indica que el documento no tiene un archivo asociado
+	ErrSinContenido = errors.New("el documento no tiene contenido asociado")
+	// ErrSinMiniatura indica que no se puede generar una miniatura para el contenido del documento
+	ErrSinMiniatura = errors.New("el documento no tiene miniatura")
+	// ErrSolicitudEliminada indica que no se puede restaurar un documento cuya solicitud ya no existe
+	ErrSolicitudEliminada = errors.New("la solicitud del documento ya no existe")
+	// ErrSolicitudSinDocumentos indica que la solicitud no tiene documentos para descargar
+	ErrSolicitudSinDocumentos = errors.New("la solicitud no tiene documentos")
+)
+
+var (
+	// ErrEscaneoPendiente indica que el contenido aún no ha sido analizado por el antivirus
+	ErrEscaneoPendiente = errors.New("el documento aún no ha sido analizado por el antivirus")
+	// ErrEnCuarentena indica que el antivirus detectó una amenaza en el contenido
+	ErrEnCuarentena = errors.New("el documento está en cuarentena por contener una amenaza")
+)
+
+var (
+	// ErrEnlaceInvalido indica que la firma del enlace no corresponde a sus parámetros
+	ErrEnlaceInvalido = errors.New("el enlace no es válido")
+	// ErrEnlaceExpirado indica que el enlace superó su vigencia
+	ErrEnlaceExpirado = errors.New("el enlace ha expirado")
+	// ErrEnlaceUsado indica que el enlace de un solo uso ya fue utilizado
+	ErrEnlaceUsado = errors.New("el enlace ya fue utilizado")
+)
+
+const (
+	vigenciaEnlacePorDefecto = 24 * time.Hour
+	vigenciaEnlaceMaxima     = 7 * 24 * time.Hour
+)
+
 type service struct {
 	repo            Repository
 	logger          *log.Logger
 	solicitudClient *httpclient.SolicitudClient
+	storage         storage.Storage
+	escaneos        ColaEscaneo
+	firmante        *firma.Firmante
+	config          Config
+}
+
+// Config agrupa la configuración de negocio del servicio de documentos
+type Config struct {
+	Politica PoliticaArchivos
+	// URLPublica es la URL base con la que los clientes acceden al servicio, por ejemplo http://localhost:8083
+	URLPublica string
+	// ClaveEnlaces es la clave secreta con la que se firman los enlaces de descarga
+	ClaveEnlaces []byte
+	Cuotas       Cuotas
 }
 
-func NewService(repo Repository, logger *log.Logger, solicitudClient *httpclient.SolicitudClient) Service {
+func NewService(repo Repository, logger *log.Logger, solicitudClient *httpclient.SolicitudClient, store storage.Storage, escaneos ColaEscaneo, config Config) Service {
 	return &service{
 		repo:            repo,
 		logger:          logger,
 		solicitudClient: solicitudClient,
+		storage:         store,
+		escaneos:        escaneos,
+		firmante:        firma.NewFirmante(config.ClaveEnlaces),
+		config:          config,
+	}
+}
+
+// verificarEscaneo impide servir contenido que no ha sido declarado limpio por el antivirus
+func verificarEscaneo(estado string) error {
+	switch estado {
+	case EstadoLimpio:
+		return nil
+	case EstadoEnCuarentena:
+		return ErrEnCuarentena
+	default:
+		return ErrEscaneoPendiente
+	}
+}
+
+// detectarTipo identifica el tipo MIME por contenido y lo valida contra la política de archivos
+func (s *service) detectarTipo(r io.Reader, extension, categoria string) (string, error) {
+	detectado, err := mimetype.DetectReader(r)
+	if err != nil {
+		return "", fmt.Errorf("error al analizar el archivo: %v", err)
+	}
+	if err := s.config.Politica.Validar(extension, categoria, detectado); err != nil {
+		s.logger.Printf("Archivo rechazado (extensión %s, tipo detectado %s): %v", extension, detectado.String(), err)
+		return "", err
+	}
+	return detectado.String(), nil
+}
+
+// ErrBlobNoEncontrado indica que no existe un archivo con el checksum indicado
+var ErrBlobNoEncontrado = errors.New("no existe un archivo con el checksum indicado")
+
+// guardarBlob guarda el archivo en el Storage bajo su clave direccionada por contenido.
+// Si el servidor ya tiene un archivo con el mismo SHA-256 se reutiliza y no se vuelve a escribir.
+// nuevo indica si el archivo fue escrito en esta llamada.
+func (s *service) guardarBlob(ctx context.Context, archivo *storage.SpooledFile) (blob *Blob, nuevo bool, err error) {
+	existente, err := s.repo.GetBlob(ctx, archivo.Checksum)
+	if err == nil {
+		s.logger.Printf("Archivo con checksum %s ya existe, se reutiliza", archivo.Checksum)
+		return existente, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+
+	clave := storage.ContentKey(archivo.Checksum)
+	if _, err := s.storage.Save(ctx, clave, archivo); err != nil {
+		return nil, false, err
+	}
+	return &Blob{Checksum: archivo.Checksum, ClaveAlmacenamiento: clave, Tamano: archivo.Size}, true, nil
+}
+
+// descartarBlob elimina un archivo recién escrito cuando no se pudo registrar el documento.
+// Solo se elimina si ningún otro documento alcanzó a referenciarlo.
+func (s *service) descartarBlob(ctx context.Context, blob *Blob) {
+	ctx = context.WithoutCancel(ctx)
+	if _, err := s.repo.GetBlob(ctx, blob.Checksum); !errors.Is(err, gorm.ErrRecordNotFound) {
+		return
+	}
+	if err := s.storage.Delete(ctx, blob.ClaveAlmacenamiento); err != nil {
+		s.logger.Printf("Advertencia: No se pudo eliminar el archivo %s: %v", blob.ClaveAlmacenamiento, err)
 	}
 }
 
@@ -39,17 +183,122 @@ func (s *service) Create(ctx context.Context, req CreateReq) (*DocumentoResponse
 		return nil, fmt.Errorf("error al validar solicitud: %v", err)
 	}
 
+	etiquetas, err := normalizarEtiquetas(req.Etiquetas)
+	if err != nil {
+		return nil, err
+	}
+	metadatos, err := normalizarMetadatos(req.Metadatos)
+	if err != nil {
+		return nil, err
+	}
+	venceEl, err := parseVenceEl(req.VenceEl)
+	if err != nil {
+		return nil, err
+	}
+
 	documento := &Documento{
 		Extension:     req.Extension,
 		NombreArchivo: req.NombreArchivo,
 		SolicitudID:   req.SolicitudID,
+		UsuarioID:     req.UsuarioID,
+		Categoria:     req.Categoria,
+		VenceEl:       venceEl,
+		Etiquetas:     etiquetas,
+		Metadatos:     metadatos,
+	}
+	if req.CargaID != "" {
+		documento.CargaID = &req.CargaID
 	}
 
-	if err := s.repo.Create(ctx, documento); err != nil {
+	// Guardar el contenido del archivo antes de registrar el documento
+	var blob *Blob
+	var blobNuevo bool
+	if req.Archivo != nil {
+		archivo, err := storage.Spool(req.Archivo)
+		if err != nil {
+			s.logger.Printf("Error al recibir el archivo del documento: %v", err)
+			return nil, fmt.Errorf("error al recibir el archivo: %v", err)
+		}
+		defer archivo.Close()
+
+		// El tipo se determina por el contenido, no por lo que declara el cliente
+		documento.TipoMime, err = s.detectarTipo(archivo, req.Extension, req.Categoria)
+		if err != nil {
+			return nil, err
+		}
+		if err := archivo.Rewind(); err != nil {
+			return nil, fmt.Errorf("error al recibir el archivo: %v", err)
+		}
+		if err := s.VerificarCuota(ctx, req.SolicitudID, req.UsuarioID, archivo.Size); err != nil {
+			return nil, err
+		}
+
+		blob, blobNuevo, err = s.guardarBlob(ctx, archivo)
+		if err != nil {
+			s.logger.Printf("Error al guardar el archivo del documento: %v", err)
+			return nil, fmt.Errorf("error al guardar el archivo: %v", err)
+		}
+	} else if req.Checksum != "" {
+		// El cliente referencia un archivo que el servidor ya tiene y que puede leer
+		blob, err = s.GetBlob(ctx, req.Checksum, req.SolicitudID, req.UsuarioID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Verificar que el contenido existente corresponda a la extensión declarada
+		existente, err := s.storage.Open(ctx, blob.ClaveAlmacenamiento)
+		if err != nil {
+			s.logger.Printf("Error al abrir el archivo con checksum %s: %v", req.Checksum, err)
+			return nil, err
+		}
+		documento.TipoMime, err = s.detectarTipo(existente, req.Extension, req.Categoria)
+		existente.Close()
+		if err != nil {
+			return nil, err
+		}
+		if err := s.VerificarCuota(ctx, req.SolicitudID, req.UsuarioID, blob.Tamano); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := s.config.Politica.ValidarExtension(req.Extension, req.Categoria); err != nil {
+			return nil, err
+		}
+		if err := s.VerificarCuota(ctx, req.SolicitudID, req.UsuarioID, 0); err != nil {
+			return nil, err
+		}
+	}
+	if blob != nil {
+		documento.ClaveAlmacenamiento = blob.ClaveAlmacenamiento
+		documento.Tamano = blob.Tamano
+		documento.Checksum = blob.Checksum
+		// Un contenido ya analizado conserva su resultado; uno nuevo queda pendiente de escaneo
+		documento.EstadoEscaneo = blob.EstadoEscaneo
+		documento.DetalleEscaneo = blob.DetalleEscaneo
+		if blobNuevo || documento.EstadoEscaneo == "" {
+			documento.EstadoEscaneo = EstadoPendienteEscaneo
+		}
+	}
+
+	err = s.repo.Transaction(ctx, func(repo Repository) error {
+		// La cuota se vuelve a verificar con el uso bloqueado para que dos cargas simultáneas no la superen
+		if err := s.verificarCuotas(ctx, repo, req.SolicitudID, req.UsuarioID, 1, documento.Tamano); err != nil {
+			return err
+		}
+		return repo.Create(ctx, documento)
+	})
+	if err != nil {
 		s.logger.Printf("Error al crear el documento: %v", err)
+		// Revertir el archivo guardado para no dejar contenido huérfano
+		if blobNuevo {
+			s.descartarBlob(ctx, blob)
+		}
 		return nil, err
 	}
 
+	if documento.EstadoEscaneo == EstadoPendienteEscaneo {
+		s.escaneos.Encolar(documento.ID)
+	}
+
 	// Crear la respuesta con la información de la solicitud
 	response := s.toDocumentoResponse(documento, solicitud)
 
@@ -62,6 +311,16 @@ func (s *service) toDocumentoResponse(doc *Documento, solicitud *httpclient.Soli
 	response.ID = doc.ID
 	response.Extension = doc.Extension
 	response.NombreArchivo = doc.NombreArchivo
+	response.Tamano = doc.Tamano
+	response.TipoMime = doc.TipoMime
+	response.Checksum = doc.Checksum
+	response.Categoria = doc.Categoria
+	response.Etiquetas = nombresEtiquetas(doc.Etiquetas)
+	response.Metadatos = doc.Metadatos
+	response.EstadoEscaneo = doc.EstadoEscaneo
+	response.VenceEl = doc.VenceEl
+	response.URLMiniatura = s.urlMiniatura(doc)
+	response.Version = doc.Version
 	response.CreatedAt = doc.CreatedAt
 	response.UpdatedAt = doc.UpdatedAt
 	response.Solicitud.ID = solicitud.ID
@@ -70,6 +329,15 @@ func (s *service) toDocumentoResponse(doc *Documento, solicitud *httpclient.Soli
 	return response
 }
 
+// urlMiniatura retorna la URL de previsualización del documento, o vacío si su contenido no admite miniatura.
+// La versión en la URL evita que el cliente muestre la miniatura en caché de un contenido anterior.
+func (s *service) urlMiniatura(doc *Documento) string {
+	if doc.ClaveAlmacenamiento == "" || doc.EstadoEscaneo == EstadoEnCuarentena || !miniatura.Soporta(doc.TipoMime) {
+		return ""
+	}
+	return fmt.Sprintf("%s/documentos/%d/miniatura?v=%d", s.config.URLPublica, doc.ID, doc.Version)
+}
+
 func (s *service) GetAll(ctx context.Context, filter GetAllReq) ([]DocumentoResponse, error) {
 	documentos, err := s.repo.GetAll(ctx, filter)
 	if err != nil {
@@ -80,22 +348,8 @@ func (s *service) GetAll(ctx context.Context, filter GetAllReq) ([]DocumentoResp
 	// Crear un slice para las respuestas
 	responses := make([]DocumentoResponse, 0, len(documentos))
 
-	// Obtener los IDs de las solicitudes únicas
-	solicitudIDs := make(map[uint]bool)
-	for _, doc := range documentos {
-		solicitudIDs[doc.SolicitudID] = true
-	}
-
 	// Obtener los detalles de las solicitudes
-	solicitudes := make(map[uint]*httpclient.SolicitudResponse)
-	for id := range solicitudIDs {
-		solicitud, err := s.solicitudClient.GetSolicitud(id)
-		if err != nil {
-			s.logger.Printf("Advertencia: No se pudo obtener la solicitud ID=%d: %v", id, err)
-			continue
-		}
-		solicitudes[id] = solicitud
-	}
+	solicitudes := s.solicitudesDe(documentos)
 
 	// Construir las respuestas
 	for _, doc := range documentos {
@@ -111,6 +365,26 @@ func (s *service) GetAll(ctx context.Context, filter GetAllReq) ([]DocumentoResp
 	return responses, nil
 }
 
+// solicitudesDe obtiene una sola vez los detalles de cada solicitud a la que pertenecen los documentos
+func (s *service) solicitudesDe(documentos []Documento) map[uint]*httpclient.SolicitudResponse {
+	solicitudes := make(map[uint]*httpclient.SolicitudResponse)
+	consultadas := make(map[uint]bool)
+	for _, doc := range documentos {
+		if consultadas[doc.SolicitudID] {
+			continue
+		}
+		consultadas[doc.SolicitudID] = true
+
+		solicitud, err := s.solicitudClient.GetSolicitud(doc.SolicitudID)
+		if err != nil {
+			s.logger.Printf("Advertencia: No se pudo obtener la solicitud ID=%d: %v", doc.SolicitudID, err)
+			continue
+		}
+		solicitudes[doc.SolicitudID] = solicitud
+	}
+	return solicitudes
+}
+
 func (s *service) GetByID(ctx context.Context, id uint) (*DocumentoResponse, error) {
 	documento, err := s.repo.GetByID(ctx, id)
 	if err != nil {
@@ -130,23 +404,362 @@ func (s *service) GetByID(ctx context.Context, id uint) (*DocumentoResponse, err
 	return &response, nil
 }
 
+// GetByCargaID retorna el documento creado a partir de una carga reanudable
+func (s *service) GetByCargaID(ctx context.Context, cargaID string) (*Documento, error) {
+	return s.repo.GetByCargaID(ctx, cargaID)
+}
+
+// GetContenido abre el archivo asociado a un documento no eliminado
+func (s *service) GetContenido(ctx context.Context, id uint) (*Contenido, error) {
+	documento, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Printf("Error al obtener el documento ID=%d: %v", id, err)
+		return nil, err
+	}
+
+	if documento.ClaveAlmacenamiento == "" {
+		return nil, ErrSinContenido
+	}
+	if err := verificarEscaneo(documento.EstadoEscaneo); err != nil {
+		return nil, err
+	}
+
+	archivo, err := s.storage.Open(ctx, documento.ClaveAlmacenamiento)
+	if err != nil {
+		s.logger.Printf("Error al abrir el archivo del documento ID=%d: %v", id, err)
+		return nil, err
+	}
+
+	return &Contenido{Documento: documento, Archivo: archivo}, nil
+}
+
+// GetMiniatura retorna la miniatura JPEG del documento, generándola en ese momento si aún no existe
+func (s *service) GetMiniatura(ctx context.Context, id uint) (*Contenido, error) {
+	documento, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Printf("Error al obtener el documento ID=%d: %v", id, err)
+		return nil, err
+	}
+
+	if documento.ClaveAlmacenamiento == "" {
+		return nil, ErrSinContenido
+	}
+	// Las miniaturas se comparten por checksum, los documentos sin checksum no las tienen
+	if documento.Checksum == "" || !miniatura.Soporta(documento.TipoMime) {
+		return nil, ErrSinMiniatura
+	}
+	if err := verificarEscaneo(documento.EstadoEscaneo); err != nil {
+		return nil, err
+	}
+
+	blob, err := s.repo.GetBlob(ctx, documento.Checksum)
+	if err != nil {
+		s.logger.Printf("Error al obtener el contenido del documento ID=%d: %v", id, err)
+		return nil, err
+	}
+	if blob.ClaveMiniatura == "" {
+		if err := generarMiniatura(ctx, s.repo, s.storage, blob, documento.TipoMime); err != nil {
+			s.logger.Printf("Advertencia: No se pudo generar la miniatura del documento ID=%d: %v", id, err)
+			if errors.Is(err, miniatura.ErrNoSoportado) {
+				return nil, ErrSinMiniatura
+			}
+			return nil, err
+		}
+	}
+
+	archivo, err := s.storage.Open(ctx, blob.ClaveMiniatura)
+	if err != nil {
+		s.logger.Printf("Error al abrir la miniatura del documento ID=%d: %v", id, err)
+		return nil, err
+	}
+
+	return &Contenido{Documento: documento, Archivo: archivo}, nil
+}
+
 func (s *service) Update(ctx context.Context, id uint, req UpdateReq) error {
 	// Verificar que el documento existe
-	_, err := s.repo.GetByID(ctx, id)
+	documento, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		s.logger.Printf("Documento no encontrado para actualizar: ID=%d", id)
 		return fmt.Errorf("documento no encontrado")
 	}
 
-	if err := s.repo.Update(ctx, id, req); err != nil {
-		s.logger.Printf("Error al actualizar el documento ID=%d: %v", id, err)
+	// Cada actualización genera una nueva versión en lugar de sobrescribir la actual
+	version := versionDesdeDocumento(documento, "", req.UsuarioID)
+	var cambios []string
+	if req.Extension != nil && *req.Extension != documento.Extension {
+		version.Extension = *req.Extension
+		cambios = append(cambios, "extension")
+	}
+	if req.NombreArchivo != nil && *req.NombreArchivo != documento.NombreArchivo {
+		version.NombreArchivo = *req.NombreArchivo
+		cambios = append(cambios, "nombre_archivo")
+	}
+
+	// La categoría, el vencimiento, las etiquetas y los metadatos clasifican al documento y no forman parte de sus versiones
+	cambiaCategoria := req.Categoria != nil && *req.Categoria != documento.Categoria
+	if cambiaCategoria {
+		if err := s.config.Politica.ValidarExtension(version.Extension, *req.Categoria); err != nil {
+			return err
+		}
+	}
+	var venceEl *time.Time
+	cambiaVencimiento := false
+	if req.VenceEl != nil {
+		if venceEl, err = parseVenceEl(*req.VenceEl); err != nil {
+			return err
+		}
+		cambiaVencimiento = !mismaFecha(venceEl, documento.VenceEl)
+	}
+	var etiquetas []Etiqueta
+	if req.Etiquetas != nil {
+		if etiquetas, err = normalizarEtiquetas(*req.Etiquetas); err != nil {
+			return err
+		}
+	}
+	var metadatos []Metadato
+	if req.Metadatos != nil {
+		if metadatos, err = normalizarMetadatos(*req.Metadatos); err != nil {
+			return err
+		}
+	}
+
+	if len(cambios) == 0 && !cambiaCategoria && !cambiaVencimiento && req.Etiquetas == nil && req.Metadatos == nil {
+		s.logger.Printf("Documento ID=%d sin cambios para actualizar", id)
+		return nil
+	}
+
+	// Todos los cambios se aplican en una transacción para no dejar el documento a medio actualizar
+	err = s.repo.Transaction(ctx, func(repo Repository) error {
+		if len(cambios) > 0 {
+			version.Cambios = strings.Join(cambios, ", ")
+			if err := repo.ApplyVersion(ctx, id, version); err != nil {
+				s.logger.Printf("Error al actualizar el documento ID=%d: %v", id, err)
+				return err
+			}
+		}
+		if cambiaCategoria {
+			if err := repo.UpdateCategoria(ctx, id, *req.Categoria); err != nil {
+				s.logger.Printf("Error al actualizar la categoría del documento ID=%d: %v", id, err)
+				return err
+			}
+		}
+		if cambiaVencimiento {
+			if err := repo.UpdateVenceEl(ctx, id, venceEl); err != nil {
+				s.logger.Printf("Error al actualizar el vencimiento del documento ID=%d: %v", id, err)
+				return err
+			}
+		}
+		if req.Etiquetas != nil {
+			if err := repo.ReemplazarEtiquetas(ctx, id, etiquetas); err != nil {
+				s.logger.Printf("Error al actualizar las etiquetas del documento ID=%d: %v", id, err)
+				return err
+			}
+		}
+		if req.Metadatos != nil {
+			if err := repo.ReemplazarMetadatos(ctx, id, metadatos); err != nil {
+				s.logger.Printf("Error al actualizar los metadatos del documento ID=%d: %v", id, err)
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		return err
 	}
 
-	s.logger.Printf("Documento actualizado exitosamente: ID=%d", id)
+	if len(cambios) > 0 {
+		s.logger.Printf("Documento actualizado exitosamente: ID=%d, versión %d", id, version.Numero)
+	}
+	if cambiaCategoria {
+		s.logger.Printf("Categoría del documento ID=%d actualizada a '%s'", id, *req.Categoria)
+	}
+	if cambiaVencimiento {
+		s.logger.Printf("Vencimiento del documento ID=%d actualizado a '%s'", id, *req.VenceEl)
+	}
 	return nil
 }
 
+// ReemplazarContenido sube un nuevo archivo para el documento, conservando el anterior en su versión
+func (s *service) ReemplazarContenido(ctx context.Context, id uint, req ContenidoReq) (*DocumentoResponse, error) {
+	documento, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Printf("Documento no encontrado para reemplazar contenido: ID=%d", id)
+		return nil, err
+	}
+
+	archivo, err := storage.Spool(req.Archivo)
+	if err != nil {
+		s.logger.Printf("Error al recibir el archivo del documento ID=%d: %v", id, err)
+		return nil, fmt.Errorf("error al recibir el archivo: %v", err)
+	}
+	defer archivo.Close()
+
+	tipoMime, err := s.detectarTipo(archivo, req.Extension, documento.Categoria)
+	if err != nil {
+		return nil, err
+	}
+	if err := archivo.Rewind(); err != nil {
+		return nil, fmt.Errorf("error al recibir el archivo: %v", err)
+	}
+	// Reemplazar no agrega archivos, solo cuenta la diferencia de tamaño
+	if err := s.verificarCuotas(ctx, s.repo, documento.SolicitudID, documento.UsuarioID, 0, archivo.Size-documento.Tamano); err != nil {
+		return nil, err
+	}
+
+	blob, blobNuevo, err := s.guardarBlob(ctx, archivo)
+	if err != nil {
+		s.logger.Printf("Error al guardar el archivo del documento ID=%d: %v", id, err)
+		return nil, fmt.Errorf("error al guardar el archivo: %v", err)
+	}
+
+	version := versionDesdeDocumento(documento, "contenido", req.UsuarioID)
+	version.ClaveAlmacenamiento = blob.ClaveAlmacenamiento
+	version.Tamano = blob.Tamano
+	version.Checksum = blob.Checksum
+	version.TipoMime = tipoMime
+	if req.Extension != documento.Extension {
+		version.Extension = req.Extension
+		version.Cambios = "contenido, extension"
+	}
+
+	err = s.repo.Transaction(ctx, func(repo Repository) error {
+		if err := s.verificarCuotas(ctx, repo, documento.SolicitudID, documento.UsuarioID, 0, archivo.Size-documento.Tamano); err != nil {
+			return err
+		}
+		return repo.ApplyVersion(ctx, id, version)
+	})
+	if err != nil {
+		s.logger.Printf("Error al registrar la nueva versión del documento ID=%d: %v", id, err)
+		if blobNuevo {
+			s.descartarBlob(ctx, blob)
+		}
+		return nil, err
+	}
+
+	s.logger.Printf("Contenido del documento ID=%d reemplazado, versión %d", id, version.Numero)
+	s.escaneos.Encolar(id)
+	return s.GetByID(ctx, id)
+}
+
+// GetVersiones retorna el historial de versiones de un documento, de la más reciente a la más antigua
+func (s *service) GetVersiones(ctx context.Context, id uint) ([]Version, error) {
+	documento, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Printf("Error al obtener el documento ID=%d: %v", id, err)
+		return nil, err
+	}
+
+	versiones, err := s.repo.GetVersiones(ctx, id)
+	if err != nil {
+		s.logger.Printf("Error al obtener las versiones del documento ID=%d: %v", id, err)
+		return nil, err
+	}
+
+	// Documentos anteriores al historial solo tienen su estado actual
+	if len(versiones) == 0 {
+		versiones = []Version{*versionDesdeDocumento(documento, "versión inicial", nil)}
+		versiones[0].CreatedAt = documento.CreatedAt
+	}
+	return versiones, nil
+}
+
+func (s *service) GetVersion(ctx context.Context, id uint, numero int) (*Version, error) {
+	documento, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Printf("Error al obtener el documento ID=%d: %v", id, err)
+		return nil, err
+	}
+
+	version, err := s.repo.GetVersion(ctx, id, numero)
+	if errors.Is(err, gorm.ErrRecordNotFound) && numero == documento.Version {
+		version = versionDesdeDocumento(documento, "versión inicial", nil)
+		version.CreatedAt = documento.CreatedAt
+		return version, nil
+	}
+	if err != nil {
+		s.logger.Printf("Error al obtener la versión %d del documento ID=%d: %v", numero, id, err)
+		return nil, err
+	}
+	return version, nil
+}
+
+// GetVersionContenido abre el archivo de una versión específica de un documento
+func (s *service) GetVersionContenido(ctx context.Context, id uint, numero int) (*Contenido, error) {
+	version, err := s.GetVersion(ctx, id, numero)
+	if err != nil {
+		return nil, err
+	}
+	if version.ClaveAlmacenamiento == "" {
+		return nil, ErrSinContenido
+	}
+	if err := s.verificarEscaneoVersion(ctx, id, version); err != nil {
+		return nil, err
+	}
+
+	archivo, err := s.storage.Open(ctx, version.ClaveAlmacenamiento)
+	if err != nil {
+		s.logger.Printf("Error al abrir el archivo de la versión %d del documento ID=%d: %v", numero, id, err)
+		return nil, err
+	}
+
+	documento := &Documento{
+		ID:                  id,
+		Extension:           version.Extension,
+		NombreArchivo:       version.NombreArchivo,
+		Tamano:              version.Tamano,
+		TipoMime:            version.TipoMime,
+		Checksum:            version.Checksum,
+		Version:             version.Numero,
+		UpdatedAt:           version.CreatedAt,
+		ClaveAlmacenamiento: version.ClaveAlmacenamiento,
+	}
+	return &Contenido{Documento: documento, Archivo: archivo}, nil
+}
+
+// verificarEscaneoVersion obtiene el estado del análisis del contenido de una versión
+func (s *service) verificarEscaneoVersion(ctx context.Context, id uint, version *Version) error {
+	if version.Checksum != "" {
+		blob, err := s.repo.GetBlob(ctx, version.Checksum)
+		if err != nil {
+			return err
+		}
+		return verificarEscaneo(blob.EstadoEscaneo)
+	}
+
+	// Contenido anterior al almacenamiento por checksum: solo se conoce el estado del contenido actual
+	documento, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if documento.ClaveAlmacenamiento != version.ClaveAlmacenamiento {
+		return ErrEscaneoPendiente
+	}
+	return verificarEscaneo(documento.EstadoEscaneo)
+}
+
+// RestaurarVersion vuelve a dejar como actual el estado de una versión anterior, creando una versión nueva
+func (s *service) RestaurarVersion(ctx context.Context, id uint, numero int, usuarioID *uint) (*DocumentoResponse, error) {
+	anterior, err := s.GetVersion(ctx, id, numero)
+	if err != nil {
+		return nil, err
+	}
+
+	version := *anterior
+	version.UsuarioID = usuarioID
+	version.Cambios = fmt.Sprintf("restauración de la versión %d", numero)
+	version.CreatedAt = time.Time{}
+
+	if err := s.repo.ApplyVersion(ctx, id, &version); err != nil {
+		s.logger.Printf("Error al restaurar la versión %d del documento ID=%d: %v", numero, id, err)
+		return nil, err
+	}
+
+	s.logger.Printf("Documento ID=%d restaurado a la versión %d (nueva versión %d)", id, numero, version.Numero)
+	s.escaneos.Encolar(id)
+	return s.GetByID(ctx, id)
+}
+
 func (s *service) Delete(ctx context.Context, id uint) error {
 	// Verificar que el documento existe
 	_, err := s.repo.GetByID(ctx, id)
@@ -175,3 +788,387 @@ func (s *service) DeleteBySolicitudID(ctx context.Context, solicitudID uint) err
 	s.logger.Printf("Documentos eliminados exitosamente para la solicitud ID=%d", solicitudID)
 	return nil
 }
+
+// GetBlob retorna el archivo con el checksum solo si ya es accesible para quien lo consulta, es decir,
+// si es el contenido de un documento de la solicitud o de uno subido por el usuario. En otro caso se
+// responde como si no existiera, para no revelar ni permitir adjuntar archivos de otras solicitudes.
+func (s *service) GetBlob(ctx context.Context, checksum string, solicitudID uint, usuarioID *uint) (*Blob, error) {
+	blob, err := s.repo.GetBlob(ctx, checksum)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrBlobNoEncontrado
+	}
+	if err != nil {
+		s.logger.Printf("Error al obtener el archivo con checksum %s: %v", checksum, err)
+		return nil, err
+	}
+
+	accesible, err := s.repo.BlobAccesible(ctx, checksum, solicitudID, usuarioID)
+	if err != nil {
+		s.logger.Printf("Error al verificar el acceso al archivo con checksum %s: %v", checksum, err)
+		return nil, err
+	}
+	if !accesible {
+		s.logger.Printf("Archivo con checksum %s no accesible para la solicitud ID=%d", checksum, solicitudID)
+		return nil, ErrBlobNoEncontrado
+	}
+	return blob, nil
+}
+
+func (s *service) Buscar(ctx context.Context, req BuscarReq) ([]ResultadoBusqueda, error) {
+	if req.Limit <= 0 || req.Limit > maxResultadosBusqueda {
+		req.Limit = resultadosBusquedaPorDefecto
+	}
+
+	coincidencias, err := s.repo.Buscar(ctx, req)
+	if err != nil {
+		s.logger.Printf("Error al buscar documentos con %q: %v", req.Q, err)
+		return nil, err
+	}
+
+	documentos := make([]Documento, len(coincidencias))
+	for i := range coincidencias {
+		documentos[i] = coincidencias[i].Documento
+	}
+	solicitudes := s.solicitudesDe(documentos)
+
+	resultados := make([]ResultadoBusqueda, 0, len(coincidencias))
+	for i := range coincidencias {
+		doc := &coincidencias[i].Documento
+		solicitud, ok := solicitudes[doc.SolicitudID]
+		if !ok {
+			s.logger.Printf("Advertencia: No se encontró la solicitud ID=%d para el documento ID=%d", doc.SolicitudID, doc.ID)
+			continue
+		}
+		resultados = append(resultados, ResultadoBusqueda{
+			DocumentoResponse: s.toDocumentoResponse(doc, solicitud),
+			Fragmentos:        fragmentos(coincidencias[i].Texto, req.Q),
+		})
+	}
+
+	s.logger.Printf("La búsqueda %q encontró %d documentos", req.Q, len(resultados))
+	return resultados, nil
+}
+
+// CrearEnlace genera una URL pública firmada para descargar la versión actual del documento
+func (s *service) CrearEnlace(ctx context.Context, id uint, req EnlaceReq) (*EnlaceResponse, error) {
+	documento, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Printf("Error al obtener el documento ID=%d: %v", id, err)
+		return nil, err
+	}
+	if documento.ClaveAlmacenamiento == "" {
+		return nil, ErrSinContenido
+	}
+	if err := verificarEscaneo(documento.EstadoEscaneo); err != nil {
+		return nil, err
+	}
+
+	vigencia := vigenciaEnlacePorDefecto
+	if req.ExpiraEnSegundos > 0 {
+		vigencia = min(time.Duration(req.ExpiraEnSegundos)*time.Second, vigenciaEnlaceMaxima)
+	}
+
+	enlace := EnlaceFirmado{
+		DocumentoID: documento.ID,
+		Version:     documento.Version,
+		Expira:      time.Now().Add(vigencia).Unix(),
+	}
+	if req.UnSoloUso {
+		nonce := make([]byte, 16)
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, fmt.Errorf("error al generar el enlace: %v", err)
+		}
+		enlace.Nonce = hex.EncodeToString(nonce)
+	}
+	enlace.Firma = s.firmante.Firmar(partesEnlace(enlace)...)
+
+	consulta := url.Values{}
+	consulta.Set("v", strconv.Itoa(enlace.Version))
+	consulta.Set("exp", strconv.FormatInt(enlace.Expira, 10))
+	if enlace.Nonce != "" {
+		consulta.Set("n", enlace.Nonce)
+	}
+	consulta.Set("firma", enlace.Firma)
+
+	if req.UsuarioID != nil {
+		s.logger.Printf("Enlace de descarga generado para el documento ID=%d versión %d por el usuario %d (un solo uso: %t)", id, enlace.Version, *req.UsuarioID, req.UnSoloUso)
+	} else {
+		s.logger.Printf("Enlace de descarga generado para el documento ID=%d versión %d (un solo uso: %t)", id, enlace.Version, req.UnSoloUso)
+	}
+	return &EnlaceResponse{
+		URL:       fmt.Sprintf("%s/publico/documentos/%d/contenido?%s", s.config.URLPublica, id, consulta.Encode()),
+		Version:   enlace.Version,
+		ExpiraEl:  time.Unix(enlace.Expira, 0),
+		UnSoloUso: req.UnSoloUso,
+	}, nil
+}
+
+// partesEnlace son los valores cubiertos por la firma; modificar cualquiera invalida el enlace
+func partesEnlace(enlace EnlaceFirmado) []string {
+	return []string{
+		strconv.FormatUint(uint64(enlace.DocumentoID), 10),
+		strconv.Itoa(enlace.Version),
+		strconv.FormatInt(enlace.Expira, 10),
+		enlace.Nonce,
+	}
+}
+
+// GetContenidoEnlace valida la firma y vigencia del enlace y abre la versión del documento que comparte
+func (s *service) GetContenidoEnlace(ctx context.Context, enlace EnlaceFirmado) (*Contenido, error) {
+	if !s.firmante.Verificar(enlace.Firma, partesEnlace(enlace)...) {
+		s.logger.Printf("Advertencia: Enlace con firma inválida para el documento ID=%d", enlace.DocumentoID)
+		return nil, ErrEnlaceInvalido
+	}
+	expiraEl := time.Unix(enlace.Expira, 0)
+	if time.Now().After(expiraEl) {
+		return nil, ErrEnlaceExpirado
+	}
+
+	// Un documento eliminado deja de estar disponible aunque el enlace siga vigente
+	if _, err := s.repo.GetByID(ctx, enlace.DocumentoID); err != nil {
+		return nil, err
+	}
+	contenido, err := s.GetVersionContenido(ctx, enlace.DocumentoID, enlace.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	if enlace.Nonce != "" {
+		err := s.repo.ConsumirEnlace(ctx, &EnlaceUsado{Nonce: enlace.Nonce, DocumentoID: enlace.DocumentoID, ExpiraEl: expiraEl})
+		if err != nil {
+			contenido.Archivo.Close()
+			return nil, err
+		}
+	}
+
+	// Si el almacenamiento lo permite, los enlaces reutilizables se descargan directamente desde él.
+	// Los de un solo uso siempre pasan por el servicio, ya que una URL presignada se puede repetir.
+	if presigner, ok := s.storage.(storage.Presigner); ok && enlace.Nonce == "" {
+		doc := contenido.Documento
+		url, err := presigner.PresignedURL(ctx, doc.ClaveAlmacenamiento, doc.NombreDescarga(), time.Until(expiraEl))
+		if err == nil {
+			contenido.Archivo.Close()
+			return &Contenido{Documento: doc, URL: url}, nil
+		}
+		s.logger.Printf("Advertencia: No se pudo generar la URL directa del documento ID=%d: %v", enlace.DocumentoID, err)
+	}
+
+	s.logger.Printf("Documento ID=%d versión %d descargado mediante enlace firmado", enlace.DocumentoID, enlace.Version)
+	return contenido, nil
+}
+
+// GetDocumentosZip obtiene los documentos de la solicitud que se incluirán en su archivo ZIP
+func (s *service) GetDocumentosZip(ctx context.Context, solicitudID uint) ([]Documento, error) {
+	documentos, err := s.repo.GetAll(ctx, GetAllReq{SolicitudID: solicitudID})
+	if err != nil {
+		s.logger.Printf("Error al obtener los documentos de la solicitud ID=%d: %v", solicitudID, err)
+		return nil, err
+	}
+	if len(documentos) == 0 {
+		return nil, ErrSolicitudSinDocumentos
+	}
+	return documentos, nil
+}
+
+// EscribirZip escribe en w un ZIP con el contenido de los documentos, leyendo cada archivo
+// del almacenamiento a medida que se comprime para no cargar la solicitud completa en memoria.
+// Los documentos sin contenido o que no están limpios solo aparecen en el manifiesto.
+func (s *service) EscribirZip(ctx context.Context, documentos []Documento, w io.Writer) error {
+	zw := zip.NewWriter(w)
+	nombres := make(nombresZip)
+	filas := make([]filaManifiesto, 0, len(documentos))
+
+	for i := range documentos {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		doc := &documentos[i]
+		fila := filaManifiesto{documento: doc}
+		switch {
+		case doc.ClaveAlmacenamiento == "":
+			fila.detalle = "sin contenido"
+		case verificarEscaneo(doc.EstadoEscaneo) != nil:
+			fila.detalle = doc.EstadoEscaneo
+		default:
+			archivo, err := s.storage.Open(ctx, doc.ClaveAlmacenamiento)
+			if err != nil {
+				// Aún no se escribió nada de este documento, así que se omite y se informa en el manifiesto
+				s.logger.Printf("Error al abrir el archivo del documento ID=%d para el ZIP: %v", doc.ID, err)
+				fila.detalle = "archivo no disponible"
+				break
+			}
+			fila.archivo = nombres.unico(doc.NombreDescarga())
+			err = agregarAlZip(zw, fila.archivo, doc, archivo)
+			archivo.Close()
+			if err != nil {
+				s.logger.Printf("Error al agregar el documento ID=%d al ZIP: %v", doc.ID, err)
+				return err
+			}
+			fila.incluido = true
+		}
+		filas = append(filas, fila)
+	}
+
+	if err := escribirManifiesto(zw, filas); err != nil {
+		return fmt.Errorf("error al escribir el manifiesto: %v", err)
+	}
+	return zw.Close()
+}
+
+// GetUso obtiene el uso de almacenamiento de la solicitud y/o del usuario junto con sus cuotas
+func (s *service) GetUso(ctx context.Context, req UsoReq) (*UsoResponse, error) {
+	var response UsoResponse
+	if req.SolicitudID > 0 {
+		uso, err := s.repo.GetUsoSolicitud(ctx, req.SolicitudID)
+		if err != nil {
+			s.logger.Printf("Error al obtener el uso de la solicitud ID=%d: %v", req.SolicitudID, err)
+			return nil, err
+		}
+		response.Solicitud = s.config.Cuotas.PorSolicitud.conLimites(uso)
+	}
+	if req.UsuarioID != nil {
+		uso, err := s.repo.GetUsoUsuario(ctx, *req.UsuarioID)
+		if err != nil {
+			s.logger.Printf("Error al obtener el uso del usuario ID=%d: %v", *req.UsuarioID, err)
+			return nil, err
+		}
+		response.Usuario = s.config.Cuotas.PorUsuario.conLimites(uso)
+	}
+	return &response, nil
+}
+
+// GetCategorias retorna el catálogo de categorías de documento
+func (s *service) GetCategorias(ctx context.Context) []CategoriaResponse {
+	return s.config.Politica.Catalogo()
+}
+
+// VerificarCuota comprueba que un nuevo documento de tamano bytes quepa en las cuotas
+// de la solicitud y del usuario que lo sube
+func (s *service) VerificarCuota(ctx context.Context, solicitudID uint, usuarioID *uint, tamano int64) error {
+	return s.verificarCuotas(ctx, s.repo, solicitudID, usuarioID, 1, tamano)
+}
+
+// verificarCuotas comprueba las cuotas con el uso que lee repo; con el repositorio de una
+// transacción el uso queda bloqueado hasta que esta termina
+func (s *service) verificarCuotas(ctx context.Context, repo Repository, solicitudID uint, usuarioID *uint, archivos, bytes int64) error {
+	cuotas := s.config.Cuotas
+	if cuotas.PorSolicitud != (Cuota{}) {
+		uso, err := repo.GetUsoSolicitud(ctx, solicitudID)
+		if err != nil {
+			return fmt.Errorf("error al verificar la cuota de la solicitud: %v", err)
+		}
+		if err := cuotas.PorSolicitud.verificar(AmbitoSolicitud, uso, archivos, bytes); err != nil {
+			s.logger.Printf("Cuota excedida para la solicitud ID=%d: %v", solicitudID, err)
+			return err
+		}
+	}
+	if usuarioID != nil && cuotas.PorUsuario != (Cuota{}) {
+		uso, err := repo.GetUsoUsuario(ctx, *usuarioID)
+		if err != nil {
+			return fmt.Errorf("error al verificar la cuota del usuario: %v", err)
+		}
+		if err := cuotas.PorUsuario.verificar(AmbitoUsuario, uso, archivos, bytes); err != nil {
+			s.logger.Printf("Cuota excedida para el usuario ID=%d: %v", *usuarioID, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// GetPapelera lista los documentos eliminados (soft delete) que aún no han sido purgados
+func (s *service) GetPapelera(ctx context.Context, filter GetAllReq) ([]DocumentoEliminado, error) {
+	documentos, err := s.repo.GetEliminados(ctx, filter)
+	if err != nil {
+		s.logger.Printf("Error al obtener los documentos eliminados: %v", err)
+		return nil, err
+	}
+
+	solicitudes := s.solicitudesDe(documentos)
+	eliminados := make([]DocumentoEliminado, 0, len(documentos))
+	for _, doc := range documentos {
+		solicitud, vigente := solicitudes[doc.SolicitudID]
+		if !vigente {
+			// La solicitud pudo haber sido eliminada junto con el documento
+			solicitud = &httpclient.SolicitudResponse{ID: doc.SolicitudID}
+		}
+		eliminados = append(eliminados, DocumentoEliminado{
+			DocumentoResponse: s.toDocumentoResponse(&doc, solicitud),
+			EliminadoEl:       doc.DeletedAt.Time,
+			SolicitudVigente:  vigente,
+		})
+	}
+
+	s.logger.Printf("Se obtuvieron %d documentos eliminados", len(eliminados))
+	return eliminados, nil
+}
+
+// Restaurar recupera un documento de la papelera si su solicitud sigue existiendo
+func (s *service) Restaurar(ctx context.Context, id uint) (*DocumentoResponse, error) {
+	documento, err := s.repo.GetEliminadoByID(ctx, id)
+	if err != nil {
+		s.logger.Printf("Documento ID=%d no encontrado en la papelera: %v", id, err)
+		return nil, err
+	}
+
+	existe, err := s.solicitudClient.ValidarSolicitud(documento.SolicitudID)
+	if err != nil {
+		s.logger.Printf("Error al validar la solicitud ID=%d: %v", documento.SolicitudID, err)
+		return nil, fmt.Errorf("error al validar solicitud: %v", err)
+	}
+	if !existe {
+		return nil, ErrSolicitudEliminada
+	}
+
+	// El documento restaurado vuelve a ocupar espacio en las cuotas
+	if err := s.VerificarCuota(ctx, documento.SolicitudID, documento.UsuarioID, documento.Tamano); err != nil {
+		return nil, err
+	}
+
+	err = s.repo.Transaction(ctx, func(repo Repository) error {
+		if err := s.verificarCuotas(ctx, repo, documento.SolicitudID, documento.UsuarioID, 1, documento.Tamano); err != nil {
+			return err
+		}
+		return repo.Restaurar(ctx, id)
+	})
+	if err != nil {
+		s.logger.Printf("Error al restaurar el documento ID=%d: %v", id, err)
+		return nil, err
+	}
+	// Un análisis interrumpido por la eliminación se retoma
+	if documento.EstadoEscaneo == EstadoPendienteEscaneo {
+		s.escaneos.Encolar(id)
+	}
+
+	s.logger.Printf("Documento ID=%d restaurado desde la papelera", id)
+	return s.GetByID(ctx, id)
+}
+
+// GetPorVencer obtiene los documentos que vencen dentro de los próximos días, ordenados por fecha de vencimiento
+func (s *service) GetPorVencer(ctx context.Context, req PorVencerReq) ([]DocumentoPorVencer, error) {
+	hoy := hoy()
+	var desde *time.Time
+	if !req.IncluirVencidos {
+		desde = &hoy
+	}
+	documentos, err := s.repo.GetPorVencer(ctx, desde, hoy.AddDate(0, 0, req.Dias), req.SolicitudID)
+	if err != nil {
+		s.logger.Printf("Error al obtener los documentos por vencer: %v", err)
+		return nil, err
+	}
+
+	solicitudes := s.solicitudesDe(documentos)
+	respuestas := make([]DocumentoPorVencer, 0, len(documentos))
+	for _, doc := range documentos {
+		solicitud, ok := solicitudes[doc.SolicitudID]
+		if !ok {
+			s.logger.Printf("Advertencia: No se encontró la solicitud ID=%d para el documento ID=%d", doc.SolicitudID, doc.ID)
+			continue
+		}
+		respuestas = append(respuestas, DocumentoPorVencer{
+			DocumentoResponse: s.toDocumentoResponse(&doc, solicitud),
+			DiasRestantes:     diasHasta(*doc.VenceEl, hoy),
+		})
+	}
+	return respuestas, nil
+}
@@ -0,0 +1,1620 @@
+package documento
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kramirez/documentos/pkg/httpclient"
+	"github.com/kramirez/documentos/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// contenidoPDF es un PDF mínimo que mimetype reconoce como application/pdf
+var contenidoPDF = []byte("%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\ntrailer\n<< /Root 1 0 R >>\n%%EOF\n")
+
+// nuevoServidorSolicitudes simula el servicio de solicitudes: solo la solicitud 1 existe
+func nuevoServidorSolicitudes(t *testing.T) *httpclient.SolicitudClient {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/solicitudes/1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(httpclient.SolicitudResponse{ID: 1, Titulo: "Analista", Area: "TI"})
+	}))
+	t.Cleanup(server.Close)
+	return httpclient.NewSolicitudClient(server.URL)
+}
+
+// setupService crea el servicio con un almacenamiento local en un directorio temporal
+func setupService(t *testing.T, repo *mockRepository, config Config) (*service, *storage.LocalStorage, *mockColaEscaneo) {
+	t.Helper()
+	store, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	cola := new(mockColaEscaneo)
+	s := NewService(repo, log.New(io.Discard, "", 0), nuevoServidorSolicitudes(t), store, cola, config)
+	return s.(*service), store, cola
+}
+
+func checksumDe(contenido []byte) string {
+	suma := sha256.Sum256(contenido)
+	return hex.EncodeToString(suma[:])
+}
+
+// guardarEnStorage deja el contenido en el almacenamiento bajo su clave por checksum
+func guardarEnStorage(t *testing.T, store storage.Storage, contenido []byte) string {
+	t.Helper()
+	clave := storage.ContentKey(checksumDe(contenido))
+	_, err := store.Save(context.Background(), clave, bytes.NewReader(contenido))
+	require.NoError(t, err)
+	return clave
+}
+
+func TestService_Create(t *testing.T) {
+	ctx := context.Background()
+	checksum := checksumDe(contenidoPDF)
+	clave := storage.ContentKey(checksum)
+
+	t.Run("debe guardar un archivo nuevo y dejarlo pendiente de escaneo", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, store, cola := setupService(t, repo, Config{})
+		repo.On("GetBlob", ctx, checksum).Return(nil, gorm.ErrRecordNotFound)
+		repo.On("Create", ctx, mock.MatchedBy(func(d *Documento) bool {
+			return d.Checksum == checksum && d.ClaveAlmacenamiento == clave &&
+				d.TipoMime == "application/pdf" && d.EstadoEscaneo == EstadoPendienteEscaneo
+		})).Return(nil).Run(func(args mock.Arguments) {
+			args.Get(1).(*Documento).ID = 10
+		})
+		cola.On("Encolar", uint(10)).Return()
+
+		// Act
+		documento, err := s.Create(ctx, CreateReq{Extension: "pdf", NombreArchivo: "cv", SolicitudID: 1, Archivo: bytes.NewReader(contenidoPDF)})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, uint(10), documento.ID)
+		assert.Equal(t, "Analista", documento.Solicitud.Titulo)
+		archivo, err := store.Open(ctx, clave)
+		require.NoError(t, err)
+		archivo.Close()
+		repo.AssertExpectations(t)
+		cola.AssertExpectations(t)
+	})
+
+	t.Run("debe reutilizar un archivo ya analizado sin volver a escribirlo", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, store, cola := setupService(t, repo, Config{})
+		repo.On("GetBlob", ctx, checksum).Return(&Blob{Checksum: checksum, ClaveAlmacenamiento: clave, Tamano: int64(len(contenidoPDF)), EstadoEscaneo: EstadoLimpio}, nil)
+		repo.On("Create", ctx, mock.MatchedBy(func(d *Documento) bool {
+			return d.Checksum == checksum && d.EstadoEscaneo == EstadoLimpio
+		})).Return(nil)
+
+		// Act
+		_, err := s.Create(ctx, CreateReq{Extension: "pdf", NombreArchivo: "cv", SolicitudID: 1, Archivo: bytes.NewReader(contenidoPDF)})
+
+		// Assert
+		require.NoError(t, err)
+		_, err = store.Open(ctx, clave)
+		assert.ErrorIs(t, err, storage.ErrNotFound, "el contenido existente no se vuelve a escribir")
+		cola.AssertNotCalled(t, "Encolar", mock.Anything)
+	})
+
+	t.Run("debe eliminar el archivo nuevo si no se pudo registrar el documento", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, store, _ := setupService(t, repo, Config{})
+		// descartarBlob consulta el blob con un contexto que no se cancela
+		repo.On("GetBlob", mock.Anything, checksum).Return(nil, gorm.ErrRecordNotFound)
+		repo.On("Create", ctx, mock.Anything).Return(assert.AnError)
+
+		// Act
+		_, err := s.Create(ctx, CreateReq{Extension: "pdf", NombreArchivo: "cv", SolicitudID: 1, Archivo: bytes.NewReader(contenidoPDF)})
+
+		// Assert
+		assert.ErrorIs(t, err, assert.AnError)
+		_, err = store.Open(ctx, clave)
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("debe crear el documento desde el checksum de un archivo de la misma solicitud", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, store, _ := setupService(t, repo, Config{})
+		guardarEnStorage(t, store, contenidoPDF)
+		usuario := uint(3)
+		repo.On("GetBlob", ctx, checksum).Return(&Blob{Checksum: checksum, ClaveAlmacenamiento: clave, EstadoEscaneo: EstadoLimpio}, nil)
+		repo.On("BlobAccesible", ctx, checksum, uint(1), &usuario).Return(true, nil)
+		repo.On("Create", ctx, mock.MatchedBy(func(d *Documento) bool {
+			return d.Checksum == checksum && d.TipoMime == "application/pdf"
+		})).Return(nil)
+
+		// Act
+		_, err := s.Create(ctx, CreateReq{Extension: "pdf", NombreArchivo: "cv", SolicitudID: 1, Checksum: checksum, UsuarioID: &usuario})
+
+		// Assert
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe rechazar el checksum de un archivo que no es accesible", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, store, _ := setupService(t, repo, Config{})
+		guardarEnStorage(t, store, contenidoPDF)
+		repo.On("GetBlob", ctx, checksum).Return(&Blob{Checksum: checksum, ClaveAlmacenamiento: clave}, nil)
+		repo.On("BlobAccesible", ctx, checksum, uint(1), (*uint)(nil)).Return(false, nil)
+
+		// Act
+		_, err := s.Create(ctx, CreateReq{Extension: "pdf", NombreArchivo: "cv", SolicitudID: 1, Checksum: checksum})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrBlobNoEncontrado)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe rechazar un checksum que no existe", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		repo.On("GetBlob", ctx, checksum).Return(nil, gorm.ErrRecordNotFound)
+
+		// Act
+		_, err := s.Create(ctx, CreateReq{Extension: "pdf", NombreArchivo: "cv", SolicitudID: 1, Checksum: checksum})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrBlobNoEncontrado)
+		repo.AssertNotCalled(t, "BlobAccesible", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe fallar si la solicitud no existe", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+
+		// Act
+		_, err := s.Create(ctx, CreateReq{Extension: "pdf", NombreArchivo: "cv", SolicitudID: 9, Archivo: bytes.NewReader(contenidoPDF)})
+
+		// Assert
+		assert.Error(t, err)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_GetBlob(t *testing.T) {
+	ctx := context.Background()
+	checksum := checksumDe(contenidoPDF)
+
+	t.Run("debe retornar el archivo accesible para el usuario", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		usuario := uint(3)
+		repo.On("GetBlob", ctx, checksum).Return(&Blob{Checksum: checksum, Tamano: 10}, nil)
+		repo.On("BlobAccesible", ctx, checksum, uint(0), &usuario).Return(true, nil)
+
+		// Act
+		blob, err := s.GetBlob(ctx, checksum, 0, &usuario)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, int64(10), blob.Tamano)
+	})
+
+	t.Run("debe responder como inexistente un archivo de otra solicitud", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		repo.On("GetBlob", ctx, checksum).Return(&Blob{Checksum: checksum}, nil)
+		repo.On("BlobAccesible", ctx, checksum, uint(2), (*uint)(nil)).Return(false, nil)
+
+		// Act
+		_, err := s.GetBlob(ctx, checksum, 2, nil)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrBlobNoEncontrado)
+	})
+}
+
+func TestService_Update(t *testing.T) {
+	ctx := context.Background()
+	actual := func() *Documento {
+		return &Documento{ID: 1, SolicitudID: 1, NombreArchivo: "cv", Extension: "pdf", Version: 2, ClaveAlmacenamiento: "blobs/ab/abc", Checksum: "abc"}
+	}
+
+	t.Run("debe registrar una nueva versión con los campos modificados", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		nombre, usuario := "cv actualizado", uint(3)
+		repo.On("GetByID", ctx, uint(1)).Return(actual(), nil)
+		repo.On("ApplyVersion", ctx, uint(1), mock.MatchedBy(func(v *Version) bool {
+			return v.NombreArchivo == "cv actualizado" && v.Extension == "pdf" && v.Checksum == "abc" &&
+				v.Cambios == "nombre_archivo" && *v.UsuarioID == 3
+		})).Return(nil)
+
+		// Act
+		err := s.Update(ctx, 1, UpdateReq{NombreArchivo: &nombre, UsuarioID: &usuario})
+
+		// Assert
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("no debe crear una versión si los valores no cambian", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		nombre, extension := "cv", "pdf"
+		repo.On("GetByID", ctx, uint(1)).Return(actual(), nil)
+
+		// Act
+		err := s.Update(ctx, 1, UpdateReq{NombreArchivo: &nombre, Extension: &extension})
+
+		// Assert
+		require.NoError(t, err)
+		repo.AssertNotCalled(t, "ApplyVersion", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe fallar si el documento no existe", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		nombre := "otro"
+		repo.On("GetByID", ctx, uint(9)).Return(nil, gorm.ErrRecordNotFound)
+
+		// Act
+		err := s.Update(ctx, 9, UpdateReq{NombreArchivo: &nombre})
+
+		// Assert
+		assert.Error(t, err)
+		repo.AssertNotCalled(t, "ApplyVersion", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_ReemplazarContenido(t *testing.T) {
+	ctx := context.Background()
+	checksum := checksumDe(contenidoPDF)
+	actual := func() *Documento {
+		return &Documento{ID: 1, SolicitudID: 1, NombreArchivo: "cv", Extension: "pdf", Version: 1, ClaveAlmacenamiento: "blobs/ab/abc", Checksum: "abc"}
+	}
+
+	t.Run("debe guardar el nuevo contenido como una versión y encolar su análisis", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, store, cola := setupService(t, repo, Config{})
+		repo.On("GetByID", ctx, uint(1)).Return(actual(), nil)
+		repo.On("GetBlob", ctx, checksum).Return(nil, gorm.ErrRecordNotFound)
+		repo.On("ApplyVersion", ctx, uint(1), mock.MatchedBy(func(v *Version) bool {
+			return v.Checksum == checksum && v.ClaveAlmacenamiento == storage.ContentKey(checksum) &&
+				v.TipoMime == "application/pdf" && v.Cambios == "contenido" && v.NombreArchivo == "cv"
+		})).Return(nil)
+		cola.On("Encolar", uint(1)).Return()
+
+		// Act
+		_, err := s.ReemplazarContenido(ctx, 1, ContenidoReq{Archivo: bytes.NewReader(contenidoPDF), Extension: "pdf"})
+
+		// Assert
+		require.NoError(t, err)
+		archivo, err := store.Open(ctx, storage.ContentKey(checksum))
+		require.NoError(t, err)
+		archivo.Close()
+		repo.AssertExpectations(t)
+		cola.AssertExpectations(t)
+	})
+
+	t.Run("debe registrar el cambio de extensión", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, cola := setupService(t, repo, Config{})
+		documento := actual()
+		documento.Extension = "txt"
+		repo.On("GetByID", ctx, uint(1)).Return(documento, nil)
+		repo.On("GetBlob", ctx, checksum).Return(nil, gorm.ErrRecordNotFound)
+		repo.On("ApplyVersion", ctx, uint(1), mock.MatchedBy(func(v *Version) bool {
+			return v.Extension == "pdf" && v.Cambios == "contenido, extension"
+		})).Return(nil)
+		cola.On("Encolar", uint(1)).Return()
+
+		// Act
+		_, err := s.ReemplazarContenido(ctx, 1, ContenidoReq{Archivo: bytes.NewReader(contenidoPDF), Extension: "pdf"})
+
+		// Assert
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe eliminar el archivo nuevo si no se pudo registrar la versión", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, store, cola := setupService(t, repo, Config{})
+		repo.On("GetByID", ctx, uint(1)).Return(actual(), nil)
+		repo.On("GetBlob", mock.Anything, checksum).Return(nil, gorm.ErrRecordNotFound)
+		repo.On("ApplyVersion", ctx, uint(1), mock.Anything).Return(assert.AnError)
+
+		// Act
+		_, err := s.ReemplazarContenido(ctx, 1, ContenidoReq{Archivo: bytes.NewReader(contenidoPDF), Extension: "pdf"})
+
+		// Assert
+		assert.ErrorIs(t, err, assert.AnError)
+		_, err = store.Open(ctx, storage.ContentKey(checksum))
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+		cola.AssertNotCalled(t, "Encolar", mock.Anything)
+	})
+
+	t.Run("debe fallar si el documento no existe", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		repo.On("GetByID", ctx, uint(9)).Return(nil, gorm.ErrRecordNotFound)
+
+		// Act
+		_, err := s.ReemplazarContenido(ctx, 9, ContenidoReq{Archivo: bytes.NewReader(contenidoPDF), Extension: "pdf"})
+
+		// Assert
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+func TestService_GetVersiones(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("debe retornar el historial registrado", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		versiones := []Version{{Numero: 2, Cambios: "contenido"}, {Numero: 1, Cambios: "versión inicial"}}
+		repo.On("GetByID", ctx, uint(1)).Return(&Documento{ID: 1, Version: 2}, nil)
+		repo.On("GetVersiones", ctx, uint(1)).Return(versiones, nil)
+
+		// Act
+		result, err := s.GetVersiones(ctx, 1)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, versiones, result)
+	})
+
+	t.Run("debe presentar el estado actual como versión inicial de un documento sin historial", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		creado := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+		repo.On("GetByID", ctx, uint(1)).Return(&Documento{ID: 1, Version: 1, NombreArchivo: "cv", Extension: "pdf", CreatedAt: creado}, nil)
+		repo.On("GetVersiones", ctx, uint(1)).Return([]Version{}, nil)
+
+		// Act
+		result, err := s.GetVersiones(ctx, 1)
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, 1, result[0].Numero)
+		assert.Equal(t, "versión inicial", result[0].Cambios)
+		assert.Equal(t, creado, result[0].CreatedAt)
+	})
+}
+
+func TestService_GetVersion(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("debe presentar el estado actual si la versión aún no está en el historial", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		repo.On("GetByID", ctx, uint(1)).Return(&Documento{ID: 1, Version: 1, NombreArchivo: "cv"}, nil)
+		repo.On("GetVersion", ctx, uint(1), 1).Return(nil, gorm.ErrRecordNotFound)
+
+		// Act
+		version, err := s.GetVersion(ctx, 1, 1)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "cv", version.NombreArchivo)
+	})
+
+	t.Run("debe fallar con una versión que no existe", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		repo.On("GetByID", ctx, uint(1)).Return(&Documento{ID: 1, Version: 1}, nil)
+		repo.On("GetVersion", ctx, uint(1), 5).Return(nil, gorm.ErrRecordNotFound)
+
+		// Act
+		_, err := s.GetVersion(ctx, 1, 5)
+
+		// Assert
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+func TestService_RestaurarVersion(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("debe aplicar el estado de la versión anterior como una versión nueva", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, cola := setupService(t, repo, Config{})
+		usuario := uint(3)
+		anterior := &Version{ID: 4, Numero: 1, NombreArchivo: "cv", Extension: "pdf", Checksum: "abc", ClaveAlmacenamiento: "blobs/ab/abc", Cambios: "versión inicial", CreatedAt: time.Now()}
+		repo.On("GetByID", ctx, uint(1)).Return(&Documento{ID: 1, SolicitudID: 1, Version: 3}, nil)
+		repo.On("GetVersion", ctx, uint(1), 1).Return(anterior, nil)
+		repo.On("ApplyVersion", ctx, uint(1), mock.MatchedBy(func(v *Version) bool {
+			return v.Checksum == "abc" && v.NombreArchivo == "cv" && v.Cambios == "restauración de la versión 1" &&
+				*v.UsuarioID == 3 && v.CreatedAt.IsZero()
+		})).Return(nil)
+		cola.On("Encolar", uint(1)).Return()
+
+		// Act
+		_, err := s.RestaurarVersion(ctx, 1, 1, &usuario)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "versión inicial", anterior.Cambios, "la versión restaurada no se modifica")
+		repo.AssertExpectations(t)
+		cola.AssertExpectations(t)
+	})
+
+	t.Run("debe fallar con una versión que no existe", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, cola := setupService(t, repo, Config{})
+		repo.On("GetByID", ctx, uint(1)).Return(&Documento{ID: 1, Version: 3}, nil)
+		repo.On("GetVersion", ctx, uint(1), 7).Return(nil, gorm.ErrRecordNotFound)
+
+		// Act
+		_, err := s.RestaurarVersion(ctx, 1, 7, nil)
+
+		// Assert
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		repo.AssertNotCalled(t, "ApplyVersion", mock.Anything, mock.Anything, mock.Anything)
+		cola.AssertNotCalled(t, "Encolar", mock.Anything)
+	})
+}
+
+func TestService_Buscar(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("debe retornar los documentos con los fragmentos que coinciden", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		repo.On("Buscar", ctx, BuscarReq{Q: "golang", Limit: resultadosBusquedaPorDefecto}).Return([]Coincidencia{
+			{Documento: Documento{ID: 1, SolicitudID: 1}, Texto: "Cinco años de experiencia en Golang"},
+			// La solicitud 9 no existe, por lo que el documento se omite
+			{Documento: Documento{ID: 2, SolicitudID: 9}, Texto: "Golang"},
+		}, nil)
+
+		// Act
+		resultados, err := s.Buscar(ctx, BuscarReq{Q: "golang"})
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, resultados, 1)
+		assert.Equal(t, uint(1), resultados[0].ID)
+		assert.Equal(t, "Analista", resultados[0].Solicitud.Titulo)
+		assert.Equal(t, []string{"Cinco años de experiencia en Golang"}, resultados[0].Fragmentos)
+	})
+
+	t.Run("debe limitar la cantidad de resultados", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		repo.On("Buscar", ctx, BuscarReq{Q: "go", SolicitudID: 1, Limit: resultadosBusquedaPorDefecto}).Return([]Coincidencia{}, nil)
+
+		// Act
+		resultados, err := s.Buscar(ctx, BuscarReq{Q: "go", SolicitudID: 1, Limit: maxResultadosBusqueda + 1})
+
+		// Assert
+		require.NoError(t, err)
+		assert.NotNil(t, resultados)
+		assert.Empty(t, resultados)
+	})
+}
+
+// imagenPNG codifica una imagen de 512x256 píxeles
+func imagenPNG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 512, 256))))
+	return buf.Bytes()
+}
+
+func TestService_GetMiniatura(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("debe generar y registrar la miniatura si aún no existe", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, store, _ := setupService(t, repo, Config{})
+		contenido := imagenPNG(t)
+		checksum := checksumDe(contenido)
+		clave := guardarEnStorage(t, store, contenido)
+		repo.On("GetByID", ctx, uint(1)).Return(&Documento{ID: 1, TipoMime: "image/png", Checksum: checksum, ClaveAlmacenamiento: clave, EstadoEscaneo: EstadoLimpio}, nil)
+		repo.On("GetBlob", ctx, checksum).Return(&Blob{Checksum: checksum, ClaveAlmacenamiento: clave}, nil)
+		repo.On("UpdateMiniatura", ctx, checksum, claveMiniatura(checksum)).Return(nil)
+
+		// Act
+		resultado, err := s.GetMiniatura(ctx, 1)
+
+		// Assert
+		require.NoError(t, err)
+		defer resultado.Archivo.Close()
+		img, err := jpeg.Decode(resultado.Archivo)
+		require.NoError(t, err)
+		assert.Equal(t, image.Pt(256, 128), img.Bounds().Size())
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe reutilizar la miniatura ya generada", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, store, _ := setupService(t, repo, Config{})
+		_, err := store.Save(ctx, claveMiniatura("abc"), bytes.NewReader([]byte("jpeg")))
+		require.NoError(t, err)
+		repo.On("GetByID", ctx, uint(1)).Return(&Documento{ID: 1, TipoMime: "application/pdf", Checksum: "abc", ClaveAlmacenamiento: "blobs/ab/abc", EstadoEscaneo: EstadoLimpio}, nil)
+		repo.On("GetBlob", ctx, "abc").Return(&Blob{Checksum: "abc", ClaveMiniatura: claveMiniatura("abc")}, nil)
+
+		// Act
+		resultado, err := s.GetMiniatura(ctx, 1)
+
+		// Assert
+		require.NoError(t, err)
+		defer resultado.Archivo.Close()
+		leido, err := io.ReadAll(resultado.Archivo)
+		require.NoError(t, err)
+		assert.Equal(t, "jpeg", string(leido))
+		repo.AssertNotCalled(t, "UpdateMiniatura", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	casos := []struct {
+		nombre    string
+		documento *Documento
+		esperado  error
+	}{
+		{"un tipo sin miniatura", &Documento{ID: 1, TipoMime: "text/plain", Checksum: "abc", ClaveAlmacenamiento: "blobs/ab/abc", EstadoEscaneo: EstadoLimpio}, ErrSinMiniatura},
+		{"un documento sin checksum", &Documento{ID: 1, TipoMime: "image/png", ClaveAlmacenamiento: "solicitudes/1/foto.png", EstadoEscaneo: EstadoLimpio}, ErrSinMiniatura},
+		{"un documento sin contenido", &Documento{ID: 1}, ErrSinContenido},
+		{"un documento en cuarentena", &Documento{ID: 1, TipoMime: "image/png", Checksum: "abc", ClaveAlmacenamiento: "blobs/ab/abc", EstadoEscaneo: EstadoEnCuarentena}, ErrEnCuarentena},
+	}
+	for _, caso := range casos {
+		t.Run("no debe generar la miniatura de "+caso.nombre, func(t *testing.T) {
+			// Arrange
+			repo := new(mockRepository)
+			s, _, _ := setupService(t, repo, Config{})
+			repo.On("GetByID", ctx, uint(1)).Return(caso.documento, nil)
+
+			// Act
+			_, err := s.GetMiniatura(ctx, 1)
+
+			// Assert
+			assert.ErrorIs(t, err, caso.esperado)
+			repo.AssertNotCalled(t, "GetBlob", mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("debe informar que no hay miniatura si el contenido no se puede dibujar", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, store, _ := setupService(t, repo, Config{})
+		contenido := []byte("no es una imagen")
+		clave := guardarEnStorage(t, store, contenido)
+		repo.On("GetByID", ctx, uint(1)).Return(&Documento{ID: 1, TipoMime: "image/png", Checksum: "abc", ClaveAlmacenamiento: clave, EstadoEscaneo: EstadoLimpio}, nil)
+		repo.On("GetBlob", ctx, "abc").Return(&Blob{Checksum: "abc", ClaveAlmacenamiento: clave}, nil)
+
+		// Act
+		_, err := s.GetMiniatura(ctx, 1)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrSinMiniatura)
+	})
+}
+
+func TestService_GetByID(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("debe incluir la URL de la miniatura con la versión del documento", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{URLPublica: "http://documentos.local"})
+		repo.On("GetByID", ctx, uint(1)).Return(&Documento{ID: 1, SolicitudID: 1, TipoMime: "image/png", Checksum: "abc", ClaveAlmacenamiento: "blobs/ab/abc", EstadoEscaneo: EstadoLimpio, Version: 3}, nil)
+
+		// Act
+		documento, err := s.GetByID(ctx, 1)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "http://documentos.local/documentos/1/miniatura?v=3", documento.URLMiniatura)
+	})
+
+	t.Run("no debe incluir la URL de la miniatura de un documento en cuarentena", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{URLPublica: "http://documentos.local"})
+		repo.On("GetByID", ctx, uint(1)).Return(&Documento{ID: 1, SolicitudID: 1, TipoMime: "image/png", Checksum: "abc", ClaveAlmacenamiento: "blobs/ab/abc", EstadoEscaneo: EstadoEnCuarentena}, nil)
+
+		// Act
+		documento, err := s.GetByID(ctx, 1)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Empty(t, documento.URLMiniatura)
+	})
+}
+
+// enlaceDesdeURL obtiene los parámetros firmados de la URL pública de un enlace de descarga
+func enlaceDesdeURL(t *testing.T, id uint, enlace string) EnlaceFirmado {
+	t.Helper()
+	u, err := url.Parse(enlace)
+	require.NoError(t, err)
+	consulta := u.Query()
+	version, err := strconv.Atoi(consulta.Get("v"))
+	require.NoError(t, err)
+	expira, err := strconv.ParseInt(consulta.Get("exp"), 10, 64)
+	require.NoError(t, err)
+	return EnlaceFirmado{DocumentoID: id, Version: version, Expira: expira, Nonce: consulta.Get("n"), Firma: consulta.Get("firma")}
+}
+
+// storagePresignado agrega URLs directas al almacenamiento local, como lo haría S3
+type storagePresignado struct {
+	*storage.LocalStorage
+}
+
+func (s storagePresignado) PresignedURL(ctx context.Context, key, filename string, expires time.Duration) (string, error) {
+	return "https://almacenamiento.example/" + key + "?archivo=" + url.QueryEscape(filename), nil
+}
+
+func TestService_CrearEnlace(t *testing.T) {
+	ctx := context.Background()
+	config := Config{URLPublica: "http://localhost:8083", ClaveEnlaces: []byte("clave-secreta")}
+
+	t.Run("debe generar un enlace firmado para la versión actual", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, config)
+		repo.On("GetByID", ctx, uint(1)).Return(&Documento{ID: 1, Version: 3, ClaveAlmacenamiento: "blobs/abc", EstadoEscaneo: EstadoLimpio}, nil)
+
+		// Act
+		enlace, err := s.CrearEnlace(ctx, 1, EnlaceReq{})
+
+		// Assert
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(enlace.URL, "http://localhost:8083/publico/documentos/1/contenido?"))
+		assert.Equal(t, 3, enlace.Version)
+		assert.False(t, enlace.UnSoloUso)
+		assert.WithinDuration(t, time.Now().Add(vigenciaEnlacePorDefecto), enlace.ExpiraEl, time.Minute)
+		firmado := enlaceDesdeURL(t, 1, enlace.URL)
+		assert.Equal(t, 3, firmado.Version)
+		assert.Empty(t, firmado.Nonce)
+		assert.True(t, s.firmante.Verificar(firmado.Firma, partesEnlace(firmado)...))
+	})
+
+	t.Run("debe limitar la vigencia solicitada a la máxima permitida", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, config)
+		repo.On("GetByID", ctx, uint(1)).Return(&Documento{ID: 1, Version: 1, ClaveAlmacenamiento: "blobs/abc", EstadoEscaneo: EstadoLimpio}, nil)
+
+		// Act
+		corto, err := s.CrearEnlace(ctx, 1, EnlaceReq{ExpiraEnSegundos: 60})
+		require.NoError(t, err)
+		largo, err := s.CrearEnlace(ctx, 1, EnlaceReq{ExpiraEnSegundos: 30 * 24 * 3600})
+		require.NoError(t, err)
+
+		// Assert
+		assert.WithinDuration(t, time.Now().Add(time.Minute), corto.ExpiraEl, 5*time.Second)
+		assert.WithinDuration(t, time.Now().Add(vigenciaEnlaceMaxima), largo.ExpiraEl, time.Minute)
+	})
+
+	t.Run("debe incluir un nonce distinto en cada enlace de un solo uso", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, config)
+		repo.On("GetByID", ctx, uint(1)).Return(&Documento{ID: 1, Version: 1, ClaveAlmacenamiento: "blobs/abc", EstadoEscaneo: EstadoLimpio}, nil)
+
+		// Act
+		primero, err := s.CrearEnlace(ctx, 1, EnlaceReq{UnSoloUso: true})
+		require.NoError(t, err)
+		segundo, err := s.CrearEnlace(ctx, 1, EnlaceReq{UnSoloUso: true})
+		require.NoError(t, err)
+
+		// Assert
+		assert.True(t, primero.UnSoloUso)
+		nonce := enlaceDesdeURL(t, 1, primero.URL).Nonce
+		assert.Len(t, nonce, 32)
+		assert.NotEqual(t, nonce, enlaceDesdeURL(t, 1, segundo.URL).Nonce)
+	})
+
+	t.Run("no debe generar enlaces de documentos sin contenido o sin analizar", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, config)
+		repo.On("GetByID", ctx, uint(1)).Return(&Documento{ID: 1}, nil)
+		repo.On("GetByID", ctx, uint(2)).Return(&Documento{ID: 2, ClaveAlmacenamiento: "blobs/abc", EstadoEscaneo: EstadoPendienteEscaneo}, nil)
+		repo.On("GetByID", ctx, uint(3)).Return(&Documento{ID: 3, ClaveAlmacenamiento: "blobs/abc", EstadoEscaneo: EstadoEnCuarentena}, nil)
+
+		// Act
+		_, errSinContenido := s.CrearEnlace(ctx, 1, EnlaceReq{})
+		_, errPendiente := s.CrearEnlace(ctx, 2, EnlaceReq{})
+		_, errCuarentena := s.CrearEnlace(ctx, 3, EnlaceReq{})
+
+		// Assert
+		assert.ErrorIs(t, errSinContenido, ErrSinContenido)
+		assert.ErrorIs(t, errPendiente, ErrEscaneoPendiente)
+		assert.ErrorIs(t, errCuarentena, ErrEnCuarentena)
+	})
+}
+
+func TestService_GetContenidoEnlace(t *testing.T) {
+	ctx := context.Background()
+	config := Config{URLPublica: "http://localhost:8083", ClaveEnlaces: []byte("clave-secreta")}
+	contenido := []byte("contenido compartido")
+	checksum := checksumDe(contenido)
+
+	// firmar crea un enlace válido para la versión 1 del documento 1 con los datos indicados
+	firmar := func(s *service, expira time.Time, nonce string) EnlaceFirmado {
+		enlace := EnlaceFirmado{DocumentoID: 1, Version: 1, Expira: expira.Unix(), Nonce: nonce}
+		enlace.Firma = s.firmante.Firmar(partesEnlace(enlace)...)
+		return enlace
+	}
+	// esperarVersion prepara el documento 1 con su versión 1 ya analizada
+	esperarVersion := func(repo *mockRepository, clave string) {
+		repo.On("GetByID", ctx, uint(1)).Return(&Documento{ID: 1, Version: 2}, nil)
+		repo.On("GetVersion", ctx, uint(1), 1).Return(&Version{
+			Numero: 1, NombreArchivo: "cv", Extension: "txt", TipoMime: "text/plain",
+			Checksum: checksum, ClaveAlmacenamiento: clave,
+		}, nil)
+		repo.On("GetBlob", ctx, checksum).Return(&Blob{Checksum: checksum, EstadoEscaneo: EstadoLimpio}, nil)
+	}
+
+	t.Run("debe abrir la versión indicada en el enlace", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, store, _ := setupService(t, repo, config)
+		esperarVersion(repo, guardarEnStorage(t, store, contenido))
+
+		// Act
+		resultado, err := s.GetContenidoEnlace(ctx, firmar(s, time.Now().Add(time.Hour), ""))
+
+		// Assert
+		require.NoError(t, err)
+		defer resultado.Archivo.Close()
+		leido, err := io.ReadAll(resultado.Archivo)
+		require.NoError(t, err)
+		assert.Equal(t, contenido, leido)
+		assert.Equal(t, 1, resultado.Documento.Version)
+		repo.AssertNotCalled(t, "ConsumirEnlace", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe rechazar un enlace con parámetros modificados", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, config)
+		enlace := firmar(s, time.Now().Add(time.Hour), "")
+		enlace.Version = 2
+
+		// Act
+		_, err := s.GetContenidoEnlace(ctx, enlace)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrEnlaceInvalido)
+		repo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe rechazar un enlace firmado con otra clave", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		otro, _, _ := setupService(t, repo, Config{ClaveEnlaces: []byte("otra-clave")})
+		s, _, _ := setupService(t, repo, config)
+
+		// Act
+		_, err := s.GetContenidoEnlace(ctx, firmar(otro, time.Now().Add(time.Hour), ""))
+
+		// Assert
+		assert.ErrorIs(t, err, ErrEnlaceInvalido)
+	})
+
+	t.Run("debe rechazar un enlace expirado", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, config)
+
+		// Act
+		_, err := s.GetContenidoEnlace(ctx, firmar(s, time.Now().Add(-time.Minute), ""))
+
+		// Assert
+		assert.ErrorIs(t, err, ErrEnlaceExpirado)
+		repo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe fallar si el documento fue eliminado", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, config)
+		repo.On("GetByID", ctx, uint(1)).Return(nil, gorm.ErrRecordNotFound)
+
+		// Act
+		_, err := s.GetContenidoEnlace(ctx, firmar(s, time.Now().Add(time.Hour), ""))
+
+		// Assert
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("debe consumir el enlace de un solo uso", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, store, _ := setupService(t, repo, config)
+		esperarVersion(repo, guardarEnStorage(t, store, contenido))
+		expira := time.Now().Add(time.Hour)
+		nonce := "0123456789abcdef0123456789abcdef"
+		repo.On("ConsumirEnlace", ctx, &EnlaceUsado{Nonce: nonce, DocumentoID: 1, ExpiraEl: time.Unix(expira.Unix(), 0)}).Return(nil).Once()
+		repo.On("ConsumirEnlace", ctx, mock.Anything).Return(ErrEnlaceUsado)
+		enlace := firmar(s, expira, nonce)
+
+		// Act
+		primero, errPrimero := s.GetContenidoEnlace(ctx, enlace)
+		_, errSegundo := s.GetContenidoEnlace(ctx, enlace)
+
+		// Assert
+		require.NoError(t, errPrimero)
+		primero.Archivo.Close()
+		assert.ErrorIs(t, errSegundo, ErrEnlaceUsado)
+		repo.AssertNumberOfCalls(t, "ConsumirEnlace", 2)
+	})
+
+	t.Run("debe redirigir al almacenamiento si admite URLs directas", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		local, err := storage.NewLocalStorage(t.TempDir())
+		require.NoError(t, err)
+		s := NewService(repo, log.New(io.Discard, "", 0), nuevoServidorSolicitudes(t), storagePresignado{local}, new(mockColaEscaneo), config).(*service)
+		esperarVersion(repo, guardarEnStorage(t, local, contenido))
+
+		// Act
+		resultado, err := s.GetContenidoEnlace(ctx, firmar(s, time.Now().Add(time.Hour), ""))
+
+		// Assert
+		require.NoError(t, err)
+		assert.Nil(t, resultado.Archivo)
+		assert.Equal(t, "https://almacenamiento.example/"+storage.ContentKey(checksum)+"?archivo=cv.txt", resultado.URL)
+	})
+
+	t.Run("no debe redirigir los enlaces de un solo uso", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		local, err := storage.NewLocalStorage(t.TempDir())
+		require.NoError(t, err)
+		s := NewService(repo, log.New(io.Discard, "", 0), nuevoServidorSolicitudes(t), storagePresignado{local}, new(mockColaEscaneo), config).(*service)
+		esperarVersion(repo, guardarEnStorage(t, local, contenido))
+		repo.On("ConsumirEnlace", ctx, mock.Anything).Return(nil)
+
+		// Act
+		resultado, err := s.GetContenidoEnlace(ctx, firmar(s, time.Now().Add(time.Hour), "0123456789abcdef0123456789abcdef"))
+
+		// Assert
+		require.NoError(t, err)
+		defer resultado.Archivo.Close()
+		assert.Empty(t, resultado.URL)
+	})
+}
+
+func TestService_GetDocumentosZip(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("debe obtener los documentos de la solicitud", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		repo.On("GetAll", ctx, GetAllReq{SolicitudID: 1}).Return([]Documento{{ID: 1}, {ID: 2}}, nil)
+
+		// Act
+		documentos, err := s.GetDocumentosZip(ctx, 1)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Len(t, documentos, 2)
+	})
+
+	t.Run("debe fallar si la solicitud no tiene documentos", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		repo.On("GetAll", ctx, GetAllReq{SolicitudID: 1}).Return([]Documento{}, nil)
+
+		// Act
+		_, err := s.GetDocumentosZip(ctx, 1)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrSolicitudSinDocumentos)
+	})
+}
+
+func TestService_EscribirZip(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("debe incluir los documentos limpios y describir todos en el manifiesto", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, store, _ := setupService(t, repo, Config{})
+		cv := []byte("curriculum")
+		otroCV := []byte("otro curriculum")
+		documentos := []Documento{
+			{ID: 1, NombreArchivo: "cv", Extension: "pdf", Version: 1, Checksum: checksumDe(cv), ClaveAlmacenamiento: guardarEnStorage(t, store, cv), EstadoEscaneo: EstadoLimpio, Categoria: "cv"},
+			{ID: 2, NombreArchivo: "CV", Extension: "pdf", Version: 2, ClaveAlmacenamiento: guardarEnStorage(t, store, otroCV), EstadoEscaneo: EstadoLimpio},
+			{ID: 3, NombreArchivo: "virus", Extension: "exe", ClaveAlmacenamiento: "blobs/xx/infectado", EstadoEscaneo: EstadoEnCuarentena},
+			{ID: 4, NombreArchivo: "pendiente", Extension: "txt", ClaveAlmacenamiento: "blobs/xx/pendiente", EstadoEscaneo: EstadoPendienteEscaneo},
+			{ID: 5, NombreArchivo: "legado", Extension: "doc"},
+			{ID: 6, NombreArchivo: "perdido", Extension: "pdf", ClaveAlmacenamiento: "blobs/xx/perdido", EstadoEscaneo: EstadoLimpio},
+		}
+		var buf bytes.Buffer
+
+		// Act
+		err := s.EscribirZip(ctx, documentos, &buf)
+
+		// Assert
+		require.NoError(t, err)
+		nombres, entradas := leerZip(t, buf.Bytes())
+		assert.Equal(t, []string{"cv.pdf", "CV (2).pdf", nombreManifiesto}, nombres)
+		assert.Equal(t, cv, entradas["cv.pdf"])
+		assert.Equal(t, otroCV, entradas["CV (2).pdf"])
+
+		filas := leerManifiesto(t, entradas[nombreManifiesto])
+		require.Len(t, filas, 7)
+		assert.Equal(t, []string{"archivo", "documento_id", "nombre_archivo", "categoria", "version", "tamano", "checksum", "fecha_carga", "estado_escaneo", "incluido"}, filas[0])
+		assert.Equal(t, []string{"cv.pdf", "1", "cv.pdf", "cv", "1", "0", checksumDe(cv)}, filas[1][:7])
+		assert.Equal(t, "si", filas[1][9])
+		assert.Equal(t, "si", filas[2][9])
+		assert.Equal(t, "no: "+EstadoEnCuarentena, filas[3][9])
+		assert.Equal(t, "no: "+EstadoPendienteEscaneo, filas[4][9])
+		assert.Equal(t, "no: sin contenido", filas[5][9])
+		assert.Equal(t, "no: archivo no disponible", filas[6][9])
+		assert.Empty(t, filas[6][0])
+	})
+
+	t.Run("debe detenerse si se cancela el contexto", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		cancelado, cancel := context.WithCancel(ctx)
+		cancel()
+
+		// Act
+		err := s.EscribirZip(cancelado, []Documento{{ID: 1}}, io.Discard)
+
+		// Assert
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestService_VerificarCuota(t *testing.T) {
+	ctx := context.Background()
+	usuarioID := uint(4)
+	config := Config{Cuotas: Cuotas{
+		PorSolicitud: Cuota{MaxArchivos: 10},
+		PorUsuario:   Cuota{MaxBytes: 1000},
+	}}
+
+	t.Run("debe verificar las cuotas de la solicitud y del usuario", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, config)
+		repo.On("GetUsoSolicitud", ctx, uint(1)).Return(Uso{Archivos: 2, Bytes: 500}, nil)
+		repo.On("GetUsoUsuario", ctx, usuarioID).Return(Uso{Archivos: 1, Bytes: 200}, nil)
+
+		// Act
+		err := s.VerificarCuota(ctx, 1, &usuarioID, 800)
+
+		// Assert
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe fallar si se supera la cuota del usuario", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, config)
+		repo.On("GetUsoSolicitud", ctx, uint(1)).Return(Uso{Archivos: 2}, nil)
+		repo.On("GetUsoUsuario", ctx, usuarioID).Return(Uso{Archivos: 1, Bytes: 200}, nil)
+
+		// Act
+		err := s.VerificarCuota(ctx, 1, &usuarioID, 801)
+
+		// Assert
+		var errCuota *ErrorCuota
+		require.ErrorAs(t, err, &errCuota)
+		assert.Equal(t, AmbitoUsuario, errCuota.Ambito)
+	})
+
+	t.Run("no debe consultar el uso de un ámbito sin cuota", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+
+		// Act
+		err := s.VerificarCuota(ctx, 1, &usuarioID, 1<<30)
+
+		// Assert
+		assert.NoError(t, err)
+		repo.AssertNotCalled(t, "GetUsoSolicitud", mock.Anything, mock.Anything)
+		repo.AssertNotCalled(t, "GetUsoUsuario", mock.Anything, mock.Anything)
+	})
+
+	t.Run("no debe verificar la cuota de usuario si no se identifica", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, config)
+		repo.On("GetUsoSolicitud", ctx, uint(1)).Return(Uso{Archivos: 2}, nil)
+
+		// Act
+		err := s.VerificarCuota(ctx, 1, nil, 5000)
+
+		// Assert
+		assert.NoError(t, err)
+		repo.AssertNotCalled(t, "GetUsoUsuario", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe fallar si no se puede obtener el uso", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, config)
+		repo.On("GetUsoSolicitud", ctx, uint(1)).Return(Uso{}, assert.AnError)
+
+		// Act
+		err := s.VerificarCuota(ctx, 1, nil, 10)
+
+		// Assert
+		assert.ErrorContains(t, err, assert.AnError.Error())
+	})
+}
+
+func TestService_Cuotas(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("no debe guardar el archivo si supera la cuota de la solicitud", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, store, _ := setupService(t, repo, Config{Cuotas: Cuotas{PorSolicitud: Cuota{MaxBytes: 50}}})
+		repo.On("GetUsoSolicitud", ctx, uint(1)).Return(Uso{Archivos: 1, Bytes: 10}, nil)
+
+		// Act
+		_, err := s.Create(ctx, CreateReq{Extension: "pdf", NombreArchivo: "cv", SolicitudID: 1, Archivo: bytes.NewReader(contenidoPDF)})
+
+		// Assert
+		var errCuota *ErrorCuota
+		require.ErrorAs(t, err, &errCuota)
+		assert.Equal(t, LimiteBytes, errCuota.Limite)
+		_, err = store.Open(ctx, storage.ContentKey(checksumDe(contenidoPDF)))
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe contar solo la diferencia de tamaño al reemplazar el contenido", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{Cuotas: Cuotas{PorSolicitud: Cuota{MaxArchivos: 1, MaxBytes: 100}}})
+		repo.On("GetByID", ctx, uint(1)).Return(&Documento{ID: 1, SolicitudID: 1, Extension: "pdf", Tamano: 10}, nil)
+		repo.On("GetUsoSolicitud", ctx, uint(1)).Return(Uso{Archivos: 1, Bytes: 60}, nil)
+
+		// Act
+		_, err := s.ReemplazarContenido(ctx, 1, ContenidoReq{Extension: "pdf", Archivo: bytes.NewReader(contenidoPDF)})
+
+		// Assert
+		var errCuota *ErrorCuota
+		require.ErrorAs(t, err, &errCuota)
+		assert.Equal(t, LimiteBytes, errCuota.Limite, "reemplazar no cuenta como un archivo más")
+	})
+
+	t.Run("debe volver a verificar la cuota al registrar el documento y descartar el archivo", func(t *testing.T) {
+		// Arrange: otra carga ocupa el último archivo disponible entre la primera verificación y el registro
+		repo := new(mockRepository)
+		s, store, _ := setupService(t, repo, Config{Cuotas: Cuotas{PorSolicitud: Cuota{MaxArchivos: 1}}})
+		checksum := checksumDe(contenidoPDF)
+		repo.On("GetUsoSolicitud", ctx, uint(1)).Return(Uso{}, nil).Once()
+		repo.On("GetUsoSolicitud", ctx, uint(1)).Return(Uso{Archivos: 1, Bytes: 10}, nil)
+		repo.On("GetBlob", mock.Anything, checksum).Return(nil, gorm.ErrRecordNotFound)
+
+		// Act
+		_, err := s.Create(ctx, CreateReq{Extension: "pdf", NombreArchivo: "cv", SolicitudID: 1, Archivo: bytes.NewReader(contenidoPDF)})
+
+		// Assert
+		var errCuota *ErrorCuota
+		require.ErrorAs(t, err, &errCuota)
+		assert.Equal(t, LimiteArchivos, errCuota.Limite)
+		_, err = store.Open(ctx, storage.ContentKey(checksum))
+		assert.ErrorIs(t, err, storage.ErrNotFound, "el archivo guardado se descarta")
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_GetUso(t *testing.T) {
+	ctx := context.Background()
+	usuarioID := uint(4)
+
+	t.Run("debe informar el uso con los límites configurados", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{Cuotas: Cuotas{PorSolicitud: Cuota{MaxArchivos: 10, MaxBytes: 1000}}})
+		repo.On("GetUsoSolicitud", ctx, uint(1)).Return(Uso{Archivos: 2, Bytes: 300}, nil)
+		repo.On("GetUsoUsuario", ctx, usuarioID).Return(Uso{Archivos: 5, Bytes: 900}, nil)
+
+		// Act
+		uso, err := s.GetUso(ctx, UsoReq{SolicitudID: 1, UsuarioID: &usuarioID})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, &Uso{Archivos: 2, Bytes: 300, MaxArchivos: 10, MaxBytes: 1000}, uso.Solicitud)
+		assert.Equal(t, &Uso{Archivos: 5, Bytes: 900}, uso.Usuario)
+	})
+
+	t.Run("debe informar solo el ámbito consultado", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		repo.On("GetUsoUsuario", ctx, usuarioID).Return(Uso{Archivos: 5}, nil)
+
+		// Act
+		uso, err := s.GetUso(ctx, UsoReq{UsuarioID: &usuarioID})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Nil(t, uso.Solicitud)
+		assert.Equal(t, int64(5), uso.Usuario.Archivos)
+	})
+}
+
+func TestService_GetPapelera(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("debe indicar si la solicitud de cada documento sigue vigente", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		eliminadoEl := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+		repo.On("GetEliminados", ctx, GetAllReq{Limit: 10}).Return([]Documento{
+			{ID: 1, SolicitudID: 1, NombreArchivo: "cv", DeletedAt: gorm.DeletedAt{Time: eliminadoEl, Valid: true}},
+			{ID: 2, SolicitudID: 9, NombreArchivo: "carta", DeletedAt: gorm.DeletedAt{Time: eliminadoEl, Valid: true}},
+		}, nil)
+
+		// Act
+		eliminados, err := s.GetPapelera(ctx, GetAllReq{Limit: 10})
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, eliminados, 2)
+		assert.True(t, eliminados[0].SolicitudVigente)
+		assert.Equal(t, "Analista", eliminados[0].Solicitud.Titulo)
+		assert.Equal(t, eliminadoEl, eliminados[0].EliminadoEl)
+		assert.False(t, eliminados[1].SolicitudVigente)
+		assert.Equal(t, uint(9), eliminados[1].Solicitud.ID)
+	})
+
+	t.Run("debe retornar una lista vacía si la papelera está vacía", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		repo.On("GetEliminados", ctx, GetAllReq{}).Return([]Documento{}, nil)
+
+		// Act
+		eliminados, err := s.GetPapelera(ctx, GetAllReq{})
+
+		// Assert
+		require.NoError(t, err)
+		assert.NotNil(t, eliminados)
+		assert.Empty(t, eliminados)
+	})
+}
+
+func TestService_Restaurar(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("debe restaurar el documento y retomar su análisis pendiente", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, cola := setupService(t, repo, Config{})
+		repo.On("GetEliminadoByID", ctx, uint(1)).Return(&Documento{ID: 1, SolicitudID: 1, EstadoEscaneo: EstadoPendienteEscaneo}, nil)
+		repo.On("Restaurar", ctx, uint(1)).Return(nil)
+		repo.On("GetByID", ctx, uint(1)).Return(&Documento{ID: 1, SolicitudID: 1, NombreArchivo: "cv"}, nil)
+		cola.On("Encolar", uint(1)).Return()
+
+		// Act
+		documento, err := s.Restaurar(ctx, 1)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "cv", documento.NombreArchivo)
+		repo.AssertExpectations(t)
+		cola.AssertExpectations(t)
+	})
+
+	t.Run("no debe encolar un documento ya analizado", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, cola := setupService(t, repo, Config{})
+		repo.On("GetEliminadoByID", ctx, uint(1)).Return(&Documento{ID: 1, SolicitudID: 1, EstadoEscaneo: EstadoLimpio}, nil)
+		repo.On("Restaurar", ctx, uint(1)).Return(nil)
+		repo.On("GetByID", ctx, uint(1)).Return(&Documento{ID: 1, SolicitudID: 1}, nil)
+
+		// Act
+		_, err := s.Restaurar(ctx, 1)
+
+		// Assert
+		require.NoError(t, err)
+		cola.AssertNotCalled(t, "Encolar", mock.Anything)
+	})
+
+	t.Run("no debe restaurar un documento cuya solicitud fue eliminada", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		repo.On("GetEliminadoByID", ctx, uint(2)).Return(&Documento{ID: 2, SolicitudID: 9}, nil)
+
+		// Act
+		_, err := s.Restaurar(ctx, 2)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrSolicitudEliminada)
+		repo.AssertNotCalled(t, "Restaurar", mock.Anything, mock.Anything)
+	})
+
+	t.Run("no debe restaurar un documento que supera la cuota", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{Cuotas: Cuotas{PorSolicitud: Cuota{MaxArchivos: 3}}})
+		repo.On("GetEliminadoByID", ctx, uint(1)).Return(&Documento{ID: 1, SolicitudID: 1, Tamano: 10}, nil)
+		repo.On("GetUsoSolicitud", ctx, uint(1)).Return(Uso{Archivos: 3}, nil)
+
+		// Act
+		_, err := s.Restaurar(ctx, 1)
+
+		// Assert
+		var errCuota *ErrorCuota
+		assert.ErrorAs(t, err, &errCuota)
+		repo.AssertNotCalled(t, "Restaurar", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe fallar si el documento no está en la papelera", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		repo.On("GetEliminadoByID", ctx, uint(5)).Return(nil, gorm.ErrRecordNotFound)
+
+		// Act
+		_, err := s.Restaurar(ctx, 5)
+
+		// Assert
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+func TestService_Categorias(t *testing.T) {
+	ctx := context.Background()
+	config := Config{Politica: politicaConCategorias}
+
+	t.Run("debe guardar la categoría del documento", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, cola := setupService(t, repo, config)
+		repo.On("GetBlob", ctx, checksumDe(contenidoPDF)).Return(nil, gorm.ErrRecordNotFound)
+		repo.On("Create", ctx, mock.MatchedBy(func(d *Documento) bool {
+			return d.Categoria == "descripcion_cargo"
+		})).Return(nil)
+		cola.On("Encolar", mock.Anything).Return()
+
+		// Act
+		documento, err := s.Create(ctx, CreateReq{Extension: "pdf", NombreArchivo: "cargo", SolicitudID: 1, Categoria: "descripcion_cargo", Archivo: bytes.NewReader(contenidoPDF)})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "descripcion_cargo", documento.Categoria)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("no debe crear un documento con una categoría fuera del catálogo", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, config)
+
+		// Act
+		_, err := s.Create(ctx, CreateReq{Extension: "pdf", NombreArchivo: "nda", SolicitudID: 1, Categoria: "nda"})
+
+		// Assert
+		var errValidacion *ErrorValidacionArchivo
+		require.ErrorAs(t, err, &errValidacion)
+		assert.Equal(t, CodigoCategoriaInvalida, errValidacion.Codigo)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe cambiar la categoría sin crear una versión", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, config)
+		categoria := "otro"
+		repo.On("GetByID", ctx, uint(1)).Return(&Documento{ID: 1, Extension: "pdf", Categoria: "descripcion_cargo"}, nil)
+		repo.On("UpdateCategoria", ctx, uint(1), "otro").Return(nil)
+
+		// Act
+		err := s.Update(ctx, 1, UpdateReq{Categoria: &categoria})
+
+		// Assert
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+		repo.AssertNotCalled(t, "ApplyVersion", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("no debe cambiar a una categoría que no acepta la extensión del documento", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, config)
+		categoria := "descripcion_cargo"
+		repo.On("GetByID", ctx, uint(1)).Return(&Documento{ID: 1, Extension: "png", Categoria: "otro"}, nil)
+
+		// Act
+		err := s.Update(ctx, 1, UpdateReq{Categoria: &categoria})
+
+		// Assert
+		var errValidacion *ErrorValidacionArchivo
+		require.ErrorAs(t, err, &errValidacion)
+		assert.Equal(t, CodigoTipoNoPermitido, errValidacion.Codigo)
+		repo.AssertNotCalled(t, "UpdateCategoria", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe retornar el catálogo configurado", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, config)
+
+		// Act
+		categorias := s.GetCategorias(ctx)
+
+		// Assert
+		assert.Equal(t, politicaConCategorias.Catalogo(), categorias)
+	})
+}
+
+func TestService_Etiquetado(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("debe crear el documento con sus etiquetas y metadatos normalizados", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		repo.On("Create", ctx, mock.MatchedBy(func(d *Documento) bool {
+			return assert.ObjectsAreEqual([]Etiqueta{{Nombre: "confidencial"}}, d.Etiquetas) &&
+				assert.ObjectsAreEqual([]Metadato{{Clave: "autor", Tipo: TipoTexto, Valor: "Karla"}}, d.Metadatos)
+		})).Return(nil)
+
+		// Act
+		documento, err := s.Create(ctx, CreateReq{
+			Extension: "pdf", NombreArchivo: "cv", SolicitudID: 1,
+			Etiquetas: []string{"Confidencial", "confidencial"},
+			Metadatos: []Metadato{{Clave: "Autor", Valor: "Karla"}},
+		})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, []string{"confidencial"}, documento.Etiquetas)
+		assert.Equal(t, []Metadato{{Clave: "autor", Tipo: TipoTexto, Valor: "Karla"}}, documento.Metadatos)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("no debe crear el documento con metadatos inválidos", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+
+		// Act
+		_, err := s.Create(ctx, CreateReq{
+			Extension: "pdf", NombreArchivo: "cv", SolicitudID: 1,
+			Metadatos: []Metadato{{Clave: "monto", Tipo: TipoNumero, Valor: "mil"}},
+		})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrEtiquetadoInvalido)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe reemplazar las etiquetas y metadatos sin crear una versión", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		etiquetas := []string{"Urgente"}
+		metadatos := []Metadato{{Clave: "emision", Tipo: TipoFecha, Valor: "2026-03-01"}}
+		repo.On("GetByID", ctx, uint(1)).Return(&Documento{ID: 1, Extension: "pdf"}, nil)
+		repo.On("ReemplazarEtiquetas", ctx, uint(1), []Etiqueta{{Nombre: "urgente"}}).Return(nil)
+		repo.On("ReemplazarMetadatos", ctx, uint(1), metadatos).Return(nil)
+
+		// Act
+		err := s.Update(ctx, 1, UpdateReq{Etiquetas: &etiquetas, Metadatos: &metadatos})
+
+		// Assert
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+		repo.AssertNotCalled(t, "ApplyVersion", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe eliminar todas las etiquetas con una lista vacía", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		etiquetas := []string{}
+		repo.On("GetByID", ctx, uint(1)).Return(&Documento{ID: 1, Etiquetas: []Etiqueta{{DocumentoID: 1, Nombre: "urgente"}}}, nil)
+		repo.On("ReemplazarEtiquetas", ctx, uint(1), []Etiqueta{}).Return(nil)
+
+		// Act
+		err := s.Update(ctx, 1, UpdateReq{Etiquetas: &etiquetas})
+
+		// Assert
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+		repo.AssertNotCalled(t, "ReemplazarMetadatos", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("no debe aplicar ningún cambio si las etiquetas son inválidas", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		nombre := "cv nuevo"
+		etiquetas := []string{strings.Repeat("x", largoMaximoNombre+1)}
+		repo.On("GetByID", ctx, uint(1)).Return(&Documento{ID: 1, NombreArchivo: "cv"}, nil)
+
+		// Act
+		err := s.Update(ctx, 1, UpdateReq{NombreArchivo: &nombre, Etiquetas: &etiquetas})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrEtiquetadoInvalido)
+		repo.AssertNotCalled(t, "ApplyVersion", mock.Anything, mock.Anything, mock.Anything)
+		repo.AssertNotCalled(t, "ReemplazarEtiquetas", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe detener la actualización y retornar el error si falla un cambio", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		nombre := "cv nuevo"
+		etiquetas := []string{"urgente"}
+		metadatos := []Metadato{{Clave: "emision", Tipo: TipoFecha, Valor: "2026-03-01"}}
+		repo.On("GetByID", ctx, uint(1)).Return(&Documento{ID: 1, NombreArchivo: "cv"}, nil)
+		repo.On("ApplyVersion", ctx, uint(1), mock.Anything).Return(nil)
+		repo.On("ReemplazarEtiquetas", ctx, uint(1), mock.Anything).Return(assert.AnError)
+
+		// Act
+		err := s.Update(ctx, 1, UpdateReq{NombreArchivo: &nombre, Etiquetas: &etiquetas, Metadatos: &metadatos})
+
+		// Assert: el error revierte la transacción, incluida la nueva versión
+		assert.ErrorIs(t, err, assert.AnError)
+		repo.AssertNotCalled(t, "ReemplazarMetadatos", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_Vencimientos(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("debe crear el documento con su fecha de vencimiento", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		repo.On("Create", ctx, mock.MatchedBy(func(d *Documento) bool {
+			return d.VenceEl != nil && d.VenceEl.Equal(time.Date(2026, 12, 31, 0, 0, 0, 0, time.Local))
+		})).Return(nil)
+
+		// Act
+		_, err := s.Create(ctx, CreateReq{Extension: "pdf", NombreArchivo: "nda", SolicitudID: 1, VenceEl: "2026-12-31"})
+
+		// Assert
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("no debe crear el documento con una fecha de vencimiento inválida", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+
+		// Act
+		_, err := s.Create(ctx, CreateReq{Extension: "pdf", NombreArchivo: "nda", SolicitudID: 1, VenceEl: "31-12-2026"})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrVencimientoInvalido)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe actualizar solo un vencimiento distinto al actual", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		actual := time.Date(2026, 12, 31, 0, 0, 0, 0, time.Local)
+		repo.On("GetByID", ctx, uint(1)).Return(&Documento{ID: 1, VenceEl: &actual}, nil)
+		repo.On("UpdateVenceEl", ctx, uint(1), mock.MatchedBy(func(v *time.Time) bool {
+			return v != nil && v.Equal(time.Date(2027, 6, 30, 0, 0, 0, 0, time.Local))
+		})).Return(nil).Once()
+		mismo, nuevo := "2026-12-31", "2027-06-30"
+
+		// Act
+		errMismo := s.Update(ctx, 1, UpdateReq{VenceEl: &mismo})
+		errNuevo := s.Update(ctx, 1, UpdateReq{VenceEl: &nuevo})
+
+		// Assert
+		require.NoError(t, errMismo)
+		require.NoError(t, errNuevo)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe quitar el vencimiento con una fecha vacía", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		actual := time.Date(2026, 12, 31, 0, 0, 0, 0, time.Local)
+		repo.On("GetByID", ctx, uint(1)).Return(&Documento{ID: 1, VenceEl: &actual}, nil)
+		repo.On("UpdateVenceEl", ctx, uint(1), (*time.Time)(nil)).Return(nil)
+		vacio := ""
+
+		// Act
+		err := s.Update(ctx, 1, UpdateReq{VenceEl: &vacio})
+
+		// Assert
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestService_GetPorVencer(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("debe consultar desde hoy e informar los días restantes", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		desde := hoy()
+		repo.On("GetPorVencer", ctx, &desde, desde.AddDate(0, 0, 30), uint(1)).Return([]Documento{
+			{ID: 1, SolicitudID: 1, VenceEl: enDias(0)},
+			{ID: 2, SolicitudID: 1, VenceEl: enDias(12)},
+		}, nil)
+
+		// Act
+		documentos, err := s.GetPorVencer(ctx, PorVencerReq{Dias: 30, SolicitudID: 1})
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, documentos, 2)
+		assert.Equal(t, 0, documentos[0].DiasRestantes)
+		assert.Equal(t, 12, documentos[1].DiasRestantes)
+		assert.Equal(t, "Analista", documentos[1].Solicitud.Titulo)
+	})
+
+	t.Run("debe incluir los vencidos sin fecha de inicio", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		s, _, _ := setupService(t, repo, Config{})
+		repo.On("GetPorVencer", ctx, (*time.Time)(nil), hoy().AddDate(0, 0, 7), uint(0)).Return([]Documento{
+			{ID: 1, SolicitudID: 1, VenceEl: enDias(-3)},
+			{ID: 2, SolicitudID: 9, VenceEl: enDias(1)},
+		}, nil)
+
+		// Act
+		documentos, err := s.GetPorVencer(ctx, PorVencerReq{Dias: 7, IncluirVencidos: true})
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, documentos, 1, "se omiten los documentos de solicitudes que no existen")
+		assert.Equal(t, -3, documentos[0].DiasRestantes)
+	})
+}
@@ -0,0 +1,219 @@
+package documento
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// EventoPorVencer identifica las notificaciones de documentos próximos a vencer
+const EventoPorVencer = "documentos_por_vencer"
+
+// ErrVencimientoInvalido indica que la fecha de vencimiento enviada no tiene el formato esperado
+var ErrVencimientoInvalido = errors.New("la fecha de vencimiento debe tener el formato YYYY-MM-DD")
+
+// PorVencerReq representa los filtros de la consulta de documentos por vencer
+type PorVencerReq struct {
+	// Dias desde hoy dentro de los que vence el documento
+	Dias        int
+	SolicitudID uint
+	// IncluirVencidos agrega los documentos cuya fecha de vencimiento ya pasó
+	IncluirVencidos bool
+}
+
+// DocumentoPorVencer es un documento con fecha de vencimiento y los días que le quedan de validez
+type DocumentoPorVencer struct {
+	DocumentoResponse
+	// DiasRestantes es negativo si el documento ya venció
+	DiasRestantes int `json:"dias_restantes"`
+}
+
+// DocumentoVencimiento describe un documento dentro de un aviso de vencimiento
+type DocumentoVencimiento struct {
+	ID            uint      `json:"id"`
+	NombreArchivo string    `json:"nombre_archivo"`
+	Categoria     string    `json:"categoria,omitempty"`
+	VenceEl       time.Time `json:"vence_el"`
+	DiasRestantes int       `json:"dias_restantes"`
+}
+
+// AvisoVencimiento agrupa los documentos de una solicitud que están por vencer o ya vencieron
+type AvisoVencimiento struct {
+	Evento      string                 `json:"evento"`
+	SolicitudID uint                   `json:"solicitud_id"`
+	Documentos  []DocumentoVencimiento `json:"documentos"`
+}
+
+// Notificador entrega los avisos de vencimiento a los responsables de las solicitudes
+type Notificador interface {
+	Notificar(ctx context.Context, aviso AvisoVencimiento) error
+}
+
+// NotificadorLog escribe los avisos en el log, para cuando no hay un destino configurado
+type NotificadorLog struct {
+	logger *log.Logger
+}
+
+func NewNotificadorLog(logger *log.Logger) *NotificadorLog {
+	return &NotificadorLog{logger: logger}
+}
+
+func (n *NotificadorLog) Notificar(ctx context.Context, aviso AvisoVencimiento) error {
+	detalles := make([]string, len(aviso.Documentos))
+	for i, doc := range aviso.Documentos {
+		detalles[i] = fmt.Sprintf("ID=%d %s (%s)", doc.ID, doc.NombreArchivo, describirVencimiento(doc.DiasRestantes))
+	}
+	n.logger.Printf("Aviso de vencimiento para la solicitud ID=%d: %s", aviso.SolicitudID, strings.Join(detalles, "; "))
+	return nil
+}
+
+// describirVencimiento expresa los días restantes en palabras para el log
+func describirVencimiento(dias int) string {
+	switch {
+	case dias < 0:
+		return fmt.Sprintf("vencido hace %d días", -dias)
+	case dias == 0:
+		return "vence hoy"
+	default:
+		return fmt.Sprintf("vence en %d días", dias)
+	}
+}
+
+// ConfigVencimientos define con cuánta anticipación se avisa que un documento está por vencer
+type ConfigVencimientos struct {
+	// DiasAviso es la anticipación del aviso en días; 0 desactiva los avisos
+	DiasAviso int
+}
+
+// AvisadorVencimientos notifica, una sola vez por fecha de vencimiento, los documentos que vencen
+// dentro del período de anticipación, agrupados por solicitud
+type AvisadorVencimientos struct {
+	repo        Repository
+	notificador Notificador
+	logger      *log.Logger
+	config      ConfigVencimientos
+}
+
+func NewAvisadorVencimientos(repo Repository, notificador Notificador, logger *log.Logger, config ConfigVencimientos) *AvisadorVencimientos {
+	return &AvisadorVencimientos{
+		repo:        repo,
+		notificador: notificador,
+		logger:      logger,
+		config:      config,
+	}
+}
+
+// Iniciar revisa los vencimientos al iniciar y luego periódicamente hasta que se cancele el contexto
+func (a *AvisadorVencimientos) Iniciar(ctx context.Context, intervalo time.Duration) {
+	if a.config.DiasAviso <= 0 {
+		a.logger.Println("Avisos de vencimiento de documentos desactivados")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(intervalo)
+		defer ticker.Stop()
+		for {
+			if _, err := a.Avisar(ctx); err != nil {
+				a.logger.Printf("Error al avisar los documentos por vencer: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Avisar envía un aviso por cada solicitud con documentos por vencer que aún no han sido notificados
+// y retorna la cantidad de documentos avisados. Si falla el envío de un aviso, sus documentos
+// quedan pendientes para la siguiente revisión.
+func (a *AvisadorVencimientos) Avisar(ctx context.Context) (int, error) {
+	hoy := hoy()
+	pendientes, err := a.repo.GetPendientesAvisoVencimiento(ctx, hoy.AddDate(0, 0, a.config.DiasAviso))
+	if err != nil {
+		return 0, err
+	}
+
+	// Agrupar por solicitud conservando el orden por fecha de vencimiento
+	var avisos []*AvisoVencimiento
+	porSolicitud := make(map[uint]*AvisoVencimiento)
+	ids := make(map[uint][]uint)
+	for _, doc := range pendientes {
+		aviso, ok := porSolicitud[doc.SolicitudID]
+		if !ok {
+			aviso = &AvisoVencimiento{Evento: EventoPorVencer, SolicitudID: doc.SolicitudID}
+			porSolicitud[doc.SolicitudID] = aviso
+			avisos = append(avisos, aviso)
+		}
+		aviso.Documentos = append(aviso.Documentos, DocumentoVencimiento{
+			ID:            doc.ID,
+			NombreArchivo: doc.NombreDescarga(),
+			Categoria:     doc.Categoria,
+			VenceEl:       *doc.VenceEl,
+			DiasRestantes: diasHasta(*doc.VenceEl, hoy),
+		})
+		ids[doc.SolicitudID] = append(ids[doc.SolicitudID], doc.ID)
+	}
+
+	avisados, fallidos := 0, 0
+	for _, aviso := range avisos {
+		if err := a.notificador.Notificar(ctx, *aviso); err != nil {
+			a.logger.Printf("Error al notificar los vencimientos de la solicitud ID=%d: %v", aviso.SolicitudID, err)
+			fallidos++
+			continue
+		}
+		if err := a.repo.MarcarAvisoVencimiento(ctx, ids[aviso.SolicitudID], time.Now()); err != nil {
+			a.logger.Printf("Error al registrar el aviso de vencimiento de la solicitud ID=%d: %v", aviso.SolicitudID, err)
+			fallidos++
+			continue
+		}
+		avisados += len(aviso.Documentos)
+	}
+
+	if avisados > 0 {
+		a.logger.Printf("Avisos de vencimiento enviados para %d documentos de %d solicitudes", avisados, len(avisos)-fallidos)
+	}
+	if fallidos > 0 {
+		return avisados, fmt.Errorf("no se pudieron enviar %d avisos de vencimiento", fallidos)
+	}
+	return avisados, nil
+}
+
+// parseVenceEl convierte una fecha YYYY-MM-DD en la fecha de vencimiento; vacío significa sin vencimiento
+func parseVenceEl(valor string) (*time.Time, error) {
+	valor = strings.TrimSpace(valor)
+	if valor == "" {
+		return nil, nil
+	}
+	fecha, err := time.ParseInLocation("2006-01-02", valor, time.Local)
+	if err != nil {
+		return nil, ErrVencimientoInvalido
+	}
+	return &fecha, nil
+}
+
+// hoy retorna la fecha actual sin la hora
+func hoy() time.Time {
+	ahora := time.Now()
+	return time.Date(ahora.Year(), ahora.Month(), ahora.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// diasHasta cuenta los días de calendario desde hoy hasta la fecha, sin considerar la hora ni la zona horaria
+func diasHasta(fecha, hoy time.Time) int {
+	desde := time.Date(hoy.Year(), hoy.Month(), hoy.Day(), 0, 0, 0, 0, time.UTC)
+	hasta := time.Date(fecha.Year(), fecha.Month(), fecha.Day(), 0, 0, 0, 0, time.UTC)
+	return int(hasta.Sub(desde).Hours() / 24)
+}
+
+// mismaFecha compara dos fechas de vencimiento opcionales por día
+func mismaFecha(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return diasHasta(*a, *b) == 0
+}
@@ -0,0 +1,200 @@
+package documento
+
+import (
+	"context"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// enDias retorna la fecha de hoy más los días indicados, sin la hora
+func enDias(dias int) *time.Time {
+	fecha := hoy().AddDate(0, 0, dias)
+	return &fecha
+}
+
+func TestParseVenceEl(t *testing.T) {
+	t.Run("debe interpretar una fecha YYYY-MM-DD en la zona local", func(t *testing.T) {
+		// Act
+		venceEl, err := parseVenceEl(" 2026-12-31 ")
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2026, 12, 31, 0, 0, 0, 0, time.Local), *venceEl)
+	})
+
+	t.Run("debe retornar sin vencimiento para un valor vacío", func(t *testing.T) {
+		// Act
+		venceEl, err := parseVenceEl("")
+
+		// Assert
+		require.NoError(t, err)
+		assert.Nil(t, venceEl)
+	})
+
+	t.Run("debe rechazar otros formatos de fecha", func(t *testing.T) {
+		for _, valor := range []string{"31/12/2026", "2026-02-30", "2026-12-31T10:00:00Z"} {
+			// Act
+			_, err := parseVenceEl(valor)
+
+			// Assert
+			assert.ErrorIs(t, err, ErrVencimientoInvalido, valor)
+		}
+	})
+}
+
+func TestDiasHasta(t *testing.T) {
+	t.Run("debe contar días de calendario sin considerar la hora", func(t *testing.T) {
+		// Arrange
+		desde := time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)
+
+		// Act & Assert
+		assert.Equal(t, 0, diasHasta(time.Date(2026, 3, 1, 23, 59, 0, 0, time.Local), desde))
+		assert.Equal(t, 31, diasHasta(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), desde))
+		assert.Equal(t, -1, diasHasta(time.Date(2026, 2, 28, 0, 0, 0, 0, time.Local), desde))
+	})
+
+	t.Run("debe comparar fechas opcionales por día", func(t *testing.T) {
+		// Arrange
+		fecha := time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)
+		mismoDia := time.Date(2026, 3, 1, 15, 0, 0, 0, time.Local)
+
+		// Act & Assert
+		assert.True(t, mismaFecha(&fecha, &mismoDia))
+		assert.True(t, mismaFecha(nil, nil))
+		assert.False(t, mismaFecha(&fecha, nil))
+		assert.False(t, mismaFecha(&fecha, enDias(400)))
+	})
+
+	t.Run("debe describir los días restantes", func(t *testing.T) {
+		// Act & Assert
+		assert.Equal(t, "vencido hace 2 días", describirVencimiento(-2))
+		assert.Equal(t, "vence hoy", describirVencimiento(0))
+		assert.Equal(t, "vence en 5 días", describirVencimiento(5))
+	})
+}
+
+func setupAvisador(repo *mockRepository, notificador *mockNotificador, dias int) *AvisadorVencimientos {
+	return NewAvisadorVencimientos(repo, notificador, log.New(io.Discard, "", 0), ConfigVencimientos{DiasAviso: dias})
+}
+
+func TestAvisadorVencimientos_Avisar(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("debe enviar un aviso por solicitud y marcar sus documentos", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		notificador := new(mockNotificador)
+		avisador := setupAvisador(repo, notificador, 7)
+		repo.On("GetPendientesAvisoVencimiento", ctx, hoy().AddDate(0, 0, 7)).Return([]Documento{
+			{ID: 1, SolicitudID: 1, NombreArchivo: "nda", Extension: "pdf", Categoria: "nda", VenceEl: enDias(-1)},
+			{ID: 2, SolicitudID: 1, NombreArchivo: "presupuesto", Extension: "xlsx", VenceEl: enDias(3)},
+			{ID: 3, SolicitudID: 2, NombreArchivo: "cargo", Extension: "docx", VenceEl: enDias(0)},
+		}, nil)
+		notificador.On("Notificar", ctx, AvisoVencimiento{Evento: EventoPorVencer, SolicitudID: 1, Documentos: []DocumentoVencimiento{
+			{ID: 1, NombreArchivo: "nda.pdf", Categoria: "nda", VenceEl: *enDias(-1), DiasRestantes: -1},
+			{ID: 2, NombreArchivo: "presupuesto.xlsx", VenceEl: *enDias(3), DiasRestantes: 3},
+		}}).Return(nil)
+		notificador.On("Notificar", ctx, AvisoVencimiento{Evento: EventoPorVencer, SolicitudID: 2, Documentos: []DocumentoVencimiento{
+			{ID: 3, NombreArchivo: "cargo.docx", VenceEl: *enDias(0), DiasRestantes: 0},
+		}}).Return(nil)
+		repo.On("MarcarAvisoVencimiento", ctx, []uint{1, 2}, mock.AnythingOfType("time.Time")).Return(nil)
+		repo.On("MarcarAvisoVencimiento", ctx, []uint{3}, mock.AnythingOfType("time.Time")).Return(nil)
+
+		// Act
+		avisados, err := avisador.Avisar(ctx)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, 3, avisados)
+		repo.AssertExpectations(t)
+		notificador.AssertExpectations(t)
+	})
+
+	t.Run("debe dejar pendientes los documentos de un aviso que no se pudo enviar", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		notificador := new(mockNotificador)
+		avisador := setupAvisador(repo, notificador, 7)
+		repo.On("GetPendientesAvisoVencimiento", ctx, mock.Anything).Return([]Documento{
+			{ID: 1, SolicitudID: 1, VenceEl: enDias(1)},
+			{ID: 2, SolicitudID: 2, VenceEl: enDias(2)},
+		}, nil)
+		notificador.On("Notificar", ctx, mock.MatchedBy(func(a AvisoVencimiento) bool { return a.SolicitudID == 1 })).Return(assert.AnError)
+		notificador.On("Notificar", ctx, mock.MatchedBy(func(a AvisoVencimiento) bool { return a.SolicitudID == 2 })).Return(nil)
+		repo.On("MarcarAvisoVencimiento", ctx, []uint{2}, mock.Anything).Return(nil)
+
+		// Act
+		avisados, err := avisador.Avisar(ctx)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Equal(t, 1, avisados)
+		repo.AssertNotCalled(t, "MarcarAvisoVencimiento", ctx, []uint{1}, mock.Anything)
+	})
+
+	t.Run("no debe notificar si no hay documentos pendientes", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		notificador := new(mockNotificador)
+		avisador := setupAvisador(repo, notificador, 7)
+		repo.On("GetPendientesAvisoVencimiento", ctx, mock.Anything).Return([]Documento{}, nil)
+
+		// Act
+		avisados, err := avisador.Avisar(ctx)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Zero(t, avisados)
+		notificador.AssertNotCalled(t, "Notificar", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAvisadorVencimientos_Iniciar(t *testing.T) {
+	t.Run("no debe revisar los vencimientos si los avisos están desactivados", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		avisador := setupAvisador(repo, new(mockNotificador), 0)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// Act
+		avisador.Iniciar(ctx, time.Millisecond)
+		time.Sleep(20 * time.Millisecond)
+
+		// Assert
+		repo.AssertNotCalled(t, "GetPendientesAvisoVencimiento", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe revisar periódicamente hasta que se cancele el contexto", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		avisador := setupAvisador(repo, new(mockNotificador), 7)
+		revisado := make(chan struct{}, 1)
+		repo.On("GetPendientesAvisoVencimiento", mock.Anything, mock.Anything).
+			Return([]Documento{}, nil).
+			Run(func(mock.Arguments) {
+				select {
+				case revisado <- struct{}{}:
+				default:
+				}
+			})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// Act
+		avisador.Iniciar(ctx, time.Millisecond)
+
+		// Assert
+		select {
+		case <-revisado:
+		case <-time.After(time.Second):
+			t.Fatal("los vencimientos no se revisaron")
+		}
+	})
+}
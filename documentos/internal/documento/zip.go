@@ -0,0 +1,91 @@
+package documento
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// nombreManifiesto es el archivo que describe el contenido del ZIP de una solicitud
+const nombreManifiesto = "manifiesto.csv"
+
+// filaManifiesto describe un documento de la solicitud dentro del ZIP
+type filaManifiesto struct {
+	archivo   string
+	documento *Documento
+	incluido  bool
+	detalle   string
+}
+
+// nombresZip evita que dos documentos con el mismo nombre se sobrescriban dentro del ZIP,
+// agregando un sufijo numérico a partir del segundo: informe.pdf, informe (2).pdf, ...
+type nombresZip map[string]int
+
+func (n nombresZip) unico(nombre string) string {
+	// Los nombres vienen del usuario, se quitan separadores para que no creen carpetas en el ZIP
+	nombre = strings.NewReplacer("/", "_", "\\", "_").Replace(nombre)
+	switch nombre {
+	case "":
+		nombre = "documento"
+	case nombreManifiesto:
+		nombre = "documento_" + nombre
+	}
+
+	clave := strings.ToLower(nombre)
+	n[clave]++
+	if n[clave] == 1 {
+		return nombre
+	}
+
+	ext := path.Ext(nombre)
+	candidato := fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(nombre, ext), n[clave], ext)
+	// El nombre con sufijo también podría pertenecer a otro documento
+	return n.unico(candidato)
+}
+
+// agregarAlZip comprime el archivo de un documento en una nueva entrada del ZIP
+func agregarAlZip(zw *zip.Writer, nombre string, doc *Documento, archivo io.Reader) error {
+	destino, err := zw.CreateHeader(&zip.FileHeader{Name: nombre, Method: zip.Deflate, Modified: doc.UpdatedAt})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(destino, archivo)
+	return err
+}
+
+// escribirManifiesto agrega al ZIP un CSV con los documentos de la solicitud, incluidos o no
+func escribirManifiesto(zw *zip.Writer, filas []filaManifiesto) error {
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: nombreManifiesto, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+
+	csvWriter := csv.NewWriter(w)
+	csvWriter.Write([]string{"archivo", "documento_id", "nombre_archivo", "categoria", "version", "tamano", "checksum", "fecha_carga", "estado_escaneo", "incluido"})
+	for _, fila := range filas {
+		doc := fila.documento
+		incluido := "si"
+		if !fila.incluido {
+			incluido = "no: " + fila.detalle
+		}
+		csvWriter.Write([]string{
+			fila.archivo,
+			strconv.FormatUint(uint64(doc.ID), 10),
+			doc.NombreDescarga(),
+			doc.Categoria,
+			strconv.Itoa(doc.Version),
+			strconv.FormatInt(doc.Tamano, 10),
+			doc.Checksum,
+			doc.CreatedAt.Format(time.RFC3339),
+			doc.EstadoEscaneo,
+			incluido,
+		})
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
@@ -0,0 +1,75 @@
+package documento
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// leerZip retorna el contenido de cada entrada del ZIP por nombre, en el orden en que fueron escritas
+func leerZip(t *testing.T, contenido []byte) ([]string, map[string][]byte) {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(contenido), int64(len(contenido)))
+	require.NoError(t, err)
+	nombres := make([]string, 0, len(zr.File))
+	entradas := make(map[string][]byte, len(zr.File))
+	for _, archivo := range zr.File {
+		r, err := archivo.Open()
+		require.NoError(t, err)
+		datos, err := io.ReadAll(r)
+		r.Close()
+		require.NoError(t, err)
+		nombres = append(nombres, archivo.Name)
+		entradas[archivo.Name] = datos
+	}
+	return nombres, entradas
+}
+
+// leerManifiesto interpreta el CSV del manifiesto, incluyendo su encabezado
+func leerManifiesto(t *testing.T, contenido []byte) [][]string {
+	t.Helper()
+	filas, err := csv.NewReader(bytes.NewReader(contenido)).ReadAll()
+	require.NoError(t, err)
+	return filas
+}
+
+func TestNombresZip_Unico(t *testing.T) {
+	t.Run("debe numerar los nombres repetidos sin distinguir mayúsculas", func(t *testing.T) {
+		// Arrange
+		nombres := make(nombresZip)
+
+		// Act & Assert
+		assert.Equal(t, "informe.pdf", nombres.unico("informe.pdf"))
+		assert.Equal(t, "Informe (2).PDF", nombres.unico("Informe.PDF"))
+		assert.Equal(t, "informe (3).pdf", nombres.unico("informe.pdf"))
+	})
+
+	t.Run("debe evitar el nombre con sufijo de otro documento", func(t *testing.T) {
+		// Arrange
+		nombres := make(nombresZip)
+		nombres.unico("cv (2).pdf")
+		nombres.unico("cv.pdf")
+
+		// Act
+		nombre := nombres.unico("cv.pdf")
+
+		// Assert
+		assert.Equal(t, "cv (2) (2).pdf", nombre)
+	})
+
+	t.Run("debe quitar separadores y reservar el nombre del manifiesto", func(t *testing.T) {
+		// Arrange
+		nombres := make(nombresZip)
+
+		// Act & Assert
+		assert.Equal(t, ".._.._etc_passwd", nombres.unico("../../etc/passwd"))
+		assert.Equal(t, "a_b.txt", nombres.unico(`a\b.txt`))
+		assert.Equal(t, "documento", nombres.unico(""))
+		assert.Equal(t, "documento_manifiesto.csv", nombres.unico(nombreManifiesto))
+	})
+}
@@ -1,12 +1,23 @@
 package bootstrap
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/kramirez/documentos/internal/carga"
 	"github.com/kramirez/documentos/internal/documento"
+	"github.com/kramirez/documentos/pkg/cifrado"
+	"github.com/kramirez/documentos/pkg/notificacion"
+	"github.com/kramirez/documentos/pkg/scanner"
+	"github.com/kramirez/documentos/pkg/storage"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
@@ -76,7 +87,7 @@ func DBConnection() (*gorm.DB, error) {
 
 	// Auto-migrate si DATABASE_MIGRATE está en "up"
 	if os.Getenv("DATABASE_MIGRATE") == "up" {
-		if err := db.AutoMigrate(&documento.Documento{}); err != nil {
+		if err := db.AutoMigrate(&documento.Documento{}, &documento.Version{}, &documento.Blob{}, &documento.TextoBlob{}, &documento.EnlaceUsado{}, &documento.Etiqueta{}, &documento.Metadato{}, &carga.Carga{}); err != nil {
 			return nil, fmt.Errorf("error al realizar migraciones: %v", err)
 		}
 		log.Println("Migraciones realizadas exitosamente")
@@ -85,6 +96,308 @@ func DBConnection() (*gorm.DB, error) {
 	return db, nil
 }
 
+// InitStorage inicializa el almacenamiento donde se guarda el contenido de los documentos,
+// en disco local o en un bucket S3 según STORAGE_BACKEND. Si hay una clave maestra configurada
+// el contenido se cifra antes de guardarlo.
+func InitStorage() (storage.Storage, error) {
+	store, err := initBackend()
+	if err != nil {
+		return nil, err
+	}
+
+	llavero, err := InitLlavero()
+	if err != nil {
+		return nil, err
+	}
+	if llavero == nil {
+		log.Println("Advertencia: no hay clave maestra configurada, los archivos se guardan sin cifrar")
+		return store, nil
+	}
+	log.Printf("Cifrado de archivos activado con la clave maestra %s\n", llavero.Actual())
+	return storage.NewCifradoStorage(store, llavero), nil
+}
+
+// initBackend crea el almacenamiento indicado por STORAGE_BACKEND
+func initBackend() (storage.Storage, error) {
+	switch backend := os.Getenv("STORAGE_BACKEND"); backend {
+	case "", "local":
+		storagePath := os.Getenv("STORAGE_PATH")
+		if storagePath == "" {
+			storagePath = "uploads"
+		}
+
+		store, err := storage.NewLocalStorage(storagePath)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Almacenamiento local configurado en: %s\n", storagePath)
+		return store, nil
+	case "s3":
+		return initS3Storage()
+	default:
+		return nil, fmt.Errorf("backend de almacenamiento no soportado: %s", backend)
+	}
+}
+
+// initS3Storage configura el almacenamiento en un bucket compatible con S3 (AWS o MinIO)
+func initS3Storage() (storage.Storage, error) {
+	config := storage.S3Config{
+		Endpoint:  os.Getenv("S3_ENDPOINT"),
+		Region:    os.Getenv("S3_REGION"),
+		Bucket:    os.Getenv("S3_BUCKET"),
+		AccessKey: os.Getenv("S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("S3_SECRET_KEY"),
+		UsarSSL:   os.Getenv("S3_USAR_SSL") != "false",
+	}
+	if config.Endpoint == "" || config.Bucket == "" {
+		return nil, fmt.Errorf("S3_ENDPOINT y S3_BUCKET son requeridos para STORAGE_BACKEND=s3")
+	}
+	if valor := os.Getenv("S3_TAMANO_PARTE"); valor != "" {
+		tamano, err := strconv.ParseUint(valor, 10, 64)
+		if err != nil {
+			log.Printf("Advertencia: S3_TAMANO_PARTE inválido (%s), se usa el valor por defecto\n", valor)
+		} else {
+			config.TamanoParte = tamano
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	store, err := storage.NewS3Storage(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Almacenamiento S3 configurado en el bucket %s de %s\n", config.Bucket, config.Endpoint)
+	return store, nil
+}
+
+// InitLlavero carga las claves maestras que protegen las claves de datos de cada archivo.
+// CIFRADO_ARCHIVO_CLAVES apunta a un archivo con una clave en base64 por línea, la primera es
+// la actual; si no está definido se usan CIFRADO_CLAVE_MAESTRA y CIFRADO_CLAVES_ANTERIORES.
+// Retorna nil si no hay ninguna clave configurada.
+func InitLlavero() (*cifrado.Llavero, error) {
+	var codificadas []string
+	if ruta := os.Getenv("CIFRADO_ARCHIVO_CLAVES"); ruta != "" {
+		contenido, err := os.ReadFile(ruta)
+		if err != nil {
+			return nil, fmt.Errorf("error al leer el archivo de claves %s: %v", ruta, err)
+		}
+		for _, linea := range strings.Split(string(contenido), "\n") {
+			linea = strings.TrimSpace(linea)
+			if linea != "" && !strings.HasPrefix(linea, "#") {
+				codificadas = append(codificadas, linea)
+			}
+		}
+		if len(codificadas) == 0 {
+			return nil, fmt.Errorf("el archivo de claves %s no contiene ninguna clave", ruta)
+		}
+	} else if actual := os.Getenv("CIFRADO_CLAVE_MAESTRA"); actual != "" {
+		codificadas = []string{actual}
+		for _, anterior := range strings.Split(os.Getenv("CIFRADO_CLAVES_ANTERIORES"), ",") {
+			if anterior = strings.TrimSpace(anterior); anterior != "" {
+				codificadas = append(codificadas, anterior)
+			}
+		}
+	} else {
+		return nil, nil
+	}
+
+	claves := make([][]byte, len(codificadas))
+	for i, codificada := range codificadas {
+		clave, err := base64.StdEncoding.DecodeString(codificada)
+		if err != nil {
+			return nil, fmt.Errorf("la clave maestra %d no está en base64: %v", i+1, err)
+		}
+		claves[i] = clave
+	}
+	return cifrado.NewLlavero(claves[0], claves[1:]...)
+}
+
+// InitScanner inicializa el antivirus usado para analizar el contenido de los documentos
+func InitScanner() (scanner.Scanner, error) {
+	switch tipo := os.Getenv("SCANNER"); tipo {
+	case "clamav":
+		address := os.Getenv("CLAMAV_ADDRESS")
+		if address == "" {
+			address = "localhost:3310"
+		}
+		log.Printf("Antivirus ClamAV configurado en: %s\n", address)
+		return scanner.NewClamAV(address, 2*time.Minute), nil
+	case "fake":
+		log.Println("Advertencia: SCANNER=fake, se usa un antivirus simulado que solo detecta la firma EICAR")
+		return scanner.NewFake(), nil
+	case "":
+		return nil, fmt.Errorf("SCANNER no configurado, debe ser clamav o fake")
+	default:
+		return nil, fmt.Errorf("tipo de antivirus no soportado: %s", tipo)
+	}
+}
+
+// claveEnlacesEjemplo es el valor de ejemplo de ENLACES_CLAVE en la documentación, que nunca debe usarse
+const claveEnlacesEjemplo = "cambiar-por-una-clave-secreta"
+
+// InitConfig carga la configuración de negocio del servicio de documentos desde las variables de entorno
+func InitConfig() (documento.Config, error) {
+	politica := documento.PoliticaArchivos{
+		Permitidas:   parseLista(os.Getenv("EXTENSIONES_PERMITIDAS")),
+		PorCategoria: make(map[string][]string),
+	}
+
+	// Formato: categoria:ext1,ext2;otra_categoria:ext3
+	for _, regla := range strings.Split(os.Getenv("EXTENSIONES_POR_CATEGORIA"), ";") {
+		categoria, extensiones, ok := strings.Cut(regla, ":")
+		if !ok || strings.TrimSpace(categoria) == "" {
+			continue
+		}
+		politica.PorCategoria[strings.TrimSpace(categoria)] = parseLista(extensiones)
+	}
+
+	// Formato: codigo:Nombre visible;otro_codigo:Otro nombre
+	for _, entrada := range strings.Split(os.Getenv("CATEGORIAS_DOCUMENTO"), ";") {
+		codigo, nombre, _ := strings.Cut(entrada, ":")
+		codigo, nombre = strings.TrimSpace(codigo), strings.TrimSpace(nombre)
+		if codigo == "" {
+			continue
+		}
+		if nombre == "" {
+			nombre = codigo
+		}
+		politica.Categorias = append(politica.Categorias, documento.Categoria{Codigo: codigo, Nombre: nombre})
+	}
+
+	// Sin una clave fija los enlaces firmados dejan de ser válidos al reiniciar el servicio
+	claveEnlaces := []byte(os.Getenv("ENLACES_CLAVE"))
+	if string(claveEnlaces) == claveEnlacesEjemplo {
+		return documento.Config{}, fmt.Errorf("ENLACES_CLAVE tiene el valor de ejemplo, configure una clave secreta propia")
+	}
+	if len(claveEnlaces) == 0 {
+		log.Println("Advertencia: ENLACES_CLAVE no configurada, se usa una clave aleatoria y los enlaces firmados no sobrevivirán a un reinicio")
+		claveEnlaces = make([]byte, 32)
+		if _, err := rand.Read(claveEnlaces); err != nil {
+			return documento.Config{}, fmt.Errorf("no se pudo generar la clave de los enlaces firmados: %v", err)
+		}
+	}
+
+	return documento.Config{
+		Politica:     politica,
+		URLPublica:   strings.TrimSuffix(os.Getenv("URL_PUBLICA"), "/"),
+		ClaveEnlaces: claveEnlaces,
+		Cuotas: documento.Cuotas{
+			PorSolicitud: documento.Cuota{
+				MaxArchivos: parseLimite("CUOTA_SOLICITUD_ARCHIVOS"),
+				MaxBytes:    parseLimite("CUOTA_SOLICITUD_BYTES"),
+			},
+			PorUsuario: documento.Cuota{
+				MaxArchivos: parseLimite("CUOTA_USUARIO_ARCHIVOS"),
+				MaxBytes:    parseLimite("CUOTA_USUARIO_BYTES"),
+			},
+		},
+	}, nil
+}
+
+// parseLimite lee un límite numérico de la variable de entorno; vacío o inválido significa sin límite
+func parseLimite(variable string) int64 {
+	valor := os.Getenv(variable)
+	if valor == "" {
+		return 0
+	}
+	limite, err := strconv.ParseInt(valor, 10, 64)
+	if err != nil || limite < 0 {
+		log.Printf("Advertencia: %s inválido (%s), se usa sin límite\n", variable, valor)
+		return 0
+	}
+	return limite
+}
+
+// InitPurgaConfig carga la política de retención de los documentos eliminados
+func InitPurgaConfig() documento.ConfigPurga {
+	var config documento.ConfigPurga
+	if valor := os.Getenv("RETENCION_ELIMINADOS"); valor != "" {
+		retencion, err := time.ParseDuration(valor)
+		if err != nil || retencion <= 0 {
+			log.Printf("Advertencia: RETENCION_ELIMINADOS inválido (%s), la purga queda desactivada\n", valor)
+		} else {
+			config.Retencion = retencion
+		}
+	}
+	config.Simular = os.Getenv("PURGA_SIMULADA") == "true"
+	if config.Retencion > 0 {
+		log.Printf("Purga de documentos eliminados hace más de %s (simulada: %t)\n", config.Retencion, config.Simular)
+	}
+	return config
+}
+
+// InitVencimientosConfig carga la anticipación con la que se avisan los documentos por vencer
+func InitVencimientosConfig() documento.ConfigVencimientos {
+	config := documento.ConfigVencimientos{DiasAviso: 30}
+	if valor := os.Getenv("VENCIMIENTOS_DIAS_AVISO"); valor != "" {
+		dias, err := strconv.Atoi(valor)
+		if err != nil || dias < 0 {
+			log.Printf("Advertencia: VENCIMIENTOS_DIAS_AVISO inválido (%s), se usan %d días\n", valor, config.DiasAviso)
+		} else {
+			config.DiasAviso = dias
+		}
+	}
+	if config.DiasAviso > 0 {
+		log.Printf("Avisos de documentos que vencen dentro de %d días\n", config.DiasAviso)
+	}
+	return config
+}
+
+// InitNotificador inicializa el destino de los avisos de vencimiento: un webhook si está
+// configurado o el log del servicio en caso contrario
+func InitNotificador(logger *log.Logger) documento.Notificador {
+	url := os.Getenv("VENCIMIENTOS_WEBHOOK_URL")
+	if url == "" {
+		log.Println("VENCIMIENTOS_WEBHOOK_URL no configurada, los avisos de vencimiento se escriben en el log")
+		return documento.NewNotificadorLog(logger)
+	}
+	log.Printf("Avisos de vencimiento enviados al webhook: %s\n", url)
+	return notificacion.NewWebhook(url)
+}
+
+// InitCargaConfig carga la configuración de las cargas reanudables (protocolo tus)
+func InitCargaConfig(politica documento.PoliticaArchivos) carga.Config {
+	config := carga.Config{
+		Directorio:   os.Getenv("CARGAS_PATH"),
+		TamanoMaximo: 1 << 30,
+		Expiracion:   24 * time.Hour,
+		Politica:     politica,
+	}
+	if config.Directorio == "" {
+		config.Directorio = "cargas"
+	}
+	if valor := os.Getenv("CARGAS_TAMANO_MAXIMO"); valor != "" {
+		tamano, err := strconv.ParseInt(valor, 10, 64)
+		if err != nil || tamano <= 0 {
+			log.Printf("Advertencia: CARGAS_TAMANO_MAXIMO inválido (%s), se usa %d bytes\n", valor, config.TamanoMaximo)
+		} else {
+			config.TamanoMaximo = tamano
+		}
+	}
+	if valor := os.Getenv("CARGAS_EXPIRACION"); valor != "" {
+		expiracion, err := time.ParseDuration(valor)
+		if err != nil || expiracion <= 0 {
+			log.Printf("Advertencia: CARGAS_EXPIRACION inválido (%s), se usa %s\n", valor, config.Expiracion)
+		} else {
+			config.Expiracion = expiracion
+		}
+	}
+	return config
+}
+
+// parseLista convierte "pdf, DOCX,.txt" en ["pdf", "docx", "txt"]
+func parseLista(valor string) []string {
+	var lista []string
+	for _, item := range strings.Split(valor, ",") {
+		item = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(item), "."))
+		if item != "" {
+			lista = append(lista, item)
+		}
+	}
+	return lista
+}
+
 func InitLogger() *log.Logger {
 	return log.New(os.Stdout, "", log.LstdFlags|log.Lshortfile)
 }
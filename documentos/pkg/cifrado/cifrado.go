@@ -0,0 +1,120 @@
+package cifrado
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// TamanoClave es el tamaño en bytes de las claves maestras y de datos (AES-256)
+	TamanoClave = 32
+	// TamanoID es el tamaño del identificador de una clave maestra
+	TamanoID = 8
+	// TamanoEnvuelta es el tamaño de una clave de datos envuelta: nonce, clave cifrada y etiqueta GCM
+	TamanoEnvuelta = 12 + TamanoClave + 16
+)
+
+// ErrClaveDesconocida indica que el archivo fue cifrado con una clave maestra que no está configurada
+var ErrClaveDesconocida = errors.New("el archivo fue cifrado con una clave maestra desconocida")
+
+// ID identifica a una clave maestra sin revelarla
+type ID [TamanoID]byte
+
+func (id ID) String() string {
+	return fmt.Sprintf("%x", id[:])
+}
+
+// Llavero contiene la clave maestra actual, con la que se envuelven las nuevas claves de datos,
+// y las anteriores, que solo se usan para leer archivos que aún no han sido re-envueltos
+type Llavero struct {
+	actual   ID
+	maestras map[ID]cipher.AEAD
+}
+
+// NewLlavero crea el llavero con la clave maestra actual y las anteriores a ella
+func NewLlavero(actual []byte, anteriores ...[]byte) (*Llavero, error) {
+	llavero := &Llavero{maestras: make(map[ID]cipher.AEAD)}
+	for i, clave := range append([][]byte{actual}, anteriores...) {
+		if len(clave) != TamanoClave {
+			return nil, fmt.Errorf("la clave maestra debe tener %d bytes, tiene %d", TamanoClave, len(clave))
+		}
+		aead, err := NuevoAEAD(clave)
+		if err != nil {
+			return nil, err
+		}
+		id := idDe(clave)
+		if i == 0 {
+			llavero.actual = id
+		}
+		llavero.maestras[id] = aead
+	}
+	return llavero, nil
+}
+
+// idDe deriva el identificador de la clave a partir de su hash
+func idDe(clave []byte) ID {
+	var id ID
+	suma := sha256.Sum256(append([]byte("documentos/clave-maestra/"), clave...))
+	copy(id[:], suma[:])
+	return id
+}
+
+// Actual retorna el identificador de la clave maestra actual
+func (l *Llavero) Actual() ID {
+	return l.actual
+}
+
+// NuevaClaveDatos genera una clave de datos aleatoria y la retorna junto con su versión envuelta
+// por la clave maestra actual
+func (l *Llavero) NuevaClaveDatos() (clave []byte, envuelta []byte, err error) {
+	clave = make([]byte, TamanoClave)
+	if _, err := io.ReadFull(rand.Reader, clave); err != nil {
+		return nil, nil, err
+	}
+	envuelta, err = l.Envolver(clave)
+	if err != nil {
+		return nil, nil, err
+	}
+	return clave, envuelta, nil
+}
+
+// Envolver cifra una clave de datos con la clave maestra actual
+func (l *Llavero) Envolver(clave []byte) ([]byte, error) {
+	aead := l.maestras[l.actual]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, clave, l.actual[:]), nil
+}
+
+// Desenvolver descifra una clave de datos envuelta con la clave maestra indicada
+func (l *Llavero) Desenvolver(id ID, envuelta []byte) ([]byte, error) {
+	aead, ok := l.maestras[id]
+	if !ok {
+		return nil, fmt.Errorf("%w (%s)", ErrClaveDesconocida, id)
+	}
+	if len(envuelta) != TamanoEnvuelta {
+		return nil, fmt.Errorf("clave de datos envuelta inválida")
+	}
+	nonce, cifrada := envuelta[:aead.NonceSize()], envuelta[aead.NonceSize():]
+	clave, err := aead.Open(nil, nonce, cifrada, id[:])
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo desenvolver la clave de datos: %v", err)
+	}
+	return clave, nil
+}
+
+// NuevoAEAD crea un cifrador AES-256-GCM con la clave indicada
+func NuevoAEAD(clave []byte) (cipher.AEAD, error) {
+	bloque, err := aes.NewCipher(clave)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(bloque)
+}
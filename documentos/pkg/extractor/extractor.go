@@ -0,0 +1,165 @@
+package extractor
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ledongthuc/pdf"
+)
+
+const (
+	// MaxTexto es el tamaño máximo en bytes del texto extraído que se conserva para el índice
+	MaxTexto = 1 << 20
+	// maxArchivo limita lo que se carga en memoria para extraer el texto
+	maxArchivo = 50 << 20
+
+	tipoDocx = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+)
+
+// ErrNoSoportado indica que no se puede extraer texto del tipo de archivo
+var ErrNoSoportado = errors.New("tipo de archivo sin texto extraíble")
+
+// Soporta indica si se puede extraer texto de un archivo con el tipo MIME dado
+func Soporta(tipoMime string) bool {
+	tipo := tipoBase(tipoMime)
+	return tipo == "application/pdf" || tipo == tipoDocx || strings.HasPrefix(tipo, "text/")
+}
+
+// Extraer obtiene el texto plano de un archivo PDF, DOCX o de texto según su tipo MIME.
+// El resultado se normaliza a una sola línea y se recorta a MaxTexto bytes.
+func Extraer(r io.Reader, tipoMime string) (string, error) {
+	if !Soporta(tipoMime) {
+		return "", ErrNoSoportado
+	}
+
+	datos, err := io.ReadAll(io.LimitReader(r, maxArchivo))
+	if err != nil {
+		return "", fmt.Errorf("error al leer el archivo: %v", err)
+	}
+
+	var texto string
+	switch tipo := tipoBase(tipoMime); {
+	case tipo == "application/pdf":
+		texto, err = extraerPDF(datos)
+	case tipo == tipoDocx:
+		texto, err = extraerDocx(datos)
+	default:
+		texto = string(datos)
+	}
+	if err != nil {
+		return "", err
+	}
+	return normalizar(texto), nil
+}
+
+func tipoBase(tipoMime string) string {
+	tipo, _, err := mime.ParseMediaType(tipoMime)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(tipoMime))
+	}
+	return tipo
+}
+
+func extraerPDF(datos []byte) (texto string, err error) {
+	// La librería de PDF entra en pánico con algunos archivos malformados
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("PDF inválido: %v", p)
+		}
+	}()
+
+	lector, err := pdf.NewReader(bytes.NewReader(datos), int64(len(datos)))
+	if err != nil {
+		return "", fmt.Errorf("PDF inválido: %v", err)
+	}
+	plano, err := lector.GetPlainText()
+	if err != nil {
+		return "", fmt.Errorf("error al extraer el texto del PDF: %v", err)
+	}
+	contenido, err := io.ReadAll(io.LimitReader(plano, MaxTexto*2))
+	if err != nil {
+		return "", fmt.Errorf("error al extraer el texto del PDF: %v", err)
+	}
+	return string(contenido), nil
+}
+
+func extraerDocx(datos []byte) (string, error) {
+	archivo, err := zip.NewReader(bytes.NewReader(datos), int64(len(datos)))
+	if err != nil {
+		return "", fmt.Errorf("DOCX inválido: %v", err)
+	}
+
+	for _, f := range archivo.File {
+		if f.Name != "word/document.xml" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return "", fmt.Errorf("DOCX inválido: %v", err)
+		}
+		defer rc.Close()
+		return textoDocumentXML(io.LimitReader(rc, maxArchivo))
+	}
+	return "", errors.New("DOCX inválido: no contiene word/document.xml")
+}
+
+// textoDocumentXML recorre word/document.xml y junta el contenido de los elementos w:t
+func textoDocumentXML(r io.Reader) (string, error) {
+	var sb strings.Builder
+	decoder := xml.NewDecoder(r)
+	enTexto := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("DOCX inválido: %v", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				enTexto = true
+			case "tab", "br":
+				sb.WriteByte(' ')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				enTexto = false
+			case "p":
+				sb.WriteByte('\n')
+			}
+		case xml.CharData:
+			if enTexto {
+				sb.Write(t)
+			}
+		}
+		if sb.Len() > MaxTexto*2 {
+			break
+		}
+	}
+	return sb.String(), nil
+}
+
+// normalizar reemplaza los espacios repetidos por uno solo y recorta el texto a MaxTexto bytes
+func normalizar(texto string) string {
+	texto = strings.Join(strings.Fields(strings.ToValidUTF8(texto, " ")), " ")
+	if len(texto) <= MaxTexto {
+		return texto
+	}
+	corte := MaxTexto
+	for corte > 0 && !utf8.RuneStart(texto[corte]) {
+		corte--
+	}
+	return texto[:corte]
+}
@@ -0,0 +1,104 @@
+package extractor
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nuevoDocx arma un DOCX mínimo con el word/document.xml indicado
+func nuevoDocx(t *testing.T, documentXML string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	archivo := zip.NewWriter(&buf)
+	w, err := archivo.Create("word/document.xml")
+	require.NoError(t, err)
+	_, err = w.Write([]byte(documentXML))
+	require.NoError(t, err)
+	require.NoError(t, archivo.Close())
+	return buf.Bytes()
+}
+
+func TestExtraer(t *testing.T) {
+	t.Run("debe normalizar el texto plano a una sola línea", func(t *testing.T) {
+		// Act
+		texto, err := Extraer(strings.NewReader("  Experiencia\n\nen   Go\ty MySQL \n"), "text/plain; charset=utf-8")
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "Experiencia en Go y MySQL", texto)
+	})
+
+	t.Run("debe extraer el texto de los párrafos de un DOCX", func(t *testing.T) {
+		// Arrange
+		docx := nuevoDocx(t, `<?xml version="1.0" encoding="UTF-8"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:r><w:t>Analista</w:t></w:r><w:r><w:tab/><w:t>de datos</w:t></w:r></w:p>
+<w:p><w:r><w:t>Santiago</w:t></w:r><w:r><w:instrText>IGNORADO</w:instrText></w:r></w:p>
+</w:body></w:document>`)
+
+		// Act
+		texto, err := Extraer(bytes.NewReader(docx), tipoDocx)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "Analista de datos Santiago", texto)
+	})
+
+	t.Run("debe fallar con un DOCX sin word/document.xml", func(t *testing.T) {
+		// Arrange
+		var buf bytes.Buffer
+		archivo := zip.NewWriter(&buf)
+		_, err := archivo.Create("otro.xml")
+		require.NoError(t, err)
+		require.NoError(t, archivo.Close())
+
+		// Act
+		_, err = Extraer(&buf, tipoDocx)
+
+		// Assert
+		assert.Error(t, err)
+	})
+
+	t.Run("debe fallar sin entrar en pánico con un PDF inválido", func(t *testing.T) {
+		// Act
+		_, err := Extraer(strings.NewReader("%PDF-1.4\nbasura"), "application/pdf")
+
+		// Assert
+		assert.Error(t, err)
+	})
+
+	t.Run("debe rechazar un tipo sin texto extraíble", func(t *testing.T) {
+		// Act
+		_, err := Extraer(strings.NewReader("\x89PNG"), "image/png")
+
+		// Assert
+		assert.ErrorIs(t, err, ErrNoSoportado)
+	})
+
+	t.Run("debe recortar el texto sin cortar un carácter", func(t *testing.T) {
+		// Arrange
+		contenido := strings.Repeat("ñ", MaxTexto)
+
+		// Act
+		texto, err := Extraer(strings.NewReader(contenido), "text/plain")
+
+		// Assert
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(texto), MaxTexto)
+		assert.True(t, utf8.ValidString(texto))
+	})
+}
+
+func TestSoporta(t *testing.T) {
+	assert.True(t, Soporta("application/pdf"))
+	assert.True(t, Soporta(tipoDocx))
+	assert.True(t, Soporta("text/csv; charset=utf-8"))
+	assert.False(t, Soporta("image/jpeg"))
+	assert.False(t, Soporta("application/msword"))
+}
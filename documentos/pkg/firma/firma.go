@@ -0,0 +1,34 @@
+package firma
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Firmante genera y verifica firmas HMAC-SHA256 con una clave secreta
+type Firmante struct {
+	clave []byte
+}
+
+func NewFirmante(clave []byte) *Firmante {
+	return &Firmante{clave: clave}
+}
+
+// Firmar retorna en hexadecimal la firma de las partes unidas con "|"
+func (f *Firmante) Firmar(partes ...string) string {
+	mac := hmac.New(sha256.New, f.clave)
+	mac.Write([]byte(strings.Join(partes, "|")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verificar compara la firma recibida con la esperada en tiempo constante
+func (f *Firmante) Verificar(firma string, partes ...string) bool {
+	recibida, err := hex.DecodeString(firma)
+	if err != nil {
+		return false
+	}
+	esperada, _ := hex.DecodeString(f.Firmar(partes...))
+	return hmac.Equal(recibida, esperada)
+}
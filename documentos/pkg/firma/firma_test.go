@@ -0,0 +1,46 @@
+package firma
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFirmante(t *testing.T) {
+	firmante := NewFirmante([]byte("clave-secreta"))
+
+	t.Run("debe verificar la firma de las mismas partes", func(t *testing.T) {
+		// Act
+		firma := firmante.Firmar("7", "2", "1700000000")
+
+		// Assert
+		assert.Len(t, firma, 64)
+		assert.True(t, firmante.Verificar(firma, "7", "2", "1700000000"))
+	})
+
+	t.Run("debe rechazar la firma si cambia alguna parte", func(t *testing.T) {
+		// Arrange
+		firma := firmante.Firmar("7", "2", "1700000000")
+
+		// Act & Assert
+		assert.False(t, firmante.Verificar(firma, "7", "3", "1700000000"))
+		assert.False(t, firmante.Verificar(firma, "7", "2", "1800000000"))
+	})
+
+	t.Run("debe rechazar la firma generada con otra clave", func(t *testing.T) {
+		// Arrange
+		otro := NewFirmante([]byte("otra-clave"))
+
+		// Act
+		firma := otro.Firmar("7", "2")
+
+		// Assert
+		assert.False(t, firmante.Verificar(firma, "7", "2"))
+	})
+
+	t.Run("debe rechazar una firma que no es hexadecimal", func(t *testing.T) {
+		// Act & Assert
+		assert.False(t, firmante.Verificar("no-es-hex", "7", "2"))
+		assert.False(t, firmante.Verificar("", "7", "2"))
+	})
+}
@@ -3,18 +3,19 @@ package handler
 import (
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/kramirez/documentos/internal/carga"
 	"github.com/kramirez/documentos/internal/documento"
 )
 
-func SetupRoutes(endpoints *documento.Endpoint) *gin.Engine {
+func SetupRoutes(endpoints *documento.Endpoint, cargas *carga.Endpoint) *gin.Engine {
 	router := gin.Default()
 
 	// Configurar CORS (permitir todos los orígenes - solo para desarrollo)
 	router.Use(cors.New(cors.Config{
 		AllowAllOrigins:  true, //Esto es solo para ambiente de desarrollo, para producción se debe configurar los orígenes permitidos
-		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods:     []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "Range", "X-Usuario-ID", "Tus-Resumable", "Upload-Length", "Upload-Offset", "Upload-Metadata"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Disposition", "Content-Range", "Accept-Ranges", "ETag", "Last-Modified", "Location", "Tus-Resumable", "Tus-Version", "Tus-Extension", "Tus-Max-Size", "Upload-Offset", "Upload-Length", "Upload-Expires", "X-Documento-ID"},
 		AllowCredentials: false,
 	}))
 
@@ -23,11 +24,40 @@ func SetupRoutes(endpoints *documento.Endpoint) *gin.Engine {
 	{
 		documentoGroup.POST("", endpoints.Create)
 		documentoGroup.GET("", endpoints.GetAll)
+		documentoGroup.GET("/buscar", endpoints.Buscar)
+		documentoGroup.GET("/categorias", endpoints.GetCategorias)
+		documentoGroup.GET("/uso", endpoints.GetUso)
+		documentoGroup.GET("/papelera", endpoints.GetPapelera)
+		documentoGroup.GET("/por-vencer", endpoints.GetPorVencer)
+		documentoGroup.GET("/checksum/:checksum", endpoints.GetBlob)
 		documentoGroup.GET("/:id", endpoints.GetByID)
+		documentoGroup.GET("/:id/contenido", endpoints.GetContenido)
+		documentoGroup.PUT("/:id/contenido", endpoints.ReemplazarContenido)
+		documentoGroup.GET("/:id/miniatura", endpoints.GetMiniatura)
+		documentoGroup.POST("/:id/enlaces", endpoints.CrearEnlace)
+		documentoGroup.GET("/:id/versiones", endpoints.GetVersiones)
+		documentoGroup.GET("/:id/versiones/:version", endpoints.GetVersion)
+		documentoGroup.GET("/:id/versiones/:version/contenido", endpoints.GetVersionContenido)
+		documentoGroup.POST("/:id/versiones/:version/restaurar", endpoints.RestaurarVersion)
+		documentoGroup.POST("/:id/restaurar", endpoints.Restaurar)
 		documentoGroup.PATCH("/:id", endpoints.Update)
 		documentoGroup.DELETE("/:id", endpoints.Delete)
+		documentoGroup.GET("/solicitud/:solicitud_id/zip", endpoints.GetZipSolicitud)
 		documentoGroup.DELETE("/solicitud/:solicitud_id", endpoints.DeleteBySolicitudID)
 	}
 
+	//Descargas mediante enlaces firmados, accesibles sin credenciales
+	router.GET("/publico/documentos/:id/contenido", endpoints.GetContenidoEnlace)
+
+	//Cargas reanudables con el protocolo tus
+	cargaGroup := router.Group("/documentos/cargas", cargas.VerificarVersion)
+	{
+		cargaGroup.OPTIONS("", cargas.Opciones)
+		cargaGroup.POST("", cargas.Crear)
+		cargaGroup.HEAD("/:id", cargas.Estado)
+		cargaGroup.PATCH("/:id", cargas.Escribir)
+		cargaGroup.DELETE("/:id", cargas.Eliminar)
+	}
+
 	return router
 }
@@ -0,0 +1,193 @@
+package miniatura
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"mime"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+	_ "golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// Lado es el tamaño máximo en píxeles del lado mayor de la miniatura
+	Lado = 256
+	// TipoMime es el formato en el que se generan las miniaturas
+	TipoMime = "image/jpeg"
+
+	maxArchivo = 50 << 20
+	// maxPixeles evita decodificar imágenes desproporcionadas que agotarían la memoria
+	maxPixeles = 50_000_000
+	// maxLadoPagina limita el tamaño en puntos con que se dibuja la página de un PDF
+	maxLadoPagina = 2000
+)
+
+// ErrNoSoportado indica que no se puede generar una miniatura para el archivo
+var ErrNoSoportado = errors.New("no se puede generar una miniatura para este archivo")
+
+var tiposImagen = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+	"image/bmp":  true,
+}
+
+// Soporta indica si se puede generar una miniatura para el tipo MIME dado
+func Soporta(tipoMime string) bool {
+	tipo := tipoBase(tipoMime)
+	return tiposImagen[tipo] || tipo == "application/pdf"
+}
+
+// Generar crea una miniatura JPEG de una imagen o de la primera página de un PDF.
+// De los PDF solo se dibuja el texto de la primera página, por lo que un PDF escaneado
+// sin texto no tiene miniatura.
+func Generar(r io.Reader, tipoMime string) ([]byte, error) {
+	if !Soporta(tipoMime) {
+		return nil, ErrNoSoportado
+	}
+
+	datos, err := io.ReadAll(io.LimitReader(r, maxArchivo))
+	if err != nil {
+		return nil, fmt.Errorf("error al leer el archivo: %v", err)
+	}
+
+	var origen image.Image
+	if tipoBase(tipoMime) == "application/pdf" {
+		origen, err = primeraPagina(datos)
+	} else {
+		origen, err = decodificar(datos)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, escalar(origen), &jpeg.Options{Quality: 80}); err != nil {
+		return nil, fmt.Errorf("error al codificar la miniatura: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func tipoBase(tipoMime string) string {
+	tipo, _, err := mime.ParseMediaType(tipoMime)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(tipoMime))
+	}
+	return tipo
+}
+
+func decodificar(datos []byte) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(datos))
+	if err != nil {
+		return nil, fmt.Errorf("%w: imagen inválida: %v", ErrNoSoportado, err)
+	}
+	if config.Width*config.Height > maxPixeles {
+		return nil, fmt.Errorf("%w: imagen de %dx%d píxeles", ErrNoSoportado, config.Width, config.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(datos))
+	if err != nil {
+		return nil, fmt.Errorf("%w: imagen inválida: %v", ErrNoSoportado, err)
+	}
+	return img, nil
+}
+
+// escalar reduce la imagen para que su lado mayor mida como máximo Lado píxeles.
+// Las zonas transparentes quedan en blanco porque JPEG no admite transparencia.
+func escalar(origen image.Image) image.Image {
+	b := origen.Bounds()
+	ancho, alto := b.Dx(), b.Dy()
+	if ancho > Lado || alto > Lado {
+		if ancho >= alto {
+			alto = max(1, alto*Lado/ancho)
+			ancho = Lado
+		} else {
+			ancho = max(1, ancho*Lado/alto)
+			alto = Lado
+		}
+	}
+
+	destino := image.NewRGBA(image.Rect(0, 0, ancho, alto))
+	draw.Draw(destino, destino.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(destino, destino.Bounds(), origen, b, draw.Over, nil)
+	return destino
+}
+
+// primeraPagina dibuja el texto de la primera página del PDF en su posición original
+func primeraPagina(datos []byte) (img image.Image, err error) {
+	// La librería de PDF entra en pánico con algunos archivos malformados
+	defer func() {
+		if p := recover(); p != nil {
+			img, err = nil, fmt.Errorf("%w: PDF inválido: %v", ErrNoSoportado, p)
+		}
+	}()
+
+	lector, err := pdf.NewReader(bytes.NewReader(datos), int64(len(datos)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: PDF inválido: %v", ErrNoSoportado, err)
+	}
+	if lector.NumPage() == 0 {
+		return nil, fmt.Errorf("%w: el PDF no tiene páginas", ErrNoSoportado)
+	}
+
+	pagina := lector.Page(1)
+	textos := pagina.Content().Text
+	if len(textos) == 0 {
+		return nil, fmt.Errorf("%w: la primera página no tiene texto", ErrNoSoportado)
+	}
+
+	// MediaBox es [x0 y0 x1 y1] en puntos; si falta se asume tamaño carta
+	ancho, alto := 612.0, 792.0
+	if caja := mediaBox(pagina); caja.Len() == 4 {
+		ancho = caja.Index(2).Float64() - caja.Index(0).Float64()
+		alto = caja.Index(3).Float64() - caja.Index(1).Float64()
+	}
+	if ancho <= 0 || alto <= 0 || ancho > maxLadoPagina || alto > maxLadoPagina {
+		return nil, fmt.Errorf("%w: tamaño de página no soportado", ErrNoSoportado)
+	}
+
+	lienzo := image.NewRGBA(image.Rect(0, 0, int(ancho), int(alto)))
+	draw.Draw(lienzo, lienzo.Bounds(), image.White, image.Point{}, draw.Src)
+	dibujante := &font.Drawer{
+		Dst:  lienzo,
+		Src:  image.NewUniform(color.Gray{Y: 40}),
+		Face: basicfont.Face7x13,
+	}
+	yPrevia := -1.0
+	for _, t := range textos {
+		// En PDF el origen está abajo a la izquierda
+		inicio := fixed.P(int(t.X), int(alto-t.Y))
+		// Sin los anchos de la fuente todas las letras de una línea llegan en la misma posición,
+		// en ese caso se continúa desde donde terminó la letra anterior
+		if t.Y != yPrevia || inicio.X > dibujante.Dot.X {
+			dibujante.Dot = inicio
+		}
+		yPrevia = t.Y
+		dibujante.DrawString(t.S)
+	}
+	return lienzo, nil
+}
+
+// mediaBox busca el tamaño de la página, que puede estar definido en alguno de sus nodos padre
+func mediaBox(pagina pdf.Page) pdf.Value {
+	for v := pagina.V; !v.IsNull(); v = v.Key("Parent") {
+		if caja := v.Key("MediaBox"); !caja.IsNull() {
+			return caja
+		}
+	}
+	return pdf.Value{}
+}
@@ -0,0 +1,136 @@
+package miniatura
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nuevoPNG codifica una imagen de un solo color con las dimensiones indicadas
+func nuevoPNG(t *testing.T, ancho, alto int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, ancho, alto))
+	for x := 0; x < ancho; x++ {
+		for y := 0; y < alto; y++ {
+			img.Set(x, y, color.RGBA{R: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// nuevoPDF arma un PDF de una página de 200x100 puntos con el texto indicado
+func nuevoPDF(texto string) []byte {
+	contenido := fmt.Sprintf("BT /F1 12 Tf 20 50 Td (%s) Tj ET", texto)
+	objetos := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 200 100] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(contenido), contenido),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	desplazamientos := make([]int, len(objetos))
+	for i, objeto := range objetos {
+		desplazamientos[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, objeto)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objetos)+1)
+	for _, d := range desplazamientos {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", d)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objetos)+1, xref)
+	return buf.Bytes()
+}
+
+func TestGenerar(t *testing.T) {
+	t.Run("debe reducir una imagen conservando su proporción", func(t *testing.T) {
+		// Act
+		datos, err := Generar(bytes.NewReader(nuevoPNG(t, 1024, 512)), "image/png")
+
+		// Assert
+		require.NoError(t, err)
+		img, err := jpeg.Decode(bytes.NewReader(datos))
+		require.NoError(t, err)
+		assert.Equal(t, image.Pt(Lado, Lado/2), img.Bounds().Size())
+	})
+
+	t.Run("no debe agrandar una imagen pequeña", func(t *testing.T) {
+		// Act
+		datos, err := Generar(bytes.NewReader(nuevoPNG(t, 40, 80)), "image/png")
+
+		// Assert
+		require.NoError(t, err)
+		img, err := jpeg.Decode(bytes.NewReader(datos))
+		require.NoError(t, err)
+		assert.Equal(t, image.Pt(40, 80), img.Bounds().Size())
+	})
+
+	t.Run("debe dibujar la primera página de un PDF con texto", func(t *testing.T) {
+		// Act
+		datos, err := Generar(bytes.NewReader(nuevoPDF("Curriculum Vitae")), "application/pdf")
+
+		// Assert
+		require.NoError(t, err)
+		img, err := jpeg.Decode(bytes.NewReader(datos))
+		require.NoError(t, err)
+		assert.Equal(t, image.Pt(200, 100), img.Bounds().Size())
+	})
+
+	casos := []struct {
+		nombre   string
+		datos    []byte
+		tipoMime string
+	}{
+		{"un tipo sin miniatura", []byte("texto"), "text/plain"},
+		{"una imagen inválida", []byte("no es una imagen"), "image/png"},
+		{"un PDF inválido", []byte("%PDF-1.4\nbasura"), "application/pdf"},
+		{"un PDF sin texto", nuevoPDF(""), "application/pdf"},
+		{"una imagen desproporcionada", pngConDimensiones(t, 10000, 10000), "image/png"},
+	}
+	for _, caso := range casos {
+		t.Run("debe rechazar "+caso.nombre, func(t *testing.T) {
+			// Act
+			_, err := Generar(bytes.NewReader(caso.datos), caso.tipoMime)
+
+			// Assert
+			assert.ErrorIs(t, err, ErrNoSoportado)
+		})
+	}
+}
+
+// pngConDimensiones codifica una imagen de 1x1 cuyo encabezado declara las dimensiones indicadas
+func pngConDimensiones(t *testing.T, ancho, alto int) []byte {
+	t.Helper()
+	datos := nuevoPNG(t, 1, 1)
+	// El encabezado IHDR guarda el ancho y el alto en los bytes 16 a 23
+	for i, v := range []int{ancho, alto} {
+		datos[16+i*4] = byte(v >> 24)
+		datos[17+i*4] = byte(v >> 16)
+		datos[18+i*4] = byte(v >> 8)
+		datos[19+i*4] = byte(v)
+	}
+	// Recalcular el CRC del encabezado para que siga siendo un PNG válido
+	binary.BigEndian.PutUint32(datos[29:33], crc32.ChecksumIEEE(datos[12:29]))
+	return datos
+}
+
+func TestSoporta(t *testing.T) {
+	assert.True(t, Soporta("image/jpeg"))
+	assert.True(t, Soporta("IMAGE/PNG"))
+	assert.True(t, Soporta("application/pdf; charset=binary"))
+	assert.False(t, Soporta("text/plain"))
+}
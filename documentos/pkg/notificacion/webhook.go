@@ -0,0 +1,53 @@
+package notificacion
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/kramirez/documentos/internal/documento"
+)
+
+// Webhook envía cada aviso de vencimiento como JSON mediante POST a una URL configurada,
+// por ejemplo un servicio de correo o un canal de mensajería
+type Webhook struct {
+	url        string
+	httpClient *http.Client
+}
+
+func NewWebhook(url string) *Webhook {
+	return &Webhook{
+		url: url,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Notificar envía el aviso y considera entregado cualquier respuesta 2xx
+func (w *Webhook) Notificar(ctx context.Context, aviso documento.AvisoVencimiento) error {
+	cuerpo, err := json.Marshal(aviso)
+	if err != nil {
+		return fmt.Errorf("error al codificar el aviso: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(cuerpo))
+	if err != nil {
+		return fmt.Errorf("error al crear la petición del webhook: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error al conectar con el webhook: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("error del webhook: status %d", resp.StatusCode)
+	}
+	return nil
+}
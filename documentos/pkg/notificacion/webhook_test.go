@@ -0,0 +1,70 @@
+package notificacion
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kramirez/documentos/internal/documento"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhook_Notificar(t *testing.T) {
+	ctx := context.Background()
+	aviso := documento.AvisoVencimiento{
+		Evento:      documento.EventoPorVencer,
+		SolicitudID: 1,
+		Documentos: []documento.DocumentoVencimiento{
+			{ID: 3, NombreArchivo: "nda.pdf", VenceEl: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), DiasRestantes: 2},
+		},
+	}
+
+	t.Run("debe enviar el aviso como JSON", func(t *testing.T) {
+		// Arrange
+		var recibido documento.AvisoVencimiento
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&recibido))
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer server.Close()
+
+		// Act
+		err := NewWebhook(server.URL).Notificar(ctx, aviso)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, aviso, recibido)
+	})
+
+	t.Run("debe fallar si el webhook no responde 2xx", func(t *testing.T) {
+		// Arrange
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		// Act
+		err := NewWebhook(server.URL).Notificar(ctx, aviso)
+
+		// Assert
+		assert.ErrorContains(t, err, "status 500")
+	})
+
+	t.Run("debe fallar si no se puede conectar", func(t *testing.T) {
+		// Arrange
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+
+		// Act
+		err := NewWebhook(server.URL).Notificar(ctx, aviso)
+
+		// Assert
+		assert.ErrorContains(t, err, "error al conectar con el webhook")
+	})
+}
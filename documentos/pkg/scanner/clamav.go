@@ -0,0 +1,92 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// chunkSize es el tamaño de cada bloque enviado a clamd con el comando INSTREAM
+const chunkSize = 64 << 10
+
+// ClamAV analiza archivos usando un servidor clamd a través de TCP
+type ClamAV struct {
+	address string
+	timeout time.Duration
+}
+
+func NewClamAV(address string, timeout time.Duration) *ClamAV {
+	return &ClamAV{address: address, timeout: timeout}
+}
+
+// Scan envía el contenido a clamd con el comando INSTREAM y espera la respuesta
+func (c *ClamAV) Scan(ctx context.Context, r io.Reader) (Resultado, error) {
+	dialer := net.Dialer{Timeout: c.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.address)
+	if err != nil {
+		return Resultado{}, fmt.Errorf("error al conectar con clamd: %v", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else if c.timeout > 0 {
+		conn.SetDeadline(time.Now().Add(c.timeout))
+	}
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return Resultado{}, fmt.Errorf("error al enviar comando a clamd: %v", err)
+	}
+
+	// Cada bloque va precedido por su largo en 4 bytes big-endian; un largo 0 indica el fin
+	buf := make([]byte, chunkSize)
+	header := make([]byte, 4)
+	for {
+		n, readErr := r.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(header, uint32(n))
+			if _, err := conn.Write(header); err != nil {
+				return Resultado{}, fmt.Errorf("error al enviar datos a clamd: %v", err)
+			}
+			if _, err := conn.Write(buf[:n]); err != nil {
+				return Resultado{}, fmt.Errorf("error al enviar datos a clamd: %v", err)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return Resultado{}, readErr
+		}
+	}
+	binary.BigEndian.PutUint32(header, 0)
+	if _, err := conn.Write(header); err != nil {
+		return Resultado{}, fmt.Errorf("error al enviar datos a clamd: %v", err)
+	}
+
+	respuesta, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && err != io.EOF {
+		return Resultado{}, fmt.Errorf("error al leer la respuesta de clamd: %v", err)
+	}
+	return parseRespuesta(respuesta)
+}
+
+// parseRespuesta interpreta respuestas como "stream: OK" o "stream: Eicar-Signature FOUND"
+func parseRespuesta(respuesta string) (Resultado, error) {
+	respuesta = strings.TrimSpace(strings.TrimRight(respuesta, "\x00"))
+	_, estado, _ := strings.Cut(respuesta, ": ")
+
+	switch {
+	case estado == "OK":
+		return Resultado{}, nil
+	case strings.HasSuffix(estado, " FOUND"):
+		return Resultado{Infectado: true, Firma: strings.TrimSuffix(estado, " FOUND")}, nil
+	default:
+		return Resultado{}, fmt.Errorf("respuesta inesperada de clamd: %q", respuesta)
+	}
+}
@@ -0,0 +1,182 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// peticionClamd es lo que el servidor simulado recibió en una conexión
+type peticionClamd struct {
+	comando   string
+	bloques   []int
+	contenido []byte
+	terminado bool
+}
+
+// nuevoClamd simula un servidor clamd que atiende una conexión INSTREAM y responde con la respuesta indicada
+func nuevoClamd(t *testing.T, respuesta string) (string, <-chan peticionClamd) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	peticiones := make(chan peticionClamd, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var peticion peticionClamd
+		comando := make([]byte, len("zINSTREAM\x00"))
+		if _, err := io.ReadFull(conn, comando); err != nil {
+			peticiones <- peticion
+			return
+		}
+		peticion.comando = string(comando)
+
+		header := make([]byte, 4)
+		for {
+			if _, err := io.ReadFull(conn, header); err != nil {
+				break
+			}
+			largo := binary.BigEndian.Uint32(header)
+			if largo == 0 {
+				peticion.terminado = true
+				break
+			}
+			bloque := make([]byte, largo)
+			if _, err := io.ReadFull(conn, bloque); err != nil {
+				break
+			}
+			peticion.bloques = append(peticion.bloques, int(largo))
+			peticion.contenido = append(peticion.contenido, bloque...)
+		}
+
+		conn.Write([]byte(respuesta + "\x00"))
+		peticiones <- peticion
+	}()
+	return listener.Addr().String(), peticiones
+}
+
+func TestClamAV_Scan(t *testing.T) {
+	t.Run("debe enviar el contenido en bloques con su largo y terminar con un bloque vacío", func(t *testing.T) {
+		// Arrange
+		address, peticiones := nuevoClamd(t, "stream: OK")
+		contenido := bytes.Repeat([]byte("a"), chunkSize+10)
+		clamav := NewClamAV(address, 5*time.Second)
+
+		// Act
+		resultado, err := clamav.Scan(context.Background(), bytes.NewReader(contenido))
+
+		// Assert
+		require.NoError(t, err)
+		assert.False(t, resultado.Infectado)
+		peticion := <-peticiones
+		assert.Equal(t, "zINSTREAM\x00", peticion.comando)
+		assert.Equal(t, []int{chunkSize, 10}, peticion.bloques)
+		assert.Equal(t, contenido, peticion.contenido)
+		assert.True(t, peticion.terminado, "el último bloque debe tener largo 0")
+	})
+
+	t.Run("debe enviar solo el bloque de término si el archivo está vacío", func(t *testing.T) {
+		// Arrange
+		address, peticiones := nuevoClamd(t, "stream: OK")
+		clamav := NewClamAV(address, 5*time.Second)
+
+		// Act
+		_, err := clamav.Scan(context.Background(), bytes.NewReader(nil))
+
+		// Assert
+		require.NoError(t, err)
+		peticion := <-peticiones
+		assert.Empty(t, peticion.bloques)
+		assert.True(t, peticion.terminado)
+	})
+
+	t.Run("debe reportar la firma detectada", func(t *testing.T) {
+		// Arrange
+		address, _ := nuevoClamd(t, "stream: Eicar-Signature FOUND")
+		clamav := NewClamAV(address, 5*time.Second)
+
+		// Act
+		resultado, err := clamav.Scan(context.Background(), bytes.NewReader([]byte("contenido")))
+
+		// Assert
+		require.NoError(t, err)
+		assert.True(t, resultado.Infectado)
+		assert.Equal(t, "Eicar-Signature", resultado.Firma)
+	})
+
+	t.Run("debe fallar si clamd responde con un error", func(t *testing.T) {
+		// Arrange
+		address, _ := nuevoClamd(t, "INSTREAM size limit exceeded. ERROR")
+		clamav := NewClamAV(address, 5*time.Second)
+
+		// Act
+		_, err := clamav.Scan(context.Background(), bytes.NewReader([]byte("contenido")))
+
+		// Assert
+		assert.ErrorContains(t, err, "respuesta inesperada de clamd")
+	})
+
+	t.Run("debe fallar si no puede conectar con clamd", func(t *testing.T) {
+		// Arrange
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		address := listener.Addr().String()
+		listener.Close()
+		clamav := NewClamAV(address, time.Second)
+
+		// Act
+		_, err = clamav.Scan(context.Background(), bytes.NewReader([]byte("contenido")))
+
+		// Assert
+		assert.ErrorContains(t, err, "error al conectar con clamd")
+	})
+}
+
+func TestParseRespuesta(t *testing.T) {
+	t.Run("debe interpretar un archivo limpio", func(t *testing.T) {
+		// Act
+		resultado, err := parseRespuesta("stream: OK\x00")
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, Resultado{}, resultado)
+	})
+
+	t.Run("debe interpretar una amenaza con su firma", func(t *testing.T) {
+		// Act
+		resultado, err := parseRespuesta("stream: Win.Test.EICAR_HDB-1 FOUND\n")
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, Resultado{Infectado: true, Firma: "Win.Test.EICAR_HDB-1"}, resultado)
+	})
+
+	t.Run("debe fallar con una respuesta de error", func(t *testing.T) {
+		// Act
+		_, err := parseRespuesta("stream: Can't allocate memory ERROR")
+
+		// Assert
+		assert.Error(t, err)
+	})
+
+	t.Run("debe fallar con una respuesta vacía", func(t *testing.T) {
+		// Act
+		_, err := parseRespuesta("")
+
+		// Assert
+		assert.Error(t, err)
+	})
+}
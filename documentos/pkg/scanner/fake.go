@@ -0,0 +1,39 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"io"
+)
+
+// eicar es la firma de prueba estándar que todos los antivirus detectan
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// Fake es un Scanner en memoria para pruebas y desarrollo local.
+// Marca como infectado todo archivo que contenga la firma EICAR o alguno de los patrones configurados.
+type Fake struct {
+	// Patrones adicionales a detectar, con el nombre de la firma reportada
+	Patrones map[string]string
+	// Err, si no es nil, se retorna en cada análisis para simular fallas del antivirus
+	Err error
+}
+
+func NewFake() *Fake {
+	return &Fake{Patrones: map[string]string{eicar: "Eicar-Test-Signature"}}
+}
+
+func (f *Fake) Scan(ctx context.Context, r io.Reader) (Resultado, error) {
+	if f.Err != nil {
+		return Resultado{}, f.Err
+	}
+	contenido, err := io.ReadAll(r)
+	if err != nil {
+		return Resultado{}, err
+	}
+	for patron, firma := range f.Patrones {
+		if bytes.Contains(contenido, []byte(patron)) {
+			return Resultado{Infectado: true, Firma: firma}, nil
+		}
+	}
+	return Resultado{}, nil
+}
@@ -0,0 +1,18 @@
+package scanner
+
+import (
+	"context"
+	"io"
+)
+
+// Resultado es el resultado de analizar un archivo
+type Resultado struct {
+	Infectado bool
+	// Firma es el nombre de la amenaza detectada, vacío si el archivo está limpio
+	Firma string
+}
+
+// Scanner analiza el contenido de un archivo en busca de malware
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (Resultado, error)
+}
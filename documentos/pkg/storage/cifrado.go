@@ -0,0 +1,342 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/kramirez/documentos/pkg/cifrado"
+)
+
+// Formato de un archivo cifrado:
+//
+//	cabecera: "DOCCIF01" | ID de la clave maestra | clave de datos envuelta | tamaño de bloque (uint32)
+//	bloques:  cada bloque de contenido cifrado con AES-256-GCM y su etiqueta de autenticación
+//
+// El contenido se cifra en bloques para poder descifrar desde cualquier posición (peticiones Range)
+// sin leer el archivo completo. El nonce de cada bloque es su índice, lo que es seguro porque cada
+// archivo tiene su propia clave de datos, y el último bloque se marca para detectar archivos truncados.
+const (
+	firmaCifrado   = "DOCCIF01"
+	tamanoCabecera = len(firmaCifrado) + cifrado.TamanoID + cifrado.TamanoEnvuelta + 4
+	tamanoBloque   = 64 << 10
+	tamanoEtiqueta = 16
+)
+
+// CifradoStorage cifra el contenido antes de guardarlo en otro Storage y lo descifra al abrirlo,
+// de forma transparente para quien lo usa. No implementa Presigner porque una URL directa al
+// almacenamiento entregaría el contenido cifrado.
+type CifradoStorage struct {
+	base    Storage
+	llavero *cifrado.Llavero
+}
+
+func NewCifradoStorage(base Storage, llavero *cifrado.Llavero) *CifradoStorage {
+	return &CifradoStorage{base: base, llavero: llavero}
+}
+
+// cabecera es la información al inicio de un archivo cifrado
+type cabecera struct {
+	clave    cifrado.ID
+	envuelta []byte
+	bloque   int64
+}
+
+func (c cabecera) bytes() []byte {
+	buf := make([]byte, 0, tamanoCabecera)
+	buf = append(buf, firmaCifrado...)
+	buf = append(buf, c.clave[:]...)
+	buf = append(buf, c.envuelta...)
+	return binary.BigEndian.AppendUint32(buf, uint32(c.bloque))
+}
+
+// leerCabecera lee la cabecera de un archivo. Retorna false si el archivo no está cifrado,
+// por ejemplo porque se guardó antes de activar el cifrado.
+func leerCabecera(r io.Reader) (*cabecera, bool, error) {
+	buf := make([]byte, tamanoCabecera)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	if string(buf[:len(firmaCifrado)]) != firmaCifrado {
+		return nil, false, nil
+	}
+	buf = buf[len(firmaCifrado):]
+	c := &cabecera{}
+	copy(c.clave[:], buf[:cifrado.TamanoID])
+	c.envuelta = buf[cifrado.TamanoID : cifrado.TamanoID+cifrado.TamanoEnvuelta]
+	c.bloque = int64(binary.BigEndian.Uint32(buf[cifrado.TamanoID+cifrado.TamanoEnvuelta:]))
+	if c.bloque <= 0 {
+		return nil, false, fmt.Errorf("tamaño de bloque inválido en la cabecera")
+	}
+	return c, true, nil
+}
+
+// nonceBloque construye el nonce de un bloque a partir de su índice
+func nonceBloque(aead cipher.AEAD, indice int64) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], uint64(indice))
+	return nonce
+}
+
+// datosAdicionales autentica si el bloque es el último del archivo
+func datosAdicionales(ultimo bool) []byte {
+	if ultimo {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// Save cifra el contenido con una nueva clave de datos y retorna los bytes de contenido guardados
+func (s *CifradoStorage) Save(ctx context.Context, key string, r io.Reader) (int64, error) {
+	clave, envuelta, err := s.llavero.NuevaClaveDatos()
+	if err != nil {
+		return 0, fmt.Errorf("error al generar la clave de datos: %v", err)
+	}
+	aead, err := cifrado.NuevoAEAD(clave)
+	if err != nil {
+		return 0, err
+	}
+	c := cabecera{clave: s.llavero.Actual(), envuelta: envuelta, bloque: tamanoBloque}
+
+	// El contenido se cifra a medida que el almacenamiento lo lee
+	pr, pw := io.Pipe()
+	type resultado struct {
+		n   int64
+		err error
+	}
+	hecho := make(chan resultado, 1)
+	go func() {
+		n, err := cifrarBloques(pw, r, aead, c)
+		pw.CloseWithError(err)
+		hecho <- resultado{n, err}
+	}()
+
+	_, err = s.base.Save(ctx, key, pr)
+	pr.CloseWithError(io.ErrClosedPipe)
+	res := <-hecho
+	if err != nil {
+		return 0, err
+	}
+	if res.err != nil {
+		return 0, res.err
+	}
+	return res.n, nil
+}
+
+// cifrarBloques escribe la cabecera y el contenido de r cifrado en bloques
+func cifrarBloques(w io.Writer, r io.Reader, aead cipher.AEAD, c cabecera) (int64, error) {
+	if _, err := w.Write(c.bytes()); err != nil {
+		return 0, err
+	}
+
+	lector := bufio.NewReaderSize(r, int(c.bloque))
+	bloque := make([]byte, c.bloque)
+	sellado := make([]byte, 0, c.bloque+tamanoEtiqueta)
+	var total int64
+	for indice := int64(0); ; indice++ {
+		n, err := io.ReadFull(lector, bloque)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return total, err
+		}
+		// Un bloque incompleto es el último; uno completo lo es si no queda nada por leer
+		ultimo := err != nil
+		if !ultimo {
+			if _, err := lector.Peek(1); errors.Is(err, io.EOF) {
+				ultimo = true
+			}
+		}
+
+		sellado = aead.Seal(sellado[:0], nonceBloque(aead, indice), bloque[:n], datosAdicionales(ultimo))
+		if _, err := w.Write(sellado); err != nil {
+			return total, err
+		}
+		total += int64(n)
+		if ultimo {
+			return total, nil
+		}
+	}
+}
+
+// Open descifra el contenido a medida que se lee. Los archivos guardados sin cifrar se
+// retornan tal cual para que sigan disponibles hasta que se ejecute el re-cifrado.
+func (s *CifradoStorage) Open(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	archivo, err := s.base.Open(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	c, cifradoOK, err := leerCabecera(archivo)
+	if err == nil && !cifradoOK {
+		_, err = archivo.Seek(0, io.SeekStart)
+		if err == nil {
+			return archivo, nil
+		}
+	}
+	if err != nil {
+		archivo.Close()
+		return nil, err
+	}
+
+	lector, err := s.nuevoLector(archivo, c)
+	if err != nil {
+		archivo.Close()
+		return nil, err
+	}
+	return lector, nil
+}
+
+func (s *CifradoStorage) nuevoLector(archivo io.ReadSeekCloser, c *cabecera) (*lectorCifrado, error) {
+	clave, err := s.llavero.Desenvolver(c.clave, c.envuelta)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cifrado.NuevoAEAD(clave)
+	if err != nil {
+		return nil, err
+	}
+
+	// El tamaño del contenido se deduce del tamaño del archivo y la cantidad de bloques
+	fin, err := archivo.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	cuerpo := fin - int64(tamanoCabecera)
+	bloqueCifrado := c.bloque + tamanoEtiqueta
+	bloques := (cuerpo + bloqueCifrado - 1) / bloqueCifrado
+	if bloques == 0 || cuerpo-(bloques-1)*bloqueCifrado < tamanoEtiqueta {
+		return nil, fmt.Errorf("el archivo cifrado está incompleto")
+	}
+
+	return &lectorCifrado{
+		archivo: archivo,
+		aead:    aead,
+		bloque:  c.bloque,
+		bloques: bloques,
+		tamano:  cuerpo - bloques*tamanoEtiqueta,
+		indice:  -1,
+	}, nil
+}
+
+func (s *CifradoStorage) Delete(ctx context.Context, key string) error {
+	return s.base.Delete(ctx, key)
+}
+
+// Recifrar vuelve a guardar un archivo para que quede protegido por la clave maestra actual.
+// Si estaba cifrado con otra clave solo se re-envuelve su clave de datos, sin descifrar el
+// contenido; si estaba guardado sin cifrar se cifra completo. Retorna false si no hubo cambios.
+func (s *CifradoStorage) Recifrar(ctx context.Context, key string) (bool, error) {
+	archivo, err := s.base.Open(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	defer archivo.Close()
+
+	c, cifradoOK, err := leerCabecera(archivo)
+	if err != nil {
+		return false, err
+	}
+	if !cifradoOK {
+		if _, err := archivo.Seek(0, io.SeekStart); err != nil {
+			return false, err
+		}
+		_, err := s.Save(ctx, key, archivo)
+		return err == nil, err
+	}
+	if c.clave == s.llavero.Actual() {
+		return false, nil
+	}
+
+	clave, err := s.llavero.Desenvolver(c.clave, c.envuelta)
+	if err != nil {
+		return false, err
+	}
+	c.envuelta, err = s.llavero.Envolver(clave)
+	if err != nil {
+		return false, err
+	}
+	c.clave = s.llavero.Actual()
+	// El archivo ya está posicionado después de la cabecera
+	_, err = s.base.Save(ctx, key, io.MultiReader(bytes.NewReader(c.bytes()), archivo))
+	return err == nil, err
+}
+
+// lectorCifrado descifra bajo demanda el bloque que contiene la posición actual
+type lectorCifrado struct {
+	archivo io.ReadSeekCloser
+	aead    cipher.AEAD
+	bloque  int64
+	bloques int64
+	tamano  int64
+	pos     int64
+	// Bloque descifrado actualmente en memoria y su índice (-1 si ninguno)
+	actual []byte
+	indice int64
+}
+
+func (l *lectorCifrado) Read(p []byte) (int, error) {
+	if l.pos >= l.tamano {
+		return 0, io.EOF
+	}
+	indice := l.pos / l.bloque
+	if indice != l.indice {
+		if err := l.cargarBloque(indice); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, l.actual[l.pos-indice*l.bloque:])
+	l.pos += int64(n)
+	return n, nil
+}
+
+func (l *lectorCifrado) cargarBloque(indice int64) error {
+	bloqueCifrado := l.bloque + tamanoEtiqueta
+	if _, err := l.archivo.Seek(int64(tamanoCabecera)+indice*bloqueCifrado, io.SeekStart); err != nil {
+		return err
+	}
+	largo := bloqueCifrado
+	ultimo := indice == l.bloques-1
+	if ultimo {
+		largo = l.tamano - indice*l.bloque + tamanoEtiqueta
+	}
+	buf := make([]byte, largo)
+	if _, err := io.ReadFull(l.archivo, buf); err != nil {
+		return err
+	}
+	contenido, err := l.aead.Open(buf[:0], nonceBloque(l.aead, indice), buf, datosAdicionales(ultimo))
+	if err != nil {
+		return fmt.Errorf("el contenido cifrado fue alterado o está dañado")
+	}
+	l.actual, l.indice = contenido, indice
+	return nil
+}
+
+func (l *lectorCifrado) Seek(offset int64, whence int) (int64, error) {
+	var pos int64
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		pos = l.pos + offset
+	case io.SeekEnd:
+		pos = l.tamano + offset
+	default:
+		return 0, fmt.Errorf("whence inválido: %d", whence)
+	}
+	if pos < 0 {
+		return 0, fmt.Errorf("posición negativa: %d", pos)
+	}
+	l.pos = pos
+	return pos, nil
+}
+
+func (l *lectorCifrado) Close() error {
+	return l.archivo.Close()
+}
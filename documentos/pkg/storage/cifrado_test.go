@@ -0,0 +1,392 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/kramirez/documentos/pkg/cifrado"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nuevaClave genera una clave maestra aleatoria
+func nuevaClave(t *testing.T) []byte {
+	t.Helper()
+	clave := make([]byte, cifrado.TamanoClave)
+	_, err := rand.Read(clave)
+	require.NoError(t, err)
+	return clave
+}
+
+// setupCifrado crea un CifradoStorage sobre un directorio temporal con el llavero indicado
+func setupCifrado(t *testing.T, claves ...[]byte) (*CifradoStorage, *LocalStorage, string) {
+	t.Helper()
+	dir := t.TempDir()
+	base, err := NewLocalStorage(dir)
+	require.NoError(t, err)
+	llavero, err := cifrado.NewLlavero(claves[0], claves[1:]...)
+	require.NoError(t, err)
+	return NewCifradoStorage(base, llavero), base, dir
+}
+
+func TestCifradoStorage_SaveOpen(t *testing.T) {
+	ctx := context.Background()
+	tamanos := []int{0, 1, tamanoBloque - 1, tamanoBloque, tamanoBloque + 1, 3*tamanoBloque + 77}
+
+	for _, n := range tamanos {
+		t.Run("debe recuperar el contenido original de "+strconv.Itoa(n)+" bytes", func(t *testing.T) {
+			// Arrange
+			s, _, dir := setupCifrado(t, nuevaClave(t))
+			contenido := contenidoAleatorio(t, n)
+
+			// Act
+			guardados, err := s.Save(ctx, "doc", bytes.NewReader(contenido))
+			leido, errLectura := leerTodo(t, s, "doc")
+
+			// Assert
+			require.NoError(t, err)
+			require.NoError(t, errLectura)
+			assert.Equal(t, int64(n), guardados)
+			assert.Equal(t, contenido, leido)
+
+			crudo, err := os.ReadFile(filepath.Join(dir, "doc"))
+			require.NoError(t, err)
+			bloques := (n + tamanoBloque - 1) / tamanoBloque
+			if bloques == 0 {
+				bloques = 1
+			}
+			assert.Equal(t, tamanoCabecera+n+bloques*tamanoEtiqueta, len(crudo))
+			if n >= 32 {
+				assert.False(t, bytes.Contains(crudo, contenido[:32]), "el contenido no debe quedar en claro")
+			}
+		})
+	}
+
+	t.Run("debe retornar tal cual los archivos guardados sin cifrar", func(t *testing.T) {
+		// Arrange
+		s, base, _ := setupCifrado(t, nuevaClave(t))
+		_, err := base.Save(ctx, "plano", bytes.NewReader([]byte("contenido sin cifrar")))
+		require.NoError(t, err)
+
+		// Act
+		leido, err := leerTodo(t, s, "plano")
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "contenido sin cifrar", string(leido))
+	})
+}
+
+func TestCifradoStorage_Seek(t *testing.T) {
+	ctx := context.Background()
+	s, _, _ := setupCifrado(t, nuevaClave(t))
+	contenido := contenidoAleatorio(t, 3*tamanoBloque+77)
+	_, err := s.Save(ctx, "doc", bytes.NewReader(contenido))
+	require.NoError(t, err)
+
+	rangos := []struct {
+		nombre string
+		inicio int
+		largo  int
+	}{
+		{"dentro del primer bloque", 10, 100},
+		{"que cruza el límite entre dos bloques", tamanoBloque - 5, 10},
+		{"que abarca varios bloques", tamanoBloque / 2, 2 * tamanoBloque},
+		{"del último bloque incompleto", 3*tamanoBloque + 10, 67},
+	}
+	for _, rango := range rangos {
+		t.Run("debe leer un rango "+rango.nombre, func(t *testing.T) {
+			// Arrange
+			archivo, err := s.Open(ctx, "doc")
+			require.NoError(t, err)
+			defer archivo.Close()
+
+			// Act
+			pos, err := archivo.Seek(int64(rango.inicio), io.SeekStart)
+			require.NoError(t, err)
+			leido := make([]byte, rango.largo)
+			_, err = io.ReadFull(archivo, leido)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, int64(rango.inicio), pos)
+			assert.Equal(t, contenido[rango.inicio:rango.inicio+rango.largo], leido)
+		})
+	}
+
+	t.Run("debe informar el tamaño del contenido al buscar desde el final", func(t *testing.T) {
+		// Arrange
+		archivo, err := s.Open(ctx, "doc")
+		require.NoError(t, err)
+		defer archivo.Close()
+
+		// Act
+		tamano, err := archivo.Seek(0, io.SeekEnd)
+		require.NoError(t, err)
+		_, err = archivo.Seek(-20, io.SeekCurrent)
+		require.NoError(t, err)
+		resto, errLectura := io.ReadAll(archivo)
+
+		// Assert
+		require.NoError(t, errLectura)
+		assert.Equal(t, int64(len(contenido)), tamano)
+		assert.Equal(t, contenido[len(contenido)-20:], resto)
+	})
+
+	t.Run("debe rechazar una posición negativa", func(t *testing.T) {
+		// Arrange
+		archivo, err := s.Open(ctx, "doc")
+		require.NoError(t, err)
+		defer archivo.Close()
+
+		// Act
+		_, err = archivo.Seek(-1, io.SeekStart)
+
+		// Assert
+		assert.Error(t, err)
+	})
+}
+
+func TestCifradoStorage_Integridad(t *testing.T) {
+	ctx := context.Background()
+	bloqueCifrado := tamanoBloque + tamanoEtiqueta
+
+	// guardarAlterado cifra el contenido, aplica la alteración al archivo y lo guarda en otra clave
+	guardarAlterado := func(t *testing.T, alterar func([]byte) []byte) (*CifradoStorage, string) {
+		t.Helper()
+		s, _, dir := setupCifrado(t, nuevaClave(t))
+		_, err := s.Save(ctx, "doc", bytes.NewReader(contenidoAleatorio(t, 3*tamanoBloque+77)))
+		require.NoError(t, err)
+		crudo, err := os.ReadFile(filepath.Join(dir, "doc"))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "alterado"), alterar(crudo), 0o644))
+		return s, "alterado"
+	}
+
+	t.Run("debe detectar un archivo truncado en el límite de un bloque", func(t *testing.T) {
+		// Arrange
+		s, key := guardarAlterado(t, func(crudo []byte) []byte {
+			return crudo[:tamanoCabecera+2*bloqueCifrado]
+		})
+
+		// Act
+		_, err := leerTodo(t, s, key)
+
+		// Assert
+		assert.Error(t, err)
+	})
+
+	t.Run("debe detectar un archivo truncado a mitad de un bloque", func(t *testing.T) {
+		// Arrange
+		s, key := guardarAlterado(t, func(crudo []byte) []byte {
+			return crudo[:tamanoCabecera+bloqueCifrado+100]
+		})
+
+		// Act
+		_, err := leerTodo(t, s, key)
+
+		// Assert
+		assert.Error(t, err)
+	})
+
+	t.Run("debe detectar bloques reordenados", func(t *testing.T) {
+		// Arrange
+		s, key := guardarAlterado(t, func(crudo []byte) []byte {
+			alterado := append([]byte(nil), crudo...)
+			primero := alterado[tamanoCabecera : tamanoCabecera+bloqueCifrado]
+			segundo := alterado[tamanoCabecera+bloqueCifrado : tamanoCabecera+2*bloqueCifrado]
+			copia := append([]byte(nil), primero...)
+			copy(primero, segundo)
+			copy(segundo, copia)
+			return alterado
+		})
+
+		// Act
+		_, err := leerTodo(t, s, key)
+
+		// Assert
+		assert.Error(t, err)
+	})
+
+	t.Run("debe detectar un bloque completo marcado como último", func(t *testing.T) {
+		// Arrange: se descarta el último bloque y el anterior queda al final del archivo
+		s, key := guardarAlterado(t, func(crudo []byte) []byte {
+			return crudo[:tamanoCabecera+3*bloqueCifrado]
+		})
+
+		// Act
+		archivo, err := s.Open(ctx, key)
+		require.NoError(t, err)
+		defer archivo.Close()
+		_, err = archivo.Seek(int64(2*tamanoBloque), io.SeekStart)
+		require.NoError(t, err)
+		_, err = io.ReadAll(archivo)
+
+		// Assert
+		assert.Error(t, err)
+	})
+
+	t.Run("debe detectar un byte alterado en el contenido", func(t *testing.T) {
+		// Arrange
+		s, key := guardarAlterado(t, func(crudo []byte) []byte {
+			alterado := append([]byte(nil), crudo...)
+			alterado[tamanoCabecera+bloqueCifrado+10] ^= 0xff
+			return alterado
+		})
+
+		// Act
+		_, err := leerTodo(t, s, key)
+
+		// Assert
+		assert.Error(t, err)
+	})
+
+	t.Run("debe rechazar una cabecera con la clave de datos alterada", func(t *testing.T) {
+		// Arrange
+		s, key := guardarAlterado(t, func(crudo []byte) []byte {
+			alterado := append([]byte(nil), crudo...)
+			alterado[len(firmaCifrado)+cifrado.TamanoID+20] ^= 0xff
+			return alterado
+		})
+
+		// Act
+		_, err := s.Open(ctx, key)
+
+		// Assert
+		assert.Error(t, err)
+	})
+
+	t.Run("debe rechazar una cabecera con una clave maestra desconocida", func(t *testing.T) {
+		// Arrange
+		s, key := guardarAlterado(t, func(crudo []byte) []byte {
+			alterado := append([]byte(nil), crudo...)
+			alterado[len(firmaCifrado)] ^= 0xff
+			return alterado
+		})
+
+		// Act
+		_, err := s.Open(ctx, key)
+
+		// Assert
+		assert.ErrorIs(t, err, cifrado.ErrClaveDesconocida)
+	})
+
+	t.Run("debe rechazar una cabecera con el tamaño de bloque alterado", func(t *testing.T) {
+		// Arrange
+		s, key := guardarAlterado(t, func(crudo []byte) []byte {
+			alterado := append([]byte(nil), crudo...)
+			alterado[tamanoCabecera-2] ^= 0x01
+			return alterado
+		})
+
+		// Act
+		_, err := leerTodo(t, s, key)
+
+		// Assert
+		assert.Error(t, err)
+	})
+}
+
+func TestCifradoStorage_Recifrar(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("debe re-envolver con la clave nueva sin dejar de leer lo cifrado con la anterior", func(t *testing.T) {
+		// Arrange
+		anterior, nueva := nuevaClave(t), nuevaClave(t)
+		viejo, base, dir := setupCifrado(t, anterior)
+		contenido := contenidoAleatorio(t, 2*tamanoBloque+5)
+		_, err := viejo.Save(ctx, "rotado", bytes.NewReader(contenido))
+		require.NoError(t, err)
+		_, err = viejo.Save(ctx, "pendiente", bytes.NewReader(contenido))
+		require.NoError(t, err)
+		antes, err := os.ReadFile(filepath.Join(dir, "rotado"))
+		require.NoError(t, err)
+
+		llavero, err := cifrado.NewLlavero(nueva, anterior)
+		require.NoError(t, err)
+		rotado := NewCifradoStorage(base, llavero)
+
+		// Act
+		cambio, err := rotado.Recifrar(ctx, "rotado")
+
+		// Assert
+		require.NoError(t, err)
+		assert.True(t, cambio)
+
+		despues, err := os.ReadFile(filepath.Join(dir, "rotado"))
+		require.NoError(t, err)
+		assert.Equal(t, antes[tamanoCabecera:], despues[tamanoCabecera:], "solo debe cambiar la cabecera")
+		assert.NotEqual(t, antes[:tamanoCabecera], despues[:tamanoCabecera])
+
+		// El archivo aún no re-envuelto se sigue leyendo con la clave anterior
+		leido, err := leerTodo(t, rotado, "pendiente")
+		require.NoError(t, err)
+		assert.Equal(t, contenido, leido)
+
+		// El re-envuelto se lee solo con la clave nueva
+		soloNueva, err := cifrado.NewLlavero(nueva)
+		require.NoError(t, err)
+		leido, err = leerTodo(t, NewCifradoStorage(base, soloNueva), "rotado")
+		require.NoError(t, err)
+		assert.Equal(t, contenido, leido)
+
+		_, err = viejo.Open(ctx, "rotado")
+		assert.ErrorIs(t, err, cifrado.ErrClaveDesconocida)
+	})
+
+	t.Run("no debe modificar un archivo cifrado con la clave actual", func(t *testing.T) {
+		// Arrange
+		s, _, dir := setupCifrado(t, nuevaClave(t))
+		_, err := s.Save(ctx, "doc", bytes.NewReader([]byte("contenido")))
+		require.NoError(t, err)
+		antes, err := os.ReadFile(filepath.Join(dir, "doc"))
+		require.NoError(t, err)
+
+		// Act
+		cambio, err := s.Recifrar(ctx, "doc")
+
+		// Assert
+		require.NoError(t, err)
+		assert.False(t, cambio)
+		despues, err := os.ReadFile(filepath.Join(dir, "doc"))
+		require.NoError(t, err)
+		assert.Equal(t, antes, despues)
+	})
+
+	t.Run("debe cifrar un archivo guardado sin cifrar", func(t *testing.T) {
+		// Arrange
+		s, base, dir := setupCifrado(t, nuevaClave(t))
+		_, err := base.Save(ctx, "plano", bytes.NewReader([]byte("contenido sin cifrar")))
+		require.NoError(t, err)
+
+		// Act
+		cambio, err := s.Recifrar(ctx, "plano")
+
+		// Assert
+		require.NoError(t, err)
+		assert.True(t, cambio)
+		crudo, err := os.ReadFile(filepath.Join(dir, "plano"))
+		require.NoError(t, err)
+		assert.Equal(t, firmaCifrado, string(crudo[:len(firmaCifrado)]))
+		leido, err := leerTodo(t, s, "plano")
+		require.NoError(t, err)
+		assert.Equal(t, "contenido sin cifrar", string(leido))
+	})
+
+	t.Run("debe fallar si el archivo no existe", func(t *testing.T) {
+		// Arrange
+		s, _, _ := setupCifrado(t, nuevaClave(t))
+
+		// Act
+		_, err := s.Recifrar(ctx, "inexistente")
+
+		// Assert
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
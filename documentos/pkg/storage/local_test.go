@@ -0,0 +1,159 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// leerTodo abre la clave y retorna su contenido completo
+func leerTodo(t *testing.T, s Storage, key string) ([]byte, error) {
+	t.Helper()
+	archivo, err := s.Open(context.Background(), key)
+	if err != nil {
+		return nil, err
+	}
+	defer archivo.Close()
+	return io.ReadAll(archivo)
+}
+
+func TestLocalStorage_Save(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("debe guardar el archivo en subdirectorios sin dejar temporales", func(t *testing.T) {
+		// Arrange
+		dir := t.TempDir()
+		s, err := NewLocalStorage(dir)
+		require.NoError(t, err)
+
+		// Act
+		n, err := s.Save(ctx, "solicitudes/1/cv.pdf", bytes.NewReader([]byte("contenido")))
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, int64(9), n)
+		contenido, err := os.ReadFile(filepath.Join(dir, "solicitudes", "1", "cv.pdf"))
+		require.NoError(t, err)
+		assert.Equal(t, "contenido", string(contenido))
+		archivos, err := os.ReadDir(filepath.Join(dir, "solicitudes", "1"))
+		require.NoError(t, err)
+		assert.Len(t, archivos, 1)
+	})
+
+	t.Run("debe reemplazar un archivo existente", func(t *testing.T) {
+		// Arrange
+		s, err := NewLocalStorage(t.TempDir())
+		require.NoError(t, err)
+		_, err = s.Save(ctx, "cv.pdf", bytes.NewReader([]byte("anterior")))
+		require.NoError(t, err)
+
+		// Act
+		_, err = s.Save(ctx, "cv.pdf", bytes.NewReader([]byte("nuevo")))
+
+		// Assert
+		require.NoError(t, err)
+		contenido, err := leerTodo(t, s, "cv.pdf")
+		require.NoError(t, err)
+		assert.Equal(t, "nuevo", string(contenido))
+	})
+
+	t.Run("debe rechazar claves fuera del directorio base", func(t *testing.T) {
+		// Arrange
+		dir := t.TempDir()
+		s, err := NewLocalStorage(filepath.Join(dir, "base"))
+		require.NoError(t, err)
+
+		for _, clave := range []string{"", "..", "../fuera.pdf", "a/../../fuera.pdf", "/etc/passwd"} {
+			// Act
+			_, err := s.Save(ctx, clave, bytes.NewReader([]byte("x")))
+
+			// Assert
+			assert.Error(t, err, clave)
+		}
+		_, err = os.Stat(filepath.Join(dir, "fuera.pdf"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("debe fallar si el contexto fue cancelado", func(t *testing.T) {
+		// Arrange
+		s, err := NewLocalStorage(t.TempDir())
+		require.NoError(t, err)
+		ctxCancelado, cancel := context.WithCancel(ctx)
+		cancel()
+
+		// Act
+		_, err = s.Save(ctxCancelado, "cv.pdf", bytes.NewReader([]byte("x")))
+
+		// Assert
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestLocalStorage_OpenDelete(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("debe retornar ErrNotFound si la clave no existe", func(t *testing.T) {
+		// Arrange
+		s, err := NewLocalStorage(t.TempDir())
+		require.NoError(t, err)
+
+		// Act
+		_, err = s.Open(ctx, "no-existe.pdf")
+
+		// Assert
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("debe eliminar el archivo", func(t *testing.T) {
+		// Arrange
+		s, err := NewLocalStorage(t.TempDir())
+		require.NoError(t, err)
+		_, err = s.Save(ctx, "cv.pdf", bytes.NewReader([]byte("x")))
+		require.NoError(t, err)
+
+		// Act
+		err = s.Delete(ctx, "cv.pdf")
+
+		// Assert
+		require.NoError(t, err)
+		_, err = s.Open(ctx, "cv.pdf")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("no debe fallar al eliminar una clave inexistente", func(t *testing.T) {
+		// Arrange
+		s, err := NewLocalStorage(t.TempDir())
+		require.NoError(t, err)
+
+		// Act
+		err = s.Delete(ctx, "no-existe.pdf")
+
+		// Assert
+		assert.NoError(t, err)
+	})
+}
+
+func TestSpool(t *testing.T) {
+	t.Run("debe calcular el checksum y eliminar el temporal al cerrar", func(t *testing.T) {
+		// Act
+		archivo, err := Spool(bytes.NewReader([]byte("contenido")))
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "6f9566ef46386b8cf372671cb9eddff5488256eff5ea5fb99e1041c3e27082bf", archivo.Checksum)
+		assert.Equal(t, int64(9), archivo.Size)
+		assert.Equal(t, "blobs/6f/"+archivo.Checksum, ContentKey(archivo.Checksum))
+		contenido, err := io.ReadAll(archivo)
+		require.NoError(t, err)
+		assert.Equal(t, "contenido", string(contenido), "el archivo queda posicionado al inicio")
+		require.NoError(t, archivo.Close())
+		_, err = os.Stat(archivo.Name())
+		assert.True(t, os.IsNotExist(err))
+	})
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// maxExpiracionPresignada es la vigencia máxima que S3 admite para una URL presignada
+const maxExpiracionPresignada = 7 * 24 * time.Hour
+
+// S3Config contiene los datos de conexión a un bucket compatible con S3 (AWS, MinIO, etc.)
+type S3Config struct {
+	Endpoint  string // por ejemplo s3.amazonaws.com o localhost:9000
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UsarSSL   bool
+	// TamanoParte es el tamaño de cada parte de la carga multiparte; 0 usa el valor por defecto del cliente
+	TamanoParte uint64
+}
+
+// S3Storage guarda los archivos como objetos de un bucket compatible con S3
+type S3Storage struct {
+	client      *minio.Client
+	bucket      string
+	tamanoParte uint64
+}
+
+// NewS3Storage se conecta al bucket y lo crea si aún no existe
+func NewS3Storage(ctx context.Context, config S3Config) (*S3Storage, error) {
+	client, err := minio.New(config.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(config.AccessKey, config.SecretKey, ""),
+		Secure: config.UsarSSL,
+		Region: config.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error al crear el cliente S3: %v", err)
+	}
+
+	existe, err := client.BucketExists(ctx, config.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("error al verificar el bucket %s: %v", config.Bucket, err)
+	}
+	if !existe {
+		if err := client.MakeBucket(ctx, config.Bucket, minio.MakeBucketOptions{Region: config.Region}); err != nil {
+			return nil, fmt.Errorf("error al crear el bucket %s: %v", config.Bucket, err)
+		}
+	}
+
+	return &S3Storage{client: client, bucket: config.Bucket, tamanoParte: config.TamanoParte}, nil
+}
+
+// Save sube el contenido sin conocer su tamaño de antemano, por lo que el cliente
+// lo envía como carga multiparte leyendo una parte a la vez
+func (s *S3Storage) Save(ctx context.Context, key string, r io.Reader) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("clave de almacenamiento inválida: %q", key)
+	}
+	info, err := s.client.PutObject(ctx, s.bucket, key, r, -1, minio.PutObjectOptions{
+		PartSize:    s.tamanoParte,
+		ContentType: "application/octet-stream",
+		// La integridad de cada parte se verifica con Content-MD5 en lugar de la firma por bloques
+		// (aws-chunked), que no todos los servicios compatibles con S3 soportan
+		DisableContentSha256: true,
+		SendContentMd5:       true,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return info.Size, nil
+}
+
+// Open retorna el objeto, que se descarga a medida que se lee. Seek permite atender
+// peticiones Range sin descargar el objeto completo.
+func (s *S3Storage) Open(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	objeto, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, traducirErrorS3(err)
+	}
+	// GetObject no consulta el bucket hasta la primera lectura; Stat detecta si la clave no existe
+	if _, err := objeto.Stat(); err != nil {
+		objeto.Close()
+		return nil, traducirErrorS3(err)
+	}
+	return objeto, nil
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	// S3 no informa error al eliminar una clave inexistente, igual que LocalStorage
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+// PresignedURL genera una URL temporal para descargar el objeto directamente desde el bucket
+func (s *S3Storage) PresignedURL(ctx context.Context, key, filename string, expires time.Duration) (string, error) {
+	expires = min(expires, maxExpiracionPresignada)
+	params := url.Values{}
+	if filename != "" {
+		params.Set("response-content-disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	}
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, key, expires, params)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// traducirErrorS3 convierte la respuesta de clave inexistente en ErrNotFound
+func traducirErrorS3(err error) error {
+	respuesta := minio.ToErrorResponse(err)
+	if respuesta.Code == "NoSuchKey" || respuesta.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// contenidoAleatorio genera n bytes aleatorios
+func contenidoAleatorio(t *testing.T, n int) []byte {
+	t.Helper()
+	contenido := make([]byte, n)
+	_, err := rand.Read(contenido)
+	require.NoError(t, err)
+	return contenido
+}
+
+// setupS3 conecta el almacenamiento a un bucket S3 simulado en memoria
+func setupS3(t *testing.T) *S3Storage {
+	t.Helper()
+	servidor := httptest.NewServer(gofakes3.New(s3mem.New()).Server())
+	t.Cleanup(servidor.Close)
+
+	s, err := NewS3Storage(context.Background(), S3Config{
+		Endpoint:  strings.TrimPrefix(servidor.URL, "http://"),
+		Region:    "us-east-1",
+		Bucket:    "documentos",
+		AccessKey: "acceso",
+		SecretKey: "secreto",
+		// El mínimo que admite S3 por parte, para que el contenido de prueba ocupe varias
+		TamanoParte: 5 << 20,
+	})
+	require.NoError(t, err)
+	return s
+}
+
+func TestS3Storage_SaveOpen(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("debe guardar y leer un archivo de varias partes", func(t *testing.T) {
+		// Arrange
+		s := setupS3(t)
+		contenido := contenidoAleatorio(t, 11<<20+123)
+
+		// Act
+		n, err := s.Save(ctx, "blobs/ab/abc", bytes.NewReader(contenido))
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(contenido)), n)
+		leido, err := leerTodo(t, s, "blobs/ab/abc")
+		require.NoError(t, err)
+		assert.True(t, bytes.Equal(contenido, leido))
+	})
+
+	t.Run("debe rechazar una clave vacía", func(t *testing.T) {
+		// Arrange
+		s := setupS3(t)
+
+		// Act
+		_, err := s.Save(ctx, "", bytes.NewReader([]byte("x")))
+
+		// Assert
+		assert.Error(t, err)
+	})
+
+	t.Run("debe retornar ErrNotFound si la clave no existe", func(t *testing.T) {
+		// Arrange
+		s := setupS3(t)
+
+		// Act
+		_, err := s.Open(ctx, "no-existe")
+
+		// Assert
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestS3Storage_Seek(t *testing.T) {
+	ctx := context.Background()
+	s := setupS3(t)
+	contenido := contenidoAleatorio(t, 64<<10)
+	_, err := s.Save(ctx, "blobs/ab/abc", bytes.NewReader(contenido))
+	require.NoError(t, err)
+
+	casos := []struct {
+		nombre string
+		offset int64
+		whence int
+		inicio int
+		largo  int
+	}{
+		{"desde el inicio", 1000, io.SeekStart, 1000, 500},
+		{"desde el final", -100, io.SeekEnd, len(contenido) - 100, 100},
+		{"hasta el final", 60 << 10, io.SeekStart, 60 << 10, 4 << 10},
+	}
+	for _, caso := range casos {
+		t.Run("debe leer un rango "+caso.nombre, func(t *testing.T) {
+			// Arrange
+			archivo, err := s.Open(ctx, "blobs/ab/abc")
+			require.NoError(t, err)
+			defer archivo.Close()
+
+			// Act
+			posicion, err := archivo.Seek(caso.offset, caso.whence)
+			require.NoError(t, err)
+			leido := make([]byte, caso.largo)
+			_, err = io.ReadFull(archivo, leido)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, int64(caso.inicio), posicion)
+			assert.True(t, bytes.Equal(contenido[caso.inicio:caso.inicio+caso.largo], leido))
+		})
+	}
+}
+
+func TestS3Storage_Delete(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("debe eliminar el archivo", func(t *testing.T) {
+		// Arrange
+		s := setupS3(t)
+		_, err := s.Save(ctx, "blobs/ab/abc", bytes.NewReader([]byte("contenido")))
+		require.NoError(t, err)
+
+		// Act
+		err = s.Delete(ctx, "blobs/ab/abc")
+
+		// Assert
+		require.NoError(t, err)
+		_, err = s.Open(ctx, "blobs/ab/abc")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("no debe fallar si la clave no existe", func(t *testing.T) {
+		// Arrange
+		s := setupS3(t)
+
+		// Act
+		err := s.Delete(ctx, "no-existe")
+
+		// Assert
+		assert.NoError(t, err)
+	})
+}
+
+func TestS3Storage_PresignedURL(t *testing.T) {
+	ctx := context.Background()
+	s := setupS3(t)
+	_, err := s.Save(ctx, "blobs/ab/abc", bytes.NewReader([]byte("contenido")))
+	require.NoError(t, err)
+
+	t.Run("debe descargar el archivo con el nombre indicado", func(t *testing.T) {
+		// Act
+		u, err := s.PresignedURL(ctx, "blobs/ab/abc", "informe final.pdf", time.Hour)
+		require.NoError(t, err)
+		resp, err := http.Get(u)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		cuerpo, err := io.ReadAll(resp.Body)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "contenido", string(cuerpo))
+		assert.Contains(t, u, "response-content-disposition")
+		assert.Contains(t, u, "X-Amz-Expires=3600")
+	})
+
+	t.Run("debe limitar la vigencia al máximo que admite S3", func(t *testing.T) {
+		// Act
+		u, err := s.PresignedURL(ctx, "blobs/ab/abc", "", 30*24*time.Hour)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Contains(t, u, "X-Amz-Expires=604800")
+		assert.NotContains(t, u, "response-content-disposition")
+	})
+}
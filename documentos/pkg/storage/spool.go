@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+)
+
+// SpooledFile es una copia temporal en disco de un archivo recibido, con su checksum SHA-256 ya calculado.
+// Permite leer el contenido varias veces antes de guardarlo en el Storage.
+type SpooledFile struct {
+	*os.File
+	Checksum string
+	Size     int64
+}
+
+// Spool copia r a un archivo temporal calculando su SHA-256. El archivo queda posicionado al inicio.
+func Spool(r io.Reader) (*SpooledFile, error) {
+	tmp, err := os.CreateTemp("", "documento-*")
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), r)
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+
+	return &SpooledFile{
+		File:     tmp,
+		Checksum: hex.EncodeToString(hash.Sum(nil)),
+		Size:     size,
+	}, nil
+}
+
+// Rewind vuelve a posicionar el archivo al inicio
+func (f *SpooledFile) Rewind() error {
+	_, err := f.Seek(0, io.SeekStart)
+	return err
+}
+
+// Close cierra y elimina el archivo temporal
+func (f *SpooledFile) Close() error {
+	err := f.File.Close()
+	os.Remove(f.Name())
+	return err
+}
+
+// ContentKey retorna la clave direccionada por contenido para un checksum SHA-256
+func ContentKey(checksum string) string {
+	return "blobs/" + checksum[:2] + "/" + checksum
+}
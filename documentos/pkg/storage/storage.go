@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotFound se retorna cuando la clave solicitada no existe en el almacenamiento
+var ErrNotFound = errors.New("archivo no encontrado en el almacenamiento")
+
+// Storage define las operaciones para persistir el contenido binario de los documentos.
+// Las claves son rutas relativas separadas por "/" (por ejemplo "solicitudes/1/abc.pdf").
+type Storage interface {
+	// Save guarda el contenido leído desde r bajo la clave indicada y retorna los bytes escritos
+	Save(ctx context.Context, key string, r io.Reader) (int64, error)
+	// Open abre el contenido almacenado bajo la clave indicada
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	// Delete elimina el contenido almacenado bajo la clave indicada
+	Delete(ctx context.Context, key string) error
+}
+
+// Presigner lo implementan los almacenamientos que pueden entregar una URL de descarga directa,
+// evitando que el contenido pase por el servicio
+type Presigner interface {
+	// PresignedURL genera una URL válida durante expires que descarga el archivo con el nombre indicado
+	PresignedURL(ctx context.Context, key, filename string, expires time.Duration) (string, error)
+}
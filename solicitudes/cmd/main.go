@@ -7,6 +7,8 @@ import (
 	"log"
 	"os"
 
+	"github.com/kramirez/solicitudes/internal/comentario"
+	"github.com/kramirez/solicitudes/internal/postulante"
 	"github.com/kramirez/solicitudes/internal/solicitud"
 	"github.com/kramirez/solicitudes/pkg/bootstrap"
 	"github.com/kramirez/solicitudes/pkg/handler"
@@ -32,17 +34,31 @@ func main() {
 	// Crear cliente para el microservicio de documentos
 	documentoClient := httpclient.NewDocumentoClient("http://localhost:8083")
 
+	// Configurar la ficha de publicación
+	generadorFicha, err := bootstrap.InitFicha()
+	if err != nil {
+		log.Fatal("Error al configurar la ficha de publicación", err)
+	}
+
 	// Inicializar repositorio
 	solicitudRepo := solicitud.NewRepository(db)
 
 	// Inicializar servicio con el cliente de documentos
-	service := solicitud.NewService(solicitudRepo, logger, documentoClient)
+	service := solicitud.NewService(solicitudRepo, logger, documentoClient, bootstrap.InitReglasDocumentos(), generadorFicha, bootstrap.InitCadenasAprobacion(), bootstrap.InitAprobadores())
 
 	// Inicializar endpoint
 	endpoint := solicitud.NewEndpoint(service)
 
+	// Comentarios de las solicitudes
+	comentarioService := comentario.NewService(comentario.NewRepository(db), logger, service)
+	comentarioEndpoint := comentario.NewEndpoint(comentarioService)
+
+	// Postulantes y pipeline de postulación de las solicitudes
+	postulanteService := postulante.NewService(postulante.NewRepository(db), logger, service, documentoClient, bootstrap.InitPipelinePostulacion())
+	postulanteEndpoint := postulante.NewEndpoint(postulanteService)
+
 	//Configurar rutas
-	router := handler.SetupRoutes(endpoint)
+	router := handler.SetupRoutes(endpoint, comentarioEndpoint, postulanteEndpoint)
 
 	//Obtener puerto del servicio
 	port := os.Getenv("SERVICE_PORT")
@@ -0,0 +1,178 @@
+package comentario
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Comentario es un mensaje en la conversación de una solicitud. Las respuestas indican el comentario
+// al que responden y las notas internas son visibles solo para el equipo de reclutamiento.
+type Comentario struct {
+	ID                uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	SolicitudID       uint           `gorm:"not null;index" json:"solicitud_id"`
+	ComentarioPadreID *uint          `gorm:"index" json:"comentario_padre_id,omitempty"`
+	UsuarioID         uint           `gorm:"not null" json:"usuario_id"`
+	Contenido         string         `gorm:"type:text;not null" json:"contenido"`
+	Interno           bool           `gorm:"not null;default:false" json:"interno"`
+	Menciones         []Mencion      `gorm:"foreignKey:ComentarioID;constraint:OnDelete:CASCADE" json:"-"`
+	EditadoEl         *time.Time     `json:"editado_el,omitempty"`
+	CreatedAt         time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName especifica el nombre de la tabla
+func (Comentario) TableName() string {
+	return "solicitud_comentarios"
+}
+
+// Mencion registra un usuario mencionado con @ en un comentario
+type Mencion struct {
+	ComentarioID uint `gorm:"primaryKey" json:"comentario_id"`
+	UsuarioID    uint `gorm:"primaryKey;index" json:"usuario_id"`
+}
+
+// TableName especifica el nombre de la tabla
+func (Mencion) TableName() string {
+	return "comentario_menciones"
+}
+
+// Edicion guarda el contenido que tenía un comentario antes de cada edición
+type Edicion struct {
+	ID                uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	ComentarioID      uint      `gorm:"not null;index" json:"comentario_id"`
+	ContenidoAnterior string    `gorm:"type:text;not null" json:"contenido_anterior"`
+	UsuarioID         uint      `gorm:"not null" json:"usuario_id"`
+	CreatedAt         time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName especifica el nombre de la tabla
+func (Edicion) TableName() string {
+	return "comentario_ediciones"
+}
+
+// CrearReq representa la petición para comentar una solicitud o responder un comentario
+type CrearReq struct {
+	Contenido         string `json:"contenido" binding:"required"`
+	ComentarioPadreID *uint  `json:"comentario_padre_id"`
+	Interno           bool   `json:"interno"`
+	// UsuarioID es el autor, tomado de la cabecera X-Usuario-ID
+	UsuarioID uint `json:"-"`
+}
+
+// EditarReq representa la petición para cambiar el contenido de un comentario
+type EditarReq struct {
+	Contenido string `json:"contenido" binding:"required"`
+	UsuarioID uint   `json:"-"`
+}
+
+// ListarReq representa los filtros del hilo de comentarios de una solicitud
+type ListarReq struct {
+	// Interno filtra notas internas (true) o comentarios visibles para todos (false); nil no filtra
+	Interno *bool
+}
+
+// ComentarioResponse representa un comentario con sus respuestas en las respuestas de la API
+type ComentarioResponse struct {
+	ID                uint                 `json:"id"`
+	SolicitudID       uint                 `json:"solicitud_id"`
+	ComentarioPadreID *uint                `json:"comentario_padre_id,omitempty"`
+	UsuarioID         uint                 `json:"usuario_id,omitempty"`
+	Contenido         string               `json:"contenido"`
+	Interno           bool                 `json:"interno"`
+	Menciones         []uint               `json:"menciones"`
+	Editado           bool                 `json:"editado"`
+	EditadoEl         *time.Time           `json:"editado_el,omitempty"`
+	// Eliminado indica un comentario eliminado que se mantiene para no cortar el hilo de sus respuestas
+	Eliminado  bool                 `json:"eliminado"`
+	CreatedAt  time.Time            `json:"created_at"`
+	UpdatedAt  time.Time            `json:"updated_at"`
+	Respuestas []ComentarioResponse `json:"respuestas,omitempty"`
+}
+
+// ToResponse convierte un comentario a su respuesta, sin el contenido si fue eliminado
+func (c *Comentario) ToResponse() ComentarioResponse {
+	response := ComentarioResponse{
+		ID:                c.ID,
+		SolicitudID:       c.SolicitudID,
+		ComentarioPadreID: c.ComentarioPadreID,
+		UsuarioID:         c.UsuarioID,
+		Contenido:         c.Contenido,
+		Interno:           c.Interno,
+		Menciones:         make([]uint, len(c.Menciones)),
+		Editado:           c.EditadoEl != nil,
+		EditadoEl:         c.EditadoEl,
+		CreatedAt:         c.CreatedAt,
+		UpdatedAt:         c.UpdatedAt,
+	}
+	for i, mencion := range c.Menciones {
+		response.Menciones[i] = mencion.UsuarioID
+	}
+	if c.DeletedAt.Valid {
+		response.Eliminado = true
+		response.Contenido = ""
+		response.UsuarioID = 0
+		response.Menciones = []uint{}
+	}
+	return response
+}
+
+// patronMencion reconoce menciones de usuarios por su ID, como @15, al inicio o después de un espacio
+var patronMencion = regexp.MustCompile(`(?:^|[^\w@])@(\d+)\b`)
+
+// extraerMenciones retorna, sin repetir y en orden de aparición, los usuarios mencionados en el contenido
+func extraerMenciones(contenido string) []Mencion {
+	menciones := []Mencion{}
+	vistos := make(map[uint]bool)
+	for _, coincidencia := range patronMencion.FindAllStringSubmatch(contenido, -1) {
+		id, err := strconv.ParseUint(coincidencia[1], 10, 32)
+		if err != nil || id == 0 || vistos[uint(id)] {
+			continue
+		}
+		vistos[uint(id)] = true
+		menciones = append(menciones, Mencion{UsuarioID: uint(id)})
+	}
+	return menciones
+}
+
+// armarHilos organiza los comentarios de una solicitud en hilos de respuestas, en orden de creación.
+// Los comentarios eliminados se conservan solo si tienen respuestas vigentes.
+func armarHilos(comentarios []Comentario) []ComentarioResponse {
+	hijos := make(map[uint][]*Comentario)
+	existentes := make(map[uint]bool)
+	for i := range comentarios {
+		existentes[comentarios[i].ID] = true
+	}
+
+	var raices []*Comentario
+	for i := range comentarios {
+		c := &comentarios[i]
+		if c.ComentarioPadreID != nil && existentes[*c.ComentarioPadreID] {
+			hijos[*c.ComentarioPadreID] = append(hijos[*c.ComentarioPadreID], c)
+		} else {
+			raices = append(raices, c)
+		}
+	}
+
+	var armar func(c *Comentario) (ComentarioResponse, bool)
+	armar = func(c *Comentario) (ComentarioResponse, bool) {
+		response := c.ToResponse()
+		for _, hijo := range hijos[c.ID] {
+			if respuesta, ok := armar(hijo); ok {
+				response.Respuestas = append(response.Respuestas, respuesta)
+			}
+		}
+		return response, !response.Eliminado || len(response.Respuestas) > 0
+	}
+
+	hilos := []ComentarioResponse{}
+	for _, raiz := range raices {
+		if hilo, ok := armar(raiz); ok {
+			hilos = append(hilos, hilo)
+		}
+	}
+	return hilos
+}
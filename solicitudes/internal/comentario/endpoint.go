@@ -0,0 +1,195 @@
+package comentario
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Endpoint struct {
+	service Service
+}
+
+func NewEndpoint(service Service) *Endpoint {
+	return &Endpoint{service: service}
+}
+
+// Crear maneja POST /solicitudes/:id/comentarios
+func (e *Endpoint) Crear(c *gin.Context) {
+	solicitudID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	usuarioID, ok := usuarioID(c)
+	if !ok {
+		return
+	}
+
+	var req CrearReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.UsuarioID = usuarioID
+
+	comentario, err := e.service.Crear(c.Request.Context(), solicitudID, req)
+	if err != nil {
+		responderError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, comentario)
+}
+
+// Listar maneja GET /solicitudes/:id/comentarios
+func (e *Endpoint) Listar(c *gin.Context) {
+	solicitudID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var filtros ListarReq
+	if interno := c.Query("interno"); interno != "" {
+		valor, err := strconv.ParseBool(interno)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El parámetro interno debe ser true o false"})
+			return
+		}
+		filtros.Interno = &valor
+	}
+
+	comentarios, err := e.service.Listar(c.Request.Context(), solicitudID, filtros)
+	if err != nil {
+		responderError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, comentarios)
+}
+
+// Get maneja GET /solicitudes/:id/comentarios/:comentarioId
+func (e *Endpoint) Get(c *gin.Context) {
+	solicitudID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	id, ok := parseID(c, "comentarioId")
+	if !ok {
+		return
+	}
+
+	comentario, err := e.service.Get(c.Request.Context(), solicitudID, id)
+	if err != nil {
+		responderError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, comentario)
+}
+
+// Editar maneja PATCH /solicitudes/:id/comentarios/:comentarioId
+func (e *Endpoint) Editar(c *gin.Context) {
+	solicitudID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	id, ok := parseID(c, "comentarioId")
+	if !ok {
+		return
+	}
+	usuarioID, ok := usuarioID(c)
+	if !ok {
+		return
+	}
+
+	var req EditarReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.UsuarioID = usuarioID
+
+	comentario, err := e.service.Editar(c.Request.Context(), solicitudID, id, req)
+	if err != nil {
+		responderError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, comentario)
+}
+
+// Eliminar maneja DELETE /solicitudes/:id/comentarios/:comentarioId
+func (e *Endpoint) Eliminar(c *gin.Context) {
+	solicitudID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	id, ok := parseID(c, "comentarioId")
+	if !ok {
+		return
+	}
+	usuarioID, ok := usuarioID(c)
+	if !ok {
+		return
+	}
+
+	if err := e.service.Eliminar(c.Request.Context(), solicitudID, id, usuarioID); err != nil {
+		responderError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Comentario eliminado exitosamente"})
+}
+
+// GetEdiciones maneja GET /solicitudes/:id/comentarios/:comentarioId/ediciones
+func (e *Endpoint) GetEdiciones(c *gin.Context) {
+	solicitudID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	id, ok := parseID(c, "comentarioId")
+	if !ok {
+		return
+	}
+
+	ediciones, err := e.service.GetEdiciones(c.Request.Context(), solicitudID, id)
+	if err != nil {
+		responderError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, ediciones)
+}
+
+// parseID obtiene un ID de la ruta y responde 400 si no es válido
+func parseID(c *gin.Context, param string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(param), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// usuarioID obtiene el autor desde la cabecera X-Usuario-ID, obligatoria para comentar
+func usuarioID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.GetHeader("X-Usuario-ID"), 10, 32)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La cabecera X-Usuario-ID es requerida"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func responderError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrSolicitudNoEncontrada):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Solicitud no encontrada"})
+	case errors.Is(err, ErrNoEncontrado):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comentario no encontrado"})
+	case errors.Is(err, ErrContenidoVacio):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrComentarioPadreInvalido):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrNoEsAutor):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
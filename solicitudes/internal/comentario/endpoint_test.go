@@ -0,0 +1,213 @@
+package comentario
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kramirez/solicitudes/internal/solicitud"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func setupEndpoint(repo *mockRepository, solicitudes *mockSolicitudes) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	ep := NewEndpoint(NewService(repo, log.New(io.Discard, "", 0), solicitudes))
+
+	r := gin.New()
+	r.POST("/solicitudes/:id/comentarios", ep.Crear)
+	r.GET("/solicitudes/:id/comentarios", ep.Listar)
+	r.GET("/solicitudes/:id/comentarios/:comentarioId", ep.Get)
+	r.PATCH("/solicitudes/:id/comentarios/:comentarioId", ep.Editar)
+	r.DELETE("/solicitudes/:id/comentarios/:comentarioId", ep.Eliminar)
+	r.GET("/solicitudes/:id/comentarios/:comentarioId/ediciones", ep.GetEdiciones)
+	return r
+}
+
+func TestEndpoint_Crear(t *testing.T) {
+	t.Run("debe crear el comentario con el autor de la cabecera", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		solicitudes := new(mockSolicitudes)
+		solicitudes.On("GetByID", mock.Anything, uint(1)).Return(&solicitud.SolicitudResponse{ID: 1}, nil)
+		repo.On("Create", mock.Anything, mock.MatchedBy(func(c *Comentario) bool {
+			return c.UsuarioID == 3 && c.Interno
+		})).Return(nil).Run(func(args mock.Arguments) {
+			args.Get(1).(*Comentario).ID = 10
+		})
+		r := setupEndpoint(repo, solicitudes)
+
+		body, _ := json.Marshal(map[string]interface{}{"contenido": "Revisar con @4", "interno": true})
+		req := httptest.NewRequest(http.MethodPost, "/solicitudes/1/comentarios", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Usuario-ID", "3")
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var resp ComentarioResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, uint(10), resp.ID)
+		assert.Equal(t, []uint{4}, resp.Menciones)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe retornar 400 sin la cabecera X-Usuario-ID", func(t *testing.T) {
+		// Arrange
+		r := setupEndpoint(new(mockRepository), new(mockSolicitudes))
+		req := httptest.NewRequest(http.MethodPost, "/solicitudes/1/comentarios", bytes.NewBufferString(`{"contenido":"Hola"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("debe retornar 422 si el comentario padre no existe", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		solicitudes := new(mockSolicitudes)
+		solicitudes.On("GetByID", mock.Anything, uint(1)).Return(&solicitud.SolicitudResponse{ID: 1}, nil)
+		repo.On("GetByID", mock.Anything, uint(1), uint(99)).Return(nil, gorm.ErrRecordNotFound)
+		r := setupEndpoint(repo, solicitudes)
+
+		req := httptest.NewRequest(http.MethodPost, "/solicitudes/1/comentarios", bytes.NewBufferString(`{"contenido":"Hola","comentario_padre_id":99}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Usuario-ID", "3")
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+}
+
+func TestEndpoint_Listar(t *testing.T) {
+	t.Run("debe filtrar por notas internas", func(t *testing.T) {
+		// Arrange
+		interno := true
+		repo := new(mockRepository)
+		solicitudes := new(mockSolicitudes)
+		solicitudes.On("GetByID", mock.Anything, uint(1)).Return(&solicitud.SolicitudResponse{ID: 1}, nil)
+		repo.On("GetBySolicitud", mock.Anything, uint(1), ListarReq{Interno: &interno}).
+			Return([]Comentario{{ID: 1, SolicitudID: 1, UsuarioID: 3, Contenido: "Nota", Interno: true}}, nil)
+		r := setupEndpoint(repo, solicitudes)
+
+		req := httptest.NewRequest(http.MethodGet, "/solicitudes/1/comentarios?interno=true", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp []ComentarioResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp, 1)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe retornar 400 con un filtro interno inválido", func(t *testing.T) {
+		// Arrange
+		r := setupEndpoint(new(mockRepository), new(mockSolicitudes))
+		req := httptest.NewRequest(http.MethodGet, "/solicitudes/1/comentarios?interno=talvez", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestEndpoint_Editar_NoAutor(t *testing.T) {
+	// Arrange
+	repo := new(mockRepository)
+	repo.On("GetByID", mock.Anything, uint(1), uint(5)).Return(&Comentario{ID: 5, SolicitudID: 1, UsuarioID: 3, Contenido: "Hola"}, nil)
+	r := setupEndpoint(repo, new(mockSolicitudes))
+
+	req := httptest.NewRequest(http.MethodPatch, "/solicitudes/1/comentarios/5", bytes.NewBufferString(`{"contenido":"Cambio"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Usuario-ID", "7")
+	w := httptest.NewRecorder()
+
+	// Act
+	r.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	repo.AssertNotCalled(t, "Editar", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestEndpoint_Eliminar(t *testing.T) {
+	t.Run("debe eliminar el comentario", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", mock.Anything, uint(1), uint(5)).Return(&Comentario{ID: 5, SolicitudID: 1, UsuarioID: 3}, nil)
+		repo.On("Delete", mock.Anything, uint(5)).Return(nil)
+		r := setupEndpoint(repo, new(mockSolicitudes))
+
+		req := httptest.NewRequest(http.MethodDelete, "/solicitudes/1/comentarios/5", nil)
+		req.Header.Set("X-Usuario-ID", "3")
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe retornar 404 si el comentario no existe", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", mock.Anything, uint(1), uint(50)).Return(nil, gorm.ErrRecordNotFound)
+		r := setupEndpoint(repo, new(mockSolicitudes))
+
+		req := httptest.NewRequest(http.MethodDelete, "/solicitudes/1/comentarios/50", nil)
+		req.Header.Set("X-Usuario-ID", "3")
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestEndpoint_GetEdiciones(t *testing.T) {
+	// Arrange
+	repo := new(mockRepository)
+	repo.On("GetByID", mock.Anything, uint(1), uint(5)).Return(&Comentario{ID: 5, SolicitudID: 1}, nil)
+	repo.On("GetEdiciones", mock.Anything, uint(5)).Return([]Edicion{{ID: 1, ComentarioID: 5, ContenidoAnterior: "v1", UsuarioID: 3}}, nil)
+	r := setupEndpoint(repo, new(mockSolicitudes))
+
+	req := httptest.NewRequest(http.MethodGet, "/solicitudes/1/comentarios/5/ediciones", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	r.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp []Edicion
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "v1", resp[0].ContenidoAnterior)
+}
@@ -0,0 +1,63 @@
+package comentario
+
+import (
+	"context"
+
+	"github.com/kramirez/solicitudes/internal/solicitud"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockRepository struct {
+	mock.Mock
+}
+
+func (m *mockRepository) Create(ctx context.Context, comentario *Comentario) error {
+	args := m.Called(ctx, comentario)
+	return args.Error(0)
+}
+
+func (m *mockRepository) GetByID(ctx context.Context, solicitudID, id uint) (*Comentario, error) {
+	args := m.Called(ctx, solicitudID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Comentario), args.Error(1)
+}
+
+func (m *mockRepository) GetBySolicitud(ctx context.Context, solicitudID uint, filtros ListarReq) ([]Comentario, error) {
+	args := m.Called(ctx, solicitudID, filtros)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Comentario), args.Error(1)
+}
+
+func (m *mockRepository) Editar(ctx context.Context, comentario *Comentario, edicion *Edicion) error {
+	args := m.Called(ctx, comentario, edicion)
+	return args.Error(0)
+}
+
+func (m *mockRepository) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *mockRepository) GetEdiciones(ctx context.Context, comentarioID uint) ([]Edicion, error) {
+	args := m.Called(ctx, comentarioID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Edicion), args.Error(1)
+}
+
+type mockSolicitudes struct {
+	mock.Mock
+}
+
+func (m *mockSolicitudes) GetByID(ctx context.Context, id uint) (*solicitud.SolicitudResponse, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*solicitud.SolicitudResponse), args.Error(1)
+}
@@ -0,0 +1,103 @@
+package comentario
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Create(ctx context.Context, comentario *Comentario) error
+	GetByID(ctx context.Context, solicitudID, id uint) (*Comentario, error)
+	GetBySolicitud(ctx context.Context, solicitudID uint, filtros ListarReq) ([]Comentario, error)
+	Editar(ctx context.Context, comentario *Comentario, edicion *Edicion) error
+	Delete(ctx context.Context, id uint) error
+	GetEdiciones(ctx context.Context, comentarioID uint) ([]Edicion, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Create guarda el comentario junto con sus menciones
+func (r *repository) Create(ctx context.Context, comentario *Comentario) error {
+	return r.db.WithContext(ctx).Create(comentario).Error
+}
+
+func (r *repository) GetByID(ctx context.Context, solicitudID, id uint) (*Comentario, error) {
+	var comentario Comentario
+	err := r.db.WithContext(ctx).
+		Preload("Menciones").
+		Where("solicitud_id = ?", solicitudID).
+		First(&comentario, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &comentario, nil
+}
+
+// GetBySolicitud obtiene los comentarios de la solicitud en orden de creación, incluidos los
+// eliminados para poder mostrar las respuestas que recibieron
+func (r *repository) GetBySolicitud(ctx context.Context, solicitudID uint, filtros ListarReq) ([]Comentario, error) {
+	var comentarios []Comentario
+	query := r.db.WithContext(ctx).Unscoped().
+		Preload("Menciones").
+		Where("solicitud_id = ?", solicitudID)
+	if filtros.Interno != nil {
+		query = query.Where("interno = ?", *filtros.Interno)
+	}
+
+	err := query.Order("created_at, id").Find(&comentarios).Error
+	return comentarios, err
+}
+
+// Editar guarda el contenido anterior en el historial de ediciones, actualiza el contenido
+// y reemplaza las menciones en una sola transacción
+func (r *repository) Editar(ctx context.Context, comentario *Comentario, edicion *Edicion) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(edicion).Error; err != nil {
+			return err
+		}
+
+		editadoEl := time.Now()
+		err := tx.Model(&Comentario{}).Where("id = ?", comentario.ID).Updates(map[string]interface{}{
+			"contenido":  comentario.Contenido,
+			"editado_el": editadoEl,
+		}).Error
+		if err != nil {
+			return err
+		}
+		comentario.EditadoEl = &editadoEl
+
+		if err := tx.Where("comentario_id = ?", comentario.ID).Delete(&Mencion{}).Error; err != nil {
+			return err
+		}
+		if len(comentario.Menciones) == 0 {
+			return nil
+		}
+		for i := range comentario.Menciones {
+			comentario.Menciones[i].ComentarioID = comentario.ID
+		}
+		return tx.Create(&comentario.Menciones).Error
+	})
+}
+
+func (r *repository) Delete(ctx context.Context, id uint) error {
+	// Soft delete: el comentario se conserva para mantener el hilo de sus respuestas
+	return r.db.WithContext(ctx).Delete(&Comentario{}, id).Error
+}
+
+// GetEdiciones obtiene las versiones anteriores del comentario, de la más antigua a la más reciente
+func (r *repository) GetEdiciones(ctx context.Context, comentarioID uint) ([]Edicion, error) {
+	var ediciones []Edicion
+	err := r.db.WithContext(ctx).
+		Where("comentario_id = ?", comentarioID).
+		Order("created_at, id").
+		Find(&ediciones).Error
+	return ediciones, err
+}
@@ -0,0 +1,103 @@
+package comentario
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+
+	return gormDB, mock
+}
+
+func TestRepository_Create(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `solicitud_comentarios`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO `comentario_menciones`").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	comentario := &Comentario{
+		SolicitudID: 1,
+		UsuarioID:   3,
+		Contenido:   "@4 @5 revisar",
+		Menciones:   []Mencion{{UsuarioID: 4}, {UsuarioID: 5}},
+	}
+
+	err := repo.Create(context.Background(), comentario)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), comentario.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_GetBySolicitud(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+
+	mock.ExpectQuery("SELECT \\* FROM `solicitud_comentarios` WHERE solicitud_id = \\? AND interno = \\? ORDER BY created_at, id").
+		WithArgs(1, false).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "solicitud_id", "contenido"}).
+			AddRow(1, 1, "Hola").
+			AddRow(2, 1, "Chao"))
+	mock.ExpectQuery("SELECT \\* FROM `comentario_menciones` WHERE `comentario_menciones`.`comentario_id` IN").
+		WillReturnRows(sqlmock.NewRows([]string{"comentario_id", "usuario_id"}).AddRow(2, 4))
+
+	interno := false
+	result, err := repo.GetBySolicitud(context.Background(), 1, ListarReq{Interno: &interno})
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, uint(4), result[1].Menciones[0].UsuarioID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Editar(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `comentario_ediciones`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE `solicitud_comentarios` SET").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM `comentario_menciones` WHERE comentario_id = \\?").
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `comentario_menciones`").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	comentario := &Comentario{ID: 5, SolicitudID: 1, Contenido: "Nuevo @8", Menciones: []Mencion{{UsuarioID: 8}}}
+	edicion := &Edicion{ComentarioID: 5, ContenidoAnterior: "Anterior", UsuarioID: 3}
+
+	err := repo.Editar(context.Background(), comentario, edicion)
+	assert.NoError(t, err)
+	assert.NotNil(t, comentario.EditadoEl)
+	assert.Equal(t, uint(5), comentario.Menciones[0].ComentarioID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Delete(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `solicitud_comentarios` SET `deleted_at`").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.Delete(context.Background(), 5)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
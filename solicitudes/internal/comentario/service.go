@@ -0,0 +1,207 @@
+package comentario
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/kramirez/solicitudes/internal/solicitud"
+)
+
+type Service interface {
+	Crear(ctx context.Context, solicitudID uint, req CrearReq) (*ComentarioResponse, error)
+	Listar(ctx context.Context, solicitudID uint, filtros ListarReq) ([]ComentarioResponse, error)
+	Get(ctx context.Context, solicitudID, id uint) (*ComentarioResponse, error)
+	Editar(ctx context.Context, solicitudID, id uint, req EditarReq) (*ComentarioResponse, error)
+	Eliminar(ctx context.Context, solicitudID, id, usuarioID uint) error
+	GetEdiciones(ctx context.Context, solicitudID, id uint) ([]Edicion, error)
+}
+
+// Solicitudes permite verificar que la solicitud comentada exista
+type Solicitudes interface {
+	GetByID(ctx context.Context, id uint) (*solicitud.SolicitudResponse, error)
+}
+
+var (
+	// ErrSolicitudNoEncontrada indica que la solicitud no existe o fue eliminada
+	ErrSolicitudNoEncontrada = errors.New("solicitud no encontrada")
+	// ErrNoEncontrado indica que el comentario no existe en la solicitud o fue eliminado
+	ErrNoEncontrado = errors.New("comentario no encontrado")
+	// ErrComentarioPadreInvalido indica que el comentario al que se responde no existe en la solicitud
+	ErrComentarioPadreInvalido = errors.New("el comentario al que se responde no existe en la solicitud")
+	// ErrContenidoVacio indica que el comentario no tiene texto
+	ErrContenidoVacio = errors.New("el contenido del comentario es requerido")
+	// ErrNoEsAutor indica que solo el autor puede editar o eliminar el comentario
+	ErrNoEsAutor = errors.New("solo el autor puede modificar el comentario")
+)
+
+type service struct {
+	repo        Repository
+	logger      *log.Logger
+	solicitudes Solicitudes
+}
+
+func NewService(repo Repository, logger *log.Logger, solicitudes Solicitudes) Service {
+	return &service{
+		repo:        repo,
+		logger:      logger,
+		solicitudes: solicitudes,
+	}
+}
+
+// Crear agrega un comentario a la solicitud. Una respuesta hereda de su comentario padre si es nota interna.
+func (s *service) Crear(ctx context.Context, solicitudID uint, req CrearReq) (*ComentarioResponse, error) {
+	contenido := strings.TrimSpace(req.Contenido)
+	if contenido == "" {
+		return nil, ErrContenidoVacio
+	}
+	if err := s.verificarSolicitud(ctx, solicitudID); err != nil {
+		return nil, err
+	}
+
+	comentario := &Comentario{
+		SolicitudID:       solicitudID,
+		ComentarioPadreID: req.ComentarioPadreID,
+		UsuarioID:         req.UsuarioID,
+		Contenido:         contenido,
+		Interno:           req.Interno,
+		Menciones:         extraerMenciones(contenido),
+	}
+	if req.ComentarioPadreID != nil {
+		padre, err := s.repo.GetByID(ctx, solicitudID, *req.ComentarioPadreID)
+		if err != nil {
+			s.logger.Printf("Comentario padre ID=%d no encontrado en la solicitud ID=%d: %v", *req.ComentarioPadreID, solicitudID, err)
+			return nil, ErrComentarioPadreInvalido
+		}
+		// Así un hilo de notas internas no se filtra al listar solo los comentarios visibles
+		comentario.Interno = padre.Interno
+	}
+
+	if err := s.repo.Create(ctx, comentario); err != nil {
+		s.logger.Printf("Error al crear el comentario en la solicitud ID=%d: %v", solicitudID, err)
+		return nil, err
+	}
+
+	s.logger.Printf("Comentario ID=%d creado en la solicitud ID=%d por Usuario ID=%d", comentario.ID, solicitudID, comentario.UsuarioID)
+	s.registrarMenciones(comentario)
+	response := comentario.ToResponse()
+	return &response, nil
+}
+
+// Listar retorna los hilos de comentarios de la solicitud
+func (s *service) Listar(ctx context.Context, solicitudID uint, filtros ListarReq) ([]ComentarioResponse, error) {
+	if err := s.verificarSolicitud(ctx, solicitudID); err != nil {
+		return nil, err
+	}
+
+	comentarios, err := s.repo.GetBySolicitud(ctx, solicitudID, filtros)
+	if err != nil {
+		s.logger.Printf("Error al obtener los comentarios de la solicitud ID=%d: %v", solicitudID, err)
+		return nil, err
+	}
+	return armarHilos(comentarios), nil
+}
+
+func (s *service) Get(ctx context.Context, solicitudID, id uint) (*ComentarioResponse, error) {
+	comentario, err := s.obtener(ctx, solicitudID, id)
+	if err != nil {
+		return nil, err
+	}
+	response := comentario.ToResponse()
+	return &response, nil
+}
+
+// Editar cambia el contenido del comentario y guarda el contenido anterior en su historial de ediciones
+func (s *service) Editar(ctx context.Context, solicitudID, id uint, req EditarReq) (*ComentarioResponse, error) {
+	contenido := strings.TrimSpace(req.Contenido)
+	if contenido == "" {
+		return nil, ErrContenidoVacio
+	}
+
+	comentario, err := s.obtener(ctx, solicitudID, id)
+	if err != nil {
+		return nil, err
+	}
+	if comentario.UsuarioID != req.UsuarioID {
+		return nil, ErrNoEsAutor
+	}
+	if comentario.Contenido == contenido {
+		response := comentario.ToResponse()
+		return &response, nil
+	}
+
+	edicion := &Edicion{
+		ComentarioID:      comentario.ID,
+		ContenidoAnterior: comentario.Contenido,
+		UsuarioID:         req.UsuarioID,
+	}
+	comentario.Contenido = contenido
+	comentario.Menciones = extraerMenciones(contenido)
+	if err := s.repo.Editar(ctx, comentario, edicion); err != nil {
+		s.logger.Printf("Error al editar el comentario ID=%d: %v", id, err)
+		return nil, err
+	}
+
+	s.logger.Printf("Comentario ID=%d editado por Usuario ID=%d", id, req.UsuarioID)
+	s.registrarMenciones(comentario)
+	response := comentario.ToResponse()
+	return &response, nil
+}
+
+// Eliminar hace un soft delete del comentario; sus respuestas se mantienen en el hilo
+func (s *service) Eliminar(ctx context.Context, solicitudID, id, usuarioID uint) error {
+	comentario, err := s.obtener(ctx, solicitudID, id)
+	if err != nil {
+		return err
+	}
+	if comentario.UsuarioID != usuarioID {
+		return ErrNoEsAutor
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		s.logger.Printf("Error al eliminar el comentario ID=%d: %v", id, err)
+		return err
+	}
+
+	s.logger.Printf("Comentario ID=%d eliminado por Usuario ID=%d", id, usuarioID)
+	return nil
+}
+
+// GetEdiciones retorna las versiones anteriores del comentario
+func (s *service) GetEdiciones(ctx context.Context, solicitudID, id uint) ([]Edicion, error) {
+	if _, err := s.obtener(ctx, solicitudID, id); err != nil {
+		return nil, err
+	}
+
+	ediciones, err := s.repo.GetEdiciones(ctx, id)
+	if err != nil {
+		s.logger.Printf("Error al obtener las ediciones del comentario ID=%d: %v", id, err)
+		return nil, err
+	}
+	return ediciones, nil
+}
+
+func (s *service) verificarSolicitud(ctx context.Context, solicitudID uint) error {
+	if _, err := s.solicitudes.GetByID(ctx, solicitudID); err != nil {
+		return fmt.Errorf("%w: %v", ErrSolicitudNoEncontrada, err)
+	}
+	return nil
+}
+
+func (s *service) obtener(ctx context.Context, solicitudID, id uint) (*Comentario, error) {
+	comentario, err := s.repo.GetByID(ctx, solicitudID, id)
+	if err != nil {
+		s.logger.Printf("Error al obtener el comentario ID=%d de la solicitud ID=%d: %v", id, solicitudID, err)
+		return nil, fmt.Errorf("%w: %v", ErrNoEncontrado, err)
+	}
+	return comentario, nil
+}
+
+// registrarMenciones deja en el log los usuarios mencionados para su seguimiento
+func (s *service) registrarMenciones(comentario *Comentario) {
+	for _, mencion := range comentario.Menciones {
+		s.logger.Printf("Usuario ID=%d mencionado en el comentario ID=%d de la solicitud ID=%d", mencion.UsuarioID, comentario.ID, comentario.SolicitudID)
+	}
+}
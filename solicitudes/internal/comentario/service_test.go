@@ -0,0 +1,308 @@
+package comentario
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kramirez/solicitudes/internal/solicitud"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func uintPtr(v uint) *uint {
+	return &v
+}
+
+func TestExtraerMenciones(t *testing.T) {
+	tests := []struct {
+		name      string
+		contenido string
+		esperado  []Mencion
+	}{
+		{"una mención", "@15 revisa la renta", []Mencion{{UsuarioID: 15}}},
+		{"varias sin repetir", "Hola @3 y @4, @3 ya lo vio.", []Mencion{{UsuarioID: 3}, {UsuarioID: 4}}},
+		{"ignora correos y texto", "escribir a rrhh@10.cl o @juan", []Mencion{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.esperado, extraerMenciones(tt.contenido))
+		})
+	}
+}
+
+func TestArmarHilos(t *testing.T) {
+	eliminado := gorm.DeletedAt{Time: time.Now(), Valid: true}
+	comentarios := []Comentario{
+		{ID: 1, SolicitudID: 1, UsuarioID: 3, Contenido: "¿Renta aprobada?"},
+		{ID: 2, SolicitudID: 1, UsuarioID: 4, Contenido: "Borrado", DeletedAt: eliminado},
+		{ID: 3, SolicitudID: 1, ComentarioPadreID: uintPtr(1), UsuarioID: 4, Contenido: "Sí"},
+		{ID: 4, SolicitudID: 1, ComentarioPadreID: uintPtr(2), UsuarioID: 3, Contenido: "Respuesta a un borrado"},
+		{ID: 5, SolicitudID: 1, UsuarioID: 5, Contenido: "Sin respuestas", DeletedAt: eliminado},
+		{ID: 6, SolicitudID: 1, ComentarioPadreID: uintPtr(3), UsuarioID: 3, Contenido: "Gracias"},
+	}
+
+	hilos := armarHilos(comentarios)
+
+	assert.Len(t, hilos, 2, "El eliminado sin respuestas no se muestra")
+	assert.Equal(t, uint(1), hilos[0].ID)
+	assert.Equal(t, uint(3), hilos[0].Respuestas[0].ID)
+	assert.Equal(t, uint(6), hilos[0].Respuestas[0].Respuestas[0].ID)
+	assert.True(t, hilos[1].Eliminado)
+	assert.Empty(t, hilos[1].Contenido, "Un comentario eliminado no muestra su contenido")
+	assert.Equal(t, uint(4), hilos[1].Respuestas[0].ID)
+}
+
+func TestService_Crear(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(os.Stdout, "TEST: ", log.LstdFlags)
+
+	t.Run("debe crear el comentario con sus menciones", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		solicitudes := new(mockSolicitudes)
+		solicitudes.On("GetByID", ctx, uint(1)).Return(&solicitud.SolicitudResponse{ID: 1}, nil)
+		repo.On("Create", ctx, mock.MatchedBy(func(c *Comentario) bool {
+			return c.SolicitudID == 1 && c.UsuarioID == 3 && c.Contenido == "@7 revisa la descripción" &&
+				len(c.Menciones) == 1 && c.Menciones[0].UsuarioID == 7
+		})).Return(nil)
+		service := NewService(repo, logger, solicitudes)
+
+		// Act
+		result, err := service.Crear(ctx, 1, CrearReq{Contenido: " @7 revisa la descripción ", UsuarioID: 3})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{7}, result.Menciones)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("una respuesta hereda si es nota interna", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		solicitudes := new(mockSolicitudes)
+		solicitudes.On("GetByID", ctx, uint(1)).Return(&solicitud.SolicitudResponse{ID: 1}, nil)
+		repo.On("GetByID", ctx, uint(1), uint(10)).Return(&Comentario{ID: 10, SolicitudID: 1, Interno: true}, nil)
+		repo.On("Create", ctx, mock.MatchedBy(func(c *Comentario) bool {
+			return *c.ComentarioPadreID == 10 && c.Interno
+		})).Return(nil)
+		service := NewService(repo, logger, solicitudes)
+
+		// Act
+		result, err := service.Crear(ctx, 1, CrearReq{Contenido: "De acuerdo", ComentarioPadreID: uintPtr(10), UsuarioID: 3})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.True(t, result.Interno)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe rechazar la respuesta a un comentario de otra solicitud", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		solicitudes := new(mockSolicitudes)
+		solicitudes.On("GetByID", ctx, uint(1)).Return(&solicitud.SolicitudResponse{ID: 1}, nil)
+		repo.On("GetByID", ctx, uint(1), uint(99)).Return(nil, gorm.ErrRecordNotFound)
+		service := NewService(repo, logger, solicitudes)
+
+		// Act
+		_, err := service.Crear(ctx, 1, CrearReq{Contenido: "Hola", ComentarioPadreID: uintPtr(99), UsuarioID: 3})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrComentarioPadreInvalido)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe retornar error si la solicitud no existe", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		solicitudes := new(mockSolicitudes)
+		solicitudes.On("GetByID", ctx, uint(404)).Return(nil, errors.New("record not found"))
+		service := NewService(repo, logger, solicitudes)
+
+		// Act
+		_, err := service.Crear(ctx, 404, CrearReq{Contenido: "Hola", UsuarioID: 3})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrSolicitudNoEncontrada)
+	})
+
+	t.Run("debe rechazar un comentario vacío", func(t *testing.T) {
+		// Arrange
+		service := NewService(new(mockRepository), logger, new(mockSolicitudes))
+
+		// Act
+		_, err := service.Crear(ctx, 1, CrearReq{Contenido: "   ", UsuarioID: 3})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrContenidoVacio)
+	})
+}
+
+func TestService_Editar(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(os.Stdout, "TEST: ", log.LstdFlags)
+	original := func() *Comentario {
+		return &Comentario{ID: 5, SolicitudID: 1, UsuarioID: 3, Contenido: "Texto original @4", Menciones: []Mencion{{ComentarioID: 5, UsuarioID: 4}}}
+	}
+
+	t.Run("debe guardar el contenido anterior y actualizar las menciones", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1), uint(5)).Return(original(), nil)
+		repo.On("Editar", ctx,
+			mock.MatchedBy(func(c *Comentario) bool {
+				return c.Contenido == "Texto corregido @8" && len(c.Menciones) == 1 && c.Menciones[0].UsuarioID == 8
+			}),
+			mock.MatchedBy(func(e *Edicion) bool {
+				return e.ComentarioID == 5 && e.ContenidoAnterior == "Texto original @4" && e.UsuarioID == 3
+			})).Return(nil)
+		service := NewService(repo, logger, new(mockSolicitudes))
+
+		// Act
+		result, err := service.Editar(ctx, 1, 5, EditarReq{Contenido: "Texto corregido @8", UsuarioID: 3})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{8}, result.Menciones)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("solo el autor puede editar", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1), uint(5)).Return(original(), nil)
+		service := NewService(repo, logger, new(mockSolicitudes))
+
+		// Act
+		_, err := service.Editar(ctx, 1, 5, EditarReq{Contenido: "Otro texto", UsuarioID: 9})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrNoEsAutor)
+		repo.AssertNotCalled(t, "Editar", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("no debe registrar una edición sin cambios", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1), uint(5)).Return(original(), nil)
+		service := NewService(repo, logger, new(mockSolicitudes))
+
+		// Act
+		_, err := service.Editar(ctx, 1, 5, EditarReq{Contenido: "Texto original @4", UsuarioID: 3})
+
+		// Assert
+		assert.NoError(t, err)
+		repo.AssertNotCalled(t, "Editar", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe retornar error si el comentario no existe", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1), uint(50)).Return(nil, gorm.ErrRecordNotFound)
+		service := NewService(repo, logger, new(mockSolicitudes))
+
+		// Act
+		_, err := service.Editar(ctx, 1, 50, EditarReq{Contenido: "Texto", UsuarioID: 3})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrNoEncontrado)
+	})
+}
+
+func TestService_Eliminar(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(os.Stdout, "TEST: ", log.LstdFlags)
+
+	t.Run("debe eliminar el comentario del autor", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1), uint(5)).Return(&Comentario{ID: 5, SolicitudID: 1, UsuarioID: 3}, nil)
+		repo.On("Delete", ctx, uint(5)).Return(nil)
+		service := NewService(repo, logger, new(mockSolicitudes))
+
+		// Act
+		err := service.Eliminar(ctx, 1, 5, 3)
+
+		// Assert
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("solo el autor puede eliminar", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1), uint(5)).Return(&Comentario{ID: 5, SolicitudID: 1, UsuarioID: 3}, nil)
+		service := NewService(repo, logger, new(mockSolicitudes))
+
+		// Act
+		err := service.Eliminar(ctx, 1, 5, 4)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrNoEsAutor)
+		repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_Listar(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(os.Stdout, "TEST: ", log.LstdFlags)
+
+	t.Run("debe retornar los hilos filtrados", func(t *testing.T) {
+		// Arrange
+		interno := false
+		repo := new(mockRepository)
+		solicitudes := new(mockSolicitudes)
+		solicitudes.On("GetByID", ctx, uint(1)).Return(&solicitud.SolicitudResponse{ID: 1}, nil)
+		repo.On("GetBySolicitud", ctx, uint(1), ListarReq{Interno: &interno}).Return([]Comentario{
+			{ID: 1, SolicitudID: 1, UsuarioID: 3, Contenido: "Hola"},
+			{ID: 2, SolicitudID: 1, ComentarioPadreID: uintPtr(1), UsuarioID: 4, Contenido: "Hola"},
+		}, nil)
+		service := NewService(repo, logger, solicitudes)
+
+		// Act
+		hilos, err := service.Listar(ctx, 1, ListarReq{Interno: &interno})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, hilos, 1)
+		assert.Len(t, hilos[0].Respuestas, 1)
+	})
+
+	t.Run("debe retornar error si la solicitud no existe", func(t *testing.T) {
+		// Arrange
+		solicitudes := new(mockSolicitudes)
+		solicitudes.On("GetByID", ctx, uint(404)).Return(nil, errors.New("record not found"))
+		service := NewService(new(mockRepository), logger, solicitudes)
+
+		// Act
+		_, err := service.Listar(ctx, 404, ListarReq{})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrSolicitudNoEncontrada)
+	})
+}
+
+func TestService_GetEdiciones(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(os.Stdout, "TEST: ", log.LstdFlags)
+
+	// Arrange
+	repo := new(mockRepository)
+	repo.On("GetByID", ctx, uint(1), uint(5)).Return(&Comentario{ID: 5, SolicitudID: 1}, nil)
+	repo.On("GetEdiciones", ctx, uint(5)).Return([]Edicion{{ID: 1, ComentarioID: 5, ContenidoAnterior: "v1"}}, nil)
+	service := NewService(repo, logger, new(mockSolicitudes))
+
+	// Act
+	ediciones, err := service.GetEdiciones(ctx, 1, 5)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, ediciones, 1)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,289 @@
+package postulante
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tamanoMaximoCV es el tamaño máximo aceptado para el cuerpo de la carga de un CV
+const tamanoMaximoCV = 10 << 20
+
+type Endpoint struct {
+	service Service
+}
+
+func NewEndpoint(service Service) *Endpoint {
+	return &Endpoint{service: service}
+}
+
+// Create maneja POST /postulantes
+func (e *Endpoint) Create(c *gin.Context) {
+	var req CreateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	postulante, err := e.service.Create(c.Request.Context(), req)
+	if err != nil {
+		responderError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, postulante)
+}
+
+// GetAll maneja GET /postulantes
+func (e *Endpoint) GetAll(c *gin.Context) {
+	filters := GetAllReq{
+		Nombre: c.Query("nombre"),
+		Email:  c.Query("email"),
+	}
+
+	//Paginacion
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			filters.Limit = l
+		}
+	}
+	if page := c.Query("page"); page != "" {
+		if p, err := strconv.Atoi(page); err == nil {
+			filters.Page = p
+		}
+	}
+
+	postulantes, err := e.service.GetAll(c.Request.Context(), filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, postulantes)
+}
+
+// GetByID maneja GET /postulantes/:id
+func (e *Endpoint) GetByID(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	postulante, err := e.service.GetByID(c.Request.Context(), id)
+	if err != nil {
+		responderError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, postulante)
+}
+
+// Update maneja PATCH /postulantes/:id
+func (e *Endpoint) Update(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req UpdateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	postulante, err := e.service.Update(c.Request.Context(), id, req)
+	if err != nil {
+		responderError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, postulante)
+}
+
+// Delete maneja DELETE /postulantes/:id
+func (e *Endpoint) Delete(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := e.service.Delete(c.Request.Context(), id); err != nil {
+		responderError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Postulante eliminado exitosamente"})
+}
+
+// Postular maneja POST /solicitudes/:id/postulaciones
+func (e *Endpoint) Postular(c *gin.Context) {
+	solicitudID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req PostularReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.UsuarioID = usuarioID(c)
+
+	postulacion, err := e.service.Postular(c.Request.Context(), solicitudID, req)
+	if err != nil {
+		responderError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, postulacion)
+}
+
+// GetPostulaciones maneja GET /solicitudes/:id/postulaciones
+func (e *Endpoint) GetPostulaciones(c *gin.Context) {
+	solicitudID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	postulaciones, err := e.service.GetPostulaciones(c.Request.Context(), solicitudID, c.Query("etapa"))
+	if err != nil {
+		responderError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, postulaciones)
+}
+
+// GetPipeline maneja GET /solicitudes/:id/pipeline
+func (e *Endpoint) GetPipeline(c *gin.Context) {
+	solicitudID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	pipeline, err := e.service.GetPipeline(c.Request.Context(), solicitudID)
+	if err != nil {
+		responderError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, pipeline)
+}
+
+// CambiarEtapa maneja POST /solicitudes/:id/postulaciones/:postulacionId/etapa
+func (e *Endpoint) CambiarEtapa(c *gin.Context) {
+	solicitudID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	id, ok := parseID(c, "postulacionId")
+	if !ok {
+		return
+	}
+
+	var req CambioEtapaReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.UsuarioID = usuarioID(c)
+
+	postulacion, err := e.service.CambiarEtapa(c.Request.Context(), solicitudID, id, req)
+	if err != nil {
+		responderError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, postulacion)
+}
+
+// GetCambiosEtapa maneja GET /solicitudes/:id/postulaciones/:postulacionId/etapas
+func (e *Endpoint) GetCambiosEtapa(c *gin.Context) {
+	solicitudID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	id, ok := parseID(c, "postulacionId")
+	if !ok {
+		return
+	}
+
+	cambios, err := e.service.GetCambiosEtapa(c.Request.Context(), solicitudID, id)
+	if err != nil {
+		responderError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, cambios)
+}
+
+// SubirCV maneja POST /solicitudes/:id/postulaciones/:postulacionId/cv con el archivo en el campo "archivo"
+func (e *Endpoint) SubirCV(c *gin.Context) {
+	solicitudID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	id, ok := parseID(c, "postulacionId")
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, tamanoMaximoCV)
+
+	fileHeader, err := c.FormFile("archivo")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("El CV supera el tamaño máximo de %d bytes", tamanoMaximoCV)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El archivo del CV es requerido en el campo archivo"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el archivo"})
+		return
+	}
+	defer file.Close()
+
+	postulacion, err := e.service.SubirCV(c.Request.Context(), solicitudID, id, fileHeader.Filename, file)
+	if err != nil {
+		responderError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, postulacion)
+}
+
+// parseID obtiene un ID de la ruta y responde 400 si no es válido
+func parseID(c *gin.Context, param string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(param), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// usuarioID obtiene el usuario de la cabecera X-Usuario-ID, si viene, para registrar quién movió la postulación
+func usuarioID(c *gin.Context) *uint {
+	id, err := strconv.ParseUint(c.GetHeader("X-Usuario-ID"), 10, 32)
+	if err != nil || id == 0 {
+		return nil
+	}
+	uid := uint(id)
+	return &uid
+}
+
+func responderError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrSolicitudNoEncontrada):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Solicitud no encontrada"})
+	case errors.Is(err, ErrNoEncontrado):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Postulante no encontrado"})
+	case errors.Is(err, ErrPostulacionNoEncontrada):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Postulación no encontrada"})
+	case errors.Is(err, ErrEtapaInvalida), errors.Is(err, ErrMotivoRequerido):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrEmailDuplicado), errors.Is(err, ErrYaPostulado), errors.Is(err, ErrSolicitudNoAbierta),
+		errors.Is(err, ErrCambioEtapaNoPermitido), errors.Is(err, ErrSinVacantes):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrCVNoGuardado):
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,290 @@
+package postulante
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kramirez/solicitudes/internal/solicitud"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func setupEndpoint(repo *mockRepository, solicitudes *mockSolicitudes, docClient *mockDocumentoClient) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	ep := NewEndpoint(NewService(repo, log.New(io.Discard, "", 0), solicitudes, docClient, Pipeline{}))
+
+	r := gin.New()
+	r.POST("/postulantes", ep.Create)
+	r.GET("/postulantes/:id", ep.GetByID)
+	r.POST("/solicitudes/:id/postulaciones", ep.Postular)
+	r.GET("/solicitudes/:id/postulaciones", ep.GetPostulaciones)
+	r.GET("/solicitudes/:id/pipeline", ep.GetPipeline)
+	r.POST("/solicitudes/:id/postulaciones/:postulacionId/etapa", ep.CambiarEtapa)
+	r.POST("/solicitudes/:id/postulaciones/:postulacionId/cv", ep.SubirCV)
+	return r
+}
+
+func TestEndpoint_Create(t *testing.T) {
+	t.Run("debe crear el postulante", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByEmail", mock.Anything, "ana@correo.cl").Return(nil, gorm.ErrRecordNotFound)
+		repo.On("Create", mock.Anything, mock.AnythingOfType("*postulante.Postulante")).Return(nil).Run(func(args mock.Arguments) {
+			args.Get(1).(*Postulante).ID = 4
+		})
+		r := setupEndpoint(repo, new(mockSolicitudes), new(mockDocumentoClient))
+
+		req := httptest.NewRequest(http.MethodPost, "/postulantes", bytes.NewBufferString(`{"nombre":"Ana","email":"ana@correo.cl"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var resp Postulante
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, uint(4), resp.ID)
+	})
+
+	t.Run("debe retornar 400 con un email inválido", func(t *testing.T) {
+		// Arrange
+		r := setupEndpoint(new(mockRepository), new(mockSolicitudes), new(mockDocumentoClient))
+		req := httptest.NewRequest(http.MethodPost, "/postulantes", bytes.NewBufferString(`{"nombre":"Ana","email":"no-es-email"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("debe retornar 409 con un email duplicado", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByEmail", mock.Anything, "ana@correo.cl").Return(&Postulante{ID: 9}, nil)
+		r := setupEndpoint(repo, new(mockSolicitudes), new(mockDocumentoClient))
+
+		req := httptest.NewRequest(http.MethodPost, "/postulantes", bytes.NewBufferString(`{"nombre":"Ana","email":"ana@correo.cl"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
+
+func TestEndpoint_GetByID_NotFound(t *testing.T) {
+	// Arrange
+	repo := new(mockRepository)
+	repo.On("GetByID", mock.Anything, uint(40)).Return(nil, gorm.ErrRecordNotFound)
+	r := setupEndpoint(repo, new(mockSolicitudes), new(mockDocumentoClient))
+
+	req := httptest.NewRequest(http.MethodGet, "/postulantes/40", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	r.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestEndpoint_Postular(t *testing.T) {
+	t.Run("debe postular con el usuario de la cabecera", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		solicitudes := new(mockSolicitudes)
+		solicitudes.On("GetByID", mock.Anything, uint(1)).Return(&solicitud.SolicitudResponse{ID: 1, Estado: solicitud.EstadoEnProceso}, nil)
+		repo.On("GetByID", mock.Anything, uint(4)).Return(&Postulante{ID: 4}, nil)
+		repo.On("GetPostulacionesPorPostulante", mock.Anything, uint(4)).Return([]Postulacion{}, nil)
+		repo.On("Postular", mock.Anything, mock.Anything, mock.MatchedBy(func(c *CambioEtapa) bool {
+			return c.UsuarioID != nil && *c.UsuarioID == 3
+		})).Return(nil)
+		r := setupEndpoint(repo, solicitudes, new(mockDocumentoClient))
+
+		req := httptest.NewRequest(http.MethodPost, "/solicitudes/1/postulaciones", bytes.NewBufferString(`{"postulante_id":4}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Usuario-ID", "3")
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var resp Postulacion
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, EtapaRecibido, resp.Etapa)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe retornar 409 si la solicitud no recibe postulaciones", func(t *testing.T) {
+		// Arrange
+		solicitudes := new(mockSolicitudes)
+		solicitudes.On("GetByID", mock.Anything, uint(1)).Return(&solicitud.SolicitudResponse{ID: 1, Estado: solicitud.EstadoCerrada}, nil)
+		r := setupEndpoint(new(mockRepository), solicitudes, new(mockDocumentoClient))
+
+		req := httptest.NewRequest(http.MethodPost, "/solicitudes/1/postulaciones", bytes.NewBufferString(`{"postulante_id":4}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
+
+func TestEndpoint_GetPipeline(t *testing.T) {
+	// Arrange
+	repo := new(mockRepository)
+	solicitudes := new(mockSolicitudes)
+	solicitudes.On("GetByID", mock.Anything, uint(1)).Return(&solicitud.SolicitudResponse{ID: 1, NumeroVacantes: 1}, nil)
+	repo.On("GetPostulaciones", mock.Anything, uint(1), "").Return([]Postulacion{{ID: 1, Etapa: EtapaOferta}}, nil)
+	r := setupEndpoint(repo, solicitudes, new(mockDocumentoClient))
+
+	req := httptest.NewRequest(http.MethodGet, "/solicitudes/1/pipeline", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	r.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp PipelineResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(t, resp.Etapas, 5)
+	assert.Equal(t, 1, resp.Etapas[2].Total)
+}
+
+func TestEndpoint_GetPostulaciones_EtapaInvalida(t *testing.T) {
+	// Arrange
+	r := setupEndpoint(new(mockRepository), new(mockSolicitudes), new(mockDocumentoClient))
+	req := httptest.NewRequest(http.MethodGet, "/solicitudes/1/postulaciones?etapa=inexistente", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	r.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestEndpoint_CambiarEtapa(t *testing.T) {
+	t.Run("debe retornar 400 al descartar sin motivo", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetPostulacion", mock.Anything, uint(1), uint(5)).Return(&Postulacion{ID: 5, SolicitudID: 1, Etapa: EtapaEntrevista}, nil)
+		r := setupEndpoint(repo, new(mockSolicitudes), new(mockDocumentoClient))
+
+		req := httptest.NewRequest(http.MethodPost, "/solicitudes/1/postulaciones/5/etapa", bytes.NewBufferString(`{"etapa":"descartado"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		repo.AssertNotCalled(t, "CambiarEtapa", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe retornar 409 desde una etapa final", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetPostulacion", mock.Anything, uint(1), uint(5)).Return(&Postulacion{ID: 5, SolicitudID: 1, Etapa: EtapaContratado}, nil)
+		r := setupEndpoint(repo, new(mockSolicitudes), new(mockDocumentoClient))
+
+		req := httptest.NewRequest(http.MethodPost, "/solicitudes/1/postulaciones/5/etapa", bytes.NewBufferString(`{"etapa":"oferta"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
+
+func TestEndpoint_SubirCV(t *testing.T) {
+	t.Run("debe subir el archivo recibido", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		docClient := new(mockDocumentoClient)
+		repo.On("GetPostulacion", mock.Anything, uint(1), uint(5)).Return(&Postulacion{ID: 5, SolicitudID: 1, PostulanteID: 4}, nil)
+		docClient.On("SubirDocumentoCategoria", uint(1), "cv-postulante-4.pdf", CategoriaCV, []byte("%PDF-1.4")).
+			Return(&solicitud.Documento{ID: 30}, nil)
+		repo.On("ActualizarCV", mock.Anything, uint(5), uint(30)).Return(nil)
+		r := setupEndpoint(repo, new(mockSolicitudes), docClient)
+
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		parte, _ := writer.CreateFormFile("archivo", "cv.pdf")
+		parte.Write([]byte("%PDF-1.4"))
+		writer.Close()
+		req := httptest.NewRequest(http.MethodPost, "/solicitudes/1/postulaciones/5/cv", &body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var resp Postulacion
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, uint(30), *resp.CVDocumentoID)
+	})
+
+	t.Run("debe retornar 413 si el CV supera el tamaño máximo", func(t *testing.T) {
+		// Arrange
+		docClient := new(mockDocumentoClient)
+		r := setupEndpoint(new(mockRepository), new(mockSolicitudes), docClient)
+
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		parte, _ := writer.CreateFormFile("archivo", "cv.pdf")
+		parte.Write(bytes.Repeat([]byte("a"), tamanoMaximoCV+1))
+		writer.Close()
+		req := httptest.NewRequest(http.MethodPost, "/solicitudes/1/postulaciones/5/cv", &body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		docClient.AssertNotCalled(t, "SubirDocumentoCategoria", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe retornar 400 sin archivo", func(t *testing.T) {
+		// Arrange
+		r := setupEndpoint(new(mockRepository), new(mockSolicitudes), new(mockDocumentoClient))
+		req := httptest.NewRequest(http.MethodPost, "/solicitudes/1/postulaciones/5/cv", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,128 @@
+package postulante
+
+import (
+	"context"
+	"io"
+
+	"github.com/kramirez/solicitudes/internal/solicitud"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockRepository struct {
+	mock.Mock
+}
+
+func (m *mockRepository) Create(ctx context.Context, postulante *Postulante) error {
+	args := m.Called(ctx, postulante)
+	return args.Error(0)
+}
+
+func (m *mockRepository) GetByID(ctx context.Context, id uint) (*Postulante, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Postulante), args.Error(1)
+}
+
+func (m *mockRepository) GetByEmail(ctx context.Context, email string) (*Postulante, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Postulante), args.Error(1)
+}
+
+func (m *mockRepository) GetAll(ctx context.Context, filters GetAllReq) ([]Postulante, error) {
+	args := m.Called(ctx, filters)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Postulante), args.Error(1)
+}
+
+func (m *mockRepository) Update(ctx context.Context, id uint, req UpdateReq) error {
+	args := m.Called(ctx, id, req)
+	return args.Error(0)
+}
+
+func (m *mockRepository) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *mockRepository) Postular(ctx context.Context, postulacion *Postulacion, cambio *CambioEtapa) error {
+	args := m.Called(ctx, postulacion, cambio)
+	return args.Error(0)
+}
+
+func (m *mockRepository) GetPostulacion(ctx context.Context, solicitudID, id uint) (*Postulacion, error) {
+	args := m.Called(ctx, solicitudID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Postulacion), args.Error(1)
+}
+
+func (m *mockRepository) GetPostulaciones(ctx context.Context, solicitudID uint, etapa string) ([]Postulacion, error) {
+	args := m.Called(ctx, solicitudID, etapa)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Postulacion), args.Error(1)
+}
+
+func (m *mockRepository) GetPostulacionesPorPostulante(ctx context.Context, postulanteID uint) ([]Postulacion, error) {
+	args := m.Called(ctx, postulanteID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Postulacion), args.Error(1)
+}
+
+func (m *mockRepository) CambiarEtapa(ctx context.Context, solicitudID uint, cambio *CambioEtapa, vacantes int) (bool, error) {
+	args := m.Called(ctx, solicitudID, cambio, vacantes)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockRepository) GetCambiosEtapa(ctx context.Context, postulacionID uint) ([]CambioEtapa, error) {
+	args := m.Called(ctx, postulacionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]CambioEtapa), args.Error(1)
+}
+
+func (m *mockRepository) ActualizarCV(ctx context.Context, id, documentoID uint) error {
+	args := m.Called(ctx, id, documentoID)
+	return args.Error(0)
+}
+
+type mockSolicitudes struct {
+	mock.Mock
+}
+
+func (m *mockSolicitudes) GetByID(ctx context.Context, id uint) (*solicitud.SolicitudResponse, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*solicitud.SolicitudResponse), args.Error(1)
+}
+
+type mockDocumentoClient struct {
+	mock.Mock
+}
+
+func (m *mockDocumentoClient) SubirDocumentoCategoria(solicitudID uint, nombreArchivo, categoria string, contenido io.Reader) (*solicitud.Documento, error) {
+	// Se compara el contenido leído para que las expectativas usen bytes
+	leido, err := io.ReadAll(contenido)
+	if err != nil {
+		return nil, err
+	}
+	args := m.Called(solicitudID, nombreArchivo, categoria, leido)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*solicitud.Documento), args.Error(1)
+}
@@ -0,0 +1,82 @@
+package postulante
+
+import (
+	"errors"
+	"strings"
+)
+
+// Etapas del pipeline por defecto
+const (
+	EtapaRecibido   = "recibido"
+	EtapaEntrevista = "entrevista"
+	EtapaOferta     = "oferta"
+	EtapaContratado = "contratado"
+	EtapaDescartado = "descartado"
+)
+
+// ErrEtapaInvalida indica que la etapa no pertenece al pipeline de postulación
+var ErrEtapaInvalida = errors.New("etapa inválida")
+
+// ErrCambioEtapaNoPermitido indica que la postulación ya terminó o ya está en la etapa solicitada
+var ErrCambioEtapaNoPermitido = errors.New("cambio de etapa no permitido")
+
+// ErrMotivoRequerido indica que la etapa exige indicar el motivo del cambio
+var ErrMotivoRequerido = errors.New("el motivo es obligatorio para descartar una postulación")
+
+// Pipeline son las etapas por las que pasa una postulación, en orden. Toda postulación empieza en
+// la primera etapa y puede moverse a cualquier otra mientras no llegue a una etapa final.
+type Pipeline struct {
+	Etapas  []string
+	Finales []string
+}
+
+// PipelinePorDefecto retorna el pipeline que se usa cuando no se configura ETAPAS_POSTULACION
+func PipelinePorDefecto() Pipeline {
+	return Pipeline{
+		Etapas:  []string{EtapaRecibido, EtapaEntrevista, EtapaOferta, EtapaContratado, EtapaDescartado},
+		Finales: []string{EtapaContratado, EtapaDescartado},
+	}
+}
+
+// Inicial retorna la etapa con la que se crean las postulaciones
+func (p Pipeline) Inicial() string {
+	return p.Etapas[0]
+}
+
+// Contiene indica si la etapa pertenece al pipeline
+func (p Pipeline) Contiene(etapa string) bool {
+	return contiene(p.Etapas, etapa)
+}
+
+// EsFinal indica si una postulación en la etapa ya terminó su proceso
+func (p Pipeline) EsFinal(etapa string) bool {
+	return contiene(p.Finales, etapa)
+}
+
+// validarCambio verifica que una postulación pueda pasar de una etapa a otra
+func (p Pipeline) validarCambio(desde, hacia, motivo string) error {
+	if !p.Contiene(hacia) {
+		return ErrEtapaInvalida
+	}
+	if desde == hacia || p.EsFinal(desde) {
+		return ErrCambioEtapaNoPermitido
+	}
+	if hacia == EtapaDescartado && strings.TrimSpace(motivo) == "" {
+		return ErrMotivoRequerido
+	}
+	return nil
+}
+
+// normalizarEtapa permite recibir la etapa con mayúsculas o espacios
+func normalizarEtapa(etapa string) string {
+	return strings.ToLower(strings.TrimSpace(etapa))
+}
+
+func contiene(etapas []string, etapa string) bool {
+	for _, e := range etapas {
+		if e == etapa {
+			return true
+		}
+	}
+	return false
+}
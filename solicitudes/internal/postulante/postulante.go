@@ -0,0 +1,121 @@
+package postulante
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CategoriaCV es la categoría con la que se guardan los CV en el servicio de documentos
+const CategoriaCV = "cv"
+
+// Postulante es una persona que postula a las vacantes de las solicitudes
+type Postulante struct {
+	ID     uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	Nombre string `gorm:"type:varchar(150);not null" json:"nombre"`
+	Email  string `gorm:"type:varchar(150);not null;index" json:"email"`
+	// EmailVigente repite el email mientras el postulante no esté eliminado. Su índice único impide
+	// que dos postulantes vigentes compartan email y permite reutilizar el de uno eliminado.
+	EmailVigente *string        `gorm:"type:varchar(150);uniqueIndex" json:"-"`
+	Telefono     string         `gorm:"type:varchar(30)" json:"telefono"`
+	CreatedAt    time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName especifica el nombre de la tabla
+func (Postulante) TableName() string {
+	return "postulantes"
+}
+
+// Postulacion vincula un postulante con la solicitud a la que postula y la etapa del proceso en que está
+type Postulacion struct {
+	ID            uint        `gorm:"primaryKey;autoIncrement" json:"id"`
+	PostulanteID  uint        `gorm:"not null;uniqueIndex:idx_postulacion" json:"postulante_id"`
+	SolicitudID   uint        `gorm:"not null;uniqueIndex:idx_postulacion;index" json:"solicitud_id"`
+	Etapa         string      `gorm:"type:varchar(30);not null;index" json:"etapa"`
+	CVDocumentoID *uint       `json:"cv_documento_id,omitempty"`
+	Postulante    *Postulante `gorm:"foreignKey:PostulanteID" json:"postulante,omitempty"`
+	CreatedAt     time.Time   `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time   `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName especifica el nombre de la tabla
+func (Postulacion) TableName() string {
+	return "postulaciones"
+}
+
+// CambioEtapa registra cada paso de una postulación por el pipeline
+type CambioEtapa struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	PostulacionID uint      `gorm:"not null;index" json:"postulacion_id"`
+	Desde         string    `gorm:"type:varchar(30)" json:"desde,omitempty"`
+	Hacia         string    `gorm:"type:varchar(30);not null" json:"hacia"`
+	Motivo        string    `gorm:"type:text" json:"motivo,omitempty"`
+	UsuarioID     *uint     `json:"usuario_id,omitempty"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName especifica el nombre de la tabla
+func (CambioEtapa) TableName() string {
+	return "postulacion_etapas"
+}
+
+// CreateReq representa la petición para registrar un postulante
+type CreateReq struct {
+	Nombre   string `json:"nombre" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+	Telefono string `json:"telefono"`
+}
+
+// UpdateReq representa la actualización parcial de un postulante
+type UpdateReq struct {
+	Nombre   *string `json:"nombre"`
+	Email    *string `json:"email" binding:"omitempty,email"`
+	Telefono *string `json:"telefono"`
+}
+
+// GetAllReq representa los filtros de la búsqueda de postulantes
+type GetAllReq struct {
+	Nombre string
+	Email  string
+	Limit  int
+	Page   int
+}
+
+// PostularReq representa la postulación de un postulante a una solicitud
+type PostularReq struct {
+	PostulanteID uint `json:"postulante_id" binding:"required"`
+	// UsuarioID es quien registra la postulación, tomado de la cabecera X-Usuario-ID
+	UsuarioID *uint `json:"-"`
+}
+
+// CambioEtapaReq representa la petición para mover una postulación a otra etapa del pipeline
+type CambioEtapaReq struct {
+	Etapa string `json:"etapa" binding:"required"`
+	// Motivo del cambio, obligatorio al descartar
+	Motivo string `json:"motivo"`
+	// UsuarioID es quien mueve la postulación, tomado de la cabecera X-Usuario-ID
+	UsuarioID *uint `json:"-"`
+}
+
+// PostulanteResponse representa un postulante con las solicitudes a las que postuló
+type PostulanteResponse struct {
+	Postulante
+	Postulaciones []Postulacion `json:"postulaciones"`
+}
+
+// EtapaPipeline agrupa las postulaciones de una solicitud que están en una etapa
+type EtapaPipeline struct {
+	Etapa         string        `json:"etapa"`
+	Final         bool          `json:"final"`
+	Total         int           `json:"total"`
+	Postulaciones []Postulacion `json:"postulaciones"`
+}
+
+// PipelineResponse representa el pipeline de postulaciones de una solicitud, en el orden de sus etapas
+type PipelineResponse struct {
+	SolicitudID    uint            `json:"solicitud_id"`
+	NumeroVacantes int             `json:"numero_vacantes"`
+	Etapas         []EtapaPipeline `json:"etapas"`
+}
@@ -0,0 +1,235 @@
+package postulante
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	Create(ctx context.Context, postulante *Postulante) error
+	GetByID(ctx context.Context, id uint) (*Postulante, error)
+	GetByEmail(ctx context.Context, email string) (*Postulante, error)
+	GetAll(ctx context.Context, filters GetAllReq) ([]Postulante, error)
+	Update(ctx context.Context, id uint, req UpdateReq) error
+	Delete(ctx context.Context, id uint) error
+	Postular(ctx context.Context, postulacion *Postulacion, cambio *CambioEtapa) error
+	GetPostulacion(ctx context.Context, solicitudID, id uint) (*Postulacion, error)
+	GetPostulaciones(ctx context.Context, solicitudID uint, etapa string) ([]Postulacion, error)
+	GetPostulacionesPorPostulante(ctx context.Context, postulanteID uint) ([]Postulacion, error)
+	CambiarEtapa(ctx context.Context, solicitudID uint, cambio *CambioEtapa, vacantes int) (bool, error)
+	GetCambiosEtapa(ctx context.Context, postulacionID uint) ([]CambioEtapa, error)
+	ActualizarCV(ctx context.Context, id, documentoID uint) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, postulante *Postulante) error {
+	postulante.EmailVigente = &postulante.Email
+	return errorEmail(r.db.WithContext(ctx).Create(postulante).Error)
+}
+
+func (r *repository) GetByID(ctx context.Context, id uint) (*Postulante, error) {
+	var postulante Postulante
+	err := r.db.WithContext(ctx).First(&postulante, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &postulante, nil
+}
+
+func (r *repository) GetByEmail(ctx context.Context, email string) (*Postulante, error) {
+	var postulante Postulante
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&postulante).Error
+	if err != nil {
+		return nil, err
+	}
+	return &postulante, nil
+}
+
+func (r *repository) GetAll(ctx context.Context, filters GetAllReq) ([]Postulante, error) {
+	var postulantes []Postulante
+	query := r.db.WithContext(ctx).Model(&Postulante{})
+
+	//Aplicar filtros
+	if filters.Nombre != "" {
+		query = query.Where("nombre LIKE ?", "%"+filters.Nombre+"%")
+	}
+	if filters.Email != "" {
+		query = query.Where("email LIKE ?", "%"+filters.Email+"%")
+	}
+
+	//Paginacion
+	if filters.Limit > 0 {
+		query = query.Limit(filters.Limit)
+	}
+	if filters.Page > 0 {
+		offset := (filters.Page - 1) * filters.Limit
+		query = query.Offset(offset)
+	}
+
+	err := query.Order("nombre, id").Find(&postulantes).Error
+	return postulantes, err
+}
+
+func (r *repository) Update(ctx context.Context, id uint, req UpdateReq) error {
+	updates := make(map[string]interface{})
+
+	if req.Nombre != nil {
+		updates["nombre"] = *req.Nombre
+	}
+	if req.Email != nil {
+		updates["email"] = *req.Email
+		updates["email_vigente"] = *req.Email
+	}
+	if req.Telefono != nil {
+		updates["telefono"] = *req.Telefono
+	}
+
+	return errorEmail(r.db.WithContext(ctx).Model(&Postulante{}).Where("id = ?", id).Updates(updates).Error)
+}
+
+func (r *repository) Delete(ctx context.Context, id uint) error {
+	// Soft delete: sus postulaciones se mantienen en el pipeline de cada solicitud, y su email
+	// queda libre para un nuevo postulante
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Postulante{}).Where("id = ?", id).Update("email_vigente", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Postulante{}, id).Error
+	})
+}
+
+// errorEmail traduce la violación del índice único de email, por ejemplo de dos altas simultáneas
+// con el mismo email, a ErrEmailDuplicado
+func errorEmail(err error) error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrEmailDuplicado
+	}
+	return err
+}
+
+// Postular crea la postulación junto con el registro de su etapa inicial
+func (r *repository) Postular(ctx context.Context, postulacion *Postulacion, cambio *CambioEtapa) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Postulante").Create(postulacion).Error; err != nil {
+			return err
+		}
+		cambio.PostulacionID = postulacion.ID
+		return tx.Create(cambio).Error
+	})
+}
+
+// conPostulante carga el postulante de la postulación, aunque haya sido eliminado
+func conPostulante(db *gorm.DB) *gorm.DB {
+	return db.Preload("Postulante", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	})
+}
+
+func (r *repository) GetPostulacion(ctx context.Context, solicitudID, id uint) (*Postulacion, error) {
+	var postulacion Postulacion
+	err := conPostulante(r.db.WithContext(ctx)).
+		Where("solicitud_id = ?", solicitudID).
+		First(&postulacion, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &postulacion, nil
+}
+
+// GetPostulaciones obtiene las postulaciones de una solicitud en orden de llegada, opcionalmente de una etapa
+func (r *repository) GetPostulaciones(ctx context.Context, solicitudID uint, etapa string) ([]Postulacion, error) {
+	var postulaciones []Postulacion
+	query := conPostulante(r.db.WithContext(ctx)).Where("solicitud_id = ?", solicitudID)
+	if etapa != "" {
+		query = query.Where("etapa = ?", etapa)
+	}
+
+	err := query.Order("created_at, id").Find(&postulaciones).Error
+	return postulaciones, err
+}
+
+func (r *repository) GetPostulacionesPorPostulante(ctx context.Context, postulanteID uint) ([]Postulacion, error) {
+	var postulaciones []Postulacion
+	err := r.db.WithContext(ctx).
+		Where("postulante_id = ?", postulanteID).
+		Order("created_at, id").
+		Find(&postulaciones).Error
+	return postulaciones, err
+}
+
+// CambiarEtapa mueve la postulación solo si sigue en la etapa de origen del cambio y lo registra en la
+// misma transacción. Retorna false si otra petición la movió antes. Al contratar con vacantes mayor
+// que 0, bloquea las postulaciones de la solicitud mientras cuenta los contratados, para que dos
+// contrataciones simultáneas no superen las vacantes.
+func (r *repository) CambiarEtapa(ctx context.Context, solicitudID uint, cambio *CambioEtapa, vacantes int) (bool, error) {
+	cambiada := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if cambio.Hacia == EtapaContratado && vacantes > 0 {
+			if err := verificarVacantes(tx, solicitudID, vacantes); err != nil {
+				return err
+			}
+		}
+
+		result := tx.Model(&Postulacion{}).
+			Where("id = ? AND etapa = ?", cambio.PostulacionID, cambio.Desde).
+			Update("etapa", cambio.Hacia)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		cambiada = true
+		return tx.Create(cambio).Error
+	})
+	return cambiada, err
+}
+
+// verificarVacantes bloquea las postulaciones de la solicitud hasta el fin de la transacción y
+// verifica que los contratados no alcancen las vacantes
+func verificarVacantes(tx *gorm.DB, solicitudID uint, vacantes int) error {
+	var etapas []string
+	err := tx.Model(&Postulacion{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("solicitud_id = ?", solicitudID).
+		Pluck("etapa", &etapas).Error
+	if err != nil {
+		return err
+	}
+
+	contratados := 0
+	for _, etapa := range etapas {
+		if etapa == EtapaContratado {
+			contratados++
+		}
+	}
+	if contratados >= vacantes {
+		return fmt.Errorf("%w: %d de %d contratados", ErrSinVacantes, contratados, vacantes)
+	}
+	return nil
+}
+
+// GetCambiosEtapa obtiene el recorrido de la postulación por el pipeline, del más antiguo al más reciente
+func (r *repository) GetCambiosEtapa(ctx context.Context, postulacionID uint) ([]CambioEtapa, error) {
+	var cambios []CambioEtapa
+	err := r.db.WithContext(ctx).
+		Where("postulacion_id = ?", postulacionID).
+		Order("created_at, id").
+		Find(&cambios).Error
+	return cambios, err
+}
+
+func (r *repository) ActualizarCV(ctx context.Context, id, documentoID uint) error {
+	return r.db.WithContext(ctx).Model(&Postulacion{}).Where("id = ?", id).Update("cv_documento_id", documentoID).Error
+}
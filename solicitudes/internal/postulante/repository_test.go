@@ -0,0 +1,211 @@
+package postulante
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+
+	return gormDB, mock
+}
+
+func TestRepository_Create(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `postulantes`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	postulante := &Postulante{Nombre: "Ana", Email: "ana@correo.cl"}
+
+	err := repo.Create(context.Background(), postulante)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), postulante.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Create_EmailDuplicado(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+
+	// Otra petición registró el mismo email después de la verificación del servicio
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `postulantes` \\(`nombre`,`email`,`email_vigente`").
+		WithArgs("Ana", "ana@correo.cl", "ana@correo.cl", "", sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnError(&mysqldriver.MySQLError{Number: 1062, Message: "Duplicate entry 'ana@correo.cl'"})
+	mock.ExpectRollback()
+
+	err := repo.Create(context.Background(), &Postulante{Nombre: "Ana", Email: "ana@correo.cl"})
+	assert.ErrorIs(t, err, ErrEmailDuplicado)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Update_Email(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `postulantes` SET `email`=\\?,`email_vigente`=\\?").
+		WithArgs("ana@correo.cl", "ana@correo.cl", sqlmock.AnyArg(), 4).
+		WillReturnError(&mysqldriver.MySQLError{Number: 1062, Message: "Duplicate entry 'ana@correo.cl'"})
+	mock.ExpectRollback()
+
+	email := "ana@correo.cl"
+	err := repo.Update(context.Background(), 4, UpdateReq{Email: &email})
+	assert.ErrorIs(t, err, ErrEmailDuplicado)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Delete(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+
+	// El email del postulante eliminado queda libre en el índice único
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `postulantes` SET `email_vigente`=\\?").
+		WithArgs(nil, sqlmock.AnyArg(), 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE `postulantes` SET `deleted_at`=\\?").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.Delete(context.Background(), 4)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_GetAll(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+
+	mock.ExpectQuery("SELECT \\* FROM `postulantes` WHERE nombre LIKE \\? AND `postulantes`.`deleted_at` IS NULL ORDER BY nombre, id LIMIT \\? OFFSET \\?").
+		WithArgs("%Ana%", 10, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "nombre"}).AddRow(1, "Ana"))
+
+	result, err := repo.GetAll(context.Background(), GetAllReq{Nombre: "Ana", Limit: 10, Page: 2})
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Postular(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `postulaciones`").WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectExec("INSERT INTO `postulacion_etapas`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	postulacion := &Postulacion{PostulanteID: 4, SolicitudID: 1, Etapa: EtapaRecibido, Postulante: &Postulante{ID: 4}}
+	cambio := &CambioEtapa{Hacia: EtapaRecibido}
+
+	err := repo.Postular(context.Background(), postulacion, cambio)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(7), cambio.PostulacionID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_GetPostulaciones(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+
+	mock.ExpectQuery("SELECT \\* FROM `postulaciones` WHERE solicitud_id = \\? AND etapa = \\? ORDER BY created_at, id").
+		WithArgs(1, EtapaEntrevista).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "postulante_id", "solicitud_id", "etapa"}).AddRow(1, 4, 1, EtapaEntrevista))
+	// El postulante se carga aunque haya sido eliminado
+	mock.ExpectQuery("SELECT \\* FROM `postulantes` WHERE `postulantes`.`id` = \\?$").
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "nombre"}).AddRow(4, "Ana"))
+
+	result, err := repo.GetPostulaciones(context.Background(), 1, EtapaEntrevista)
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	assert.Equal(t, "Ana", result[0].Postulante.Nombre)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_CambiarEtapa(t *testing.T) {
+	t.Run("debe mover la postulación y registrar el cambio", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE `postulaciones` SET `etapa`=\\?,`updated_at`=\\? WHERE id = \\? AND etapa = \\?").
+			WithArgs(EtapaEntrevista, sqlmock.AnyArg(), 5, EtapaRecibido).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO `postulacion_etapas`").WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+
+		cambiada, err := repo.CambiarEtapa(context.Background(), 1, &CambioEtapa{PostulacionID: 5, Desde: EtapaRecibido, Hacia: EtapaEntrevista}, 0)
+		assert.NoError(t, err)
+		assert.True(t, cambiada)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("no debe registrar el cambio si la postulación ya no está en la etapa de origen", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE `postulaciones` SET").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		cambiada, err := repo.CambiarEtapa(context.Background(), 1, &CambioEtapa{PostulacionID: 5, Desde: EtapaRecibido, Hacia: EtapaEntrevista}, 0)
+		assert.NoError(t, err)
+		assert.False(t, cambiada)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("debe contar los contratados con las postulaciones bloqueadas antes de contratar", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT `etapa` FROM `postulaciones` WHERE solicitud_id = \\? .*FOR UPDATE").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"etapa"}).AddRow(EtapaContratado).AddRow(EtapaOferta))
+		mock.ExpectExec("UPDATE `postulaciones` SET `etapa`").
+			WithArgs(EtapaContratado, sqlmock.AnyArg(), 5, EtapaOferta).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO `postulacion_etapas`").WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+
+		cambiada, err := repo.CambiarEtapa(context.Background(), 1, &CambioEtapa{PostulacionID: 5, Desde: EtapaOferta, Hacia: EtapaContratado}, 2)
+		assert.NoError(t, err)
+		assert.True(t, cambiada)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("no debe contratar si las vacantes están completas", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT `etapa` FROM `postulaciones`.*FOR UPDATE").
+			WillReturnRows(sqlmock.NewRows([]string{"etapa"}).AddRow(EtapaContratado).AddRow(EtapaContratado).AddRow(EtapaOferta))
+		mock.ExpectRollback()
+
+		cambiada, err := repo.CambiarEtapa(context.Background(), 1, &CambioEtapa{PostulacionID: 5, Desde: EtapaOferta, Hacia: EtapaContratado}, 2)
+		assert.ErrorIs(t, err, ErrSinVacantes)
+		assert.False(t, cambiada)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
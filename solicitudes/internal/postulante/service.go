@@ -0,0 +1,393 @@
+package postulante
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/kramirez/solicitudes/internal/solicitud"
+	"gorm.io/gorm"
+)
+
+type Service interface {
+	Create(ctx context.Context, req CreateReq) (*Postulante, error)
+	GetAll(ctx context.Context, filters GetAllReq) ([]Postulante, error)
+	GetByID(ctx context.Context, id uint) (*PostulanteResponse, error)
+	Update(ctx context.Context, id uint, req UpdateReq) (*Postulante, error)
+	Delete(ctx context.Context, id uint) error
+	Postular(ctx context.Context, solicitudID uint, req PostularReq) (*Postulacion, error)
+	GetPostulaciones(ctx context.Context, solicitudID uint, etapa string) ([]Postulacion, error)
+	GetPipeline(ctx context.Context, solicitudID uint) (*PipelineResponse, error)
+	CambiarEtapa(ctx context.Context, solicitudID, id uint, req CambioEtapaReq) (*Postulacion, error)
+	GetCambiosEtapa(ctx context.Context, solicitudID, id uint) ([]CambioEtapa, error)
+	SubirCV(ctx context.Context, solicitudID, id uint, nombreArchivo string, contenido io.Reader) (*Postulacion, error)
+}
+
+// Solicitudes permite consultar la solicitud a la que se postula
+type Solicitudes interface {
+	GetByID(ctx context.Context, id uint) (*solicitud.SolicitudResponse, error)
+}
+
+// DocumentoClient permite guardar los CV en el servicio de documentos
+type DocumentoClient interface {
+	SubirDocumentoCategoria(solicitudID uint, nombreArchivo, categoria string, contenido io.Reader) (*solicitud.Documento, error)
+}
+
+var (
+	// ErrNoEncontrado indica que el postulante no existe o fue eliminado
+	ErrNoEncontrado = errors.New("postulante no encontrado")
+	// ErrPostulacionNoEncontrada indica que la postulación no existe en la solicitud
+	ErrPostulacionNoEncontrada = errors.New("postulación no encontrada")
+	// ErrSolicitudNoEncontrada indica que la solicitud no existe o fue eliminada
+	ErrSolicitudNoEncontrada = errors.New("solicitud no encontrada")
+	// ErrEmailDuplicado indica que ya existe un postulante con el email
+	ErrEmailDuplicado = errors.New("ya existe un postulante con ese email")
+	// ErrYaPostulado indica que el postulante ya postuló a la solicitud
+	ErrYaPostulado = errors.New("el postulante ya postuló a la solicitud")
+	// ErrSolicitudNoAbierta indica que la solicitud no está recibiendo postulaciones
+	ErrSolicitudNoAbierta = errors.New("la solicitud no está recibiendo postulaciones")
+	// ErrSinVacantes indica que la solicitud ya contrató a todas sus vacantes
+	ErrSinVacantes = errors.New("la solicitud no tiene vacantes disponibles")
+	// ErrCVNoGuardado indica que el servicio de documentos no aceptó el CV
+	ErrCVNoGuardado = errors.New("no se pudo guardar el CV en el servicio de documentos")
+)
+
+// estadosAbiertos son los estados de solicitud que reciben postulaciones
+var estadosAbiertos = []string{solicitud.EstadoPublicada, solicitud.EstadoEnProceso}
+
+type service struct {
+	repo            Repository
+	logger          *log.Logger
+	solicitudes     Solicitudes
+	documentoClient DocumentoClient
+	pipeline        Pipeline
+}
+
+func NewService(repo Repository, logger *log.Logger, solicitudes Solicitudes, documentoClient DocumentoClient, pipeline Pipeline) Service {
+	if len(pipeline.Etapas) == 0 {
+		pipeline = PipelinePorDefecto()
+	}
+	return &service{
+		repo:            repo,
+		logger:          logger,
+		solicitudes:     solicitudes,
+		documentoClient: documentoClient,
+		pipeline:        pipeline,
+	}
+}
+
+func (s *service) Create(ctx context.Context, req CreateReq) (*Postulante, error) {
+	postulante := &Postulante{
+		Nombre:   strings.TrimSpace(req.Nombre),
+		Email:    normalizarEmail(req.Email),
+		Telefono: strings.TrimSpace(req.Telefono),
+	}
+	if err := s.verificarEmail(ctx, postulante.Email, 0); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, postulante); err != nil {
+		s.logger.Printf("Error al crear el postulante: %v", err)
+		return nil, err
+	}
+
+	s.logger.Printf("Postulante creado con ID=%d", postulante.ID)
+	return postulante, nil
+}
+
+func (s *service) GetAll(ctx context.Context, filters GetAllReq) ([]Postulante, error) {
+	postulantes, err := s.repo.GetAll(ctx, filters)
+	if err != nil {
+		s.logger.Printf("Error al obtener los postulantes: %v", err)
+		return nil, err
+	}
+	return postulantes, nil
+}
+
+// GetByID obtiene el postulante con las solicitudes a las que postuló
+func (s *service) GetByID(ctx context.Context, id uint) (*PostulanteResponse, error) {
+	postulante, err := s.obtener(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	postulaciones, err := s.repo.GetPostulacionesPorPostulante(ctx, id)
+	if err != nil {
+		s.logger.Printf("Error al obtener las postulaciones del postulante ID=%d: %v", id, err)
+		return nil, err
+	}
+	return &PostulanteResponse{Postulante: *postulante, Postulaciones: postulaciones}, nil
+}
+
+func (s *service) Update(ctx context.Context, id uint, req UpdateReq) (*Postulante, error) {
+	if _, err := s.obtener(ctx, id); err != nil {
+		return nil, err
+	}
+	if req.Nombre != nil {
+		nombre := strings.TrimSpace(*req.Nombre)
+		req.Nombre = &nombre
+	}
+	if req.Email != nil {
+		email := normalizarEmail(*req.Email)
+		if err := s.verificarEmail(ctx, email, id); err != nil {
+			return nil, err
+		}
+		req.Email = &email
+	}
+
+	if err := s.repo.Update(ctx, id, req); err != nil {
+		s.logger.Printf("Error al actualizar el postulante ID=%d: %v", id, err)
+		return nil, err
+	}
+
+	s.logger.Printf("Postulante ID=%d actualizado", id)
+	return s.obtener(ctx, id)
+}
+
+// Delete hace un soft delete del postulante; sus postulaciones se mantienen en el pipeline
+func (s *service) Delete(ctx context.Context, id uint) error {
+	if _, err := s.obtener(ctx, id); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		s.logger.Printf("Error al eliminar el postulante ID=%d: %v", id, err)
+		return err
+	}
+
+	s.logger.Printf("Postulante ID=%d eliminado", id)
+	return nil
+}
+
+// Postular inscribe al postulante en la primera etapa del pipeline de una solicitud publicada o en proceso
+func (s *service) Postular(ctx context.Context, solicitudID uint, req PostularReq) (*Postulacion, error) {
+	sol, err := s.obtenerSolicitud(ctx, solicitudID)
+	if err != nil {
+		return nil, err
+	}
+	if !contiene(estadosAbiertos, sol.Estado) {
+		return nil, fmt.Errorf("%w: está en estado %s", ErrSolicitudNoAbierta, sol.Estado)
+	}
+
+	postulante, err := s.obtener(ctx, req.PostulanteID)
+	if err != nil {
+		return nil, err
+	}
+	postulaciones, err := s.repo.GetPostulacionesPorPostulante(ctx, postulante.ID)
+	if err != nil {
+		s.logger.Printf("Error al obtener las postulaciones del postulante ID=%d: %v", postulante.ID, err)
+		return nil, err
+	}
+	for _, p := range postulaciones {
+		if p.SolicitudID == solicitudID {
+			return nil, ErrYaPostulado
+		}
+	}
+
+	postulacion := &Postulacion{
+		PostulanteID: postulante.ID,
+		SolicitudID:  solicitudID,
+		Etapa:        s.pipeline.Inicial(),
+	}
+	cambio := &CambioEtapa{Hacia: postulacion.Etapa, UsuarioID: req.UsuarioID}
+	if err := s.repo.Postular(ctx, postulacion, cambio); err != nil {
+		s.logger.Printf("Error al postular al postulante ID=%d a la solicitud ID=%d: %v", postulante.ID, solicitudID, err)
+		return nil, err
+	}
+
+	s.logger.Printf("Postulante ID=%d postuló a la solicitud ID=%d (postulación ID=%d)", postulante.ID, solicitudID, postulacion.ID)
+	postulacion.Postulante = postulante
+	return postulacion, nil
+}
+
+// GetPostulaciones obtiene las postulaciones de una solicitud, opcionalmente solo las de una etapa
+func (s *service) GetPostulaciones(ctx context.Context, solicitudID uint, etapa string) ([]Postulacion, error) {
+	etapa = normalizarEtapa(etapa)
+	if etapa != "" && !s.pipeline.Contiene(etapa) {
+		return nil, ErrEtapaInvalida
+	}
+	if _, err := s.obtenerSolicitud(ctx, solicitudID); err != nil {
+		return nil, err
+	}
+
+	postulaciones, err := s.repo.GetPostulaciones(ctx, solicitudID, etapa)
+	if err != nil {
+		s.logger.Printf("Error al obtener las postulaciones de la solicitud ID=%d: %v", solicitudID, err)
+		return nil, err
+	}
+	return postulaciones, nil
+}
+
+// GetPipeline agrupa las postulaciones de la solicitud por etapa, incluidas las etapas sin postulaciones.
+// Las postulaciones en etapas que ya no están configuradas se agrupan al final.
+func (s *service) GetPipeline(ctx context.Context, solicitudID uint) (*PipelineResponse, error) {
+	sol, err := s.obtenerSolicitud(ctx, solicitudID)
+	if err != nil {
+		return nil, err
+	}
+
+	postulaciones, err := s.repo.GetPostulaciones(ctx, solicitudID, "")
+	if err != nil {
+		s.logger.Printf("Error al obtener las postulaciones de la solicitud ID=%d: %v", solicitudID, err)
+		return nil, err
+	}
+
+	response := &PipelineResponse{SolicitudID: solicitudID, NumeroVacantes: sol.NumeroVacantes}
+	indices := make(map[string]int)
+	for _, etapa := range s.pipeline.Etapas {
+		indices[etapa] = len(response.Etapas)
+		response.Etapas = append(response.Etapas, EtapaPipeline{Etapa: etapa, Final: s.pipeline.EsFinal(etapa), Postulaciones: []Postulacion{}})
+	}
+	for _, postulacion := range postulaciones {
+		i, ok := indices[postulacion.Etapa]
+		if !ok {
+			i = len(response.Etapas)
+			indices[postulacion.Etapa] = i
+			response.Etapas = append(response.Etapas, EtapaPipeline{Etapa: postulacion.Etapa, Postulaciones: []Postulacion{}})
+		}
+		response.Etapas[i].Postulaciones = append(response.Etapas[i].Postulaciones, postulacion)
+		response.Etapas[i].Total++
+	}
+	return response, nil
+}
+
+// CambiarEtapa mueve la postulación a otra etapa del pipeline. No se contrata a más postulantes que vacantes.
+func (s *service) CambiarEtapa(ctx context.Context, solicitudID, id uint, req CambioEtapaReq) (*Postulacion, error) {
+	postulacion, err := s.obtenerPostulacion(ctx, solicitudID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	hacia := normalizarEtapa(req.Etapa)
+	if err := s.pipeline.validarCambio(postulacion.Etapa, hacia, req.Motivo); err != nil {
+		return nil, fmt.Errorf("%w: de %s a %s", err, postulacion.Etapa, hacia)
+	}
+	// Las vacantes se verifican al cambiar la etapa, con las postulaciones de la solicitud bloqueadas
+	vacantes := 0
+	if hacia == EtapaContratado {
+		if vacantes, err = s.vacantes(ctx, solicitudID); err != nil {
+			return nil, err
+		}
+	}
+
+	cambio := &CambioEtapa{
+		PostulacionID: postulacion.ID,
+		Desde:         postulacion.Etapa,
+		Hacia:         hacia,
+		Motivo:        strings.TrimSpace(req.Motivo),
+		UsuarioID:     req.UsuarioID,
+	}
+	cambiada, err := s.repo.CambiarEtapa(ctx, solicitudID, cambio, vacantes)
+	if errors.Is(err, ErrSinVacantes) {
+		return nil, err
+	}
+	if err != nil {
+		s.logger.Printf("Error al cambiar la etapa de la postulación ID=%d: %v", id, err)
+		return nil, err
+	}
+	if !cambiada {
+		return nil, fmt.Errorf("%w: la postulación ya no está en %s", ErrCambioEtapaNoPermitido, postulacion.Etapa)
+	}
+
+	s.logger.Printf("Postulación ID=%d movida de %s a %s", id, cambio.Desde, cambio.Hacia)
+	postulacion.Etapa = hacia
+	return postulacion, nil
+}
+
+// GetCambiosEtapa obtiene el recorrido de la postulación por el pipeline
+func (s *service) GetCambiosEtapa(ctx context.Context, solicitudID, id uint) ([]CambioEtapa, error) {
+	if _, err := s.obtenerPostulacion(ctx, solicitudID, id); err != nil {
+		return nil, err
+	}
+
+	cambios, err := s.repo.GetCambiosEtapa(ctx, id)
+	if err != nil {
+		s.logger.Printf("Error al obtener las etapas de la postulación ID=%d: %v", id, err)
+		return nil, err
+	}
+	return cambios, nil
+}
+
+// SubirCV guarda el CV en el servicio de documentos como documento de la solicitud y lo asocia a la postulación
+func (s *service) SubirCV(ctx context.Context, solicitudID, id uint, nombreArchivo string, contenido io.Reader) (*Postulacion, error) {
+	postulacion, err := s.obtenerPostulacion(ctx, solicitudID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	nombre := fmt.Sprintf("cv-postulante-%d%s", postulacion.PostulanteID, strings.ToLower(filepath.Ext(nombreArchivo)))
+	documento, err := s.documentoClient.SubirDocumentoCategoria(solicitudID, nombre, CategoriaCV, contenido)
+	if err != nil {
+		s.logger.Printf("Error al guardar el CV de la postulación ID=%d: %v", id, err)
+		return nil, fmt.Errorf("%w: %v", ErrCVNoGuardado, err)
+	}
+	if err := s.repo.ActualizarCV(ctx, id, documento.ID); err != nil {
+		s.logger.Printf("Error al asociar el documento ID=%d a la postulación ID=%d: %v", documento.ID, id, err)
+		return nil, err
+	}
+
+	s.logger.Printf("CV de la postulación ID=%d guardado como documento ID=%d", id, documento.ID)
+	postulacion.CVDocumentoID = &documento.ID
+	return postulacion, nil
+}
+
+func (s *service) obtener(ctx context.Context, id uint) (*Postulante, error) {
+	postulante, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Printf("Error al obtener el postulante ID=%d: %v", id, err)
+		return nil, fmt.Errorf("%w: %v", ErrNoEncontrado, err)
+	}
+	return postulante, nil
+}
+
+func (s *service) obtenerPostulacion(ctx context.Context, solicitudID, id uint) (*Postulacion, error) {
+	postulacion, err := s.repo.GetPostulacion(ctx, solicitudID, id)
+	if err != nil {
+		s.logger.Printf("Error al obtener la postulación ID=%d de la solicitud ID=%d: %v", id, solicitudID, err)
+		return nil, fmt.Errorf("%w: %v", ErrPostulacionNoEncontrada, err)
+	}
+	return postulacion, nil
+}
+
+func (s *service) obtenerSolicitud(ctx context.Context, solicitudID uint) (*solicitud.SolicitudResponse, error) {
+	sol, err := s.solicitudes.GetByID(ctx, solicitudID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSolicitudNoEncontrada, err)
+	}
+	return sol, nil
+}
+
+// verificarEmail asegura que ningún otro postulante vigente use el email
+func (s *service) verificarEmail(ctx context.Context, email string, id uint) error {
+	existente, err := s.repo.GetByEmail(ctx, email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		s.logger.Printf("Error al buscar el postulante con email %s: %v", email, err)
+		return err
+	}
+	if existente.ID != id {
+		return ErrEmailDuplicado
+	}
+	return nil
+}
+
+// vacantes obtiene el número de vacantes de la solicitud, 0 si no tiene límite
+func (s *service) vacantes(ctx context.Context, solicitudID uint) (int, error) {
+	sol, err := s.obtenerSolicitud(ctx, solicitudID)
+	if err != nil {
+		return 0, err
+	}
+	if sol.NumeroVacantes <= 0 {
+		return 0, nil
+	}
+	return sol.NumeroVacantes, nil
+}
+
+func normalizarEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,386 @@
+package postulante
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/kramirez/solicitudes/internal/solicitud"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func uintPtr(v uint) *uint {
+	return &v
+}
+
+func TestPipeline_validarCambio(t *testing.T) {
+	pipeline := PipelinePorDefecto()
+
+	tests := []struct {
+		name     string
+		desde    string
+		hacia    string
+		motivo   string
+		esperado error
+	}{
+		{"avanza a la siguiente etapa", EtapaRecibido, EtapaEntrevista, "", nil},
+		{"puede saltar etapas", EtapaRecibido, EtapaOferta, "", nil},
+		{"puede volver a una etapa anterior", EtapaOferta, EtapaEntrevista, "", nil},
+		{"descarta con motivo", EtapaEntrevista, EtapaDescartado, "No cumple el perfil", nil},
+		{"descartar exige motivo", EtapaEntrevista, EtapaDescartado, "  ", ErrMotivoRequerido},
+		{"etapa fuera del pipeline", EtapaRecibido, "prueba_tecnica", "", ErrEtapaInvalida},
+		{"misma etapa", EtapaEntrevista, EtapaEntrevista, "", ErrCambioEtapaNoPermitido},
+		{"contratado es final", EtapaContratado, EtapaOferta, "", ErrCambioEtapaNoPermitido},
+		{"descartado es final", EtapaDescartado, EtapaRecibido, "", ErrCambioEtapaNoPermitido},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, pipeline.validarCambio(tt.desde, tt.hacia, tt.motivo), tt.esperado)
+		})
+	}
+}
+
+func TestService_Create(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(os.Stdout, "TEST: ", log.LstdFlags)
+
+	t.Run("debe crear el postulante con el email normalizado", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByEmail", ctx, "ana.perez@correo.cl").Return(nil, gorm.ErrRecordNotFound)
+		repo.On("Create", ctx, mock.MatchedBy(func(p *Postulante) bool {
+			return p.Nombre == "Ana Pérez" && p.Email == "ana.perez@correo.cl"
+		})).Return(nil)
+		service := NewService(repo, logger, new(mockSolicitudes), new(mockDocumentoClient), Pipeline{})
+
+		// Act
+		result, err := service.Create(ctx, CreateReq{Nombre: " Ana Pérez ", Email: "Ana.Perez@Correo.cl "})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, "ana.perez@correo.cl", result.Email)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe rechazar un email ya registrado", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByEmail", ctx, "ana@correo.cl").Return(&Postulante{ID: 4, Email: "ana@correo.cl"}, nil)
+		service := NewService(repo, logger, new(mockSolicitudes), new(mockDocumentoClient), Pipeline{})
+
+		// Act
+		_, err := service.Create(ctx, CreateReq{Nombre: "Ana", Email: "ana@correo.cl"})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrEmailDuplicado)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe rechazar el email que otra petición registró después de verificarlo", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByEmail", ctx, "ana@correo.cl").Return(nil, gorm.ErrRecordNotFound)
+		repo.On("Create", ctx, mock.Anything).Return(ErrEmailDuplicado)
+		service := NewService(repo, logger, new(mockSolicitudes), new(mockDocumentoClient), Pipeline{})
+
+		// Act
+		_, err := service.Create(ctx, CreateReq{Nombre: "Ana", Email: "ana@correo.cl"})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrEmailDuplicado)
+	})
+}
+
+func TestService_Update(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(os.Stdout, "TEST: ", log.LstdFlags)
+
+	t.Run("debe permitir mantener su propio email", func(t *testing.T) {
+		// Arrange
+		email := "ana@correo.cl"
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(4)).Return(&Postulante{ID: 4, Email: email}, nil)
+		repo.On("GetByEmail", ctx, email).Return(&Postulante{ID: 4, Email: email}, nil)
+		repo.On("Update", ctx, uint(4), UpdateReq{Email: &email}).Return(nil)
+		service := NewService(repo, logger, new(mockSolicitudes), new(mockDocumentoClient), Pipeline{})
+
+		// Act
+		_, err := service.Update(ctx, 4, UpdateReq{Email: &email})
+
+		// Assert
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe rechazar el email de otro postulante", func(t *testing.T) {
+		// Arrange
+		email := "otro@correo.cl"
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(4)).Return(&Postulante{ID: 4}, nil)
+		repo.On("GetByEmail", ctx, email).Return(&Postulante{ID: 9, Email: email}, nil)
+		service := NewService(repo, logger, new(mockSolicitudes), new(mockDocumentoClient), Pipeline{})
+
+		// Act
+		_, err := service.Update(ctx, 4, UpdateReq{Email: &email})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrEmailDuplicado)
+		repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_Postular(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(os.Stdout, "TEST: ", log.LstdFlags)
+	publicada := &solicitud.SolicitudResponse{ID: 1, Estado: solicitud.EstadoPublicada, NumeroVacantes: 2}
+
+	t.Run("debe crear la postulación en la primera etapa del pipeline", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		solicitudes := new(mockSolicitudes)
+		solicitudes.On("GetByID", ctx, uint(1)).Return(publicada, nil)
+		repo.On("GetByID", ctx, uint(4)).Return(&Postulante{ID: 4, Nombre: "Ana"}, nil)
+		repo.On("GetPostulacionesPorPostulante", ctx, uint(4)).Return([]Postulacion{{ID: 2, PostulanteID: 4, SolicitudID: 7}}, nil)
+		repo.On("Postular", ctx,
+			mock.MatchedBy(func(p *Postulacion) bool {
+				return p.PostulanteID == 4 && p.SolicitudID == 1 && p.Etapa == "postulado"
+			}),
+			mock.MatchedBy(func(c *CambioEtapa) bool {
+				return c.Desde == "" && c.Hacia == "postulado" && *c.UsuarioID == 3
+			})).Return(nil)
+		service := NewService(repo, logger, solicitudes, new(mockDocumentoClient), Pipeline{Etapas: []string{"postulado", "contratado"}})
+
+		// Act
+		result, err := service.Postular(ctx, 1, PostularReq{PostulanteID: 4, UsuarioID: uintPtr(3)})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, "postulado", result.Etapa)
+		assert.Equal(t, "Ana", result.Postulante.Nombre)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe rechazar una segunda postulación a la misma solicitud", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		solicitudes := new(mockSolicitudes)
+		solicitudes.On("GetByID", ctx, uint(1)).Return(publicada, nil)
+		repo.On("GetByID", ctx, uint(4)).Return(&Postulante{ID: 4}, nil)
+		repo.On("GetPostulacionesPorPostulante", ctx, uint(4)).Return([]Postulacion{{ID: 2, PostulanteID: 4, SolicitudID: 1}}, nil)
+		service := NewService(repo, logger, solicitudes, new(mockDocumentoClient), Pipeline{})
+
+		// Act
+		_, err := service.Postular(ctx, 1, PostularReq{PostulanteID: 4})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrYaPostulado)
+		repo.AssertNotCalled(t, "Postular", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe rechazar una solicitud que no está publicada", func(t *testing.T) {
+		// Arrange
+		solicitudes := new(mockSolicitudes)
+		solicitudes.On("GetByID", ctx, uint(1)).Return(&solicitud.SolicitudResponse{ID: 1, Estado: solicitud.EstadoBorrador}, nil)
+		service := NewService(new(mockRepository), logger, solicitudes, new(mockDocumentoClient), Pipeline{})
+
+		// Act
+		_, err := service.Postular(ctx, 1, PostularReq{PostulanteID: 4})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrSolicitudNoAbierta)
+	})
+
+	t.Run("debe retornar error si el postulante no existe", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		solicitudes := new(mockSolicitudes)
+		solicitudes.On("GetByID", ctx, uint(1)).Return(publicada, nil)
+		repo.On("GetByID", ctx, uint(40)).Return(nil, gorm.ErrRecordNotFound)
+		service := NewService(repo, logger, solicitudes, new(mockDocumentoClient), Pipeline{})
+
+		// Act
+		_, err := service.Postular(ctx, 1, PostularReq{PostulanteID: 40})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrNoEncontrado)
+	})
+}
+
+func TestService_GetPipeline(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(os.Stdout, "TEST: ", log.LstdFlags)
+
+	// Arrange
+	repo := new(mockRepository)
+	solicitudes := new(mockSolicitudes)
+	solicitudes.On("GetByID", ctx, uint(1)).Return(&solicitud.SolicitudResponse{ID: 1, NumeroVacantes: 2}, nil)
+	repo.On("GetPostulaciones", ctx, uint(1), "").Return([]Postulacion{
+		{ID: 1, Etapa: EtapaRecibido},
+		{ID: 2, Etapa: EtapaEntrevista},
+		{ID: 3, Etapa: EtapaRecibido},
+		{ID: 4, Etapa: "prueba_tecnica"},
+	}, nil)
+	service := NewService(repo, logger, solicitudes, new(mockDocumentoClient), Pipeline{})
+
+	// Act
+	result, err := service.GetPipeline(ctx, 1)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.NumeroVacantes)
+	assert.Len(t, result.Etapas, 6, "Las cinco etapas del pipeline y la etapa que ya no está configurada")
+	assert.Equal(t, EtapaRecibido, result.Etapas[0].Etapa)
+	assert.Equal(t, 2, result.Etapas[0].Total)
+	assert.Equal(t, 1, result.Etapas[1].Total)
+	assert.Equal(t, 0, result.Etapas[2].Total)
+	assert.NotNil(t, result.Etapas[2].Postulaciones)
+	assert.True(t, result.Etapas[3].Final)
+	assert.Equal(t, "prueba_tecnica", result.Etapas[5].Etapa)
+}
+
+func TestService_CambiarEtapa(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(os.Stdout, "TEST: ", log.LstdFlags)
+
+	t.Run("debe mover la postulación y registrar el cambio", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetPostulacion", ctx, uint(1), uint(5)).Return(&Postulacion{ID: 5, SolicitudID: 1, Etapa: EtapaRecibido}, nil)
+		repo.On("CambiarEtapa", ctx, uint(1), mock.MatchedBy(func(c *CambioEtapa) bool {
+			return c.PostulacionID == 5 && c.Desde == EtapaRecibido && c.Hacia == EtapaEntrevista && *c.UsuarioID == 3
+		}), 0).Return(true, nil)
+		service := NewService(repo, logger, new(mockSolicitudes), new(mockDocumentoClient), Pipeline{})
+
+		// Act
+		result, err := service.CambiarEtapa(ctx, 1, 5, CambioEtapaReq{Etapa: " Entrevista", UsuarioID: uintPtr(3)})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, EtapaEntrevista, result.Etapa)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("no debe contratar más postulantes que vacantes", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		solicitudes := new(mockSolicitudes)
+		repo.On("GetPostulacion", ctx, uint(1), uint(5)).Return(&Postulacion{ID: 5, SolicitudID: 1, Etapa: EtapaOferta}, nil)
+		solicitudes.On("GetByID", ctx, uint(1)).Return(&solicitud.SolicitudResponse{ID: 1, NumeroVacantes: 2}, nil)
+		repo.On("CambiarEtapa", ctx, uint(1), mock.AnythingOfType("*postulante.CambioEtapa"), 2).
+			Return(false, fmt.Errorf("%w: 2 de 2 contratados", ErrSinVacantes))
+		service := NewService(repo, logger, solicitudes, new(mockDocumentoClient), Pipeline{})
+
+		// Act
+		_, err := service.CambiarEtapa(ctx, 1, 5, CambioEtapaReq{Etapa: EtapaContratado})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrSinVacantes)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe contratar mientras queden vacantes", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		solicitudes := new(mockSolicitudes)
+		repo.On("GetPostulacion", ctx, uint(1), uint(5)).Return(&Postulacion{ID: 5, SolicitudID: 1, Etapa: EtapaOferta}, nil)
+		solicitudes.On("GetByID", ctx, uint(1)).Return(&solicitud.SolicitudResponse{ID: 1, NumeroVacantes: 2}, nil)
+		repo.On("CambiarEtapa", ctx, uint(1), mock.AnythingOfType("*postulante.CambioEtapa"), 2).Return(true, nil)
+		service := NewService(repo, logger, solicitudes, new(mockDocumentoClient), Pipeline{})
+
+		// Act
+		result, err := service.CambiarEtapa(ctx, 1, 5, CambioEtapaReq{Etapa: EtapaContratado})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, EtapaContratado, result.Etapa)
+	})
+
+	t.Run("debe retornar conflicto si otra petición movió la postulación", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetPostulacion", ctx, uint(1), uint(5)).Return(&Postulacion{ID: 5, SolicitudID: 1, Etapa: EtapaRecibido}, nil)
+		repo.On("CambiarEtapa", ctx, uint(1), mock.Anything, 0).Return(false, nil)
+		service := NewService(repo, logger, new(mockSolicitudes), new(mockDocumentoClient), Pipeline{})
+
+		// Act
+		_, err := service.CambiarEtapa(ctx, 1, 5, CambioEtapaReq{Etapa: EtapaEntrevista})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrCambioEtapaNoPermitido)
+	})
+
+	t.Run("debe retornar error si la postulación no es de la solicitud", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetPostulacion", ctx, uint(2), uint(5)).Return(nil, gorm.ErrRecordNotFound)
+		service := NewService(repo, logger, new(mockSolicitudes), new(mockDocumentoClient), Pipeline{})
+
+		// Act
+		_, err := service.CambiarEtapa(ctx, 2, 5, CambioEtapaReq{Etapa: EtapaEntrevista})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrPostulacionNoEncontrada)
+	})
+}
+
+func TestService_SubirCV(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(os.Stdout, "TEST: ", log.LstdFlags)
+	contenido := []byte("%PDF-1.4")
+
+	t.Run("debe guardar el CV en documentos y asociarlo a la postulación", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		docClient := new(mockDocumentoClient)
+		repo.On("GetPostulacion", ctx, uint(1), uint(5)).Return(&Postulacion{ID: 5, SolicitudID: 1, PostulanteID: 4}, nil)
+		docClient.On("SubirDocumentoCategoria", uint(1), "cv-postulante-4.pdf", CategoriaCV, contenido).
+			Return(&solicitud.Documento{ID: 30}, nil)
+		repo.On("ActualizarCV", ctx, uint(5), uint(30)).Return(nil)
+		service := NewService(repo, logger, new(mockSolicitudes), docClient, Pipeline{})
+
+		// Act
+		result, err := service.SubirCV(ctx, 1, 5, "CV Ana Pérez.PDF", bytes.NewReader(contenido))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, uint(30), *result.CVDocumentoID)
+		repo.AssertExpectations(t)
+		docClient.AssertExpectations(t)
+	})
+
+	t.Run("debe retornar error si documentos rechaza el CV", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		docClient := new(mockDocumentoClient)
+		repo.On("GetPostulacion", ctx, uint(1), uint(5)).Return(&Postulacion{ID: 5, SolicitudID: 1, PostulanteID: 4}, nil)
+		docClient.On("SubirDocumentoCategoria", uint(1), "cv-postulante-4.exe", CategoriaCV, contenido).
+			Return(nil, errors.New("error al subir el documento: status 415"))
+		service := NewService(repo, logger, new(mockSolicitudes), docClient, Pipeline{})
+
+		// Act
+		_, err := service.SubirCV(ctx, 1, 5, "cv.exe", bytes.NewReader(contenido))
+
+		// Assert
+		assert.ErrorIs(t, err, ErrCVNoGuardado)
+		repo.AssertNotCalled(t, "ActualizarCV", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_GetPostulaciones_EtapaInvalida(t *testing.T) {
+	// Arrange
+	logger := log.New(os.Stdout, "TEST: ", log.LstdFlags)
+	service := NewService(new(mockRepository), logger, new(mockSolicitudes), new(mockDocumentoClient), Pipeline{})
+
+	// Act
+	_, err := service.GetPostulaciones(context.Background(), 1, "inexistente")
+
+	// Assert
+	assert.ErrorIs(t, err, ErrEtapaInvalida)
+}
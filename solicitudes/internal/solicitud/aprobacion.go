@@ -0,0 +1,240 @@
+package solicitud
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TodasLasAreas es el área de las reglas de aprobación que aplican a cualquier área
+const TodasLasAreas = "*"
+
+// Estados de la decisión de cada nivel de una cadena de aprobación
+const (
+	AprobacionPendiente = "pendiente"
+	AprobacionAprobada  = "aprobada"
+	AprobacionRechazada = "rechazada"
+)
+
+// Estados de la aprobación de una solicitud sin ronda de aprobación en curso
+const (
+	AprobacionNoRequerida = "no_requerida"
+	AprobacionSinEnviar   = "sin_enviar"
+)
+
+// ErrAprobacionNoPermitida indica que la solicitud o el nivel no admiten la decisión en este momento
+var ErrAprobacionNoPermitida = errors.New("aprobación no permitida")
+
+// ErrEdicionNoPermitida indica que el estado de la solicitud no admite el cambio de esos campos
+var ErrEdicionNoPermitida = errors.New("edición no permitida")
+
+// ErrDecisionInvalida indica que faltan datos de la decisión
+var ErrDecisionInvalida = errors.New("decisión de aprobación inválida")
+
+// ErrAprobadorNoAutorizado indica que el usuario no puede decidir el nivel de aprobación
+var ErrAprobadorNoAutorizado = errors.New("aprobador no autorizado")
+
+// ReglaAprobacion define los niveles que deben aprobar, en orden, las solicitudes de un área
+// cuya renta máxima supera un umbral
+type ReglaAprobacion struct {
+	// Area de la solicitud, o TodasLasAreas
+	Area string
+	// RentaSobre es el umbral: la regla aplica si la renta hasta de la solicitud es mayor
+	RentaSobre int
+	Niveles    []string
+}
+
+// CadenasAprobacion son las reglas de aprobación en orden de prioridad; a cada solicitud
+// se le aplica la primera regla que corresponde
+type CadenasAprobacion []ReglaAprobacion
+
+// Cadena retorna los niveles que deben aprobar la solicitud, vacío si no requiere aprobación
+func (c CadenasAprobacion) Cadena(s *Solicitud) []string {
+	area := strings.ToLower(strings.TrimSpace(s.Area))
+	for _, regla := range c {
+		if regla.Area != TodasLasAreas && regla.Area != area {
+			continue
+		}
+		if s.RentaHasta > regla.RentaSobre {
+			return regla.Niveles
+		}
+	}
+	return []string{}
+}
+
+// AprobadoresPorNivel son los usuarios autorizados a decidir cada nivel de aprobación.
+// Un nivel sin usuarios configurados lo puede decidir cualquier usuario.
+type AprobadoresPorNivel map[string][]uint
+
+// Autorizado indica si el usuario puede decidir el nivel
+func (a AprobadoresPorNivel) Autorizado(nivel string, usuarioID uint) bool {
+	aprobadores, ok := a[nivel]
+	if !ok {
+		return true
+	}
+	for _, aprobador := range aprobadores {
+		if aprobador == usuarioID {
+			return true
+		}
+	}
+	return false
+}
+
+// Aprobacion es la decisión de un nivel de la cadena en una ronda de aprobación de la solicitud.
+// Cada envío de la solicitud a pendiente inicia una ronda nueva.
+type Aprobacion struct {
+	ID          uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	SolicitudID uint       `gorm:"not null;index" json:"solicitud_id"`
+	Ronda       int        `gorm:"not null" json:"ronda"`
+	Orden       int        `gorm:"not null" json:"orden"`
+	Nivel       string     `gorm:"type:varchar(50);not null" json:"nivel"`
+	Estado      string     `gorm:"type:varchar(20);not null" json:"estado"`
+	UsuarioID   *uint      `json:"usuario_id,omitempty"`
+	Comentario  string     `gorm:"type:text" json:"comentario,omitempty"`
+	DecididaEl  *time.Time `json:"decidida_el,omitempty"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName especifica el nombre de la tabla
+func (Aprobacion) TableName() string {
+	return "solicitud_aprobaciones"
+}
+
+// DecisionReq representa la aprobación o el rechazo de un nivel
+type DecisionReq struct {
+	UsuarioID *uint `json:"usuario_id" binding:"required"`
+	// Comentario de la decisión, obligatorio al rechazar
+	Comentario string `json:"comentario"`
+}
+
+// EstadoAprobacion reporta la ronda de aprobación actual de una solicitud
+type EstadoAprobacion struct {
+	SolicitudID     uint   `json:"solicitud_id"`
+	EstadoSolicitud string `json:"estado_solicitud"`
+	Requerida       bool   `json:"requerida"`
+	Ronda           int    `json:"ronda"`
+	// Estado es pendiente, aprobada o rechazada; sin_enviar o no_requerida si no hay ronda
+	Estado      string       `json:"estado"`
+	NivelActual string       `json:"nivel_actual,omitempty"`
+	Niveles     []Aprobacion `json:"niveles"`
+}
+
+// resumirRonda calcula el estado de una ronda y el nivel al que le corresponde decidir
+func resumirRonda(ronda []Aprobacion) (string, *Aprobacion) {
+	for i := range ronda {
+		if ronda[i].Estado == AprobacionRechazada {
+			return AprobacionRechazada, nil
+		}
+	}
+	for i := range ronda {
+		if ronda[i].Estado == AprobacionPendiente {
+			return AprobacionPendiente, &ronda[i]
+		}
+	}
+	return AprobacionAprobada, nil
+}
+
+// armarEstadoAprobacion resume la ronda actual; sin ronda, muestra los niveles que deberán aprobar
+func armarEstadoAprobacion(solicitud *Solicitud, cadena []string, ronda []Aprobacion) *EstadoAprobacion {
+	estado := &EstadoAprobacion{
+		SolicitudID:     solicitud.ID,
+		EstadoSolicitud: solicitud.Estado,
+		Requerida:       len(cadena) > 0,
+		Niveles:         ronda,
+	}
+	if len(ronda) == 0 {
+		estado.Estado = AprobacionNoRequerida
+		estado.Niveles = []Aprobacion{}
+		if len(cadena) > 0 {
+			estado.Estado = AprobacionSinEnviar
+			for i, nivel := range cadena {
+				estado.Niveles = append(estado.Niveles, Aprobacion{SolicitudID: solicitud.ID, Orden: i + 1, Nivel: nivel, Estado: AprobacionPendiente})
+			}
+		}
+		return estado
+	}
+
+	var actual *Aprobacion
+	estado.Ronda = ronda[0].Ronda
+	estado.Estado, actual = resumirRonda(ronda)
+	if actual != nil {
+		estado.NivelActual = actual.Nivel
+	}
+	return estado
+}
+
+// nuevaRonda arma los niveles pendientes de la ronda que sigue a la anterior
+func nuevaRonda(solicitudID uint, cadena []string, anterior []Aprobacion) []Aprobacion {
+	numero := 1
+	if len(anterior) > 0 {
+		numero = anterior[0].Ronda + 1
+	}
+
+	ronda := make([]Aprobacion, len(cadena))
+	for i, nivel := range cadena {
+		ronda[i] = Aprobacion{
+			SolicitudID: solicitudID,
+			Ronda:       numero,
+			Orden:       i + 1,
+			Nivel:       nivel,
+			Estado:      AprobacionPendiente,
+		}
+	}
+	return ronda
+}
+
+// nivelesPendientes lista los niveles de la ronda que aún no aprueban
+func nivelesPendientes(ronda []Aprobacion) []string {
+	var niveles []string
+	for _, aprobacion := range ronda {
+		if aprobacion.Estado != AprobacionAprobada {
+			niveles = append(niveles, aprobacion.Nivel)
+		}
+	}
+	return niveles
+}
+
+// mismosNiveles indica si la ronda corresponde a la cadena vigente para la solicitud
+func mismosNiveles(ronda []Aprobacion, cadena []string) bool {
+	if len(ronda) != len(cadena) {
+		return false
+	}
+	for i, nivel := range cadena {
+		if ronda[i].Nivel != nivel {
+			return false
+		}
+	}
+	return true
+}
+
+// validarEdicion impide cambiar fuera de borrador el área y la renta hasta, que determinan la
+// cadena de aprobación, para que la solicitud no avance con una cadena distinta a la que la aprobó
+func validarEdicion(s *Solicitud, req UpdateReq) error {
+	if normalizarEstado(s.Estado) == EstadoBorrador {
+		return nil
+	}
+
+	var campos []string
+	if req.Area != nil && !strings.EqualFold(strings.TrimSpace(*req.Area), strings.TrimSpace(s.Area)) {
+		campos = append(campos, "area")
+	}
+	if req.RentaHasta != nil && *req.RentaHasta != s.RentaHasta {
+		campos = append(campos, "renta_hasta")
+	}
+	if len(campos) > 0 {
+		return fmt.Errorf("%w: %s solo se puede modificar en borrador y la solicitud está %s", ErrEdicionNoPermitida, strings.Join(campos, " y "), s.Estado)
+	}
+	return nil
+}
+
+// validarDecision verifica que la decisión indique quién decide y que el rechazo indique un comentario
+func validarDecision(decision string, req DecisionReq) error {
+	if req.UsuarioID == nil {
+		return fmt.Errorf("%w: indique el usuario que decide", ErrDecisionInvalida)
+	}
+	if decision == AprobacionRechazada && strings.TrimSpace(req.Comentario) == "" {
+		return fmt.Errorf("%w: indique el motivo del rechazo en el comentario", ErrDecisionInvalida)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package solicitud
+
+import (
+	"errors"
+	"strings"
+)
+
+// TodosLosTipos es la clave de las reglas que aplican a cualquier tipo de servicio
+const TodosLosTipos = "*"
+
+// ErrDocumentosNoDisponibles indica que no se pudieron consultar los documentos de la solicitud
+var ErrDocumentosNoDisponibles = errors.New("no se pudieron obtener los documentos de la solicitud")
+
+// ReglasDocumentos indica, por tipo de servicio, las categorías de documento obligatorias.
+// Las categorías bajo TodosLosTipos se exigen a todas las solicitudes.
+type ReglasDocumentos map[string][]string
+
+// Obligatorias retorna las categorías que debe tener una solicitud del tipo de servicio indicado
+func (r ReglasDocumentos) Obligatorias(tipoServicio string) []string {
+	categorias := []string{}
+	vistas := make(map[string]bool)
+	for _, clave := range []string{TodosLosTipos, strings.ToLower(strings.TrimSpace(tipoServicio))} {
+		for _, categoria := range r[clave] {
+			if !vistas[categoria] {
+				vistas[categoria] = true
+				categorias = append(categorias, categoria)
+			}
+		}
+	}
+	return categorias
+}
+
+// ItemChecklist indica si la solicitud tiene documentos de una categoría obligatoria
+type ItemChecklist struct {
+	Categoria  string `json:"categoria"`
+	Presente   bool   `json:"presente"`
+	Documentos []uint `json:"documentos"`
+}
+
+// ChecklistDocumentos reporta qué documentos obligatorios tiene y cuáles le faltan a una solicitud
+type ChecklistDocumentos struct {
+	SolicitudID  uint            `json:"solicitud_id"`
+	TipoServicio string          `json:"tipo_servicio"`
+	Completo     bool            `json:"completo"`
+	Items        []ItemChecklist `json:"items"`
+	Faltantes    []string        `json:"faltantes"`
+}
+
+// armarChecklist compara los documentos de la solicitud con las categorías obligatorias
+func armarChecklist(solicitud *Solicitud, obligatorias []string, documentos []Documento) *ChecklistDocumentos {
+	porCategoria := make(map[string][]uint)
+	for _, doc := range documentos {
+		if doc.Categoria != "" {
+			porCategoria[doc.Categoria] = append(porCategoria[doc.Categoria], doc.ID)
+		}
+	}
+
+	checklist := &ChecklistDocumentos{
+		SolicitudID:  solicitud.ID,
+		TipoServicio: solicitud.TipoServicio,
+		Items:        make([]ItemChecklist, len(obligatorias)),
+		Faltantes:    []string{},
+	}
+	for i, categoria := range obligatorias {
+		ids := porCategoria[categoria]
+		if ids == nil {
+			ids = []uint{}
+			checklist.Faltantes = append(checklist.Faltantes, categoria)
+		}
+		checklist.Items[i] = ItemChecklist{
+			Categoria:  categoria,
+			Presente:   len(ids) > 0,
+			Documentos: ids,
+		}
+	}
+	checklist.Completo = len(checklist.Faltantes) == 0
+	return checklist
+}
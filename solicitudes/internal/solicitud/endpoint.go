@@ -1,7 +1,10 @@
 package solicitud
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"mime"
 	"net/http"
 	"strconv"
 
@@ -24,7 +27,15 @@ func (e *Endpoint) Create(c *gin.Context) {
 		return
 	}
 
-	solicitud, err := e.service.Create(c.Request.Context(), req)
+	solicitud, err := e.service.Create(contexto(c), req)
+	if errors.Is(err, ErrEstadoInvalido) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, ErrRequisitosTransicion) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -111,6 +122,75 @@ func (e *Endpoint) GetByIDWithDocuments(c *gin.Context) {
 	c.JSON(http.StatusOK, solicitud)
 }
 
+// GetChecklistDocumentos maneja GET /solicitudes/:id/checklist-documentos
+func (e *Endpoint) GetChecklistDocumentos(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	checklist, err := e.service.GetChecklistDocumentos(c.Request.Context(), uint(id))
+	if errors.Is(err, ErrDocumentosNoDisponibles) {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Solicitud no encontrada"})
+		return
+	}
+
+	c.JSON(http.StatusOK, checklist)
+}
+
+// GetFicha maneja GET /solicitudes/:id/ficha.pdf
+func (e *Endpoint) GetFicha(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	pdf, err := e.service.GenerarFicha(c.Request.Context(), uint(id))
+	if errors.Is(err, ErrFichaNoGenerada) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Solicitud no encontrada"})
+		return
+	}
+
+	// inline permite abrir la ficha en el navegador; el nombre se usa al guardarla
+	c.Header("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": NombreFicha(uint(id))}))
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+// GuardarFicha maneja POST /solicitudes/:id/ficha.pdf, que guarda la ficha como documento de la solicitud
+func (e *Endpoint) GuardarFicha(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	documento, err := e.service.GuardarFicha(c.Request.Context(), uint(id))
+	if errors.Is(err, ErrFichaNoGuardada) {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, ErrFichaNoGenerada) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Solicitud no encontrada"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, documento)
+}
+
 // Update maneja PATCH /solicitudes/:id
 func (e *Endpoint) Update(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -142,10 +222,15 @@ func (e *Endpoint) Update(c *gin.Context) {
 		}
 	}
 
+	// El estado solo cambia mediante transiciones, que validan el ciclo de vida
+	if _, exists := rawBody["estado"]; exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Campo 'estado' no puede ser actualizado, use POST /solicitudes/:id/transiciones"})
+		return
+	}
+
 	// Validar que solo se envíen campos válidos
 	validFields := map[string]bool{
 		"titulo":                    true,
-		"estado":                    true,
 		"area":                      true,
 		"pais":                      true,
 		"localizacion":              true,
@@ -175,7 +260,11 @@ func (e *Endpoint) Update(c *gin.Context) {
 		return
 	}
 
-	if err := e.service.Update(c.Request.Context(), uint(id), req); err != nil {
+	if err := e.service.Update(contexto(c), uint(id), req); err != nil {
+		if errors.Is(err, ErrEdicionNoPermitida) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -183,6 +272,135 @@ func (e *Endpoint) Update(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Solicitud actualizada exitosamente"})
 }
 
+// Transicionar maneja POST /solicitudes/:id/transiciones
+func (e *Endpoint) Transicionar(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req TransicionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	solicitud, err := e.service.Transicionar(contexto(c), uint(id), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrSolicitudNoEncontrada):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Solicitud no encontrada"})
+		case errors.Is(err, ErrEstadoInvalido):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrTransicionNoPermitida):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrRequisitosTransicion):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrDocumentosNoDisponibles):
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, solicitud)
+}
+
+// GetAprobaciones maneja GET /solicitudes/:id/aprobaciones
+func (e *Endpoint) GetAprobaciones(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	aprobacion, err := e.service.GetAprobaciones(c.Request.Context(), uint(id))
+	if errors.Is(err, ErrSolicitudNoEncontrada) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Solicitud no encontrada"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, aprobacion)
+}
+
+// Aprobar maneja POST /solicitudes/:id/aprobaciones/:nivel/aprobar
+func (e *Endpoint) Aprobar(c *gin.Context) {
+	e.decidir(c, e.service.Aprobar)
+}
+
+// Rechazar maneja POST /solicitudes/:id/aprobaciones/:nivel/rechazar
+func (e *Endpoint) Rechazar(c *gin.Context) {
+	e.decidir(c, e.service.Rechazar)
+}
+
+func (e *Endpoint) decidir(c *gin.Context, decidir func(ctx context.Context, id uint, nivel string, req DecisionReq) (*EstadoAprobacion, error)) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req DecisionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	aprobacion, err := decidir(contexto(c), uint(id), c.Param("nivel"), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrSolicitudNoEncontrada):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Solicitud no encontrada"})
+		case errors.Is(err, ErrDecisionInvalida):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrAprobadorNoAutorizado):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrAprobacionNoPermitida):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, aprobacion)
+}
+
+// GetHistorial maneja GET /solicitudes/:id/historial
+func (e *Endpoint) GetHistorial(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	filtros := HistorialReq{Accion: c.Query("accion")}
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil {
+		filtros.Limit = limit
+	}
+	if page, err := strconv.Atoi(c.Query("page")); err == nil {
+		filtros.Page = page
+	}
+
+	eventos, err := e.service.GetHistorial(c.Request.Context(), uint(id), filtros)
+	if errors.Is(err, ErrSolicitudNoEncontrada) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Solicitud no encontrada"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, eventos)
+}
+
 // Delete, maneja DELETE /solicitudes/:id
 func (e *Endpoint) Delete(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -191,9 +409,20 @@ func (e *Endpoint) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := e.service.Delete(c.Request.Context(), uint(id)); err != nil {
+	if err := e.service.Delete(contexto(c), uint(id)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Solicitud eliminada exitosamente"})
 }
+
+// contexto agrega al contexto de la petición el usuario de la cabecera X-Usuario-ID y el
+// identificador X-Request-ID, para registrarlos en el historial de la solicitud
+func contexto(c *gin.Context) context.Context {
+	actor := Actor{RequestID: c.GetHeader("X-Request-ID")}
+	if id, err := strconv.ParseUint(c.GetHeader("X-Usuario-ID"), 10, 32); err == nil && id > 0 {
+		uid := uint(id)
+		actor.UsuarioID = &uid
+	}
+	return ConActor(c.Request.Context(), actor)
+}
@@ -2,7 +2,9 @@ package solicitud
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"log"
 	"net/http"
@@ -21,7 +23,7 @@ func TestEndpoint_Create_Success(t *testing.T) {
 	repo := new(mockRepository)
 	docClient := new(mockDocumentoClient)
 	logger := log.New(io.Discard, "", 0)
-	svc := NewService(repo, logger, docClient)
+	svc := NewService(repo, logger, docClient, nil, nil, nil, nil)
 	ep := NewEndpoint(svc)
 
 	r := gin.New()
@@ -82,7 +84,7 @@ func TestEndpoint_Create_InvalidJSON(t *testing.T) {
 	repo := new(mockRepository)
 	docClient := new(mockDocumentoClient)
 	logger := log.New(io.Discard, "", 0)
-	svc := NewService(repo, logger, docClient)
+	svc := NewService(repo, logger, docClient, nil, nil, nil, nil)
 	ep := NewEndpoint(svc)
 
 	r := gin.New()
@@ -106,7 +108,7 @@ func TestEndpoint_Create_RepoError(t *testing.T) {
 	repo := new(mockRepository)
 	docClient := new(mockDocumentoClient)
 	logger := log.New(io.Discard, "", 0)
-	svc := NewService(repo, logger, docClient)
+	svc := NewService(repo, logger, docClient, nil, nil, nil, nil)
 	ep := NewEndpoint(svc)
 
 	r := gin.New()
@@ -152,7 +154,7 @@ func TestEndpoint_GetByID_InvalidID(t *testing.T) {
 	repo := new(mockRepository)
 	docClient := new(mockDocumentoClient)
 	logger := log.New(io.Discard, "", 0)
-	svc := NewService(repo, logger, docClient)
+	svc := NewService(repo, logger, docClient, nil, nil, nil, nil)
 	ep := NewEndpoint(svc)
 
 	r := gin.New()
@@ -173,7 +175,7 @@ func TestEndpoint_GetByID_NotFound(t *testing.T) {
 	repo := new(mockRepository)
 	docClient := new(mockDocumentoClient)
 	logger := log.New(io.Discard, "", 0)
-	svc := NewService(repo, logger, docClient)
+	svc := NewService(repo, logger, docClient, nil, nil, nil, nil)
 	ep := NewEndpoint(svc)
 
 	r := gin.New()
@@ -197,7 +199,7 @@ func TestEndpoint_GetByID_Success(t *testing.T) {
 	repo := new(mockRepository)
 	docClient := new(mockDocumentoClient)
 	logger := log.New(io.Discard, "", 0)
-	svc := NewService(repo, logger, docClient)
+	svc := NewService(repo, logger, docClient, nil, nil, nil, nil)
 	ep := NewEndpoint(svc)
 
 	r := gin.New()
@@ -226,7 +228,7 @@ func TestEndpoint_Update_ForbiddenFields(t *testing.T) {
 	repo := new(mockRepository)
 	docClient := new(mockDocumentoClient)
 	logger := log.New(io.Discard, "", 0)
-	svc := NewService(repo, logger, docClient)
+	svc := NewService(repo, logger, docClient, nil, nil, nil, nil)
 	ep := NewEndpoint(svc)
 
 	r := gin.New()
@@ -250,7 +252,7 @@ func TestEndpoint_Update_NotFound(t *testing.T) {
 	repo := new(mockRepository)
 	docClient := new(mockDocumentoClient)
 	logger := log.New(io.Discard, "", 0)
-	svc := NewService(repo, logger, docClient)
+	svc := NewService(repo, logger, docClient, nil, nil, nil, nil)
 	ep := NewEndpoint(svc)
 
 	r := gin.New()
@@ -276,7 +278,7 @@ func TestEndpoint_Update_Success(t *testing.T) {
 	repo := new(mockRepository)
 	docClient := new(mockDocumentoClient)
 	logger := log.New(io.Discard, "", 0)
-	svc := NewService(repo, logger, docClient)
+	svc := NewService(repo, logger, docClient, nil, nil, nil, nil)
 	ep := NewEndpoint(svc)
 
 	r := gin.New()
@@ -297,13 +299,39 @@ func TestEndpoint_Update_Success(t *testing.T) {
 	repo.AssertExpectations(t)
 }
 
+func TestEndpoint_Update_RentaFueraDeBorrador(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := new(mockRepository)
+	docClient := new(mockDocumentoClient)
+	logger := log.New(io.Discard, "", 0)
+	svc := NewService(repo, logger, docClient, nil, nil, nil, nil)
+	ep := NewEndpoint(svc)
+
+	r := gin.New()
+	r.PATCH("/solicitudes/:id", ep.Update)
+
+	// Subir la renta de una solicitud aprobada podría exigir otra cadena de aprobación
+	repo.On("GetByID", mock.Anything, uint(5)).Return(&Solicitud{ID: 5, Estado: EstadoAprobada, RentaHasta: 1500000}, nil)
+
+	body := bytes.NewBufferString(`{"renta_hasta":5000000}`)
+	req := httptest.NewRequest(http.MethodPatch, "/solicitudes/5", body)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestEndpoint_Delete_InvalidID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	repo := new(mockRepository)
 	docClient := new(mockDocumentoClient)
 	logger := log.New(io.Discard, "", 0)
-	svc := NewService(repo, logger, docClient)
+	svc := NewService(repo, logger, docClient, nil, nil, nil, nil)
 	ep := NewEndpoint(svc)
 
 	r := gin.New()
@@ -324,7 +352,7 @@ func TestEndpoint_Delete_NotFound(t *testing.T) {
 	repo := new(mockRepository)
 	docClient := new(mockDocumentoClient)
 	logger := log.New(io.Discard, "", 0)
-	svc := NewService(repo, logger, docClient)
+	svc := NewService(repo, logger, docClient, nil, nil, nil, nil)
 	ep := NewEndpoint(svc)
 
 	r := gin.New()
@@ -351,7 +379,7 @@ func TestEndpoint_Delete_Success(t *testing.T) {
 	repo := new(mockRepository)
 	docClient := new(mockDocumentoClient)
 	logger := log.New(io.Discard, "", 0)
-	svc := NewService(repo, logger, docClient)
+	svc := NewService(repo, logger, docClient, nil, nil, nil, nil)
 	ep := NewEndpoint(svc)
 
 	r := gin.New()
@@ -377,7 +405,7 @@ func TestEndpoint_Update_InvalidJSON(t *testing.T) {
 	repo := new(mockRepository)
 	docClient := new(mockDocumentoClient)
 	logger := log.New(io.Discard, "", 0)
-	svc := NewService(repo, logger, docClient)
+	svc := NewService(repo, logger, docClient, nil, nil, nil, nil)
 	ep := NewEndpoint(svc)
 
 	r := gin.New()
@@ -399,7 +427,7 @@ func TestEndpoint_Update_InvalidID(t *testing.T) {
 	repo := new(mockRepository)
 	docClient := new(mockDocumentoClient)
 	logger := log.New(io.Discard, "", 0)
-	svc := NewService(repo, logger, docClient)
+	svc := NewService(repo, logger, docClient, nil, nil, nil, nil)
 	ep := NewEndpoint(svc)
 
 	r := gin.New()
@@ -421,7 +449,7 @@ func TestEndpoint_GetAll_Success(t *testing.T) {
 	repo := new(mockRepository)
 	docClient := new(mockDocumentoClient)
 	logger := log.New(io.Discard, "", 0)
-	svc := NewService(repo, logger, docClient)
+	svc := NewService(repo, logger, docClient, nil, nil, nil, nil)
 	ep := NewEndpoint(svc)
 
 	r := gin.New()
@@ -453,7 +481,7 @@ func TestEndpoint_GetByIDWithDocuments_Success(t *testing.T) {
 	repo := new(mockRepository)
 	docClient := new(mockDocumentoClient)
 	logger := log.New(io.Discard, "", 0)
-	svc := NewService(repo, logger, docClient)
+	svc := NewService(repo, logger, docClient, nil, nil, nil, nil)
 	ep := NewEndpoint(svc)
 
 	r := gin.New()
@@ -477,3 +505,647 @@ func TestEndpoint_GetByIDWithDocuments_Success(t *testing.T) {
 	repo.AssertExpectations(t)
 	docClient.AssertExpectations(t)
 }
+
+func TestEndpoint_GetChecklistDocumentos_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := new(mockRepository)
+	docClient := new(mockDocumentoClient)
+	logger := log.New(io.Discard, "", 0)
+	svc := NewService(repo, logger, docClient, ReglasDocumentos{TodosLosTipos: {"descripcion_cargo", "nda"}}, nil, nil, nil)
+	ep := NewEndpoint(svc)
+
+	r := gin.New()
+	r.GET("/solicitudes/:id/checklist-documentos", ep.GetChecklistDocumentos)
+
+	repo.On("GetByID", mock.Anything, uint(7)).Return(&Solicitud{ID: 7, TipoServicio: "outsourcing"}, nil)
+	docClient.On("GetBySolicitudID", uint(7)).Return([]Documento{{ID: 1, NombreArchivo: "cargo", Extension: "pdf", Categoria: "descripcion_cargo"}}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/solicitudes/7/checklist-documentos", nil)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp ChecklistDocumentos
+	err := json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(7), resp.SolicitudID)
+	assert.False(t, resp.Completo)
+	assert.Equal(t, []string{"nda"}, resp.Faltantes)
+	repo.AssertExpectations(t)
+	docClient.AssertExpectations(t)
+}
+
+func TestEndpoint_GetChecklistDocumentos_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := new(mockRepository)
+	docClient := new(mockDocumentoClient)
+	logger := log.New(io.Discard, "", 0)
+	svc := NewService(repo, logger, docClient, nil, nil, nil, nil)
+	ep := NewEndpoint(svc)
+
+	r := gin.New()
+	r.GET("/solicitudes/:id/checklist-documentos", ep.GetChecklistDocumentos)
+
+	repo.On("GetByID", mock.Anything, uint(404)).Return(nil, errors.New("record not found"))
+
+	req := httptest.NewRequest(http.MethodGet, "/solicitudes/404/checklist-documentos", nil)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	repo.AssertExpectations(t)
+}
+
+func TestEndpoint_GetChecklistDocumentos_DocumentosNoDisponibles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := new(mockRepository)
+	docClient := new(mockDocumentoClient)
+	logger := log.New(io.Discard, "", 0)
+	svc := NewService(repo, logger, docClient, ReglasDocumentos{TodosLosTipos: {"nda"}}, nil, nil, nil)
+	ep := NewEndpoint(svc)
+
+	r := gin.New()
+	r.GET("/solicitudes/:id/checklist-documentos", ep.GetChecklistDocumentos)
+
+	repo.On("GetByID", mock.Anything, uint(8)).Return(&Solicitud{ID: 8}, nil)
+	docClient.On("GetBySolicitudID", uint(8)).Return(nil, errors.New("connection refused"))
+
+	req := httptest.NewRequest(http.MethodGet, "/solicitudes/8/checklist-documentos", nil)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	repo.AssertExpectations(t)
+	docClient.AssertExpectations(t)
+}
+
+func TestEndpoint_GetFicha_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := new(mockRepository)
+	generador := new(mockGeneradorFicha)
+	logger := log.New(io.Discard, "", 0)
+	svc := NewService(repo, logger, new(mockDocumentoClient), nil, generador, nil, nil)
+	ep := NewEndpoint(svc)
+
+	r := gin.New()
+	r.GET("/solicitudes/:id/ficha.pdf", ep.GetFicha)
+
+	repo.On("GetByID", mock.Anything, uint(5)).Return(&Solicitud{ID: 5, Titulo: "Backend Developer"}, nil)
+	generador.On("Generar", mock.MatchedBy(func(s SolicitudResponse) bool { return s.ID == 5 })).Return([]byte("%PDF-1.3"), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/solicitudes/5/ficha.pdf", nil)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "ficha-solicitud-5.pdf")
+	assert.Equal(t, "%PDF-1.3", w.Body.String())
+	repo.AssertExpectations(t)
+	generador.AssertExpectations(t)
+}
+
+func TestEndpoint_GetFicha_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := new(mockRepository)
+	generador := new(mockGeneradorFicha)
+	logger := log.New(io.Discard, "", 0)
+	svc := NewService(repo, logger, new(mockDocumentoClient), nil, generador, nil, nil)
+	ep := NewEndpoint(svc)
+
+	r := gin.New()
+	r.GET("/solicitudes/:id/ficha.pdf", ep.GetFicha)
+
+	repo.On("GetByID", mock.Anything, uint(404)).Return(nil, errors.New("record not found"))
+
+	req := httptest.NewRequest(http.MethodGet, "/solicitudes/404/ficha.pdf", nil)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	generador.AssertNotCalled(t, "Generar", mock.Anything)
+}
+
+func TestEndpoint_GetFicha_PlantillaInvalida(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := new(mockRepository)
+	generador := new(mockGeneradorFicha)
+	logger := log.New(io.Discard, "", 0)
+	svc := NewService(repo, logger, new(mockDocumentoClient), nil, generador, nil, nil)
+	ep := NewEndpoint(svc)
+
+	r := gin.New()
+	r.GET("/solicitudes/:id/ficha.pdf", ep.GetFicha)
+
+	repo.On("GetByID", mock.Anything, uint(5)).Return(&Solicitud{ID: 5}, nil)
+	generador.On("Generar", mock.Anything).Return(nil, errors.New("can't evaluate field Salario"))
+
+	req := httptest.NewRequest(http.MethodGet, "/solicitudes/5/ficha.pdf", nil)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestEndpoint_GuardarFicha_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := new(mockRepository)
+	docClient := new(mockDocumentoClient)
+	generador := new(mockGeneradorFicha)
+	logger := log.New(io.Discard, "", 0)
+	svc := NewService(repo, logger, docClient, nil, generador, nil, nil)
+	ep := NewEndpoint(svc)
+
+	r := gin.New()
+	r.POST("/solicitudes/:id/ficha.pdf", ep.GuardarFicha)
+
+	repo.On("GetByID", mock.Anything, uint(5)).Return(&Solicitud{ID: 5}, nil)
+	generador.On("Generar", mock.Anything).Return([]byte("%PDF-1.3"), nil)
+	docClient.On("SubirDocumento", uint(5), "ficha-solicitud-5.pdf", []byte("%PDF-1.3")).
+		Return(&Documento{ID: 30, NombreArchivo: "ficha-solicitud-5", Extension: "pdf"}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/solicitudes/5/ficha.pdf", nil)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var resp DocumentoResponse
+	err := json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(30), resp.ID)
+	assert.Equal(t, "pdf", resp.Extension)
+	docClient.AssertExpectations(t)
+}
+
+func TestEndpoint_GuardarFicha_DocumentosNoDisponibles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := new(mockRepository)
+	docClient := new(mockDocumentoClient)
+	generador := new(mockGeneradorFicha)
+	logger := log.New(io.Discard, "", 0)
+	svc := NewService(repo, logger, docClient, nil, generador, nil, nil)
+	ep := NewEndpoint(svc)
+
+	r := gin.New()
+	r.POST("/solicitudes/:id/ficha.pdf", ep.GuardarFicha)
+
+	repo.On("GetByID", mock.Anything, uint(5)).Return(&Solicitud{ID: 5}, nil)
+	generador.On("Generar", mock.Anything).Return([]byte("%PDF-1.3"), nil)
+	docClient.On("SubirDocumento", uint(5), mock.Anything, mock.Anything).Return(nil, errors.New("connection refused"))
+
+	req := httptest.NewRequest(http.MethodPost, "/solicitudes/5/ficha.pdf", nil)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	docClient.AssertExpectations(t)
+}
+
+func TestEndpoint_Create_EstadoInvalido(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := new(mockRepository)
+	docClient := new(mockDocumentoClient)
+	logger := log.New(io.Discard, "", 0)
+	svc := NewService(repo, logger, docClient, nil, nil, nil, nil)
+	ep := NewEndpoint(svc)
+
+	r := gin.New()
+	r.POST("/solicitudes", ep.Create)
+
+	payload := CreateReq{
+		Titulo:              "DevOps Engineer",
+		Estado:              "publicada",
+		Area:                "Infraestructura",
+		Pais:                "Chile",
+		Localizacion:        "Concepción",
+		FechaInicioProyecto: "2025-12-01",
+		UsuarioID:           uintPtr(3),
+	}
+	body, _ := json.Marshal(payload)
+	req := httptest.NewRequest(http.MethodPost, "/solicitudes", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestEndpoint_Create_PendienteIncompleta(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := new(mockRepository)
+	docClient := new(mockDocumentoClient)
+	logger := log.New(io.Discard, "", 0)
+	svc := NewService(repo, logger, docClient, nil, nil, nil, nil)
+	ep := NewEndpoint(svc)
+
+	r := gin.New()
+	r.POST("/solicitudes", ep.Create)
+
+	// Una descripción en blanco pasa el binding pero no permite enviarla a aprobación
+	payload := CreateReq{
+		Titulo:                   "DevOps Engineer",
+		Estado:                   "pendiente",
+		Area:                     "Infraestructura",
+		Pais:                     "Chile",
+		Localizacion:             "Concepción",
+		NumeroVacantes:           1,
+		Descripcion:              "   ",
+		BaseEducacional:          "Ingeniería",
+		ConocimientosExcluyentes: "AWS",
+		RentaDesde:               1500000,
+		RentaHasta:               2200000,
+		ModalidadTrabajo:         "presencial",
+		TipoServicio:             "infraestructura",
+		NivelExperiencia:         "senior",
+		FechaInicioProyecto:      "2025-12-01",
+		UsuarioID:                uintPtr(3),
+	}
+	body, _ := json.Marshal(payload)
+	req := httptest.NewRequest(http.MethodPost, "/solicitudes", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestEndpoint_Update_Estado(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := new(mockRepository)
+	docClient := new(mockDocumentoClient)
+	logger := log.New(io.Discard, "", 0)
+	svc := NewService(repo, logger, docClient, nil, nil, nil, nil)
+	ep := NewEndpoint(svc)
+
+	r := gin.New()
+	r.PATCH("/solicitudes/:id", ep.Update)
+
+	// El estado solo cambia con POST /solicitudes/:id/transiciones
+	body := bytes.NewBufferString(`{"titulo":"nuevo","estado":"publicada"}`)
+	req := httptest.NewRequest(http.MethodPatch, "/solicitudes/1", body)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "transiciones")
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestEndpoint_Transicionar(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		url        string
+		body       string
+		setupMocks func(r *mockRepository, d *mockDocumentoClient)
+		status     int
+	}{
+		{
+			name: "cambia el estado",
+			url:  "/solicitudes/1/transiciones",
+			body: `{"estado":"aprobada"}`,
+			setupMocks: func(r *mockRepository, d *mockDocumentoClient) {
+				r.On("GetByID", mock.Anything, uint(1)).Return(&Solicitud{ID: 1, Estado: EstadoPendiente}, nil)
+				r.On("CambiarEstado", mock.Anything, uint(1), EstadoPendiente, EstadoAprobada, mock.Anything).Return(true, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "ID inválido",
+			url:    "/solicitudes/abc/transiciones",
+			body:   `{"estado":"aprobada"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "sin estado",
+			url:    "/solicitudes/1/transiciones",
+			body:   `{"motivo":"x"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "estado desconocido",
+			url:  "/solicitudes/1/transiciones",
+			body: `{"estado":"archivada"}`,
+			setupMocks: func(r *mockRepository, d *mockDocumentoClient) {
+				r.On("GetByID", mock.Anything, uint(1)).Return(&Solicitud{ID: 1, Estado: EstadoPendiente}, nil)
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "solicitud no encontrada",
+			url:  "/solicitudes/404/transiciones",
+			body: `{"estado":"aprobada"}`,
+			setupMocks: func(r *mockRepository, d *mockDocumentoClient) {
+				r.On("GetByID", mock.Anything, uint(404)).Return(nil, errors.New("record not found"))
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name: "transición no permitida",
+			url:  "/solicitudes/1/transiciones",
+			body: `{"estado":"publicada"}`,
+			setupMocks: func(r *mockRepository, d *mockDocumentoClient) {
+				r.On("GetByID", mock.Anything, uint(1)).Return(&Solicitud{ID: 1, Estado: EstadoBorrador}, nil)
+			},
+			status: http.StatusConflict,
+		},
+		{
+			name: "requisitos no cumplidos",
+			url:  "/solicitudes/1/transiciones",
+			body: `{"estado":"cancelada"}`,
+			setupMocks: func(r *mockRepository, d *mockDocumentoClient) {
+				r.On("GetByID", mock.Anything, uint(1)).Return(&Solicitud{ID: 1, Estado: EstadoPublicada}, nil)
+			},
+			status: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "documentos no disponibles",
+			url:  "/solicitudes/1/transiciones",
+			body: `{"estado":"publicada"}`,
+			setupMocks: func(r *mockRepository, d *mockDocumentoClient) {
+				r.On("GetByID", mock.Anything, uint(1)).Return(&Solicitud{ID: 1, Estado: EstadoAprobada}, nil)
+				d.On("GetBySolicitudID", uint(1)).Return(nil, errors.New("connection refused"))
+			},
+			status: http.StatusBadGateway,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepository)
+			docClient := new(mockDocumentoClient)
+			if tt.setupMocks != nil {
+				tt.setupMocks(repo, docClient)
+			}
+			logger := log.New(io.Discard, "", 0)
+			svc := NewService(repo, logger, docClient, ReglasDocumentos{TodosLosTipos: {"nda"}}, nil, nil, nil)
+			ep := NewEndpoint(svc)
+
+			r := gin.New()
+			r.POST("/solicitudes/:id/transiciones", ep.Transicionar)
+
+			req := httptest.NewRequest(http.MethodPost, tt.url, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+			repo.AssertExpectations(t)
+			docClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestEndpoint_Aprobaciones(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cadenas := CadenasAprobacion{{Area: TodasLasAreas, RentaSobre: 2000000, Niveles: []string{"jefe_area", "finanzas"}}}
+	pendiente := func() *Solicitud {
+		return &Solicitud{ID: 1, Estado: EstadoPendiente, Area: "IT", RentaHasta: 2500000}
+	}
+	ronda := func() []Aprobacion {
+		return []Aprobacion{
+			{ID: 10, SolicitudID: 1, Ronda: 1, Orden: 1, Nivel: "jefe_area", Estado: AprobacionPendiente},
+			{ID: 11, SolicitudID: 1, Ronda: 1, Orden: 2, Nivel: "finanzas", Estado: AprobacionPendiente},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		url        string
+		body       string
+		setupMocks func(r *mockRepository)
+		status     int
+	}{
+		{
+			name:   "consulta la ronda actual",
+			method: http.MethodGet,
+			url:    "/solicitudes/1/aprobaciones",
+			setupMocks: func(r *mockRepository) {
+				r.On("GetByID", mock.Anything, uint(1)).Return(pendiente(), nil)
+				r.On("GetRondaAprobacion", mock.Anything, uint(1)).Return(ronda(), nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "consulta de solicitud no encontrada",
+			method: http.MethodGet,
+			url:    "/solicitudes/404/aprobaciones",
+			setupMocks: func(r *mockRepository) {
+				r.On("GetByID", mock.Anything, uint(404)).Return(nil, errors.New("record not found"))
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "aprueba el nivel actual",
+			method: http.MethodPost,
+			url:    "/solicitudes/1/aprobaciones/jefe_area/aprobar",
+			body:   `{"usuario_id":7,"comentario":"OK"}`,
+			setupMocks: func(r *mockRepository) {
+				r.On("GetByID", mock.Anything, uint(1)).Return(pendiente(), nil)
+				r.On("GetRondaAprobacion", mock.Anything, uint(1)).Return(ronda(), nil)
+				r.On("DecidirAprobacion", mock.Anything, mock.Anything).Return(true, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "decisión sin usuario",
+			method: http.MethodPost,
+			url:    "/solicitudes/1/aprobaciones/jefe_area/aprobar",
+			body:   `{"comentario":"OK"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "ID inválido",
+			method: http.MethodPost,
+			url:    "/solicitudes/abc/aprobaciones/jefe_area/aprobar",
+			body:   `{"usuario_id":7}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "rechazo sin comentario",
+			method: http.MethodPost,
+			url:    "/solicitudes/1/aprobaciones/jefe_area/rechazar",
+			body:   `{"usuario_id":7}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "nivel al que no le corresponde decidir",
+			method: http.MethodPost,
+			url:    "/solicitudes/1/aprobaciones/finanzas/rechazar",
+			body:   `{"usuario_id":7,"comentario":"No"}`,
+			setupMocks: func(r *mockRepository) {
+				r.On("GetByID", mock.Anything, uint(1)).Return(pendiente(), nil)
+				r.On("GetRondaAprobacion", mock.Anything, uint(1)).Return(ronda(), nil)
+			},
+			status: http.StatusConflict,
+		},
+		{
+			name:   "decisión de quien creó la solicitud",
+			method: http.MethodPost,
+			url:    "/solicitudes/1/aprobaciones/jefe_area/aprobar",
+			body:   `{"usuario_id":7}`,
+			setupMocks: func(r *mockRepository) {
+				creador := uint(7)
+				propia := pendiente()
+				propia.UsuarioID = &creador
+				r.On("GetByID", mock.Anything, uint(1)).Return(propia, nil)
+				r.On("GetRondaAprobacion", mock.Anything, uint(1)).Return(ronda(), nil)
+			},
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "decisión sobre solicitud no encontrada",
+			method: http.MethodPost,
+			url:    "/solicitudes/404/aprobaciones/jefe_area/aprobar",
+			body:   `{"usuario_id":7}`,
+			setupMocks: func(r *mockRepository) {
+				r.On("GetByID", mock.Anything, uint(404)).Return(nil, errors.New("record not found"))
+			},
+			status: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepository)
+			if tt.setupMocks != nil {
+				tt.setupMocks(repo)
+			}
+			logger := log.New(io.Discard, "", 0)
+			svc := NewService(repo, logger, new(mockDocumentoClient), nil, nil, cadenas, nil)
+			ep := NewEndpoint(svc)
+
+			r := gin.New()
+			r.GET("/solicitudes/:id/aprobaciones", ep.GetAprobaciones)
+			r.POST("/solicitudes/:id/aprobaciones/:nivel/aprobar", ep.Aprobar)
+			r.POST("/solicitudes/:id/aprobaciones/:nivel/rechazar", ep.Rechazar)
+
+			req := httptest.NewRequest(tt.method, tt.url, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestEndpoint_GetHistorial(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		url        string
+		setupMocks func(r *mockRepository)
+		status     int
+	}{
+		{
+			name: "retorna los eventos con filtros y paginación",
+			url:  "/solicitudes/1/historial?accion=transicion&limit=5&page=2",
+			setupMocks: func(r *mockRepository) {
+				r.On("GetHistorial", mock.Anything, uint(1), HistorialReq{Accion: "transicion", Limit: 5, Page: 2}).
+					Return([]EventoHistorial{{ID: 3, SolicitudID: 1, Accion: AccionTransicion}}, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "ID inválido",
+			url:    "/solicitudes/abc/historial",
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "solicitud no encontrada",
+			url:  "/solicitudes/404/historial",
+			setupMocks: func(r *mockRepository) {
+				r.On("GetHistorial", mock.Anything, uint(404), HistorialReq{}).Return([]EventoHistorial{}, nil)
+				r.On("GetByID", mock.Anything, uint(404)).Return(nil, errors.New("record not found"))
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name: "error al consultar",
+			url:  "/solicitudes/1/historial",
+			setupMocks: func(r *mockRepository) {
+				r.On("GetHistorial", mock.Anything, uint(1), HistorialReq{}).Return(nil, errors.New("database error"))
+			},
+			status: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepository)
+			if tt.setupMocks != nil {
+				tt.setupMocks(repo)
+			}
+			logger := log.New(io.Discard, "", 0)
+			ep := NewEndpoint(NewService(repo, logger, new(mockDocumentoClient), nil, nil, nil, nil))
+
+			r := gin.New()
+			r.GET("/solicitudes/:id/historial", ep.GetHistorial)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestEndpoint_Transicionar_Actor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// El usuario y el identificador de la petición llegan al repositorio para el historial
+	repo := new(mockRepository)
+	repo.On("GetByID", mock.Anything, uint(1)).Return(&Solicitud{ID: 1, Estado: EstadoPendiente}, nil)
+	repo.On("CambiarEstado", mock.MatchedBy(func(ctx context.Context) bool {
+		actor := actorDe(ctx)
+		return actor.UsuarioID != nil && *actor.UsuarioID == 9 && actor.RequestID == "req-9"
+	}), uint(1), EstadoPendiente, EstadoBorrador, "faltan datos").Return(true, nil)
+	ep := NewEndpoint(NewService(repo, log.New(io.Discard, "", 0), new(mockDocumentoClient), nil, nil, nil, nil))
+
+	r := gin.New()
+	r.POST("/solicitudes/:id/transiciones", ep.Transicionar)
+
+	req := httptest.NewRequest(http.MethodPost, "/solicitudes/1/transiciones", bytes.NewBufferString(`{"estado":"borrador","motivo":"faltan datos"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Usuario-ID", "9")
+	req.Header.Set("X-Request-ID", "req-9")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,133 @@
+package solicitud
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Estados del ciclo de vida de una solicitud
+const (
+	EstadoBorrador  = "borrador"
+	EstadoPendiente = "pendiente"
+	EstadoAprobada  = "aprobada"
+	EstadoPublicada = "publicada"
+	EstadoEnProceso = "en_proceso"
+	EstadoCerrada   = "cerrada"
+	EstadoCancelada = "cancelada"
+)
+
+// transiciones indica a qué estados se puede pasar desde cada estado. Cerrada y cancelada son finales.
+var transiciones = map[string][]string{
+	EstadoBorrador:  {EstadoPendiente, EstadoCancelada},
+	EstadoPendiente: {EstadoAprobada, EstadoBorrador, EstadoCancelada},
+	EstadoAprobada:  {EstadoPublicada, EstadoBorrador, EstadoCancelada},
+	EstadoPublicada: {EstadoEnProceso, EstadoCancelada},
+	EstadoEnProceso: {EstadoCerrada, EstadoCancelada},
+	EstadoCerrada:   {},
+	EstadoCancelada: {},
+}
+
+// estadosLegados traduce los estados que se usaban antes del ciclo de vida
+var estadosLegados = map[string]string{
+	"activa":     EstadoPublicada,
+	"abierta":    EstadoPublicada,
+	"en proceso": EstadoEnProceso,
+	"completada": EstadoCerrada,
+	"finalizada": EstadoCerrada,
+	"rechazada":  EstadoCancelada,
+	"anulada":    EstadoCancelada,
+}
+
+// estadosIniciales son los estados con los que se puede crear una solicitud
+var estadosIniciales = []string{EstadoBorrador, EstadoPendiente}
+
+// ErrEstadoInvalido indica que el estado no pertenece al ciclo de vida de las solicitudes
+var ErrEstadoInvalido = errors.New("estado inválido")
+
+// ErrTransicionNoPermitida indica que no se puede pasar del estado actual al solicitado
+var ErrTransicionNoPermitida = errors.New("transición de estado no permitida")
+
+// ErrRequisitosTransicion indica que la solicitud no cumple las condiciones para pasar al estado solicitado
+var ErrRequisitosTransicion = errors.New("la solicitud no cumple los requisitos para la transición")
+
+// TransicionReq representa la petición para cambiar el estado de una solicitud
+type TransicionReq struct {
+	Estado string `json:"estado" binding:"required"`
+	// Motivo del cambio, obligatorio al cancelar
+	Motivo string `json:"motivo"`
+}
+
+// normalizarEstado permite recibir el estado con mayúsculas o espacios
+func normalizarEstado(estado string) string {
+	return strings.ToLower(strings.TrimSpace(estado))
+}
+
+// esEstado indica si el estado pertenece al ciclo de vida
+func esEstado(estado string) bool {
+	_, ok := transiciones[estado]
+	return ok
+}
+
+// estadoMigrado retorna el estado del ciclo de vida que corresponde a un estado anterior a él.
+// Los estados desconocidos pasan a borrador para revisarse y enviarse de nuevo.
+func estadoMigrado(estado string) string {
+	normalizado := normalizarEstado(estado)
+	if esEstado(normalizado) {
+		return normalizado
+	}
+	if hacia, ok := estadosLegados[normalizado]; ok {
+		return hacia
+	}
+	return EstadoBorrador
+}
+
+// validarEstadoInicial verifica que la solicitud se cree en borrador o pendiente
+func validarEstadoInicial(estado string) error {
+	for _, inicial := range estadosIniciales {
+		if estado == inicial {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w '%s', una solicitud se crea en estado %s", ErrEstadoInvalido, estado, strings.Join(estadosIniciales, " o "))
+}
+
+// validarTransicion verifica que el ciclo de vida permita pasar de un estado a otro
+func validarTransicion(desde, hacia string) error {
+	if !esEstado(hacia) {
+		return fmt.Errorf("%w '%s'", ErrEstadoInvalido, hacia)
+	}
+	if !esEstado(desde) {
+		return fmt.Errorf("%w: el estado actual '%s' no pertenece al ciclo de vida", ErrTransicionNoPermitida, desde)
+	}
+	for _, permitido := range transiciones[desde] {
+		if permitido == hacia {
+			return nil
+		}
+	}
+	if len(transiciones[desde]) == 0 {
+		return fmt.Errorf("%w: la solicitud está %s y no admite cambios de estado", ErrTransicionNoPermitida, desde)
+	}
+	return fmt.Errorf("%w de %s a %s, desde %s se puede pasar a: %s", ErrTransicionNoPermitida, desde, hacia, desde, strings.Join(transiciones[desde], ", "))
+}
+
+// validarDatosCompletos verifica que la solicitud tenga la información necesaria para enviarse a aprobación
+func validarDatosCompletos(s *Solicitud) error {
+	var faltantes []string
+	if strings.TrimSpace(s.Descripcion) == "" {
+		faltantes = append(faltantes, "descripcion")
+	}
+	if strings.TrimSpace(s.BaseEducacional) == "" {
+		faltantes = append(faltantes, "base_educacional")
+	}
+	if s.NumeroVacantes <= 0 {
+		faltantes = append(faltantes, "numero_vacantes")
+	}
+	if len(faltantes) > 0 {
+		return fmt.Errorf("%w: faltan los campos %s", ErrRequisitosTransicion, strings.Join(faltantes, ", "))
+	}
+	if s.RentaDesde > 0 && s.RentaHasta > 0 && s.RentaDesde > s.RentaHasta {
+		return fmt.Errorf("%w: el rango de renta es inválido", ErrRequisitosTransicion)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package solicitud
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrFichaNoGenerada indica que la plantilla de ficha no se pudo aplicar a la solicitud
+var ErrFichaNoGenerada = errors.New("no se pudo generar la ficha de la solicitud")
+
+// ErrFichaNoGuardada indica que el servicio de documentos no aceptó la ficha generada
+var ErrFichaNoGuardada = errors.New("no se pudo guardar la ficha en el servicio de documentos")
+
+// GeneradorFicha genera la ficha de publicación en PDF de una solicitud
+type GeneradorFicha interface {
+	Generar(solicitud SolicitudResponse) ([]byte, error)
+}
+
+// NombreFicha es el nombre con el que se descarga o se guarda la ficha de una solicitud
+func NombreFicha(id uint) string {
+	return fmt.Sprintf("ficha-solicitud-%d.pdf", id)
+}
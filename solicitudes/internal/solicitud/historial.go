@@ -0,0 +1,147 @@
+package solicitud
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// Acciones registradas en el historial de una solicitud
+const (
+	AccionCreacion      = "creacion"
+	AccionActualizacion = "actualizacion"
+	AccionEliminacion   = "eliminacion"
+	AccionTransicion    = "transicion"
+)
+
+// CambioCampo es el valor anterior y el nuevo de un campo de la solicitud; nil indica sin valor
+type CambioCampo struct {
+	Campo    string  `json:"campo"`
+	Anterior *string `json:"anterior"`
+	Nuevo    *string `json:"nuevo"`
+}
+
+// EventoHistorial registra una operación sobre una solicitud, quién la hizo y en qué petición
+type EventoHistorial struct {
+	ID          uint          `gorm:"primaryKey;autoIncrement" json:"id"`
+	SolicitudID uint          `gorm:"not null;index" json:"solicitud_id"`
+	Accion      string        `gorm:"type:varchar(20);not null" json:"accion"`
+	Cambios     []CambioCampo `gorm:"type:json;serializer:json" json:"cambios"`
+	Motivo      string        `gorm:"type:text" json:"motivo,omitempty"`
+	UsuarioID   *uint         `json:"usuario_id,omitempty"`
+	RequestID   string        `gorm:"type:varchar(64);index" json:"request_id,omitempty"`
+	CreatedAt   time.Time     `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName especifica el nombre de la tabla
+func (EventoHistorial) TableName() string {
+	return "solicitud_historial"
+}
+
+// HistorialReq representa los filtros de la consulta del historial de una solicitud
+type HistorialReq struct {
+	Accion string
+	Limit  int
+	Page   int
+}
+
+// Actor identifica al usuario y la petición que originan un cambio
+type Actor struct {
+	UsuarioID *uint
+	RequestID string
+}
+
+type claveActor struct{}
+
+// ConActor agrega al contexto el actor que se registrará en el historial
+func ConActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, claveActor{}, actor)
+}
+
+// actorDe obtiene el actor del contexto; vacío si la operación no viene de una petición
+func actorDe(ctx context.Context) Actor {
+	actor, _ := ctx.Value(claveActor{}).(Actor)
+	return actor
+}
+
+// nuevoEvento crea el evento de historial con el actor del contexto
+func nuevoEvento(ctx context.Context, solicitudID uint, accion string, cambios []CambioCampo, motivo string) *EventoHistorial {
+	actor := actorDe(ctx)
+	if cambios == nil {
+		cambios = []CambioCampo{}
+	}
+	return &EventoHistorial{
+		SolicitudID: solicitudID,
+		Accion:      accion,
+		Cambios:     cambios,
+		Motivo:      motivo,
+		UsuarioID:   actor.UsuarioID,
+		RequestID:   actor.RequestID,
+	}
+}
+
+// valoresHistorial retorna, en orden, los campos de la solicitud que se registran en el historial
+func valoresHistorial(s *Solicitud) []CambioCampo {
+	texto := func(valor string) *string {
+		return &valor
+	}
+	entero := func(valor int) *string {
+		return texto(strconv.Itoa(valor))
+	}
+
+	var usuario, fecha *string
+	if s.UsuarioID != nil {
+		usuario = texto(strconv.FormatUint(uint64(*s.UsuarioID), 10))
+	}
+	if !s.FechaInicioProyecto.IsZero() {
+		fecha = texto(s.FechaInicioProyecto.Format("2006-01-02"))
+	}
+
+	return []CambioCampo{
+		{Campo: "titulo", Nuevo: texto(s.Titulo)},
+		{Campo: "estado", Nuevo: texto(s.Estado)},
+		{Campo: "area", Nuevo: texto(s.Area)},
+		{Campo: "pais", Nuevo: texto(s.Pais)},
+		{Campo: "localizacion", Nuevo: texto(s.Localizacion)},
+		{Campo: "numero_vacantes", Nuevo: entero(s.NumeroVacantes)},
+		{Campo: "descripcion", Nuevo: texto(s.Descripcion)},
+		{Campo: "base_educacional", Nuevo: texto(s.BaseEducacional)},
+		{Campo: "conocimientos_excluyentes", Nuevo: texto(s.ConocimientosExcluyentes)},
+		{Campo: "renta_desde", Nuevo: entero(s.RentaDesde)},
+		{Campo: "renta_hasta", Nuevo: entero(s.RentaHasta)},
+		{Campo: "modalidad_trabajo", Nuevo: texto(s.ModalidadTrabajo)},
+		{Campo: "tipo_servicio", Nuevo: texto(s.TipoServicio)},
+		{Campo: "nivel_experiencia", Nuevo: texto(s.NivelExperiencia)},
+		{Campo: "fecha_inicio_proyecto", Nuevo: fecha},
+		{Campo: "usuario_id", Nuevo: usuario},
+	}
+}
+
+// diferencias compara dos estados de una solicitud campo a campo. Sin estado anterior,
+// como al crearla, retorna los campos que tienen valor.
+func diferencias(antes, despues *Solicitud) []CambioCampo {
+	cambios := []CambioCampo{}
+	if antes == nil {
+		for _, campo := range valoresHistorial(despues) {
+			if campo.Nuevo != nil && *campo.Nuevo != "" && *campo.Nuevo != "0" {
+				cambios = append(cambios, campo)
+			}
+		}
+		return cambios
+	}
+
+	anteriores := valoresHistorial(antes)
+	for i, campo := range valoresHistorial(despues) {
+		if !mismoValor(anteriores[i].Nuevo, campo.Nuevo) {
+			cambios = append(cambios, CambioCampo{Campo: campo.Campo, Anterior: anteriores[i].Nuevo, Nuevo: campo.Nuevo})
+		}
+	}
+	return cambios
+}
+
+func mismoValor(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
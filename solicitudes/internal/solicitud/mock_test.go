@@ -15,6 +15,11 @@ func (m *mockRepository) Create(ctx context.Context, solicitud *Solicitud) error
 	return args.Error(0)
 }
 
+func (m *mockRepository) CreateConRonda(ctx context.Context, solicitud *Solicitud, ronda []Aprobacion) error {
+	args := m.Called(ctx, solicitud, ronda)
+	return args.Error(0)
+}
+
 func (m *mockRepository) GetAll(ctx context.Context, filters GetAllReq) ([]Solicitud, error) {
 	args := m.Called(ctx, filters)
 	if args.Get(0) == nil {
@@ -40,3 +45,51 @@ func (m *mockRepository) Delete(ctx context.Context, id uint) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
+
+func (m *mockRepository) CambiarEstado(ctx context.Context, id uint, desde, hacia, motivo string) (bool, error) {
+	args := m.Called(ctx, id, desde, hacia, motivo)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockRepository) MigrarEstados(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockRepository) CambiarEstadoConRonda(ctx context.Context, id uint, desde, hacia, motivo string, ronda []Aprobacion) (bool, error) {
+	args := m.Called(ctx, id, desde, hacia, motivo, ronda)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockRepository) GetRondaAprobacion(ctx context.Context, solicitudID uint) ([]Aprobacion, error) {
+	args := m.Called(ctx, solicitudID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Aprobacion), args.Error(1)
+}
+
+func (m *mockRepository) DecidirAprobacion(ctx context.Context, aprobacion *Aprobacion) (bool, error) {
+	args := m.Called(ctx, aprobacion)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockRepository) GetHistorial(ctx context.Context, solicitudID uint, filtros HistorialReq) ([]EventoHistorial, error) {
+	args := m.Called(ctx, solicitudID, filtros)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]EventoHistorial), args.Error(1)
+}
+
+type mockGeneradorFicha struct {
+	mock.Mock
+}
+
+func (m *mockGeneradorFicha) Generar(solicitud SolicitudResponse) ([]byte, error) {
+	args := m.Called(solicitud)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
+}
@@ -2,16 +2,26 @@ package solicitud
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository interface {
 	Create(ctx context.Context, solicitud *Solicitud) error
+	CreateConRonda(ctx context.Context, solicitud *Solicitud, ronda []Aprobacion) error
 	GetAll(ctx context.Context, filters GetAllReq) ([]Solicitud, error)
 	GetByID(ctx context.Context, id uint) (*Solicitud, error)
 	Update(ctx context.Context, id uint, req UpdateReq) error
 	Delete(ctx context.Context, id uint) error
+	CambiarEstado(ctx context.Context, id uint, desde, hacia, motivo string) (bool, error)
+	CambiarEstadoConRonda(ctx context.Context, id uint, desde, hacia, motivo string, ronda []Aprobacion) (bool, error)
+	MigrarEstados(ctx context.Context) (int, error)
+	GetHistorial(ctx context.Context, solicitudID uint, filtros HistorialReq) ([]EventoHistorial, error)
+	GetRondaAprobacion(ctx context.Context, solicitudID uint) ([]Aprobacion, error)
+	DecidirAprobacion(ctx context.Context, aprobacion *Aprobacion) (bool, error)
 }
 
 type repository struct {
@@ -22,8 +32,29 @@ func NewRepository(db *gorm.DB) Repository {
 	return &repository{db: db}
 }
 
+// Create guarda la solicitud y su evento de creación en la misma transacción
 func (r *repository) Create(ctx context.Context, solicitud *Solicitud) error {
-	return r.db.WithContext(ctx).Create(solicitud).Error
+	return r.CreateConRonda(ctx, solicitud, nil)
+}
+
+// CreateConRonda guarda la solicitud creada pendiente de aprobación junto con su primera ronda,
+// para que no quede una solicitud pendiente sin ronda ni una ronda sin solicitud
+func (r *repository) CreateConRonda(ctx context.Context, solicitud *Solicitud, ronda []Aprobacion) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(solicitud).Error; err != nil {
+			return err
+		}
+
+		evento := nuevoEvento(ctx, solicitud.ID, AccionCreacion, diferencias(nil, solicitud), "")
+		if evento.UsuarioID == nil {
+			// Sin usuario en la petición, la crea el usuario dueño de la solicitud
+			evento.UsuarioID = solicitud.UsuarioID
+		}
+		if err := tx.Create(evento).Error; err != nil {
+			return err
+		}
+		return crearRonda(tx, solicitud.ID, ronda)
+	})
 }
 
 func (r *repository) GetAll(ctx context.Context, filters GetAllReq) ([]Solicitud, error) {
@@ -94,9 +125,6 @@ func (r *repository) Update(ctx context.Context, id uint, req UpdateReq) error {
 	if req.Titulo != nil {
 		updates["titulo"] = *req.Titulo
 	}
-	if req.Estado != nil {
-		updates["estado"] = *req.Estado
-	}
 	if req.Area != nil {
 		updates["area"] = *req.Area
 	}
@@ -124,11 +152,157 @@ func (r *repository) Update(ctx context.Context, id uint, req UpdateReq) error {
 	if req.FechaInicioProyecto != nil {
 		updates["fecha_inicio_proyecto"] = *req.FechaInicioProyecto
 	}
-	return r.db.WithContext(ctx).Model(&Solicitud{}).Where("id = ?", id).Updates(updates).Error
+
+	// Se bloquea la fila para que el valor anterior registrado sea el que se reemplaza
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var antes Solicitud
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&antes, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&Solicitud{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+		var despues Solicitud
+		if err := tx.First(&despues, id).Error; err != nil {
+			return err
+		}
+
+		cambios := diferencias(&antes, &despues)
+		if len(cambios) == 0 {
+			return nil
+		}
+		return tx.Create(nuevoEvento(ctx, id, AccionActualizacion, cambios, "")).Error
+	})
 }
 
 func (r *repository) Delete(ctx context.Context, id uint) error {
-	// Usamos Delete de GORM que manejará automáticamente el soft delete
-	// ya que el modelo Solicitud tiene el campo DeletedAt de tipo gorm.DeletedAt
-	return r.db.WithContext(ctx).Delete(&Solicitud{}, id).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Usamos Delete de GORM que manejará automáticamente el soft delete
+		// ya que el modelo Solicitud tiene el campo DeletedAt de tipo gorm.DeletedAt
+		result := tx.Delete(&Solicitud{}, id)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Create(nuevoEvento(ctx, id, AccionEliminacion, nil, "")).Error
+	})
+}
+
+// CambiarEstado actualiza el estado solo si la solicitud sigue en el estado desde y registra la transición.
+// Retorna false si la solicitud cambió de estado o fue eliminada entretanto.
+func (r *repository) CambiarEstado(ctx context.Context, id uint, desde, hacia, motivo string) (bool, error) {
+	return r.CambiarEstadoConRonda(ctx, id, desde, hacia, motivo, nil)
+}
+
+// CambiarEstadoConRonda cambia el estado como CambiarEstado y, en la misma transacción, guarda la
+// ronda de aprobación que inicia el cambio. Si el estado no cambia tampoco se guarda la ronda.
+func (r *repository) CambiarEstadoConRonda(ctx context.Context, id uint, desde, hacia, motivo string, ronda []Aprobacion) (bool, error) {
+	cambiado := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Solicitud{}).
+			Where("id = ? AND estado = ?", id, desde).
+			Update("estado", hacia)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		cambiado = true
+
+		cambios := []CambioCampo{{Campo: "estado", Anterior: &desde, Nuevo: &hacia}}
+		if err := tx.Create(nuevoEvento(ctx, id, AccionTransicion, cambios, motivo)).Error; err != nil {
+			return err
+		}
+		return crearRonda(tx, id, ronda)
+	})
+	if err != nil {
+		return false, err
+	}
+	return cambiado, nil
+}
+
+// MigrarEstados lleva al ciclo de vida las solicitudes cuyo estado no pertenece a él,
+// registrando cada cambio en su historial. Retorna cuántas solicitudes se migraron.
+func (r *repository) MigrarEstados(ctx context.Context) (int, error) {
+	migradas := 0
+	var lote []Solicitud
+	result := r.db.WithContext(ctx).Select("id", "estado").
+		FindInBatches(&lote, 500, func(tx *gorm.DB, _ int) error {
+			for _, s := range lote {
+				// La comparación se hace aquí porque la del motor puede ignorar mayúsculas
+				if esEstado(s.Estado) {
+					continue
+				}
+				motivo := fmt.Sprintf("migración del estado anterior '%s'", s.Estado)
+				cambiado, err := r.CambiarEstado(ctx, s.ID, s.Estado, estadoMigrado(s.Estado), motivo)
+				if err != nil {
+					return fmt.Errorf("error al migrar el estado de la solicitud %d: %v", s.ID, err)
+				}
+				if cambiado {
+					migradas++
+				}
+			}
+			return nil
+		})
+	return migradas, result.Error
+}
+
+// GetHistorial obtiene los eventos de una solicitud, del más reciente al más antiguo,
+// incluso si la solicitud fue eliminada
+func (r *repository) GetHistorial(ctx context.Context, solicitudID uint, filtros HistorialReq) ([]EventoHistorial, error) {
+	var eventos []EventoHistorial
+	query := r.db.WithContext(ctx).Where("solicitud_id = ?", solicitudID)
+	if filtros.Accion != "" {
+		query = query.Where("accion = ?", filtros.Accion)
+	}
+
+	//Paginacion
+	if filtros.Limit > 0 {
+		query = query.Limit(filtros.Limit)
+	}
+	if filtros.Page > 0 {
+		offset := (filtros.Page - 1) * filtros.Limit
+		query = query.Offset(offset)
+	}
+
+	err := query.Order("created_at DESC, id DESC").Find(&eventos).Error
+	return eventos, err
+}
+
+// crearRonda guarda los niveles de una nueva ronda de aprobación de la solicitud
+func crearRonda(tx *gorm.DB, solicitudID uint, ronda []Aprobacion) error {
+	if len(ronda) == 0 {
+		return nil
+	}
+	for i := range ronda {
+		ronda[i].SolicitudID = solicitudID
+	}
+	return tx.Create(&ronda).Error
+}
+
+// GetRondaAprobacion obtiene los niveles de la última ronda de aprobación de la solicitud, en orden
+func (r *repository) GetRondaAprobacion(ctx context.Context, solicitudID uint) ([]Aprobacion, error) {
+	var aprobaciones []Aprobacion
+	ultima := r.db.Model(&Aprobacion{}).Select("MAX(ronda)").Where("solicitud_id = ?", solicitudID)
+	err := r.db.WithContext(ctx).
+		Where("solicitud_id = ? AND ronda = (?)", solicitudID, ultima).
+		Order("orden").
+		Find(&aprobaciones).Error
+	return aprobaciones, err
+}
+
+// DecidirAprobacion registra la decisión de un nivel solo si sigue pendiente.
+// Retorna false si otro usuario decidió el nivel entretanto.
+func (r *repository) DecidirAprobacion(ctx context.Context, aprobacion *Aprobacion) (bool, error) {
+	ahora := time.Now()
+	result := r.db.WithContext(ctx).Model(&Aprobacion{}).
+		Where("id = ? AND estado = ?", aprobacion.ID, AprobacionPendiente).
+		Updates(map[string]interface{}{
+			"estado":      aprobacion.Estado,
+			"usuario_id":  aprobacion.UsuarioID,
+			"comentario":  aprobacion.Comentario,
+			"decidida_el": ahora,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	aprobacion.DecididaEl = &ahora
+	return result.RowsAffected > 0, nil
 }
@@ -29,7 +29,8 @@ func TestRepository_Create(t *testing.T) {
 	repo := NewRepository(db)
 
 	mock.ExpectBegin()
-	mock.ExpectExec("INSERT INTO").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO `solicitudes`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO `solicitud_historial`").WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
 
 	solicitud := &Solicitud{
@@ -78,13 +79,39 @@ func TestRepository_Update(t *testing.T) {
 	repo := NewRepository(db)
 
 	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `solicitudes` .* FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "titulo"}).AddRow(1, "Original"))
 	mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT \\* FROM `solicitudes`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "titulo"}).AddRow(1, "Updated"))
+	mock.ExpectExec("INSERT INTO `solicitud_historial`").WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
 
 	err := repo.Update(context.Background(), 1, UpdateReq{
 		Titulo: stringPtr("Updated"),
 	})
 	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Update_SinCambios(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+
+	// Si los valores no cambian no se registra un evento en el historial
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `solicitudes` .* FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "titulo"}).AddRow(1, "Igual"))
+	mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT \\* FROM `solicitudes`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "titulo"}).AddRow(1, "Igual"))
+	mock.ExpectCommit()
+
+	err := repo.Update(context.Background(), 1, UpdateReq{
+		Titulo: stringPtr("Igual"),
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestRepository_Delete(t *testing.T) {
@@ -96,6 +123,7 @@ func TestRepository_Delete(t *testing.T) {
 	mock.ExpectExec("(?i)UPDATE `solicitudes` SET `deleted_at`=\\? WHERE `solicitudes`\\.`id` = \\? AND `solicitudes`\\.`deleted_at` IS NULL").
 		WithArgs(sqlmock.AnyArg(), uint(1)).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `solicitud_historial`").WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
 
 	err := repo.Delete(context.Background(), 1)
@@ -209,6 +237,8 @@ func TestRepository_Update_Error(t *testing.T) {
 	repo := NewRepository(db)
 
 	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `solicitudes` .* FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "titulo"}).AddRow(1, "Original"))
 	mock.ExpectExec("UPDATE").WillReturnError(assert.AnError)
 	mock.ExpectRollback()
 
@@ -224,7 +254,12 @@ func TestRepository_Update_AllFields(t *testing.T) {
 	repo := NewRepository(db)
 
 	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `solicitudes` .* FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "titulo"}).AddRow(1, "Original"))
 	mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT \\* FROM `solicitudes`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "titulo", "area"}).AddRow(1, "Full Update", "Marketing"))
+	mock.ExpectExec("INSERT INTO `solicitud_historial`").WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
 
 	// Test actualizando todos los campos posibles
@@ -233,7 +268,6 @@ func TestRepository_Update_AllFields(t *testing.T) {
 	rentaHasta := 800000
 	err := repo.Update(context.Background(), 1, UpdateReq{
 		Titulo:              stringPtr("Full Update"),
-		Estado:              stringPtr("completada"),
 		Area:                stringPtr("Marketing"),
 		Pais:                stringPtr("Argentina"),
 		NumeroVacantes:      &numeroVacantes,
@@ -261,6 +295,260 @@ func TestRepository_Delete_Error(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestRepository_CambiarEstado(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `solicitudes` SET `estado`=\\?,`updated_at`=\\? WHERE \\(id = \\? AND estado = \\?\\)").
+		WithArgs("aprobada", sqlmock.AnyArg(), 1, "pendiente").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// El evento registra el usuario y la petición del contexto junto con el motivo
+	mock.ExpectExec("INSERT INTO `solicitud_historial`").
+		WithArgs(1, AccionTransicion, `[{"campo":"estado","anterior":"pendiente","nuevo":"aprobada"}]`, "presupuesto aprobado", 7, "req-1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	usuario := uint(7)
+	ctx := ConActor(context.Background(), Actor{UsuarioID: &usuario, RequestID: "req-1"})
+	cambiado, err := repo.CambiarEstado(ctx, 1, "pendiente", "aprobada", "presupuesto aprobado")
+	assert.NoError(t, err)
+	assert.True(t, cambiado)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_CambiarEstado_EstadoDistinto(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+
+	// Ninguna fila coincide si otro proceso cambió el estado antes
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	cambiado, err := repo.CambiarEstado(context.Background(), 1, "pendiente", "aprobada", "")
+	assert.NoError(t, err)
+	assert.False(t, cambiado)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_MigrarEstados(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+
+	mock.ExpectQuery("SELECT `id`,`estado` FROM `solicitudes`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "estado"}).
+			AddRow(1, "pendiente").
+			AddRow(2, "activa").
+			AddRow(3, "Completada").
+			AddRow(4, "en revisión"))
+	// Las solicitudes con estados del ciclo de vida no se tocan
+	for _, fila := range []struct {
+		id           int
+		desde, hacia string
+	}{{2, "activa", EstadoPublicada}, {3, "Completada", EstadoCerrada}, {4, "en revisión", EstadoBorrador}} {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE `solicitudes` SET `estado`=\\?").
+			WithArgs(fila.hacia, sqlmock.AnyArg(), fila.id, fila.desde).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO `solicitud_historial`").
+			WithArgs(fila.id, AccionTransicion, sqlmock.AnyArg(), "migración del estado anterior '"+fila.desde+"'", nil, "", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+	}
+
+	migradas, err := repo.MigrarEstados(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, migradas)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_CreateConRonda(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+
+	// La ronda toma el ID de la solicitud recién creada
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `solicitudes`").WillReturnResult(sqlmock.NewResult(9, 1))
+	mock.ExpectExec("INSERT INTO `solicitud_historial`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO `solicitud_aprobaciones`").
+		WithArgs(9, 1, 1, "jefe_area", AprobacionPendiente, nil, "", nil, sqlmock.AnyArg(),
+			9, 1, 2, "finanzas", AprobacionPendiente, nil, "", nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 2))
+	mock.ExpectCommit()
+
+	err := repo.CreateConRonda(context.Background(), &Solicitud{Titulo: "Backend", Estado: EstadoPendiente}, []Aprobacion{
+		{Ronda: 1, Orden: 1, Nivel: "jefe_area", Estado: AprobacionPendiente},
+		{Ronda: 1, Orden: 2, Nivel: "finanzas", Estado: AprobacionPendiente},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_CreateConRonda_Error(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+
+	// Si la ronda no se guarda tampoco queda la solicitud
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `solicitudes`").WillReturnResult(sqlmock.NewResult(9, 1))
+	mock.ExpectExec("INSERT INTO `solicitud_historial`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO `solicitud_aprobaciones`").WillReturnError(assert.AnError)
+	mock.ExpectRollback()
+
+	err := repo.CreateConRonda(context.Background(), &Solicitud{Titulo: "Backend", Estado: EstadoPendiente}, []Aprobacion{
+		{Ronda: 1, Orden: 1, Nivel: "jefe_area", Estado: AprobacionPendiente},
+	})
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_CambiarEstadoConRonda(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `solicitudes` SET `estado`=\\?").
+		WithArgs(EstadoPendiente, sqlmock.AnyArg(), 1, EstadoBorrador).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `solicitud_historial`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO `solicitud_aprobaciones`").WillReturnResult(sqlmock.NewResult(1, 2))
+	mock.ExpectCommit()
+
+	cambiado, err := repo.CambiarEstadoConRonda(context.Background(), 1, EstadoBorrador, EstadoPendiente, "", []Aprobacion{
+		{SolicitudID: 1, Ronda: 2, Orden: 1, Nivel: "jefe_area", Estado: AprobacionPendiente},
+		{SolicitudID: 1, Ronda: 2, Orden: 2, Nivel: "finanzas", Estado: AprobacionPendiente},
+	})
+	assert.NoError(t, err)
+	assert.True(t, cambiado)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_CambiarEstadoConRonda_EstadoDistinto(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+
+	// Si otro proceso cambió el estado no se guarda la ronda
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	cambiado, err := repo.CambiarEstadoConRonda(context.Background(), 1, EstadoBorrador, EstadoPendiente, "", []Aprobacion{
+		{SolicitudID: 1, Ronda: 2, Orden: 1, Nivel: "jefe_area", Estado: AprobacionPendiente},
+	})
+	assert.NoError(t, err)
+	assert.False(t, cambiado)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_CambiarEstadoConRonda_ErrorRonda(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+
+	// Si la ronda no se guarda el estado no cambia
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `solicitud_historial`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO `solicitud_aprobaciones`").WillReturnError(assert.AnError)
+	mock.ExpectRollback()
+
+	cambiado, err := repo.CambiarEstadoConRonda(context.Background(), 1, EstadoBorrador, EstadoPendiente, "", []Aprobacion{
+		{SolicitudID: 1, Ronda: 2, Orden: 1, Nivel: "jefe_area", Estado: AprobacionPendiente},
+	})
+	assert.Error(t, err)
+	assert.False(t, cambiado)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_GetRondaAprobacion(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+
+	rows := sqlmock.NewRows([]string{"id", "solicitud_id", "ronda", "orden", "nivel", "estado"}).
+		AddRow(3, 1, 2, 1, "jefe_area", "aprobada").
+		AddRow(4, 1, 2, 2, "finanzas", "pendiente")
+	mock.ExpectQuery("SELECT \\* FROM `solicitud_aprobaciones` WHERE solicitud_id = \\? AND ronda = \\(SELECT MAX\\(ronda\\)").
+		WithArgs(1, 1).
+		WillReturnRows(rows)
+
+	ronda, err := repo.GetRondaAprobacion(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Len(t, ronda, 2)
+	assert.Equal(t, "finanzas", ronda[1].Nivel)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_DecidirAprobacion(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `solicitud_aprobaciones` SET").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	usuario := uint(7)
+	aprobacion := &Aprobacion{ID: 3, Estado: AprobacionAprobada, UsuarioID: &usuario}
+	decidida, err := repo.DecidirAprobacion(context.Background(), aprobacion)
+	assert.NoError(t, err)
+	assert.True(t, decidida)
+	assert.NotNil(t, aprobacion.DecididaEl)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_DecidirAprobacion_YaDecidida(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+
+	// Ninguna fila coincide si el nivel ya no está pendiente
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `solicitud_aprobaciones` SET").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	decidida, err := repo.DecidirAprobacion(context.Background(), &Aprobacion{ID: 3, Estado: AprobacionRechazada})
+	assert.NoError(t, err)
+	assert.False(t, decidida)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_GetHistorial(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+
+	rows := sqlmock.NewRows([]string{"id", "solicitud_id", "accion", "cambios", "request_id"}).
+		AddRow(2, 1, "actualizacion", `[{"campo":"titulo","anterior":"Original","nuevo":"Updated"}]`, "req-2")
+	mock.ExpectQuery("SELECT \\* FROM `solicitud_historial` WHERE solicitud_id = \\? AND accion = \\? ORDER BY created_at DESC, id DESC LIMIT \\?").
+		WithArgs(1, "actualizacion", 10).
+		WillReturnRows(rows)
+
+	eventos, err := repo.GetHistorial(context.Background(), 1, HistorialReq{Accion: "actualizacion", Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, eventos, 1)
+	assert.Equal(t, "titulo", eventos[0].Cambios[0].Campo)
+	assert.Equal(t, "Original", *eventos[0].Cambios[0].Anterior)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDiferencias(t *testing.T) {
+	usuario := uint(3)
+	antes := &Solicitud{Titulo: "Backend", Estado: "pendiente", RentaHasta: 1000000, UsuarioID: &usuario}
+	despues := &Solicitud{Titulo: "Backend Senior", Estado: "pendiente", RentaHasta: 1500000}
+
+	cambios := diferencias(antes, despues)
+
+	assert.Len(t, cambios, 3)
+	assert.Equal(t, "titulo", cambios[0].Campo)
+	assert.Equal(t, "Backend", *cambios[0].Anterior)
+	assert.Equal(t, "Backend Senior", *cambios[0].Nuevo)
+	assert.Equal(t, "renta_hasta", cambios[1].Campo)
+	assert.Equal(t, "usuario_id", cambios[2].Campo)
+	assert.Nil(t, cambios[2].Nuevo)
+
+	// Al crear se registran solo los campos con valor
+	creacion := diferencias(nil, antes)
+	assert.Len(t, creacion, 4)
+	assert.Nil(t, creacion[0].Anterior)
+}
+
 func TestSolicitud_ToResponse(t *testing.T) {
 	s := &Solicitud{
 		ID:     1,
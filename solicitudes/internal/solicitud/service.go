@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 )
 
@@ -14,25 +15,42 @@ type Service interface {
 	GetByIDWithDocuments(ctx context.Context, id uint) (*SolicitudResponse, error) // New method
 	Update(ctx context.Context, id uint, req UpdateReq) error
 	Delete(ctx context.Context, id uint) error
+	GetChecklistDocumentos(ctx context.Context, id uint) (*ChecklistDocumentos, error)
+	GenerarFicha(ctx context.Context, id uint) ([]byte, error)
+	GuardarFicha(ctx context.Context, id uint) (*DocumentoResponse, error)
+	Transicionar(ctx context.Context, id uint, req TransicionReq) (*SolicitudResponse, error)
+	GetAprobaciones(ctx context.Context, id uint) (*EstadoAprobacion, error)
+	Aprobar(ctx context.Context, id uint, nivel string, req DecisionReq) (*EstadoAprobacion, error)
+	Rechazar(ctx context.Context, id uint, nivel string, req DecisionReq) (*EstadoAprobacion, error)
+	GetHistorial(ctx context.Context, id uint, filtros HistorialReq) ([]EventoHistorial, error)
 }
 
 // DocumentoClient define la interfaz para el cliente de documentos
 type DocumentoClient interface {
 	GetBySolicitudID(solicitudID uint) ([]Documento, error)
 	DeleteBySolicitudID(solicitudID uint) error
+	SubirDocumento(solicitudID uint, nombreArchivo string, contenido []byte) (*Documento, error)
 }
 
 type service struct {
 	repo            Repository
 	logger          *log.Logger
 	documentoClient DocumentoClient
+	reglas          ReglasDocumentos
+	ficha           GeneradorFicha
+	cadenas         CadenasAprobacion
+	aprobadores     AprobadoresPorNivel
 }
 
-func NewService(repo Repository, logger *log.Logger, docClient DocumentoClient) Service {
+func NewService(repo Repository, logger *log.Logger, docClient DocumentoClient, reglas ReglasDocumentos, ficha GeneradorFicha, cadenas CadenasAprobacion, aprobadores AprobadoresPorNivel) Service {
 	return &service{
 		repo:            repo,
 		logger:          logger,
 		documentoClient: docClient,
+		reglas:          reglas,
+		ficha:           ficha,
+		cadenas:         cadenas,
+		aprobadores:     aprobadores,
 	}
 }
 
@@ -41,9 +59,6 @@ func validateCreateRequest(req CreateReq) error {
 	if req.Titulo == "" {
 		return fmt.Errorf("el título es requerido")
 	}
-	if req.Estado == "" {
-		req.Estado = "pendiente" // Valor por defecto
-	}
 	if req.Area == "" {
 		return fmt.Errorf("el área es requerida")
 	}
@@ -89,9 +104,13 @@ func (s *service) Create(ctx context.Context, req CreateReq) (*Solicitud, error)
 	}
 
 	// Establecer valor por defecto para Estado si está vacío
-	estado := req.Estado
+	estado := normalizarEstado(req.Estado)
 	if estado == "" {
-		estado = "pendiente"
+		estado = EstadoBorrador
+	}
+	if err := validarEstadoInicial(estado); err != nil {
+		s.logger.Printf("Validación fallida: %v", err)
+		return nil, err
 	}
 
 	solicitud := &Solicitud{
@@ -113,12 +132,33 @@ func (s *service) Create(ctx context.Context, req CreateReq) (*Solicitud, error)
 		UsuarioID:                req.UsuarioID,
 	}
 
-	if err := s.repo.Create(ctx, solicitud); err != nil {
+	// Crear directamente en pendiente exige los mismos datos que la transición desde borrador
+	if estado == EstadoPendiente {
+		if err := validarDatosCompletos(solicitud); err != nil {
+			s.logger.Printf("Validación fallida: %v", err)
+			return nil, err
+		}
+	}
+
+	// Una solicitud creada pendiente de aprobación se guarda junto con su primera ronda
+	var ronda []Aprobacion
+	if estado == EstadoPendiente {
+		ronda = nuevaRonda(0, s.cadenas.Cadena(solicitud), nil)
+	}
+	if len(ronda) > 0 {
+		err = s.repo.CreateConRonda(ctx, solicitud, ronda)
+	} else {
+		err = s.repo.Create(ctx, solicitud)
+	}
+	if err != nil {
 		s.logger.Printf("Error al crear la solicitud: %v", err)
 		return nil, err
 	}
 
 	s.logger.Printf("Solicitud creada exitosamente: ID=%d para Usuario ID=%d", solicitud.ID, solicitud.UsuarioID)
+	if len(ronda) > 0 {
+		s.logRonda(solicitud.ID, ronda)
+	}
 	return solicitud, nil
 }
 
@@ -149,6 +189,8 @@ func (s *service) GetAll(ctx context.Context, filter GetAllReq) ([]SolicitudResp
 					ID:            doc.ID,
 					NombreArchivo: doc.NombreArchivo,
 					Extension:     doc.Extension,
+					Categoria:     doc.Categoria,
+					URLMiniatura:  doc.URLMiniatura,
 				}
 			}
 			responses[i].Documentos = docResponses
@@ -195,6 +237,8 @@ func (s *service) GetByIDWithDocuments(ctx context.Context, id uint) (*Solicitud
 				ID:            doc.ID,
 				NombreArchivo: doc.NombreArchivo,
 				Extension:     doc.Extension,
+				Categoria:     doc.Categoria,
+				URLMiniatura:  doc.URLMiniatura,
 			}
 		}
 	}
@@ -204,11 +248,15 @@ func (s *service) GetByIDWithDocuments(ctx context.Context, id uint) (*Solicitud
 
 func (s *service) Update(ctx context.Context, id uint, req UpdateReq) error {
 	// Verificar que la solicitud exista antes de actualizar
-	_, err := s.repo.GetByID(ctx, id)
+	solicitud, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		s.logger.Printf("Error al buscar solicitud ID=%d: %v", id, err)
 		return fmt.Errorf("solicitud no encontrada")
 	}
+	if err := validarEdicion(solicitud, req); err != nil {
+		s.logger.Printf("Actualización rechazada para la solicitud ID=%d: %v", id, err)
+		return err
+	}
 
 	if err := s.repo.Update(ctx, id, req); err != nil {
 		s.logger.Printf("Error al actualizar la solicitud ID=%d: %v", id, err)
@@ -244,3 +292,340 @@ func (s *service) Delete(ctx context.Context, id uint) error {
 	s.logger.Printf("Solicitud eliminada exitosamente: ID=%d", id)
 	return nil
 }
+
+// GetChecklistDocumentos reporta las categorías de documento obligatorias para el tipo de servicio
+// de la solicitud y cuáles de ellas aún no tienen un documento
+func (s *service) GetChecklistDocumentos(ctx context.Context, id uint) (*ChecklistDocumentos, error) {
+	solicitud, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Printf("Error al obtener solicitud ID=%d: %v", id, err)
+		return nil, fmt.Errorf("error al obtener la solicitud: %v", err)
+	}
+
+	return s.checklist(solicitud)
+}
+
+func (s *service) checklist(solicitud *Solicitud) (*ChecklistDocumentos, error) {
+	obligatorias := s.reglas.Obligatorias(solicitud.TipoServicio)
+	var documentos []Documento
+	if len(obligatorias) > 0 {
+		// A diferencia de la consulta con documentos, sin ellos no se puede informar qué falta
+		var err error
+		documentos, err = s.documentoClient.GetBySolicitudID(solicitud.ID)
+		if err != nil {
+			s.logger.Printf("Error al obtener documentos para solicitud ID=%d: %v", solicitud.ID, err)
+			return nil, ErrDocumentosNoDisponibles
+		}
+	}
+
+	return armarChecklist(solicitud, obligatorias, documentos), nil
+}
+
+// GenerarFicha genera la ficha de publicación en PDF de la solicitud
+func (s *service) GenerarFicha(ctx context.Context, id uint) ([]byte, error) {
+	solicitud, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Printf("Error al obtener solicitud ID=%d: %v", id, err)
+		return nil, fmt.Errorf("error al obtener la solicitud: %v", err)
+	}
+
+	pdf, err := s.ficha.Generar(solicitud.ToResponse())
+	if err != nil {
+		s.logger.Printf("Error al generar la ficha de la solicitud ID=%d: %v", id, err)
+		return nil, fmt.Errorf("%w: %v", ErrFichaNoGenerada, err)
+	}
+	return pdf, nil
+}
+
+// GuardarFicha genera la ficha y la sube como un documento de la solicitud
+func (s *service) GuardarFicha(ctx context.Context, id uint) (*DocumentoResponse, error) {
+	pdf, err := s.GenerarFicha(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	documento, err := s.documentoClient.SubirDocumento(id, NombreFicha(id), pdf)
+	if err != nil {
+		s.logger.Printf("Error al guardar la ficha de la solicitud ID=%d: %v", id, err)
+		return nil, fmt.Errorf("%w: %v", ErrFichaNoGuardada, err)
+	}
+
+	s.logger.Printf("Ficha de la solicitud ID=%d guardada como documento ID=%d", id, documento.ID)
+	return &DocumentoResponse{
+		ID:            documento.ID,
+		NombreArchivo: documento.NombreArchivo,
+		Extension:     documento.Extension,
+		Categoria:     documento.Categoria,
+		URLMiniatura:  documento.URLMiniatura,
+	}, nil
+}
+
+// Transicionar cambia el estado de la solicitud si el ciclo de vida lo permite y la solicitud
+// cumple los requisitos del nuevo estado
+func (s *service) Transicionar(ctx context.Context, id uint, req TransicionReq) (*SolicitudResponse, error) {
+	solicitud, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Printf("Error al obtener solicitud ID=%d: %v", id, err)
+		return nil, fmt.Errorf("%w: %v", ErrSolicitudNoEncontrada, err)
+	}
+
+	return s.transicionar(ctx, solicitud, req)
+}
+
+func (s *service) transicionar(ctx context.Context, solicitud *Solicitud, req TransicionReq) (*SolicitudResponse, error) {
+	id := solicitud.ID
+	desde := normalizarEstado(solicitud.Estado)
+	hacia := normalizarEstado(req.Estado)
+	if err := validarTransicion(desde, hacia); err != nil {
+		s.logger.Printf("Transición rechazada para la solicitud ID=%d: %v", id, err)
+		return nil, err
+	}
+	if err := s.verificarRequisitos(ctx, solicitud, hacia, req); err != nil {
+		s.logger.Printf("Transición rechazada para la solicitud ID=%d: %v", id, err)
+		return nil, err
+	}
+
+	// Cada envío a aprobación inicia una ronda nueva, que se guarda junto con el cambio de estado
+	var ronda []Aprobacion
+	if hacia == EstadoPendiente {
+		if cadena := s.cadenas.Cadena(solicitud); len(cadena) > 0 {
+			anterior, err := s.rondaActual(ctx, solicitud)
+			if err != nil {
+				return nil, err
+			}
+			ronda = nuevaRonda(id, cadena, anterior)
+		}
+	}
+
+	// Se compara con el estado leído para no pisar un cambio concurrente
+	var cambiado bool
+	var err error
+	if len(ronda) > 0 {
+		cambiado, err = s.repo.CambiarEstadoConRonda(ctx, id, solicitud.Estado, hacia, req.Motivo, ronda)
+	} else {
+		cambiado, err = s.repo.CambiarEstado(ctx, id, solicitud.Estado, hacia, req.Motivo)
+	}
+	if err != nil {
+		s.logger.Printf("Error al cambiar el estado de la solicitud ID=%d: %v", id, err)
+		return nil, err
+	}
+	if !cambiado {
+		return nil, fmt.Errorf("%w: el estado de la solicitud cambió mientras se procesaba la petición", ErrTransicionNoPermitida)
+	}
+
+	if req.Motivo != "" {
+		s.logger.Printf("Solicitud ID=%d pasó de %s a %s. Motivo: %s", id, desde, hacia, req.Motivo)
+	} else {
+		s.logger.Printf("Solicitud ID=%d pasó de %s a %s", id, desde, hacia)
+	}
+	if len(ronda) > 0 {
+		s.logRonda(id, ronda)
+	}
+	solicitud.Estado = hacia
+	response := solicitud.ToResponse()
+	response.Documentos = []DocumentoResponse{}
+	return &response, nil
+}
+
+// verificarRequisitos aplica las condiciones que exige cada estado además de la transición permitida
+func (s *service) verificarRequisitos(ctx context.Context, solicitud *Solicitud, hacia string, req TransicionReq) error {
+	switch hacia {
+	case EstadoPendiente:
+		return validarDatosCompletos(solicitud)
+	case EstadoAprobada:
+		return s.verificarAprobaciones(ctx, solicitud)
+	case EstadoPublicada:
+		// La cadena se vuelve a resolver con los datos actuales por si cambiaron después de aprobarse
+		if err := s.verificarAprobaciones(ctx, solicitud); err != nil {
+			return err
+		}
+		// Solo se publica una vacante con todos sus documentos obligatorios
+		checklist, err := s.checklist(solicitud)
+		if err != nil {
+			return err
+		}
+		if !checklist.Completo {
+			return fmt.Errorf("%w: faltan documentos de las categorías %s", ErrRequisitosTransicion, strings.Join(checklist.Faltantes, ", "))
+		}
+	case EstadoCancelada:
+		if strings.TrimSpace(req.Motivo) == "" {
+			return fmt.Errorf("%w: indique el motivo de la cancelación", ErrRequisitosTransicion)
+		}
+	}
+	return nil
+}
+
+// verificarAprobaciones exige que todos los niveles de la cadena vigente hayan aprobado la solicitud
+func (s *service) verificarAprobaciones(ctx context.Context, solicitud *Solicitud) error {
+	cadena := s.cadenas.Cadena(solicitud)
+	if len(cadena) == 0 {
+		return nil
+	}
+
+	ronda, err := s.rondaActual(ctx, solicitud)
+	if err != nil {
+		return err
+	}
+	if !mismosNiveles(ronda, cadena) {
+		return fmt.Errorf("%w: la solicitud requiere la aprobación de %s, devuélvala a borrador y envíela de nuevo a aprobación", ErrRequisitosTransicion, strings.Join(cadena, ", "))
+	}
+	switch estado, _ := resumirRonda(ronda); estado {
+	case AprobacionRechazada:
+		return fmt.Errorf("%w: la cadena de aprobación rechazó la solicitud", ErrRequisitosTransicion)
+	case AprobacionPendiente:
+		return fmt.Errorf("%w: falta la aprobación de %s", ErrRequisitosTransicion, strings.Join(nivelesPendientes(ronda), ", "))
+	}
+	return nil
+}
+
+// rondaActual obtiene la última ronda de aprobación de la solicitud
+func (s *service) rondaActual(ctx context.Context, solicitud *Solicitud) ([]Aprobacion, error) {
+	ronda, err := s.repo.GetRondaAprobacion(ctx, solicitud.ID)
+	if err != nil {
+		s.logger.Printf("Error al obtener la aprobación de la solicitud ID=%d: %v", solicitud.ID, err)
+		return nil, err
+	}
+	return ronda, nil
+}
+
+// logRonda registra el inicio de una ronda de aprobación
+func (s *service) logRonda(solicitudID uint, ronda []Aprobacion) {
+	niveles := make([]string, len(ronda))
+	for i, aprobacion := range ronda {
+		niveles[i] = aprobacion.Nivel
+	}
+	s.logger.Printf("Solicitud ID=%d enviada a aprobación (ronda %d): %s", solicitudID, ronda[0].Ronda, strings.Join(niveles, ", "))
+}
+
+// GetAprobaciones reporta la ronda de aprobación actual de la solicitud, o los niveles que
+// deberán aprobarla si aún no se envía a aprobación
+func (s *service) GetAprobaciones(ctx context.Context, id uint) (*EstadoAprobacion, error) {
+	solicitud, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Printf("Error al obtener solicitud ID=%d: %v", id, err)
+		return nil, fmt.Errorf("%w: %v", ErrSolicitudNoEncontrada, err)
+	}
+
+	ronda, err := s.rondaActual(ctx, solicitud)
+	if err != nil {
+		return nil, err
+	}
+
+	return armarEstadoAprobacion(solicitud, s.cadenas.Cadena(solicitud), ronda), nil
+}
+
+// Aprobar registra la aprobación del nivel y, si era el último de la cadena, aprueba la solicitud
+func (s *service) Aprobar(ctx context.Context, id uint, nivel string, req DecisionReq) (*EstadoAprobacion, error) {
+	return s.decidir(ctx, id, nivel, AprobacionAprobada, req)
+}
+
+// Rechazar registra el rechazo del nivel y devuelve la solicitud a borrador para que se corrija
+func (s *service) Rechazar(ctx context.Context, id uint, nivel string, req DecisionReq) (*EstadoAprobacion, error) {
+	return s.decidir(ctx, id, nivel, AprobacionRechazada, req)
+}
+
+// decidir aplica la decisión del nivel al que le corresponde decidir en la ronda actual
+func (s *service) decidir(ctx context.Context, id uint, nivel, decision string, req DecisionReq) (*EstadoAprobacion, error) {
+	if err := validarDecision(decision, req); err != nil {
+		return nil, err
+	}
+
+	solicitud, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Printf("Error al obtener solicitud ID=%d: %v", id, err)
+		return nil, fmt.Errorf("%w: %v", ErrSolicitudNoEncontrada, err)
+	}
+	if normalizarEstado(solicitud.Estado) != EstadoPendiente {
+		return nil, fmt.Errorf("%w: la solicitud está %s y no espera aprobación", ErrAprobacionNoPermitida, solicitud.Estado)
+	}
+	cadena := s.cadenas.Cadena(solicitud)
+	if len(cadena) == 0 {
+		return nil, fmt.Errorf("%w: la solicitud no requiere aprobaciones", ErrAprobacionNoPermitida)
+	}
+
+	ronda, err := s.rondaActual(ctx, solicitud)
+	if err != nil {
+		return nil, err
+	}
+	// Sin ronda, o con la de una cadena que ya no corresponde, debe enviarse de nuevo a aprobación
+	if !mismosNiveles(ronda, cadena) {
+		return nil, fmt.Errorf("%w: la solicitud no tiene una ronda de aprobación de %s, devuélvala a borrador y envíela de nuevo", ErrAprobacionNoPermitida, strings.Join(cadena, ", "))
+	}
+	estado, actual := resumirRonda(ronda)
+	nivel = strings.ToLower(strings.TrimSpace(nivel))
+	if actual == nil {
+		return nil, fmt.Errorf("%w: la ronda de aprobación ya está %s", ErrAprobacionNoPermitida, estado)
+	}
+	if actual.Nivel != nivel {
+		return nil, fmt.Errorf("%w: corresponde decidir al nivel %s", ErrAprobacionNoPermitida, actual.Nivel)
+	}
+	if err := s.verificarAprobador(solicitud, nivel, *req.UsuarioID); err != nil {
+		s.logger.Printf("Decisión de %s rechazada para la solicitud ID=%d: %v", nivel, id, err)
+		return nil, err
+	}
+
+	actual.Estado = decision
+	actual.UsuarioID = req.UsuarioID
+	actual.Comentario = strings.TrimSpace(req.Comentario)
+	decidida, err := s.repo.DecidirAprobacion(ctx, actual)
+	if err != nil {
+		s.logger.Printf("Error al registrar la decisión de %s para la solicitud ID=%d: %v", nivel, id, err)
+		return nil, err
+	}
+	if !decidida {
+		return nil, fmt.Errorf("%w: el nivel %s ya fue decidido", ErrAprobacionNoPermitida, nivel)
+	}
+	s.logger.Printf("Nivel %s %s la solicitud ID=%d (ronda %d)", nivel, decision, id, actual.Ronda)
+
+	// El cambio de estado automático queda en el historial a nombre de quien decide
+	if actor := actorDe(ctx); actor.UsuarioID == nil {
+		actor.UsuarioID = req.UsuarioID
+		ctx = ConActor(ctx, actor)
+	}
+
+	// El cambio de estado automático puede fallar si la solicitud cambió entretanto; la decisión
+	// queda registrada y el estado se puede cambiar con una transición
+	switch estado, _ := resumirRonda(ronda); estado {
+	case AprobacionAprobada:
+		if _, err := s.transicionar(ctx, solicitud, TransicionReq{Estado: EstadoAprobada, Motivo: "cadena de aprobación completa"}); err != nil {
+			s.logger.Printf("Advertencia: No se pudo aprobar la solicitud ID=%d: %v", id, err)
+		}
+	case AprobacionRechazada:
+		motivo := fmt.Sprintf("rechazada por %s: %s", nivel, actual.Comentario)
+		if _, err := s.transicionar(ctx, solicitud, TransicionReq{Estado: EstadoBorrador, Motivo: motivo}); err != nil {
+			s.logger.Printf("Advertencia: No se pudo devolver a borrador la solicitud ID=%d: %v", id, err)
+		}
+	}
+
+	return armarEstadoAprobacion(solicitud, s.cadenas.Cadena(solicitud), ronda), nil
+}
+
+// verificarAprobador impide que quien creó la solicitud la apruebe o rechace, y que decida
+// un nivel quien no está entre sus aprobadores configurados
+func (s *service) verificarAprobador(solicitud *Solicitud, nivel string, usuarioID uint) error {
+	if solicitud.UsuarioID != nil && *solicitud.UsuarioID == usuarioID {
+		return fmt.Errorf("%w: quien crea la solicitud no puede decidir su aprobación", ErrAprobadorNoAutorizado)
+	}
+	if !s.aprobadores.Autorizado(nivel, usuarioID) {
+		return fmt.Errorf("%w: el usuario %d no es aprobador del nivel %s", ErrAprobadorNoAutorizado, usuarioID, nivel)
+	}
+	return nil
+}
+
+// GetHistorial retorna los cambios registrados de la solicitud, del más reciente al más antiguo
+func (s *service) GetHistorial(ctx context.Context, id uint, filtros HistorialReq) ([]EventoHistorial, error) {
+	eventos, err := s.repo.GetHistorial(ctx, id, filtros)
+	if err != nil {
+		s.logger.Printf("Error al obtener el historial de la solicitud ID=%d: %v", id, err)
+		return nil, err
+	}
+
+	// Sin eventos, se distingue una solicitud inexistente de una anterior al historial
+	if len(eventos) == 0 {
+		if _, err := s.repo.GetByID(ctx, id); err != nil {
+			s.logger.Printf("Error al obtener solicitud ID=%d: %v", id, err)
+			return nil, fmt.Errorf("%w: %v", ErrSolicitudNoEncontrada, err)
+		}
+	}
+	return eventos, nil
+}
@@ -29,6 +29,14 @@ func (m *mockDocumentoClient) DeleteBySolicitudID(solicitudID uint) error {
 	return args.Error(0)
 }
 
+func (m *mockDocumentoClient) SubirDocumento(solicitudID uint, nombreArchivo string, contenido []byte) (*Documento, error) {
+	args := m.Called(solicitudID, nombreArchivo, contenido)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Documento), args.Error(1)
+}
+
 func TestService_GetAll(t *testing.T) {
 	ctx := context.Background()
 	logger := log.New(os.Stdout, "TEST: ", log.LstdFlags)
@@ -42,7 +50,7 @@ func TestService_GetAll(t *testing.T) {
 		repo.On("GetAll", ctx, mock.AnythingOfType("solicitud.GetAllReq")).
 			Return(nil, expectedError)
 
-		service := NewService(repo, logger, docClient)
+		service := NewService(repo, logger, docClient, nil, nil, nil, nil)
 
 		// Act
 		result, err := service.GetAll(ctx, GetAllReq{})
@@ -72,7 +80,7 @@ func TestService_GetAll(t *testing.T) {
 			Return([]Solicitud{testSolicitud}, nil)
 		docClient.On("GetBySolicitudID", uint(1)).Return(documentos, nil)
 
-		service := NewService(repo, logger, docClient)
+		service := NewService(repo, logger, docClient, nil, nil, nil, nil)
 
 		// Act
 		result, err := service.GetAll(ctx, GetAllReq{})
@@ -124,7 +132,7 @@ func TestService_Create(t *testing.T) {
 				s.ID = 1
 			})
 
-		service := NewService(repo, logger, docClient)
+		service := NewService(repo, logger, docClient, nil, nil, nil, nil)
 
 		// Act
 		result, err := service.Create(ctx, validRequest)
@@ -148,7 +156,7 @@ func TestService_Create(t *testing.T) {
 		expectedError := errors.New("error de base de datos")
 		repo.On("Create", ctx, mock.AnythingOfType("*solicitud.Solicitud")).Return(expectedError)
 
-		service := NewService(repo, logger, docClient)
+		service := NewService(repo, logger, docClient, nil, nil, nil, nil)
 
 		// Act
 		result, err := service.Create(ctx, validRequest)
@@ -276,7 +284,7 @@ func TestService_Create(t *testing.T) {
 				// Arrange
 				repo := new(mockRepository)
 				docClient := new(mockDocumentoClient)
-				service := NewService(repo, logger, docClient)
+				service := NewService(repo, logger, docClient, nil, nil, nil, nil)
 
 				// Configurar mocks si es necesario
 				if tt.setupMocks != nil {
@@ -319,10 +327,10 @@ func TestService_Create(t *testing.T) {
 				s := args.Get(1).(*Solicitud)
 				s.ID = 1
 				// Verificar que el estado se haya establecido correctamente
-				assert.Equal(t, "pendiente", s.Estado, "El estado debería tener el valor por defecto 'pendiente'")
+				assert.Equal(t, "borrador", s.Estado, "El estado debería tener el valor por defecto 'borrador'")
 			})
 
-		service := NewService(repo, logger, docClient)
+		service := NewService(repo, logger, docClient, nil, nil, nil, nil)
 
 		// Act
 		result, err := service.Create(ctx, req)
@@ -330,9 +338,29 @@ func TestService_Create(t *testing.T) {
 		// Assert
 		assert.NoError(t, err, "No debería haber error al crear la solicitud")
 		assert.NotNil(t, result, "El resultado no debería ser nulo")
-		assert.Equal(t, "pendiente", result.Estado, "El estado debería ser 'pendiente'")
+		assert.Equal(t, "borrador", result.Estado, "El estado debería ser 'borrador'")
 		repo.AssertExpectations(t)
 	})
+
+	t.Run("debe exigir los datos completos al crear en pendiente", func(t *testing.T) {
+		// Arrange
+		req := validRequest
+		req.Estado = "pendiente"
+		req.Descripcion = ""
+		req.NumeroVacantes = 0
+
+		repo := new(mockRepository)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, nil, nil)
+
+		// Act
+		result, err := service.Create(ctx, req)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrRequisitosTransicion)
+		assert.Contains(t, err.Error(), "descripcion, numero_vacantes")
+		assert.Nil(t, result)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
 }
 
 func TestService_GetByID(t *testing.T) {
@@ -352,7 +380,7 @@ func TestService_GetByID(t *testing.T) {
 
 		repo.On("GetByID", ctx, uint(1)).Return(testSolicitud, nil)
 
-		service := NewService(repo, logger, docClient)
+		service := NewService(repo, logger, docClient, nil, nil, nil, nil)
 
 		// Act
 		result, err := service.GetByID(ctx, 1)
@@ -374,7 +402,7 @@ func TestService_GetByID(t *testing.T) {
 		expectedError := errors.New("solicitud no encontrada")
 		repo.On("GetByID", ctx, uint(999)).Return(nil, expectedError)
 
-		service := NewService(repo, logger, docClient)
+		service := NewService(repo, logger, docClient, nil, nil, nil, nil)
 
 		// Act
 		result, err := service.GetByID(ctx, 999)
@@ -400,7 +428,7 @@ func TestService_GetByIDWithDocuments(t *testing.T) {
 
 		documentos := []Documento{
 			{ID: 1, NombreArchivo: "doc1.pdf", Extension: "pdf"},
-			{ID: 2, NombreArchivo: "doc2.jpg", Extension: "jpg"},
+			{ID: 2, NombreArchivo: "doc2.jpg", Extension: "jpg", URLMiniatura: "http://localhost:8083/documentos/2/miniatura?v=1"},
 		}
 
 		repo := new(mockRepository)
@@ -409,7 +437,7 @@ func TestService_GetByIDWithDocuments(t *testing.T) {
 		repo.On("GetByID", ctx, uint(1)).Return(testSolicitud, nil)
 		docClient.On("GetBySolicitudID", uint(1)).Return(documentos, nil)
 
-		service := NewService(repo, logger, docClient)
+		service := NewService(repo, logger, docClient, nil, nil, nil, nil)
 
 		// Act
 		result, err := service.GetByIDWithDocuments(ctx, 1)
@@ -422,6 +450,8 @@ func TestService_GetByIDWithDocuments(t *testing.T) {
 		assert.Len(t, result.Documentos, 2)
 		assert.Equal(t, "doc1.pdf", result.Documentos[0].NombreArchivo)
 		assert.Equal(t, "doc2.jpg", result.Documentos[1].NombreArchivo)
+		assert.Empty(t, result.Documentos[0].URLMiniatura)
+		assert.Equal(t, "http://localhost:8083/documentos/2/miniatura?v=1", result.Documentos[1].URLMiniatura)
 		repo.AssertExpectations(t)
 		docClient.AssertExpectations(t)
 	})
@@ -440,7 +470,7 @@ func TestService_GetByIDWithDocuments(t *testing.T) {
 		repo.On("GetByID", ctx, uint(1)).Return(testSolicitud, nil)
 		docClient.On("GetBySolicitudID", uint(1)).Return(nil, errors.New("error cliente documentos"))
 
-		service := NewService(repo, logger, docClient)
+		service := NewService(repo, logger, docClient, nil, nil, nil, nil)
 
 		// Act
 		result, err := service.GetByIDWithDocuments(ctx, 1)
@@ -462,7 +492,7 @@ func TestService_GetByIDWithDocuments(t *testing.T) {
 		expectedError := errors.New("solicitud no encontrada")
 		repo.On("GetByID", ctx, uint(999)).Return(nil, expectedError)
 
-		service := NewService(repo, logger, docClient)
+		service := NewService(repo, logger, docClient, nil, nil, nil, nil)
 
 		// Act
 		result, err := service.GetByIDWithDocuments(ctx, 999)
@@ -474,6 +504,258 @@ func TestService_GetByIDWithDocuments(t *testing.T) {
 	})
 }
 
+func TestService_GetChecklistDocumentos(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(os.Stdout, "TEST: ", log.LstdFlags)
+	reglas := ReglasDocumentos{
+		TodosLosTipos: {"descripcion_cargo", "aprobacion_presupuesto"},
+		"outsourcing":  {"nda"},
+	}
+
+	t.Run("debe informar las categorías faltantes según el tipo de servicio", func(t *testing.T) {
+		// Arrange
+		testSolicitud := &Solicitud{ID: 1, Titulo: "Test", TipoServicio: "Outsourcing"}
+		documentos := []Documento{
+			{ID: 10, NombreArchivo: "cargo", Extension: "pdf", Categoria: "descripcion_cargo"},
+			{ID: 11, NombreArchivo: "cargo_v2", Extension: "docx", Categoria: "descripcion_cargo"},
+			{ID: 12, NombreArchivo: "foto", Extension: "png"},
+		}
+
+		repo := new(mockRepository)
+		docClient := new(mockDocumentoClient)
+
+		repo.On("GetByID", ctx, uint(1)).Return(testSolicitud, nil)
+		docClient.On("GetBySolicitudID", uint(1)).Return(documentos, nil)
+
+		service := NewService(repo, logger, docClient, reglas, nil, nil, nil)
+
+		// Act
+		result, err := service.GetChecklistDocumentos(ctx, 1)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.False(t, result.Completo)
+		assert.Len(t, result.Items, 3)
+		assert.Equal(t, "descripcion_cargo", result.Items[0].Categoria)
+		assert.True(t, result.Items[0].Presente)
+		assert.Equal(t, []uint{10, 11}, result.Items[0].Documentos)
+		assert.Equal(t, "nda", result.Items[2].Categoria)
+		assert.Equal(t, []string{"aprobacion_presupuesto", "nda"}, result.Faltantes)
+		repo.AssertExpectations(t)
+		docClient.AssertExpectations(t)
+	})
+
+	t.Run("debe estar completa cuando tiene todas las categorías obligatorias", func(t *testing.T) {
+		// Arrange
+		testSolicitud := &Solicitud{ID: 2, Titulo: "Test", TipoServicio: "consultoria"}
+		documentos := []Documento{
+			{ID: 20, Categoria: "descripcion_cargo"},
+			{ID: 21, Categoria: "aprobacion_presupuesto"},
+		}
+
+		repo := new(mockRepository)
+		docClient := new(mockDocumentoClient)
+
+		repo.On("GetByID", ctx, uint(2)).Return(testSolicitud, nil)
+		docClient.On("GetBySolicitudID", uint(2)).Return(documentos, nil)
+
+		service := NewService(repo, logger, docClient, reglas, nil, nil, nil)
+
+		// Act
+		result, err := service.GetChecklistDocumentos(ctx, 2)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.True(t, result.Completo)
+		assert.Len(t, result.Items, 2)
+		assert.Empty(t, result.Faltantes)
+	})
+
+	t.Run("no debe consultar documentos cuando no hay categorías obligatorias", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		docClient := new(mockDocumentoClient)
+
+		repo.On("GetByID", ctx, uint(3)).Return(&Solicitud{ID: 3, TipoServicio: "consultoria"}, nil)
+
+		service := NewService(repo, logger, docClient, nil, nil, nil, nil)
+
+		// Act
+		result, err := service.GetChecklistDocumentos(ctx, 3)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.True(t, result.Completo)
+		assert.Empty(t, result.Items)
+		docClient.AssertNotCalled(t, "GetBySolicitudID", mock.Anything)
+	})
+
+	t.Run("debe retornar error cuando falla el cliente de documentos", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		docClient := new(mockDocumentoClient)
+
+		repo.On("GetByID", ctx, uint(1)).Return(&Solicitud{ID: 1, TipoServicio: "outsourcing"}, nil)
+		docClient.On("GetBySolicitudID", uint(1)).Return(nil, errors.New("error cliente documentos"))
+
+		service := NewService(repo, logger, docClient, reglas, nil, nil, nil)
+
+		// Act
+		result, err := service.GetChecklistDocumentos(ctx, 1)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrDocumentosNoDisponibles)
+		assert.Nil(t, result)
+	})
+
+	t.Run("debe retornar error cuando no encuentra solicitud", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		docClient := new(mockDocumentoClient)
+
+		repo.On("GetByID", ctx, uint(999)).Return(nil, errors.New("solicitud no encontrada"))
+
+		service := NewService(repo, logger, docClient, reglas, nil, nil, nil)
+
+		// Act
+		result, err := service.GetChecklistDocumentos(ctx, 999)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestService_GenerarFicha(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(os.Stdout, "TEST: ", log.LstdFlags)
+
+	t.Run("debe generar la ficha con los datos de la solicitud", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		generador := new(mockGeneradorFicha)
+
+		repo.On("GetByID", ctx, uint(1)).Return(&Solicitud{ID: 1, Titulo: "QA Analyst", RentaDesde: 900000}, nil)
+		generador.On("Generar", mock.MatchedBy(func(s SolicitudResponse) bool {
+			return s.Titulo == "QA Analyst" && s.RentaDesde == 900000
+		})).Return([]byte("%PDF"), nil)
+
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, generador, nil, nil)
+
+		// Act
+		pdf, err := service.GenerarFicha(ctx, 1)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("%PDF"), pdf)
+		generador.AssertExpectations(t)
+	})
+
+	t.Run("debe retornar error cuando no encuentra solicitud", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		generador := new(mockGeneradorFicha)
+
+		repo.On("GetByID", ctx, uint(999)).Return(nil, errors.New("solicitud no encontrada"))
+
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, generador, nil, nil)
+
+		// Act
+		pdf, err := service.GenerarFicha(ctx, 999)
+
+		// Assert
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrFichaNoGenerada)
+		assert.Nil(t, pdf)
+		generador.AssertNotCalled(t, "Generar", mock.Anything)
+	})
+
+	t.Run("debe retornar error cuando falla la plantilla", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		generador := new(mockGeneradorFicha)
+
+		repo.On("GetByID", ctx, uint(1)).Return(&Solicitud{ID: 1}, nil)
+		generador.On("Generar", mock.Anything).Return(nil, errors.New("plantilla inválida"))
+
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, generador, nil, nil)
+
+		// Act
+		pdf, err := service.GenerarFicha(ctx, 1)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrFichaNoGenerada)
+		assert.Nil(t, pdf)
+	})
+}
+
+func TestService_GuardarFicha(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(os.Stdout, "TEST: ", log.LstdFlags)
+
+	t.Run("debe subir la ficha como documento de la solicitud", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		docClient := new(mockDocumentoClient)
+		generador := new(mockGeneradorFicha)
+
+		repo.On("GetByID", ctx, uint(4)).Return(&Solicitud{ID: 4}, nil)
+		generador.On("Generar", mock.Anything).Return([]byte("%PDF"), nil)
+		docClient.On("SubirDocumento", uint(4), "ficha-solicitud-4.pdf", []byte("%PDF")).
+			Return(&Documento{ID: 15, NombreArchivo: "ficha-solicitud-4", Extension: "pdf"}, nil)
+
+		service := NewService(repo, logger, docClient, nil, generador, nil, nil)
+
+		// Act
+		documento, err := service.GuardarFicha(ctx, 4)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, uint(15), documento.ID)
+		assert.Equal(t, "ficha-solicitud-4", documento.NombreArchivo)
+		docClient.AssertExpectations(t)
+	})
+
+	t.Run("debe retornar error cuando falla el cliente de documentos", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		docClient := new(mockDocumentoClient)
+		generador := new(mockGeneradorFicha)
+
+		repo.On("GetByID", ctx, uint(4)).Return(&Solicitud{ID: 4}, nil)
+		generador.On("Generar", mock.Anything).Return([]byte("%PDF"), nil)
+		docClient.On("SubirDocumento", uint(4), mock.Anything, mock.Anything).Return(nil, errors.New("status 415"))
+
+		service := NewService(repo, logger, docClient, nil, generador, nil, nil)
+
+		// Act
+		documento, err := service.GuardarFicha(ctx, 4)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrFichaNoGuardada)
+		assert.Nil(t, documento)
+	})
+
+	t.Run("no debe subir nada cuando no encuentra solicitud", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		docClient := new(mockDocumentoClient)
+
+		repo.On("GetByID", ctx, uint(999)).Return(nil, errors.New("solicitud no encontrada"))
+
+		service := NewService(repo, logger, docClient, nil, new(mockGeneradorFicha), nil, nil)
+
+		// Act
+		documento, err := service.GuardarFicha(ctx, 999)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, documento)
+		docClient.AssertNotCalled(t, "SubirDocumento", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
 func TestService_Update(t *testing.T) {
 	ctx := context.Background()
 	logger := log.New(os.Stdout, "TEST: ", log.LstdFlags)
@@ -482,7 +764,6 @@ func TestService_Update(t *testing.T) {
 		// Arrange
 		updateReq := UpdateReq{
 			Titulo: stringPtr("Solicitud Actualizada"),
-			Estado: stringPtr("aprobada"),
 			Area:   stringPtr("Marketing"),
 		}
 
@@ -490,11 +771,45 @@ func TestService_Update(t *testing.T) {
 		docClient := new(mockDocumentoClient)
 
 		// Mock para verificar que existe la solicitud
-		existingSolicitud := &Solicitud{ID: 1, Titulo: "Original"}
+		existingSolicitud := &Solicitud{ID: 1, Titulo: "Original", Estado: EstadoBorrador}
 		repo.On("GetByID", ctx, uint(1)).Return(existingSolicitud, nil)
 		repo.On("Update", ctx, uint(1), updateReq).Return(nil)
 
-		service := NewService(repo, logger, docClient)
+		service := NewService(repo, logger, docClient, nil, nil, nil, nil)
+
+		// Act
+		err := service.Update(ctx, 1, updateReq)
+
+		// Assert
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("no debe modificar el área ni la renta hasta fuera de borrador", func(t *testing.T) {
+		// Arrange
+		updateReq := UpdateReq{Area: stringPtr("Finanzas"), RentaHasta: intPtr(5000000)}
+
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1)).Return(&Solicitud{ID: 1, Estado: EstadoAprobada, Area: "Tecnología", RentaHasta: 1500000}, nil)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, nil, nil)
+
+		// Act
+		err := service.Update(ctx, 1, updateReq)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrEdicionNoPermitida)
+		assert.Contains(t, err.Error(), "area y renta_hasta")
+		repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe permitir los demás campos fuera de borrador", func(t *testing.T) {
+		// Arrange: el área se envía sin cambios
+		updateReq := UpdateReq{Titulo: stringPtr("Backend Senior"), Area: stringPtr("tecnología")}
+
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1)).Return(&Solicitud{ID: 1, Estado: EstadoPublicada, Area: "Tecnología"}, nil)
+		repo.On("Update", ctx, uint(1), updateReq).Return(nil)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, nil, nil)
 
 		// Act
 		err := service.Update(ctx, 1, updateReq)
@@ -519,7 +834,7 @@ func TestService_Update(t *testing.T) {
 		expectedError := errors.New("error en actualización")
 		repo.On("Update", ctx, uint(999), updateReq).Return(expectedError)
 
-		service := NewService(repo, logger, docClient)
+		service := NewService(repo, logger, docClient, nil, nil, nil, nil)
 
 		// Act
 		err := service.Update(ctx, 999, updateReq)
@@ -544,7 +859,7 @@ func TestService_Update(t *testing.T) {
 		repo.On("GetByID", ctx, uint(1)).Return(existingSolicitud, nil)
 		repo.On("Update", ctx, uint(1), updateReq).Return(nil)
 
-		service := NewService(repo, logger, docClient)
+		service := NewService(repo, logger, docClient, nil, nil, nil, nil)
 
 		// Act
 		err := service.Update(ctx, 1, updateReq)
@@ -570,7 +885,7 @@ func TestService_Delete(t *testing.T) {
 		docClient.On("DeleteBySolicitudID", uint(1)).Return(nil)
 		repo.On("Delete", ctx, uint(1)).Return(nil)
 
-		service := NewService(repo, logger, docClient)
+		service := NewService(repo, logger, docClient, nil, nil, nil, nil)
 
 		// Act
 		err := service.Delete(ctx, 1)
@@ -589,7 +904,7 @@ func TestService_Delete(t *testing.T) {
 		expectedError := errors.New("solicitud no encontrada")
 		repo.On("GetByID", ctx, uint(999)).Return(nil, expectedError)
 
-		service := NewService(repo, logger, docClient)
+		service := NewService(repo, logger, docClient, nil, nil, nil, nil)
 
 		// Act
 		err := service.Delete(ctx, 999)
@@ -613,7 +928,7 @@ func TestService_Delete(t *testing.T) {
 		expectedError := errors.New("error de base de datos")
 		repo.On("Delete", ctx, uint(1)).Return(expectedError)
 
-		service := NewService(repo, logger, docClient)
+		service := NewService(repo, logger, docClient, nil, nil, nil, nil)
 
 		// Act
 		err := service.Delete(ctx, 1)
@@ -640,7 +955,7 @@ func TestService_Delete(t *testing.T) {
 		// Pero la solicitud se elimina exitosamente
 		repo.On("Delete", ctx, uint(1)).Return(nil)
 
-		service := NewService(repo, logger, docClient)
+		service := NewService(repo, logger, docClient, nil, nil, nil, nil)
 
 		// Act
 		err := service.Delete(ctx, 1)
@@ -661,3 +976,735 @@ func stringPtr(s string) *string {
 func uintPtr(u uint) *uint {
 	return &u
 }
+
+// Función auxiliar para crear punteros a int
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestService_Create_EstadoInicial(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(os.Stdout, "TEST: ", log.LstdFlags)
+	req := CreateReq{
+		Titulo:              "Analista QA",
+		Area:                "Calidad",
+		Pais:                "Chile",
+		Localizacion:        "Santiago",
+		FechaInicioProyecto: "2025-12-01",
+		UsuarioID:           uintPtr(1),
+	}
+
+	t.Run("debe permitir crear la solicitud como borrador", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("Create", ctx, mock.AnythingOfType("*solicitud.Solicitud")).Return(nil)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, nil, nil)
+
+		borrador := req
+		borrador.Estado = " Borrador "
+
+		// Act
+		result, err := service.Create(ctx, borrador)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, EstadoBorrador, result.Estado)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe rechazar un estado inicial fuera del ciclo de vida", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, nil, nil)
+
+		aprobada := req
+		aprobada.Estado = EstadoAprobada
+
+		// Act
+		result, err := service.Create(ctx, aprobada)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrEstadoInvalido)
+		assert.Nil(t, result)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_Transicionar(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(os.Stdout, "TEST: ", log.LstdFlags)
+	completa := func(estado string) *Solicitud {
+		return &Solicitud{
+			ID:              1,
+			Titulo:          "Backend Developer",
+			Estado:          estado,
+			Descripcion:     "Desarrollo de APIs",
+			BaseEducacional: "Ingeniería",
+			NumeroVacantes:  1,
+			TipoServicio:    "outsourcing",
+		}
+	}
+
+	t.Run("debe cambiar el estado cuando la transición está permitida", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1)).Return(completa(EstadoPendiente), nil)
+		repo.On("CambiarEstado", ctx, uint(1), EstadoPendiente, EstadoAprobada, mock.Anything).Return(true, nil)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, nil, nil)
+
+		// Act
+		result, err := service.Transicionar(ctx, 1, TransicionReq{Estado: "Aprobada"})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, EstadoAprobada, result.Estado)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe rechazar una transición que el ciclo de vida no permite", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1)).Return(completa(EstadoBorrador), nil)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, nil, nil)
+
+		// Act
+		result, err := service.Transicionar(ctx, 1, TransicionReq{Estado: EstadoPublicada})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrTransicionNoPermitida)
+		assert.Nil(t, result)
+		repo.AssertNotCalled(t, "CambiarEstado", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe rechazar cambios de estado en una solicitud cerrada", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1)).Return(completa(EstadoCerrada), nil)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, nil, nil)
+
+		// Act
+		_, err := service.Transicionar(ctx, 1, TransicionReq{Estado: EstadoEnProceso})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrTransicionNoPermitida)
+	})
+
+	t.Run("debe rechazar un estado desconocido", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1)).Return(completa(EstadoPendiente), nil)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, nil, nil)
+
+		// Act
+		_, err := service.Transicionar(ctx, 1, TransicionReq{Estado: "completada"})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrEstadoInvalido)
+	})
+
+	t.Run("debe exigir los datos completos para enviar a aprobación", func(t *testing.T) {
+		// Arrange
+		borrador := completa(EstadoBorrador)
+		borrador.NumeroVacantes = 0
+		borrador.Descripcion = ""
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1)).Return(borrador, nil)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, nil, nil)
+
+		// Act
+		_, err := service.Transicionar(ctx, 1, TransicionReq{Estado: EstadoPendiente})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrRequisitosTransicion)
+		assert.Contains(t, err.Error(), "descripcion, numero_vacantes")
+		repo.AssertNotCalled(t, "CambiarEstado", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe exigir los documentos obligatorios para publicar", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		docClient := new(mockDocumentoClient)
+		repo.On("GetByID", ctx, uint(1)).Return(completa(EstadoAprobada), nil)
+		docClient.On("GetBySolicitudID", uint(1)).Return([]Documento{{ID: 3, Categoria: "descripcion_cargo"}}, nil)
+		service := NewService(repo, logger, docClient, ReglasDocumentos{"outsourcing": {"descripcion_cargo", "nda"}}, nil, nil, nil)
+
+		// Act
+		_, err := service.Transicionar(ctx, 1, TransicionReq{Estado: EstadoPublicada})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrRequisitosTransicion)
+		assert.Contains(t, err.Error(), "nda")
+		repo.AssertNotCalled(t, "CambiarEstado", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe publicar cuando están todos los documentos obligatorios", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		docClient := new(mockDocumentoClient)
+		repo.On("GetByID", ctx, uint(1)).Return(completa(EstadoAprobada), nil)
+		repo.On("CambiarEstado", ctx, uint(1), EstadoAprobada, EstadoPublicada, mock.Anything).Return(true, nil)
+		docClient.On("GetBySolicitudID", uint(1)).Return([]Documento{{ID: 3, Categoria: "nda"}}, nil)
+		service := NewService(repo, logger, docClient, ReglasDocumentos{"outsourcing": {"nda"}}, nil, nil, nil)
+
+		// Act
+		result, err := service.Transicionar(ctx, 1, TransicionReq{Estado: EstadoPublicada})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, EstadoPublicada, result.Estado)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe informar cuando no se pueden consultar los documentos para publicar", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		docClient := new(mockDocumentoClient)
+		repo.On("GetByID", ctx, uint(1)).Return(completa(EstadoAprobada), nil)
+		docClient.On("GetBySolicitudID", uint(1)).Return(nil, errors.New("connection refused"))
+		service := NewService(repo, logger, docClient, ReglasDocumentos{"outsourcing": {"nda"}}, nil, nil, nil)
+
+		// Act
+		_, err := service.Transicionar(ctx, 1, TransicionReq{Estado: EstadoPublicada})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrDocumentosNoDisponibles)
+	})
+
+	t.Run("debe exigir el motivo para cancelar", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1)).Return(completa(EstadoPublicada), nil)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, nil, nil)
+
+		// Act
+		_, err := service.Transicionar(ctx, 1, TransicionReq{Estado: EstadoCancelada, Motivo: "  "})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrRequisitosTransicion)
+	})
+
+	t.Run("debe rechazar la transición si el estado cambió entretanto", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1)).Return(completa(EstadoEnProceso), nil)
+		repo.On("CambiarEstado", ctx, uint(1), EstadoEnProceso, EstadoCancelada, "Proyecto suspendido").Return(false, nil)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, nil, nil)
+
+		// Act
+		_, err := service.Transicionar(ctx, 1, TransicionReq{Estado: EstadoCancelada, Motivo: "Proyecto suspendido"})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrTransicionNoPermitida)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe retornar error cuando la solicitud no existe", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(99)).Return(nil, errors.New("record not found"))
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, nil, nil)
+
+		// Act
+		_, err := service.Transicionar(ctx, 99, TransicionReq{Estado: EstadoAprobada})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrSolicitudNoEncontrada)
+	})
+}
+
+func TestCadenasAprobacion_Cadena(t *testing.T) {
+	cadenas := CadenasAprobacion{
+		{Area: "tecnología", RentaSobre: 3000000, Niveles: []string{"jefe_area", "finanzas", "gerencia"}},
+		{Area: TodasLasAreas, RentaSobre: 2000000, Niveles: []string{"jefe_area", "finanzas"}},
+	}
+
+	tests := []struct {
+		name       string
+		area       string
+		rentaHasta int
+		esperado   []string
+	}{
+		{"aplica la regla del área", " Tecnología ", 3500000, []string{"jefe_area", "finanzas", "gerencia"}},
+		{"bajo el umbral del área aplica la regla general", "Tecnología", 2500000, []string{"jefe_area", "finanzas"}},
+		{"otra área sobre el umbral general", "Ventas", 2500000, []string{"jefe_area", "finanzas"}},
+		{"el umbral no requiere aprobación", "Ventas", 2000000, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.esperado, cadenas.Cadena(&Solicitud{Area: tt.area, RentaHasta: tt.rentaHasta}))
+		})
+	}
+}
+
+func TestService_Aprobaciones(t *testing.T) {
+	ctx := context.Background()
+	// El cambio de estado automático se registra a nombre del usuario que decide
+	conActor := func(usuarioID uint) interface{} {
+		return mock.MatchedBy(func(ctx context.Context) bool {
+			actor := actorDe(ctx)
+			return actor.UsuarioID != nil && *actor.UsuarioID == usuarioID
+		})
+	}
+	logger := log.New(os.Stdout, "TEST: ", log.LstdFlags)
+	usuario := uint(7)
+	cadenas := CadenasAprobacion{{Area: TodasLasAreas, RentaSobre: 2000000, Niveles: []string{"jefe_area", "finanzas"}}}
+	solicitud := func(estado string) *Solicitud {
+		return &Solicitud{
+			ID:              1,
+			Estado:          estado,
+			Area:            "Tecnología",
+			RentaHasta:      2500000,
+			Descripcion:     "Desarrollo de APIs",
+			BaseEducacional: "Ingeniería",
+			NumeroVacantes:  1,
+		}
+	}
+	ronda := func(numero int, estados ...string) []Aprobacion {
+		niveles := []string{"jefe_area", "finanzas"}
+		aprobaciones := make([]Aprobacion, len(estados))
+		for i, estado := range estados {
+			aprobaciones[i] = Aprobacion{ID: uint(numero*10 + i), SolicitudID: 1, Ronda: numero, Orden: i + 1, Nivel: niveles[i], Estado: estado}
+		}
+		return aprobaciones
+	}
+
+	t.Run("debe iniciar una ronda nueva al enviar la solicitud a aprobación", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1)).Return(solicitud(EstadoBorrador), nil)
+		repo.On("GetRondaAprobacion", ctx, uint(1)).Return(ronda(1, AprobacionRechazada, AprobacionPendiente), nil)
+		repo.On("CambiarEstadoConRonda", ctx, uint(1), EstadoBorrador, EstadoPendiente, mock.Anything, mock.MatchedBy(func(aprobaciones []Aprobacion) bool {
+			return len(aprobaciones) == 2 && aprobaciones[0].Ronda == 2 && aprobaciones[0].Nivel == "jefe_area" &&
+				aprobaciones[1].Nivel == "finanzas" && aprobaciones[1].Estado == AprobacionPendiente
+		})).Return(true, nil)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, cadenas, nil)
+
+		// Act
+		result, err := service.Transicionar(ctx, 1, TransicionReq{Estado: EstadoPendiente})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, EstadoPendiente, result.Estado)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe informar cuando otro proceso cambió el estado antes de iniciar la ronda", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1)).Return(solicitud(EstadoBorrador), nil)
+		repo.On("GetRondaAprobacion", ctx, uint(1)).Return([]Aprobacion{}, nil)
+		repo.On("CambiarEstadoConRonda", ctx, uint(1), EstadoBorrador, EstadoPendiente, "", mock.Anything).Return(false, nil)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, cadenas, nil)
+
+		// Act
+		_, err := service.Transicionar(ctx, 1, TransicionReq{Estado: EstadoPendiente})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrTransicionNoPermitida)
+		repo.AssertNotCalled(t, "CambiarEstado", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe crear la solicitud pendiente junto con su primera ronda", func(t *testing.T) {
+		// Arrange
+		req := CreateReq{
+			Titulo:              "Backend",
+			Estado:              EstadoPendiente,
+			Area:                "Tecnología",
+			Pais:                "Chile",
+			Localizacion:        "Santiago",
+			NumeroVacantes:      1,
+			Descripcion:         "Desarrollo de APIs",
+			BaseEducacional:     "Ingeniería",
+			RentaHasta:          2500000,
+			FechaInicioProyecto: "2025-12-01",
+			UsuarioID:           &usuario,
+		}
+		repo := new(mockRepository)
+		repo.On("CreateConRonda", ctx, mock.AnythingOfType("*solicitud.Solicitud"), mock.MatchedBy(func(aprobaciones []Aprobacion) bool {
+			return len(aprobaciones) == 2 && aprobaciones[0].Ronda == 1 && aprobaciones[1].Nivel == "finanzas"
+		})).Return(nil)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, cadenas, nil)
+
+		// Act
+		result, err := service.Create(ctx, req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, EstadoPendiente, result.Estado)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("no debe aprobar manualmente con niveles pendientes", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1)).Return(solicitud(EstadoPendiente), nil)
+		repo.On("GetRondaAprobacion", ctx, uint(1)).Return(ronda(1, AprobacionAprobada, AprobacionPendiente), nil)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, cadenas, nil)
+
+		// Act
+		_, err := service.Transicionar(ctx, 1, TransicionReq{Estado: EstadoAprobada})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrRequisitosTransicion)
+		assert.Contains(t, err.Error(), "finanzas")
+		repo.AssertNotCalled(t, "CambiarEstado", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe registrar la aprobación de un nivel intermedio sin cambiar el estado", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1)).Return(solicitud(EstadoPendiente), nil)
+		repo.On("GetRondaAprobacion", ctx, uint(1)).Return(ronda(1, AprobacionPendiente, AprobacionPendiente), nil)
+		repo.On("DecidirAprobacion", ctx, mock.MatchedBy(func(a *Aprobacion) bool {
+			return a.Nivel == "jefe_area" && a.Estado == AprobacionAprobada && *a.UsuarioID == usuario && a.Comentario == "Presupuesto OK"
+		})).Return(true, nil)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, cadenas, nil)
+
+		// Act
+		result, err := service.Aprobar(ctx, 1, "Jefe_Area", DecisionReq{UsuarioID: &usuario, Comentario: " Presupuesto OK "})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, AprobacionPendiente, result.Estado)
+		assert.Equal(t, "finanzas", result.NivelActual)
+		assert.Equal(t, EstadoPendiente, result.EstadoSolicitud)
+		repo.AssertNotCalled(t, "CambiarEstado", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe aprobar la solicitud cuando aprueba el último nivel", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1)).Return(solicitud(EstadoPendiente), nil)
+		repo.On("GetRondaAprobacion", ctx, uint(1)).Return(ronda(1, AprobacionAprobada, AprobacionPendiente), nil).Once()
+		repo.On("GetRondaAprobacion", mock.Anything, uint(1)).Return(ronda(1, AprobacionAprobada, AprobacionAprobada), nil).Once()
+		repo.On("DecidirAprobacion", ctx, mock.Anything).Return(true, nil)
+		repo.On("CambiarEstado", conActor(usuario), uint(1), EstadoPendiente, EstadoAprobada, mock.Anything).Return(true, nil)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, cadenas, nil)
+
+		// Act
+		result, err := service.Aprobar(ctx, 1, "finanzas", DecisionReq{UsuarioID: &usuario})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, AprobacionAprobada, result.Estado)
+		assert.Equal(t, EstadoAprobada, result.EstadoSolicitud)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe devolver la solicitud a borrador al rechazar", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1)).Return(solicitud(EstadoPendiente), nil)
+		repo.On("GetRondaAprobacion", ctx, uint(1)).Return(ronda(1, AprobacionAprobada, AprobacionPendiente), nil)
+		repo.On("DecidirAprobacion", ctx, mock.MatchedBy(func(a *Aprobacion) bool {
+			return a.Nivel == "finanzas" && a.Estado == AprobacionRechazada
+		})).Return(true, nil)
+		repo.On("CambiarEstado", conActor(usuario), uint(1), EstadoPendiente, EstadoBorrador, "rechazada por finanzas: Renta fuera de presupuesto").Return(true, nil)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, cadenas, nil)
+
+		// Act
+		result, err := service.Rechazar(ctx, 1, "finanzas", DecisionReq{UsuarioID: &usuario, Comentario: "Renta fuera de presupuesto"})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, AprobacionRechazada, result.Estado)
+		assert.Equal(t, EstadoBorrador, result.EstadoSolicitud)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe exigir un comentario al rechazar", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, cadenas, nil)
+
+		// Act
+		_, err := service.Rechazar(ctx, 1, "jefe_area", DecisionReq{UsuarioID: &usuario, Comentario: "  "})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrDecisionInvalida)
+		repo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe rechazar la decisión de un nivel al que no le corresponde", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1)).Return(solicitud(EstadoPendiente), nil)
+		repo.On("GetRondaAprobacion", ctx, uint(1)).Return(ronda(1, AprobacionPendiente, AprobacionPendiente), nil)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, cadenas, nil)
+
+		// Act
+		_, err := service.Aprobar(ctx, 1, "finanzas", DecisionReq{UsuarioID: &usuario})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrAprobacionNoPermitida)
+		assert.Contains(t, err.Error(), "jefe_area")
+		repo.AssertNotCalled(t, "DecidirAprobacion", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe rechazar decisiones sobre una solicitud que no está pendiente", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1)).Return(solicitud(EstadoBorrador), nil)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, cadenas, nil)
+
+		// Act
+		_, err := service.Aprobar(ctx, 1, "jefe_area", DecisionReq{UsuarioID: &usuario})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrAprobacionNoPermitida)
+	})
+
+	t.Run("debe informar cuando otro usuario decidió el nivel", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1)).Return(solicitud(EstadoPendiente), nil)
+		repo.On("GetRondaAprobacion", ctx, uint(1)).Return(ronda(1, AprobacionPendiente, AprobacionPendiente), nil)
+		repo.On("DecidirAprobacion", ctx, mock.Anything).Return(false, nil)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, cadenas, nil)
+
+		// Act
+		_, err := service.Aprobar(ctx, 1, "jefe_area", DecisionReq{UsuarioID: &usuario})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrAprobacionNoPermitida)
+	})
+
+	t.Run("debe impedir que quien creó la solicitud la apruebe o rechace", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		propia := solicitud(EstadoPendiente)
+		propia.UsuarioID = &usuario
+		repo.On("GetByID", ctx, uint(1)).Return(propia, nil)
+		repo.On("GetRondaAprobacion", ctx, uint(1)).Return(ronda(1, AprobacionPendiente, AprobacionPendiente), nil)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, cadenas, nil)
+
+		// Act
+		_, errAprobar := service.Aprobar(ctx, 1, "jefe_area", DecisionReq{UsuarioID: &usuario})
+		_, errRechazar := service.Rechazar(ctx, 1, "jefe_area", DecisionReq{UsuarioID: &usuario, Comentario: "No corresponde"})
+
+		// Assert
+		assert.ErrorIs(t, errAprobar, ErrAprobadorNoAutorizado)
+		assert.ErrorIs(t, errRechazar, ErrAprobadorNoAutorizado)
+		repo.AssertNotCalled(t, "DecidirAprobacion", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe impedir decidir un nivel a quien no es uno de sus aprobadores", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1)).Return(solicitud(EstadoPendiente), nil)
+		repo.On("GetRondaAprobacion", ctx, uint(1)).Return(ronda(1, AprobacionPendiente, AprobacionPendiente), nil)
+		aprobadores := AprobadoresPorNivel{"jefe_area": {3, 4}}
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, cadenas, aprobadores)
+
+		// Act
+		_, err := service.Aprobar(ctx, 1, "jefe_area", DecisionReq{UsuarioID: &usuario})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrAprobadorNoAutorizado)
+		assert.Contains(t, err.Error(), "jefe_area")
+		repo.AssertNotCalled(t, "DecidirAprobacion", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe permitir decidir un nivel a uno de sus aprobadores", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1)).Return(solicitud(EstadoPendiente), nil)
+		repo.On("GetRondaAprobacion", ctx, uint(1)).Return(ronda(1, AprobacionPendiente, AprobacionPendiente), nil)
+		repo.On("DecidirAprobacion", ctx, mock.Anything).Return(true, nil)
+		aprobadores := AprobadoresPorNivel{"jefe_area": {usuario}, "finanzas": {3}}
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, cadenas, aprobadores)
+
+		// Act
+		_, err := service.Aprobar(ctx, 1, "jefe_area", DecisionReq{UsuarioID: &usuario})
+
+		// Assert
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe exigir el usuario que decide", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, cadenas, nil)
+
+		// Act
+		_, err := service.Aprobar(ctx, 1, "jefe_area", DecisionReq{})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrDecisionInvalida)
+		repo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe mostrar los niveles requeridos de una solicitud sin enviar", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1)).Return(solicitud(EstadoBorrador), nil)
+		repo.On("GetRondaAprobacion", ctx, uint(1)).Return([]Aprobacion{}, nil)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, cadenas, nil)
+
+		// Act
+		result, err := service.GetAprobaciones(ctx, 1)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.True(t, result.Requerida)
+		assert.Equal(t, AprobacionSinEnviar, result.Estado)
+		assert.Len(t, result.Niveles, 2)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("no debe crear rondas al consultar una solicitud pendiente cuya cadena cambió", func(t *testing.T) {
+		// Arrange: mock estricto, cualquier escritura haría fallar la prueba
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1)).Return(solicitud(EstadoPendiente), nil)
+		repo.On("GetRondaAprobacion", ctx, uint(1)).Return(ronda(1, AprobacionAprobada), nil)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, cadenas, nil)
+
+		// Act
+		result, err := service.GetAprobaciones(ctx, 1)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 1, result.Ronda)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe rechazar decisiones sobre una ronda que no corresponde a la cadena vigente", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1)).Return(solicitud(EstadoPendiente), nil)
+		repo.On("GetRondaAprobacion", ctx, uint(1)).Return(ronda(1, AprobacionPendiente), nil)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, cadenas, nil)
+
+		// Act
+		_, err := service.Aprobar(ctx, 1, "jefe_area", DecisionReq{UsuarioID: &usuario})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrAprobacionNoPermitida)
+		assert.Contains(t, err.Error(), "devuélvala a borrador")
+		repo.AssertNotCalled(t, "DecidirAprobacion", mock.Anything, mock.Anything)
+	})
+
+	t.Run("no debe publicar una solicitud sin la aprobación de su cadena vigente", func(t *testing.T) {
+		// Arrange: se aprobó bajo el umbral y luego se subió la renta
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1)).Return(solicitud(EstadoAprobada), nil)
+		repo.On("GetRondaAprobacion", ctx, uint(1)).Return([]Aprobacion{}, nil)
+		docClient := new(mockDocumentoClient)
+		service := NewService(repo, logger, docClient, nil, nil, cadenas, nil)
+
+		// Act
+		_, err := service.Transicionar(ctx, 1, TransicionReq{Estado: EstadoPublicada})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrRequisitosTransicion)
+		assert.Contains(t, err.Error(), "jefe_area, finanzas")
+		repo.AssertNotCalled(t, "CambiarEstado", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		docClient.AssertNotCalled(t, "GetBySolicitudID", mock.Anything)
+	})
+
+	t.Run("debe publicar una solicitud aprobada por su cadena vigente", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1)).Return(solicitud(EstadoAprobada), nil)
+		repo.On("GetRondaAprobacion", ctx, uint(1)).Return(ronda(1, AprobacionAprobada, AprobacionAprobada), nil)
+		repo.On("CambiarEstado", ctx, uint(1), EstadoAprobada, EstadoPublicada, "").Return(true, nil)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, cadenas, nil)
+
+		// Act
+		result, err := service.Transicionar(ctx, 1, TransicionReq{Estado: EstadoPublicada})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, EstadoPublicada, result.Estado)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe permitir devolver una solicitud aprobada a borrador", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1)).Return(solicitud(EstadoAprobada), nil)
+		repo.On("CambiarEstado", ctx, uint(1), EstadoAprobada, EstadoBorrador, "ajustar renta").Return(true, nil)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, cadenas, nil)
+
+		// Act
+		result, err := service.Transicionar(ctx, 1, TransicionReq{Estado: EstadoBorrador, Motivo: "ajustar renta"})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, EstadoBorrador, result.Estado)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("debe informar que no requiere aprobación bajo el umbral", func(t *testing.T) {
+		// Arrange
+		bajoUmbral := solicitud(EstadoPendiente)
+		bajoUmbral.RentaHasta = 1500000
+		repo := new(mockRepository)
+		repo.On("GetByID", ctx, uint(1)).Return(bajoUmbral, nil)
+		repo.On("GetRondaAprobacion", ctx, uint(1)).Return([]Aprobacion{}, nil)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, cadenas, nil)
+
+		// Act
+		result, err := service.GetAprobaciones(ctx, 1)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.False(t, result.Requerida)
+		assert.Equal(t, AprobacionNoRequerida, result.Estado)
+	})
+}
+
+func TestService_GetHistorial(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(os.Stdout, "TEST: ", log.LstdFlags)
+	filtros := HistorialReq{Limit: 20}
+
+	t.Run("debe retornar los eventos de la solicitud", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		eventos := []EventoHistorial{{ID: 2, SolicitudID: 1, Accion: AccionTransicion}, {ID: 1, SolicitudID: 1, Accion: AccionCreacion}}
+		repo.On("GetHistorial", ctx, uint(1), filtros).Return(eventos, nil)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, nil, nil)
+
+		// Act
+		result, err := service.GetHistorial(ctx, 1, filtros)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, eventos, result)
+		repo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("debe retornar una lista vacía para una solicitud sin eventos", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetHistorial", ctx, uint(1), filtros).Return([]EventoHistorial{}, nil)
+		repo.On("GetByID", ctx, uint(1)).Return(&Solicitud{ID: 1}, nil)
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, nil, nil)
+
+		// Act
+		result, err := service.GetHistorial(ctx, 1, filtros)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Empty(t, result)
+	})
+
+	t.Run("debe retornar error si la solicitud no existe", func(t *testing.T) {
+		// Arrange
+		repo := new(mockRepository)
+		repo.On("GetHistorial", ctx, uint(99), filtros).Return([]EventoHistorial{}, nil)
+		repo.On("GetByID", ctx, uint(99)).Return(nil, errors.New("record not found"))
+		service := NewService(repo, logger, new(mockDocumentoClient), nil, nil, nil, nil)
+
+		// Act
+		_, err := service.GetHistorial(ctx, 99, filtros)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrSolicitudNoEncontrada)
+	})
+}
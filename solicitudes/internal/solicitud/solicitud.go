@@ -1,15 +1,22 @@
 package solicitud
 
 import (
+	"errors"
 	"time"
+
 	"gorm.io/gorm"
 )
 
+// ErrSolicitudNoEncontrada indica que la solicitud no existe o fue eliminada
+var ErrSolicitudNoEncontrada = errors.New("solicitud no encontrada")
+
 // Documento representa un documento asociado a una solicitud
 type Documento struct {
 	ID            uint   `json:"id"`
 	NombreArchivo string `json:"nombre_archivo"`
 	Extension     string `json:"extension"`
+	Categoria     string `json:"categoria,omitempty"`
+	URLMiniatura  string `json:"url_miniatura,omitempty"`
 }
 
 // DocumentoResponse representa un documento en las respuestas de la API
@@ -17,6 +24,8 @@ type DocumentoResponse struct {
 	ID            uint   `json:"id"`
 	NombreArchivo string `json:"nombre_archivo"`
 	Extension     string `json:"extension"`
+	Categoria     string `json:"categoria,omitempty"`
+	URLMiniatura  string `json:"url_miniatura,omitempty"`
 }
 
 // SolicitudResponse representa la respuesta de una solicitud
@@ -77,6 +86,8 @@ func (s *Solicitud) ToResponse() SolicitudResponse {
 			ID:            doc.ID,
 			NombreArchivo: doc.NombreArchivo,
 			Extension:     doc.Extension,
+			Categoria:     doc.Categoria,
+			URLMiniatura:  doc.URLMiniatura,
 		}
 	}
 
@@ -112,7 +123,7 @@ func (Solicitud) TableName() string {
 // CreateReq representa la petición para crear una solicitud
 type CreateReq struct {
 	Titulo                   string `json:"titulo" binding:"required"`
-	Estado                   string `json:"estado" binding:"required"`
+	Estado                   string `json:"estado"` // borrador o pendiente; por defecto borrador
 	Area                     string `json:"area" binding:"required"`
 	Pais                     string `json:"pais" binding:"required"`
 	Localizacion             string `json:"localizacion" binding:"required"`
@@ -129,10 +140,10 @@ type CreateReq struct {
 	UsuarioID                *uint  `json:"usuario_id,omitempty"`
 }
 
-// UpdateReq representa la petición para actualizar una solicitud
+// UpdateReq representa la petición para actualizar una solicitud. El estado no forma parte
+// de ella porque solo cambia mediante una transición (ver TransicionReq).
 type UpdateReq struct {
 	Titulo                   *string `json:"titulo"`
-	Estado                   *string `json:"estado"`
 	Area                     *string `json:"area"`
 	Pais                     *string `json:"pais"`
 	Localizacion             *string `json:"localizacion"`
@@ -1,12 +1,18 @@
 package bootstrap
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 
     "github.com/joho/godotenv"
+    "github.com/kramirez/solicitudes/internal/comentario"
+    "github.com/kramirez/solicitudes/internal/postulante"
     "github.com/kramirez/solicitudes/internal/solicitud"
+	"github.com/kramirez/solicitudes/pkg/ficha"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
@@ -24,7 +30,8 @@ func DBConnection() (*gorm.DB, error) {
 		os.Getenv("DB_HOST"),
 		os.Getenv("DB_PORT"),
 		os.Getenv("DB_NAME"))
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	// TranslateError permite reconocer las violaciones de índices únicos con gorm.ErrDuplicatedKey
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		return nil, err
 	}
@@ -35,15 +42,157 @@ func DBConnection() (*gorm.DB, error) {
 
 	// Auto-migrate si DATABASE_MIGRATE está en "up"
 	if os.Getenv("DATABASE_MIGRATE") == "up" {
-		if err := db.AutoMigrate(&solicitud.Solicitud{}); err != nil {
+		if err := db.AutoMigrate(&solicitud.Solicitud{}, &solicitud.Aprobacion{}, &solicitud.EventoHistorial{},
+			&comentario.Comentario{}, &comentario.Mencion{}, &comentario.Edicion{},
+			&postulante.Postulante{}, &postulante.Postulacion{}, &postulante.CambioEtapa{}); err != nil {
 			return nil, fmt.Errorf("error al realizar migraciones: %v", err)
 		}
 		log.Println("Migraciones realizadas exitosamente")
+
+		// Los postulantes registrados antes del índice único de email aún no tienen su email vigente
+		if err := db.Model(&postulante.Postulante{}).Where("email_vigente IS NULL").
+			Update("email_vigente", gorm.Expr("email")).Error; err != nil {
+			return nil, fmt.Errorf("error al migrar los emails de los postulantes: %v", err)
+		}
+
+		// Las solicitudes creadas antes del ciclo de vida no podrían cambiar de estado
+		migradas, err := solicitud.NewRepository(db).MigrarEstados(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("error al migrar los estados de las solicitudes: %v", err)
+		}
+		if migradas > 0 {
+			log.Printf("Estados de %d solicitudes migrados al ciclo de vida", migradas)
+		}
 	}
 
 	return db, nil
 }
 
+// InitReglasDocumentos carga las categorías de documento obligatorias por tipo de servicio.
+// Formato: tipo_servicio:categoria1,categoria2;otro_tipo:categoria3, donde el tipo * aplica a todos.
+func InitReglasDocumentos() solicitud.ReglasDocumentos {
+	reglas := make(solicitud.ReglasDocumentos)
+	for _, regla := range strings.Split(os.Getenv("CATEGORIAS_OBLIGATORIAS"), ";") {
+		tipo, categorias, ok := strings.Cut(regla, ":")
+		tipo = strings.ToLower(strings.TrimSpace(tipo))
+		if !ok || tipo == "" {
+			continue
+		}
+		for _, categoria := range strings.Split(categorias, ",") {
+			if categoria = strings.TrimSpace(categoria); categoria != "" {
+				reglas[tipo] = append(reglas[tipo], categoria)
+			}
+		}
+	}
+	return reglas
+}
+
+// InitCadenasAprobacion carga las cadenas de aprobación por área y renta máxima, en orden de prioridad.
+// Formato: area:renta:nivel1,nivel2;otra_area:renta:nivel1, donde el área * aplica a todas y la regla
+// se aplica a las solicitudes cuya renta hasta supera la renta indicada.
+func InitCadenasAprobacion() solicitud.CadenasAprobacion {
+	var cadenas solicitud.CadenasAprobacion
+	for _, regla := range strings.Split(os.Getenv("CADENAS_APROBACION"), ";") {
+		partes := strings.SplitN(regla, ":", 3)
+		if len(partes) != 3 {
+			continue
+		}
+		area := strings.ToLower(strings.TrimSpace(partes[0]))
+		renta, err := strconv.Atoi(strings.TrimSpace(partes[1]))
+		if area == "" || err != nil {
+			log.Printf("Regla de aprobación inválida, se ignora: %s", regla)
+			continue
+		}
+		var niveles []string
+		for _, nivel := range strings.Split(partes[2], ",") {
+			if nivel = strings.ToLower(strings.TrimSpace(nivel)); nivel != "" {
+				niveles = append(niveles, nivel)
+			}
+		}
+		if len(niveles) > 0 {
+			cadenas = append(cadenas, solicitud.ReglaAprobacion{Area: area, RentaSobre: renta, Niveles: niveles})
+		}
+	}
+	return cadenas
+}
+
+// InitAprobadores carga los usuarios autorizados a decidir cada nivel de aprobación.
+// Formato: nivel1:usuario1,usuario2;nivel2:usuario3. Un nivel sin usuarios lo puede decidir cualquiera.
+func InitAprobadores() solicitud.AprobadoresPorNivel {
+	aprobadores := make(solicitud.AprobadoresPorNivel)
+	for _, regla := range strings.Split(os.Getenv("APROBADORES_NIVEL"), ";") {
+		nivel, usuarios, ok := strings.Cut(regla, ":")
+		nivel = strings.ToLower(strings.TrimSpace(nivel))
+		if !ok || nivel == "" {
+			continue
+		}
+		for _, usuario := range strings.Split(usuarios, ",") {
+			if usuario = strings.TrimSpace(usuario); usuario == "" {
+				continue
+			}
+			id, err := strconv.ParseUint(usuario, 10, 32)
+			if err != nil {
+				log.Printf("Aprobador inválido del nivel %s, se ignora: %s", nivel, usuario)
+				continue
+			}
+			aprobadores[nivel] = append(aprobadores[nivel], uint(id))
+		}
+	}
+	return aprobadores
+}
+
+// InitPipelinePostulacion carga las etapas del pipeline de postulación, en orden, desde ETAPAS_POSTULACION
+// (formato etapa1,etapa2) y las etapas que terminan el proceso desde ETAPAS_POSTULACION_FINALES.
+// Sin configuración se usa el pipeline por defecto.
+func InitPipelinePostulacion() postulante.Pipeline {
+	etapas := parseEtapas(os.Getenv("ETAPAS_POSTULACION"))
+	if len(etapas) == 0 {
+		return postulante.PipelinePorDefecto()
+	}
+
+	pipeline := postulante.Pipeline{Etapas: etapas}
+	for _, final := range parseEtapas(os.Getenv("ETAPAS_POSTULACION_FINALES")) {
+		if !pipeline.Contiene(final) {
+			log.Printf("Etapa final de postulación desconocida, se ignora: %s", final)
+			continue
+		}
+		pipeline.Finales = append(pipeline.Finales, final)
+	}
+	return pipeline
+}
+
+func parseEtapas(valor string) []string {
+	var etapas []string
+	for _, etapa := range strings.Split(valor, ",") {
+		if etapa = strings.ToLower(strings.TrimSpace(etapa)); etapa != "" {
+			etapas = append(etapas, etapa)
+		}
+	}
+	return etapas
+}
+
+// InitFicha configura la marca y la plantilla de la ficha de publicación de las solicitudes.
+// FICHA_PLANTILLA es la ruta a un archivo con la plantilla; sin ella se usa la plantilla por defecto.
+func InitFicha() (*ficha.Generador, error) {
+	config := ficha.Config{
+		Empresa: os.Getenv("FICHA_EMPRESA"),
+		Color:   os.Getenv("FICHA_COLOR"),
+		Logo:    os.Getenv("FICHA_LOGO"),
+		Pie:     os.Getenv("FICHA_PIE"),
+	}
+	if config.Empresa == "" {
+		config.Empresa = "Oferta laboral"
+	}
+	if ruta := os.Getenv("FICHA_PLANTILLA"); ruta != "" {
+		contenido, err := os.ReadFile(ruta)
+		if err != nil {
+			return nil, fmt.Errorf("error al leer la plantilla de ficha %s: %v", ruta, err)
+		}
+		config.Plantilla = string(contenido)
+	}
+	return ficha.NewGenerador(config)
+}
+
 func InitLogger() *log.Logger {
 	return log.New(os.Stdout, "", log.LstdFlags|log.Lshortfile)
 }
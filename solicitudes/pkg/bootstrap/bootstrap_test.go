@@ -3,8 +3,11 @@ package bootstrap
 import (
 	"log"
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/kramirez/solicitudes/internal/postulante"
+	"github.com/kramirez/solicitudes/internal/solicitud"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -183,6 +186,166 @@ func TestDBConnection(t *testing.T) {
 	})
 }
 
+func TestInitReglasDocumentos(t *testing.T) {
+	t.Run("debe parsear las categorías obligatorias por tipo de servicio", func(t *testing.T) {
+		// Arrange
+		original := os.Getenv("CATEGORIAS_OBLIGATORIAS")
+		os.Setenv("CATEGORIAS_OBLIGATORIAS", "*:descripcion_cargo, aprobacion_presupuesto; Outsourcing:nda;invalida;:otro")
+
+		// Act
+		reglas := InitReglasDocumentos()
+
+		// Assert
+		assert.Equal(t, []string{"descripcion_cargo", "aprobacion_presupuesto"}, reglas["*"])
+		assert.Equal(t, []string{"nda"}, reglas["outsourcing"])
+		assert.Len(t, reglas, 2)
+
+		// Cleanup
+		os.Setenv("CATEGORIAS_OBLIGATORIAS", original)
+	})
+
+	t.Run("debe retornar reglas vacías cuando no está configurada", func(t *testing.T) {
+		// Arrange
+		original := os.Getenv("CATEGORIAS_OBLIGATORIAS")
+		os.Unsetenv("CATEGORIAS_OBLIGATORIAS")
+
+		// Act
+		reglas := InitReglasDocumentos()
+
+		// Assert
+		assert.Empty(t, reglas)
+		assert.Empty(t, reglas.Obligatorias("outsourcing"))
+
+		// Cleanup
+		os.Setenv("CATEGORIAS_OBLIGATORIAS", original)
+	})
+}
+
+func TestInitCadenasAprobacion(t *testing.T) {
+	t.Run("debe parsear las cadenas en orden de prioridad", func(t *testing.T) {
+		// Arrange
+		original := os.Getenv("CADENAS_APROBACION")
+		os.Setenv("CADENAS_APROBACION", "Tecnología:3000000:Jefe_Area, finanzas, gerencia; *:2000000:jefe_area,finanzas;ventas:abc:finanzas;invalida;*:0:")
+
+		// Act
+		cadenas := InitCadenasAprobacion()
+
+		// Assert
+		assert.Len(t, cadenas, 2)
+		assert.Equal(t, solicitud.ReglaAprobacion{Area: "tecnología", RentaSobre: 3000000, Niveles: []string{"jefe_area", "finanzas", "gerencia"}}, cadenas[0])
+		assert.Equal(t, solicitud.ReglaAprobacion{Area: "*", RentaSobre: 2000000, Niveles: []string{"jefe_area", "finanzas"}}, cadenas[1])
+
+		// Cleanup
+		os.Setenv("CADENAS_APROBACION", original)
+	})
+
+	t.Run("debe retornar cadenas vacías cuando no está configurada", func(t *testing.T) {
+		// Arrange
+		original := os.Getenv("CADENAS_APROBACION")
+		os.Unsetenv("CADENAS_APROBACION")
+
+		// Act
+		cadenas := InitCadenasAprobacion()
+
+		// Assert
+		assert.Empty(t, cadenas)
+		assert.Empty(t, cadenas.Cadena(&solicitud.Solicitud{RentaHasta: 5000000}))
+
+		// Cleanup
+		os.Setenv("CADENAS_APROBACION", original)
+	})
+}
+
+func TestInitAprobadores(t *testing.T) {
+	t.Run("debe parsear los aprobadores de cada nivel", func(t *testing.T) {
+		// Arrange
+		original := os.Getenv("APROBADORES_NIVEL")
+		os.Setenv("APROBADORES_NIVEL", "Jefe_Area: 3, 4;finanzas:5,abc;invalida;gerencia:")
+
+		// Act
+		aprobadores := InitAprobadores()
+
+		// Assert
+		assert.Equal(t, solicitud.AprobadoresPorNivel{"jefe_area": {3, 4}, "finanzas": {5}}, aprobadores)
+		assert.True(t, aprobadores.Autorizado("jefe_area", 4))
+		assert.False(t, aprobadores.Autorizado("finanzas", 3))
+		assert.True(t, aprobadores.Autorizado("gerencia", 3), "un nivel sin aprobadores lo decide cualquiera")
+
+		// Cleanup
+		os.Setenv("APROBADORES_NIVEL", original)
+	})
+}
+
+func TestInitPipelinePostulacion(t *testing.T) {
+	t.Run("debe parsear las etapas y sus finales", func(t *testing.T) {
+		// Arrange
+		t.Setenv("ETAPAS_POSTULACION", " Recibido, prueba_tecnica,entrevista,,contratado,descartado")
+		t.Setenv("ETAPAS_POSTULACION_FINALES", "contratado, Descartado, desconocida")
+
+		// Act
+		pipeline := InitPipelinePostulacion()
+
+		// Assert
+		assert.Equal(t, []string{"recibido", "prueba_tecnica", "entrevista", "contratado", "descartado"}, pipeline.Etapas)
+		assert.Equal(t, []string{"contratado", "descartado"}, pipeline.Finales)
+	})
+
+	t.Run("debe usar el pipeline por defecto cuando no está configurado", func(t *testing.T) {
+		// Arrange
+		t.Setenv("ETAPAS_POSTULACION", "")
+
+		// Act
+		pipeline := InitPipelinePostulacion()
+
+		// Assert
+		assert.Equal(t, postulante.PipelinePorDefecto(), pipeline)
+		assert.Equal(t, postulante.EtapaRecibido, pipeline.Inicial())
+	})
+}
+
+func TestInitFicha(t *testing.T) {
+	t.Run("debe crear el generador con la configuración por defecto", func(t *testing.T) {
+		// Arrange
+		for _, variable := range []string{"FICHA_EMPRESA", "FICHA_COLOR", "FICHA_LOGO", "FICHA_PIE", "FICHA_PLANTILLA"} {
+			t.Setenv(variable, "")
+		}
+
+		// Act
+		generador, err := InitFicha()
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NotNil(t, generador)
+	})
+
+	t.Run("debe leer la plantilla desde el archivo configurado", func(t *testing.T) {
+		// Arrange
+		ruta := filepath.Join(t.TempDir(), "ficha.tmpl")
+		os.WriteFile(ruta, []byte("# {{.Titulo}}\n{{.Descripcion}}\n"), 0644)
+		t.Setenv("FICHA_PLANTILLA", ruta)
+		t.Setenv("FICHA_COLOR", "#336699")
+
+		// Act
+		generador, err := InitFicha()
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NotNil(t, generador)
+	})
+
+	t.Run("debe retornar error cuando el archivo de plantilla no existe", func(t *testing.T) {
+		// Arrange
+		t.Setenv("FICHA_PLANTILLA", filepath.Join(t.TempDir(), "no-existe.tmpl"))
+
+		// Act
+		generador, err := InitFicha()
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, generador)
+	})
+}
+
 func TestInitLogger(t *testing.T) {
 	t.Run("debe crear logger correctamente", func(t *testing.T) {
 		// Act
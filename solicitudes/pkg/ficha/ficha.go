@@ -0,0 +1,221 @@
+package ficha
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/kramirez/solicitudes/internal/solicitud"
+)
+
+// PlantillaPorDefecto es el contenido de la ficha cuando no se configura una plantilla propia.
+// Cada línea generada se interpreta según su inicio:
+//
+//	# texto   título de la ficha
+//	## texto  título de sección
+//	- texto   elemento de una lista
+//	texto     párrafo; una línea vacía agrega espacio
+const PlantillaPorDefecto = `# {{.Titulo}}
+{{.Area}} · {{.Localizacion}}, {{.Pais}}
+
+## Condiciones
+- Modalidad de trabajo: {{.ModalidadTrabajo}}
+- Renta: {{renta .RentaDesde .RentaHasta}}
+- Vacantes: {{.NumeroVacantes}}
+- Nivel de experiencia: {{.NivelExperiencia}}
+- Inicio: {{fecha .FechaInicioProyecto}}
+
+## Descripción del cargo
+{{.Descripcion}}
+
+## Requisitos
+- Formación: {{.BaseEducacional}}
+- Conocimientos excluyentes: {{.ConocimientosExcluyentes}}
+`
+
+// margen es el margen izquierdo y derecho de la página en milímetros
+const margen = 20.0
+
+// Config define la marca y el contenido de las fichas
+type Config struct {
+	// Empresa es el nombre que se muestra en el encabezado
+	Empresa string
+	// Color es el color de la marca en formato hexadecimal, por ejemplo #1F4E79
+	Color string
+	// Logo es la ruta a una imagen PNG o JPG para el encabezado; vacío omite el logo
+	Logo string
+	// Pie es el texto al final de cada página
+	Pie string
+	// Plantilla es el contenido de la ficha como text/template sobre la solicitud; vacío usa PlantillaPorDefecto
+	Plantilla string
+}
+
+// Generador crea la ficha de publicación en PDF de una solicitud
+type Generador struct {
+	config    Config
+	color     [3]int
+	plantilla *template.Template
+}
+
+// NewGenerador valida la configuración y compila la plantilla
+func NewGenerador(config Config) (*Generador, error) {
+	color, err := parseColor(config.Color)
+	if err != nil {
+		return nil, err
+	}
+	if config.Logo != "" {
+		if _, err := os.Stat(config.Logo); err != nil {
+			return nil, fmt.Errorf("no se pudo leer el logo de la ficha: %v", err)
+		}
+	}
+	if config.Plantilla == "" {
+		config.Plantilla = PlantillaPorDefecto
+	}
+	plantilla, err := template.New("ficha").Funcs(template.FuncMap{
+		"renta":  renta,
+		"moneda": moneda,
+		"fecha":  fecha,
+	}).Parse(config.Plantilla)
+	if err != nil {
+		return nil, fmt.Errorf("plantilla de ficha inválida: %v", err)
+	}
+	return &Generador{config: config, color: color, plantilla: plantilla}, nil
+}
+
+// Generar aplica la plantilla a la solicitud y retorna el PDF
+func (g *Generador) Generar(s solicitud.SolicitudResponse) ([]byte, error) {
+	var texto strings.Builder
+	if err := g.plantilla.Execute(&texto, s); err != nil {
+		return nil, fmt.Errorf("error al aplicar la plantilla de ficha: %v", err)
+	}
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	// Las fuentes estándar de PDF usan cp1252, que cubre los caracteres del español
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetTitle(tr(s.Titulo), false)
+	pdf.SetAuthor(tr(g.config.Empresa), false)
+	pdf.SetMargins(margen, 35, margen)
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.AliasNbPages("")
+	pdf.SetHeaderFunc(func() { g.encabezado(pdf, tr) })
+	pdf.SetFooterFunc(func() { g.pie(pdf, tr) })
+	pdf.AddPage()
+
+	for _, linea := range strings.Split(texto.String(), "\n") {
+		g.escribirLinea(pdf, tr, strings.TrimRight(linea, " \t\r"))
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("error al generar el PDF: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// encabezado dibuja la franja con el color de la marca, el logo y el nombre de la empresa
+func (g *Generador) encabezado(pdf *fpdf.Fpdf, tr func(string) string) {
+	ancho, _ := pdf.GetPageSize()
+	pdf.SetFillColor(g.color[0], g.color[1], g.color[2])
+	pdf.Rect(0, 0, ancho, 25, "F")
+
+	x := margen
+	if g.config.Logo != "" {
+		pdf.ImageOptions(g.config.Logo, x, 5, 0, 15, false, fpdf.ImageOptions{ReadDpi: true}, 0, "")
+		x += 45
+	}
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.SetXY(x, 8)
+	pdf.CellFormat(ancho-x-margen, 10, tr(g.config.Empresa), "", 0, "L", false, 0, "")
+	pdf.SetXY(margen, 35)
+}
+
+// pie escribe el texto configurado y el número de página
+func (g *Generador) pie(pdf *fpdf.Fpdf, tr func(string) string) {
+	pdf.SetY(-15)
+	pdf.SetFont("Helvetica", "I", 8)
+	pdf.SetTextColor(128, 128, 128)
+	pdf.CellFormat(0, 10, tr(g.config.Pie), "", 0, "L", false, 0, "")
+	pdf.SetX(margen)
+	pdf.CellFormat(0, 10, tr(fmt.Sprintf("Página %d de {nb}", pdf.PageNo())), "", 0, "R", false, 0, "")
+}
+
+// escribirLinea da formato a una línea de la plantilla según su tipo
+func (g *Generador) escribirLinea(pdf *fpdf.Fpdf, tr func(string) string, linea string) {
+	switch {
+	case strings.HasPrefix(linea, "## "):
+		pdf.Ln(4)
+		pdf.SetFont("Helvetica", "B", 13)
+		pdf.SetTextColor(g.color[0], g.color[1], g.color[2])
+		pdf.MultiCell(0, 7, tr(strings.TrimPrefix(linea, "## ")), "B", "L", false)
+		pdf.Ln(2)
+	case strings.HasPrefix(linea, "# "):
+		pdf.SetFont("Helvetica", "B", 20)
+		pdf.SetTextColor(g.color[0], g.color[1], g.color[2])
+		pdf.MultiCell(0, 10, tr(strings.TrimPrefix(linea, "# ")), "", "L", false)
+	case strings.HasPrefix(linea, "- "):
+		pdf.SetFont("Helvetica", "", 11)
+		pdf.SetTextColor(40, 40, 40)
+		pdf.CellFormat(6, 6, tr("•"), "", 0, "L", false, 0, "")
+		// Las líneas siguientes de un elemento largo quedan alineadas con la primera
+		pdf.SetLeftMargin(margen + 6)
+		pdf.MultiCell(0, 6, tr(strings.TrimPrefix(linea, "- ")), "", "L", false)
+		pdf.SetLeftMargin(margen)
+	case linea == "":
+		pdf.Ln(3)
+	default:
+		pdf.SetFont("Helvetica", "", 11)
+		pdf.SetTextColor(40, 40, 40)
+		pdf.MultiCell(0, 6, tr(linea), "", "L", false)
+	}
+}
+
+// parseColor convierte un color #RRGGBB en sus componentes; vacío usa el azul por defecto
+func parseColor(hex string) ([3]int, error) {
+	if hex == "" {
+		return [3]int{31, 78, 121}, nil
+	}
+	valor, err := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 32)
+	if err != nil || len(strings.TrimPrefix(hex, "#")) != 6 {
+		return [3]int{}, fmt.Errorf("color de ficha inválido '%s', use el formato #RRGGBB", hex)
+	}
+	return [3]int{int(valor >> 16 & 0xff), int(valor >> 8 & 0xff), int(valor & 0xff)}, nil
+}
+
+// moneda formatea un monto con separador de miles, por ejemplo 1500000 como $1.500.000
+func moneda(monto int) string {
+	digitos := strconv.Itoa(monto)
+	var partes []string
+	for len(digitos) > 3 {
+		partes = append([]string{digitos[len(digitos)-3:]}, partes...)
+		digitos = digitos[:len(digitos)-3]
+	}
+	return "$" + strings.Join(append([]string{digitos}, partes...), ".")
+}
+
+// renta describe el rango de renta; un extremo en 0 se considera no informado
+func renta(desde, hasta int) string {
+	switch {
+	case desde > 0 && hasta > 0:
+		return moneda(desde) + " a " + moneda(hasta)
+	case desde > 0:
+		return "Desde " + moneda(desde)
+	case hasta > 0:
+		return "Hasta " + moneda(hasta)
+	default:
+		return "A convenir"
+	}
+}
+
+// fecha formatea una fecha como DD/MM/AAAA
+func fecha(t time.Time) string {
+	if t.IsZero() {
+		return "Por definir"
+	}
+	return t.Format("02/01/2006")
+}
@@ -0,0 +1,126 @@
+package ficha
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/kramirez/solicitudes/internal/solicitud"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewGenerador(t *testing.T) {
+	t.Run("debe usar la plantilla y el color por defecto", func(t *testing.T) {
+		// Act
+		generador, err := NewGenerador(Config{Empresa: "Acme"})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, [3]int{31, 78, 121}, generador.color)
+		assert.Equal(t, PlantillaPorDefecto, generador.config.Plantilla)
+	})
+
+	t.Run("debe rechazar un color inválido", func(t *testing.T) {
+		// Act
+		generador, err := NewGenerador(Config{Color: "azul"})
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, generador)
+		assert.Contains(t, err.Error(), "#RRGGBB")
+	})
+
+	t.Run("debe rechazar una plantilla con errores de sintaxis", func(t *testing.T) {
+		// Act
+		generador, err := NewGenerador(Config{Plantilla: "# {{.Titulo"})
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, generador)
+		assert.Contains(t, err.Error(), "plantilla de ficha inválida")
+	})
+
+	t.Run("debe rechazar un logo inexistente", func(t *testing.T) {
+		// Act
+		generador, err := NewGenerador(Config{Logo: "/no/existe/logo.png"})
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, generador)
+	})
+}
+
+func TestGenerador_Generar(t *testing.T) {
+	solicitudDePrueba := solicitud.SolicitudResponse{
+		ID:                       1,
+		Titulo:                   "Desarrollador Backend Go",
+		Area:                     "Tecnología",
+		Pais:                     "Chile",
+		Localizacion:             "Santiago",
+		NumeroVacantes:           2,
+		Descripcion:              "Desarrollo de microservicios.\n- Diseño de APIs\n- Revisión de código",
+		BaseEducacional:          "Ingeniería en Informática",
+		ConocimientosExcluyentes: "Go, MySQL",
+		RentaDesde:               1500000,
+		RentaHasta:               2200000,
+		ModalidadTrabajo:         "híbrido",
+		NivelExperiencia:         "senior",
+		FechaInicioProyecto:      time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	t.Run("debe generar un PDF con la plantilla por defecto", func(t *testing.T) {
+		// Arrange
+		generador, err := NewGenerador(Config{Empresa: "Acme Ltda.", Pie: "Postula en acme.cl"})
+		assert.NoError(t, err)
+
+		// Act
+		pdf, err := generador.Generar(solicitudDePrueba)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-")))
+		assert.True(t, bytes.Contains(pdf, []byte("%%EOF")))
+	})
+
+	t.Run("debe retornar error cuando la plantilla usa un campo inexistente", func(t *testing.T) {
+		// Arrange
+		generador, err := NewGenerador(Config{Plantilla: "# {{.Salario}}"})
+		assert.NoError(t, err)
+
+		// Act
+		pdf, err := generador.Generar(solicitudDePrueba)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, pdf)
+	})
+}
+
+func TestFormatos(t *testing.T) {
+	t.Run("debe formatear montos con separador de miles", func(t *testing.T) {
+		assert.Equal(t, "$0", moneda(0))
+		assert.Equal(t, "$950", moneda(950))
+		assert.Equal(t, "$1.500.000", moneda(1500000))
+	})
+
+	t.Run("debe describir el rango de renta", func(t *testing.T) {
+		assert.Equal(t, "$1.500.000 a $2.200.000", renta(1500000, 2200000))
+		assert.Equal(t, "Desde $800.000", renta(800000, 0))
+		assert.Equal(t, "Hasta $800.000", renta(0, 800000))
+		assert.Equal(t, "A convenir", renta(0, 0))
+	})
+
+	t.Run("debe formatear la fecha de inicio", func(t *testing.T) {
+		assert.Equal(t, "01/12/2025", fecha(time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)))
+		assert.Equal(t, "Por definir", fecha(time.Time{}))
+	})
+
+	t.Run("debe parsear colores hexadecimales", func(t *testing.T) {
+		color, err := parseColor("#FF8000")
+		assert.NoError(t, err)
+		assert.Equal(t, [3]int{255, 128, 0}, color)
+
+		_, err = parseColor("#FFF")
+		assert.Error(t, err)
+	})
+}
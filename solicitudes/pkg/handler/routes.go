@@ -1,22 +1,28 @@
 package handler
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/kramirez/solicitudes/internal/comentario"
+	"github.com/kramirez/solicitudes/internal/postulante"
 	"github.com/kramirez/solicitudes/internal/solicitud"
 )
 
-func SetupRoutes(endpoints *solicitud.Endpoint) *gin.Engine {
+func SetupRoutes(endpoints *solicitud.Endpoint, comentarios *comentario.Endpoint, postulantes *postulante.Endpoint) *gin.Engine {
 	router := gin.Default()
 
 	// Configurar CORS (permitir todos los orígenes - solo para desarrollo)
 	router.Use(cors.New(cors.Config{
 		AllowAllOrigins:  true, //Esto es solo para ambiente de desarrollo, para producción se debe configurar los orígenes
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Usuario-ID", "X-Request-ID"},
+		ExposeHeaders:    []string{"Content-Length", "X-Request-ID"},
 		AllowCredentials: false,
 	}))
+	router.Use(requestID())
 
 	//Grupo de rutas para solicitudes
 	solicitudGroup := router.Group("/solicitudes")
@@ -25,9 +31,55 @@ func SetupRoutes(endpoints *solicitud.Endpoint) *gin.Engine {
 		solicitudGroup.GET("", endpoints.GetAll)
 		solicitudGroup.GET("/:id", endpoints.GetByID)                             // Obtiene solo la información básica
 		solicitudGroup.GET("/:id/con-documentos", endpoints.GetByIDWithDocuments) // Obtiene la solicitud con sus documentos
+		solicitudGroup.GET("/:id/checklist-documentos", endpoints.GetChecklistDocumentos)
+		solicitudGroup.GET("/:id/ficha.pdf", endpoints.GetFicha)           // Genera la ficha de publicación
+		solicitudGroup.POST("/:id/ficha.pdf", endpoints.GuardarFicha)      // Guarda la ficha como documento de la solicitud
+		solicitudGroup.POST("/:id/transiciones", endpoints.Transicionar)   // Cambia el estado según el ciclo de vida
+		solicitudGroup.GET("/:id/aprobaciones", endpoints.GetAprobaciones) // Ronda de aprobación actual
+		solicitudGroup.POST("/:id/aprobaciones/:nivel/aprobar", endpoints.Aprobar)
+		solicitudGroup.POST("/:id/aprobaciones/:nivel/rechazar", endpoints.Rechazar)
+		solicitudGroup.GET("/:id/historial", endpoints.GetHistorial) // Cambios registrados de la solicitud
+		solicitudGroup.POST("/:id/comentarios", comentarios.Crear)
+		solicitudGroup.GET("/:id/comentarios", comentarios.Listar) // Hilos de comentarios con sus respuestas
+		solicitudGroup.GET("/:id/comentarios/:comentarioId", comentarios.Get)
+		solicitudGroup.PATCH("/:id/comentarios/:comentarioId", comentarios.Editar)
+		solicitudGroup.DELETE("/:id/comentarios/:comentarioId", comentarios.Eliminar)
+		solicitudGroup.GET("/:id/comentarios/:comentarioId/ediciones", comentarios.GetEdiciones)
+		solicitudGroup.POST("/:id/postulaciones", postulantes.Postular)
+		solicitudGroup.GET("/:id/postulaciones", postulantes.GetPostulaciones)
+		solicitudGroup.GET("/:id/pipeline", postulantes.GetPipeline) // Postulaciones agrupadas por etapa
+		solicitudGroup.POST("/:id/postulaciones/:postulacionId/etapa", postulantes.CambiarEtapa)
+		solicitudGroup.GET("/:id/postulaciones/:postulacionId/etapas", postulantes.GetCambiosEtapa)
+		solicitudGroup.POST("/:id/postulaciones/:postulacionId/cv", postulantes.SubirCV) // Guarda el CV en el servicio de documentos
 		solicitudGroup.PATCH("/:id", endpoints.Update)
 		solicitudGroup.DELETE("/:id", endpoints.Delete)
 	}
 
+	//Grupo de rutas para postulantes
+	postulanteGroup := router.Group("/postulantes")
+	{
+		postulanteGroup.POST("", postulantes.Create)
+		postulanteGroup.GET("", postulantes.GetAll)
+		postulanteGroup.GET("/:id", postulantes.GetByID) // Incluye las solicitudes a las que postuló
+		postulanteGroup.PATCH("/:id", postulantes.Update)
+		postulanteGroup.DELETE("/:id", postulantes.Delete)
+	}
+
 	return router
 }
+
+// requestID asegura que cada petición tenga un identificador en la cabecera X-Request-ID, generado
+// si el cliente no lo envía, y lo devuelve en la respuesta para relacionarla con el historial
+func requestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader("X-Request-ID")
+		if id == "" || len(id) > 64 {
+			bytes := make([]byte, 16)
+			rand.Read(bytes)
+			id = hex.EncodeToString(bytes)
+			c.Request.Header.Set("X-Request-ID", id)
+		}
+		c.Header("X-Request-ID", id)
+		c.Next()
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kramirez/solicitudes/internal/comentario"
+	"github.com/kramirez/solicitudes/internal/postulante"
 	"github.com/kramirez/solicitudes/internal/solicitud"
 	"github.com/stretchr/testify/assert"
 )
@@ -17,10 +19,12 @@ func TestSetupRoutes(t *testing.T) {
 
 	// Crear un mock endpoint para las pruebas
 	mockEndpoint := &solicitud.Endpoint{}
+	mockComentarios := &comentario.Endpoint{}
+	mockPostulantes := &postulante.Endpoint{}
 
 	t.Run("debe configurar todas las rutas correctamente", func(t *testing.T) {
 		// Arrange
-		router := SetupRoutes(mockEndpoint)
+		router := SetupRoutes(mockEndpoint, mockComentarios, mockPostulantes)
 
 		// Verificar que el router se haya creado
 		assert.NotNil(t, router)
@@ -37,8 +41,33 @@ func TestSetupRoutes(t *testing.T) {
 			{"GET", "/solicitudes"},
 			{"GET", "/solicitudes/:id"},
 			{"GET", "/solicitudes/:id/con-documentos"},
+			{"GET", "/solicitudes/:id/checklist-documentos"},
+			{"GET", "/solicitudes/:id/ficha.pdf"},
+			{"POST", "/solicitudes/:id/ficha.pdf"},
+			{"POST", "/solicitudes/:id/transiciones"},
+			{"GET", "/solicitudes/:id/aprobaciones"},
+			{"POST", "/solicitudes/:id/aprobaciones/:nivel/aprobar"},
+			{"POST", "/solicitudes/:id/aprobaciones/:nivel/rechazar"},
+			{"GET", "/solicitudes/:id/historial"},
+			{"POST", "/solicitudes/:id/comentarios"},
+			{"GET", "/solicitudes/:id/comentarios"},
+			{"GET", "/solicitudes/:id/comentarios/:comentarioId"},
+			{"PATCH", "/solicitudes/:id/comentarios/:comentarioId"},
+			{"DELETE", "/solicitudes/:id/comentarios/:comentarioId"},
+			{"GET", "/solicitudes/:id/comentarios/:comentarioId/ediciones"},
+			{"POST", "/solicitudes/:id/postulaciones"},
+			{"GET", "/solicitudes/:id/postulaciones"},
+			{"GET", "/solicitudes/:id/pipeline"},
+			{"POST", "/solicitudes/:id/postulaciones/:postulacionId/etapa"},
+			{"GET", "/solicitudes/:id/postulaciones/:postulacionId/etapas"},
+			{"POST", "/solicitudes/:id/postulaciones/:postulacionId/cv"},
 			{"PATCH", "/solicitudes/:id"},
 			{"DELETE", "/solicitudes/:id"},
+			{"POST", "/postulantes"},
+			{"GET", "/postulantes"},
+			{"GET", "/postulantes/:id"},
+			{"PATCH", "/postulantes/:id"},
+			{"DELETE", "/postulantes/:id"},
 		}
 
 		// Verificar que se registraron las rutas correctas
@@ -60,7 +89,7 @@ func TestSetupRoutes(t *testing.T) {
 
 	t.Run("debe responder a rutas POST correctamente", func(t *testing.T) {
 		// Arrange
-		router := SetupRoutes(mockEndpoint)
+		router := SetupRoutes(mockEndpoint, mockComentarios, mockPostulantes)
 
 		req := httptest.NewRequest("POST", "/solicitudes", strings.NewReader(`{}`))
 		req.Header.Set("Content-Type", "application/json")
@@ -75,7 +104,7 @@ func TestSetupRoutes(t *testing.T) {
 
 	t.Run("debe responder a rutas GET correctamente", func(t *testing.T) {
 		// Arrange
-		router := SetupRoutes(mockEndpoint)
+		router := SetupRoutes(mockEndpoint, mockComentarios, mockPostulantes)
 
 		// Test GET /solicitudes
 		req := httptest.NewRequest("GET", "/solicitudes", nil)
@@ -90,7 +119,7 @@ func TestSetupRoutes(t *testing.T) {
 
 	t.Run("debe responder a rutas GET con ID correctamente", func(t *testing.T) {
 		// Arrange
-		router := SetupRoutes(mockEndpoint)
+		router := SetupRoutes(mockEndpoint, mockComentarios, mockPostulantes)
 
 		// Test GET /solicitudes/1
 		req := httptest.NewRequest("GET", "/solicitudes/1", nil)
@@ -105,7 +134,7 @@ func TestSetupRoutes(t *testing.T) {
 
 	t.Run("debe responder a rutas con documentos correctamente", func(t *testing.T) {
 		// Arrange
-		router := SetupRoutes(mockEndpoint)
+		router := SetupRoutes(mockEndpoint, mockComentarios, mockPostulantes)
 
 		// Test GET /solicitudes/1/con-documentos
 		req := httptest.NewRequest("GET", "/solicitudes/1/con-documentos", nil)
@@ -120,7 +149,7 @@ func TestSetupRoutes(t *testing.T) {
 
 	t.Run("debe responder a rutas PATCH correctamente", func(t *testing.T) {
 		// Arrange
-		router := SetupRoutes(mockEndpoint)
+		router := SetupRoutes(mockEndpoint, mockComentarios, mockPostulantes)
 
 		req := httptest.NewRequest("PATCH", "/solicitudes/1", strings.NewReader(`{}`))
 		req.Header.Set("Content-Type", "application/json")
@@ -135,7 +164,7 @@ func TestSetupRoutes(t *testing.T) {
 
 	t.Run("debe responder a rutas DELETE correctamente", func(t *testing.T) {
 		// Arrange
-		router := SetupRoutes(mockEndpoint)
+		router := SetupRoutes(mockEndpoint, mockComentarios, mockPostulantes)
 
 		req := httptest.NewRequest("DELETE", "/solicitudes/1", nil)
 		w := httptest.NewRecorder()
@@ -149,7 +178,7 @@ func TestSetupRoutes(t *testing.T) {
 
 	t.Run("debe retornar 404 para rutas inexistentes", func(t *testing.T) {
 		// Arrange
-		router := SetupRoutes(mockEndpoint)
+		router := SetupRoutes(mockEndpoint, mockComentarios, mockPostulantes)
 
 		req := httptest.NewRequest("GET", "/ruta-inexistente", nil)
 		w := httptest.NewRecorder()
@@ -161,9 +190,26 @@ func TestSetupRoutes(t *testing.T) {
 		assert.Equal(t, http.StatusNotFound, w.Code, "Rutas inexistentes deben retornar 404")
 	})
 
+	t.Run("debe asignar un X-Request-ID a cada petición", func(t *testing.T) {
+		// Arrange
+		router := SetupRoutes(mockEndpoint, mockComentarios, mockPostulantes)
+
+		// Act
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/ruta-inexistente", nil))
+		propio := httptest.NewRequest("GET", "/ruta-inexistente", nil)
+		propio.Header.Set("X-Request-ID", "req-cliente")
+		wPropio := httptest.NewRecorder()
+		router.ServeHTTP(wPropio, propio)
+
+		// Assert
+		assert.Len(t, w.Header().Get("X-Request-ID"), 32, "Sin cabecera se genera un identificador")
+		assert.Equal(t, "req-cliente", wPropio.Header().Get("X-Request-ID"), "Se respeta el identificador del cliente")
+	})
+
 	t.Run("debe agrupar correctamente las rutas bajo /solicitudes", func(t *testing.T) {
 		// Arrange
-		router := SetupRoutes(mockEndpoint)
+		router := SetupRoutes(mockEndpoint, mockComentarios, mockPostulantes)
 		routes := router.Routes()
 
 		// Act & Assert - Verificar que todas las rutas están bajo el grupo /solicitudes
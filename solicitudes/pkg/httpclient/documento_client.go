@@ -1,9 +1,13 @@
 package httpclient
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/kramirez/solicitudes/internal/solicitud"
@@ -15,6 +19,8 @@ type DocumentoDTO struct {
 	Extension     string `json:"extension"`
 	NombreArchivo string `json:"nombre_archivo"`
 	SolicitudID   uint   `json:"solicitud_id"`
+	Categoria     string `json:"categoria"`
+	URLMiniatura  string `json:"url_miniatura"`
 }
 
 // DocumentoClient implementa la interfaz solicitud.DocumentoClient
@@ -29,6 +35,8 @@ func (d *DocumentoDTO) toSolicitudDocumento() solicitud.Documento {
 		ID:            d.ID,
 		NombreArchivo: d.NombreArchivo,
 		Extension:     d.Extension,
+		Categoria:     d.Categoria,
+		URLMiniatura:  d.URLMiniatura,
 	}
 }
 
@@ -44,7 +52,6 @@ func NewDocumentoClient(baseURL string) *DocumentoClient {
 // GetBySolicitudID obtiene los documentos de una solicitud
 func (c *DocumentoClient) GetBySolicitudID(solicitudID uint) ([]solicitud.Documento, error) {
 	var documentosDTO []DocumentoDTO
-	var documentos []solicitud.Documento
 	
 	// Construir la URL para obtener los documentos de la solicitud
 	url := fmt.Sprintf("%s/documentos?solicitud_id=%d", c.baseURL, solicitudID)
@@ -76,6 +83,7 @@ func (c *DocumentoClient) GetBySolicitudID(solicitudID uint) ([]solicitud.Docume
 	}
 
 	// Convertir DTOs a modelos de dominio
+	documentos := make([]solicitud.Documento, 0, len(documentosDTO))
 	for _, dto := range documentosDTO {
 		documentos = append(documentos, dto.toSolicitudDocumento())
 	}
@@ -110,4 +118,71 @@ func (c *DocumentoClient) DeleteBySolicitudID(solicitudID uint) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// SubirDocumento crea un documento de la solicitud con el contenido indicado
+func (c *DocumentoClient) SubirDocumento(solicitudID uint, nombreArchivo string, contenido []byte) (*solicitud.Documento, error) {
+	return c.SubirDocumentoCategoria(solicitudID, nombreArchivo, "", bytes.NewReader(contenido))
+}
+
+// SubirDocumentoCategoria crea un documento de la solicitud en la categoría indicada, como los CV de los postulantes.
+// El contenido se envía a medida que se lee, sin cargarlo completo en memoria.
+func (c *DocumentoClient) SubirDocumentoCategoria(solicitudID uint, nombreArchivo, categoria string, contenido io.Reader) (*solicitud.Documento, error) {
+	// Armar el formulario multipart que espera el servicio de documentos mientras se envía
+	lector, escritor := io.Pipe()
+	writer := multipart.NewWriter(escritor)
+	go func() {
+		escritor.CloseWithError(escribirFormulario(writer, solicitudID, nombreArchivo, categoria, contenido))
+	}()
+
+	req, err := http.NewRequest("POST", c.baseURL+"/documentos", lector)
+	if err != nil {
+		lector.Close()
+		return nil, fmt.Errorf("error al crear la petición: %v", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	// Realizar la petición
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error al conectar con el servicio de documentos: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// Verificar el código de estado, incluyendo el motivo del rechazo si lo hay
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		var respuesta struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&respuesta)
+		return nil, fmt.Errorf("error al subir el documento: status %d %s", resp.StatusCode, respuesta.Error)
+	}
+
+	var dto DocumentoDTO
+	if err := json.NewDecoder(resp.Body).Decode(&dto); err != nil {
+		return nil, fmt.Errorf("error al decodificar respuesta: %v", err)
+	}
+
+	documento := dto.toSolicitudDocumento()
+	return &documento, nil
+}
+
+// escribirFormulario escribe el formulario multipart de la carga de un documento
+func escribirFormulario(writer *multipart.Writer, solicitudID uint, nombreArchivo, categoria string, contenido io.Reader) error {
+	if err := writer.WriteField("solicitud_id", strconv.FormatUint(uint64(solicitudID), 10)); err != nil {
+		return fmt.Errorf("error al armar la petición: %v", err)
+	}
+	if categoria != "" {
+		if err := writer.WriteField("categoria", categoria); err != nil {
+			return fmt.Errorf("error al armar la petición: %v", err)
+		}
+	}
+	parte, err := writer.CreateFormFile("archivo", nombreArchivo)
+	if err != nil {
+		return fmt.Errorf("error al armar la petición: %v", err)
+	}
+	if _, err := io.Copy(parte, contenido); err != nil {
+		return fmt.Errorf("error al leer el archivo: %v", err)
+	}
+	return writer.Close()
+}
@@ -3,8 +3,10 @@ package httpclient
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -50,6 +52,8 @@ func TestDocumentoDTO_toSolicitudDocumento(t *testing.T) {
 			Extension:     "jpg",
 			NombreArchivo: "imagen_compleja_nombre.jpg",
 			SolicitudID:   555,
+			Categoria:     "descripcion_cargo",
+			URLMiniatura:  "http://localhost:8083/documentos/999/miniatura?v=2",
 		}
 
 		// Act
@@ -59,6 +63,8 @@ func TestDocumentoDTO_toSolicitudDocumento(t *testing.T) {
 		assert.Equal(t, dto.ID, documento.ID)
 		assert.Equal(t, dto.Extension, documento.Extension)
 		assert.Equal(t, dto.NombreArchivo, documento.NombreArchivo)
+		assert.Equal(t, dto.Categoria, documento.Categoria)
+		assert.Equal(t, dto.URLMiniatura, documento.URLMiniatura)
 	})
 }
 
@@ -104,7 +110,7 @@ func TestDocumentoClient_GetBySolicitudID(t *testing.T) {
 		// Arrange - Mock server
 		mockDocumentos := []DocumentoDTO{
 			{ID: 1, Extension: "pdf", NombreArchivo: "doc1.pdf", SolicitudID: 100},
-			{ID: 2, Extension: "jpg", NombreArchivo: "img1.jpg", SolicitudID: 100},
+			{ID: 2, Extension: "jpg", NombreArchivo: "img1.jpg", SolicitudID: 100, URLMiniatura: "/documentos/2/miniatura?v=1"},
 		}
 
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -134,6 +140,8 @@ func TestDocumentoClient_GetBySolicitudID(t *testing.T) {
 		assert.Equal(t, "doc1.pdf", documentos[0].NombreArchivo)
 		assert.Equal(t, uint(2), documentos[1].ID)
 		assert.Equal(t, "jpg", documentos[1].Extension)
+		assert.Empty(t, documentos[0].URLMiniatura)
+		assert.Equal(t, "/documentos/2/miniatura?v=1", documentos[1].URLMiniatura)
 	})
 
 	t.Run("debe manejar respuesta vacía correctamente", func(t *testing.T) {
@@ -155,6 +163,26 @@ func TestDocumentoClient_GetBySolicitudID(t *testing.T) {
 		assert.Len(t, documentos, 0)
 	})
 
+	t.Run("debe retornar una lista vacía y no nil cuando la solicitud no tiene documentos", func(t *testing.T) {
+		// Arrange - El servicio de documentos responde null si no hay documentos
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("null"))
+		}))
+		defer server.Close()
+
+		client := NewDocumentoClient(server.URL)
+
+		// Act
+		documentos, err := client.GetBySolicitudID(999)
+
+		// Assert - Al serializarla la respuesta debe ser [] y no null
+		assert.NoError(t, err)
+		assert.NotNil(t, documentos)
+		assert.Empty(t, documentos)
+	})
+
 	t.Run("debe manejar error de conexión", func(t *testing.T) {
 		// Arrange - URL inválida
 		client := NewDocumentoClient("http://servidor-inexistente:9999")
@@ -288,3 +316,99 @@ func TestDocumentoClient_GetBySolicitudID(t *testing.T) {
 		assert.Equal(t, "application/json", capturedHeaders.Get("Content-Type"))
 	})
 }
+
+func TestDocumentoClient_SubirDocumento(t *testing.T) {
+	t.Run("debe subir el archivo como formulario multipart", func(t *testing.T) {
+		// Arrange - Mock server que valida el formulario
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "POST", r.Method)
+			assert.Equal(t, "/documentos", r.URL.Path)
+			assert.Equal(t, "12", r.FormValue("solicitud_id"))
+			assert.Empty(t, r.FormValue("categoria"))
+
+			archivo, header, err := r.FormFile("archivo")
+			assert.NoError(t, err)
+			assert.Equal(t, "ficha-solicitud-12.pdf", header.Filename)
+			contenido, _ := io.ReadAll(archivo)
+			assert.Equal(t, "%PDF-1.3", string(contenido))
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(DocumentoDTO{ID: 40, NombreArchivo: "ficha-solicitud-12", Extension: "pdf", SolicitudID: 12})
+		}))
+		defer server.Close()
+
+		client := NewDocumentoClient(server.URL)
+
+		// Act
+		documento, err := client.SubirDocumento(12, "ficha-solicitud-12.pdf", []byte("%PDF-1.3"))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, uint(40), documento.ID)
+		assert.Equal(t, "ficha-solicitud-12", documento.NombreArchivo)
+		assert.Equal(t, "pdf", documento.Extension)
+	})
+
+	t.Run("debe enviar la categoría del documento", func(t *testing.T) {
+		// Arrange - Mock server que valida la categoría
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "12", r.FormValue("solicitud_id"))
+			assert.Equal(t, "cv", r.FormValue("categoria"))
+			archivo, fileHeader, err := r.FormFile("archivo")
+			assert.NoError(t, err)
+			contenido, _ := io.ReadAll(archivo)
+			assert.Equal(t, "cv-postulante-3.pdf", fileHeader.Filename)
+			assert.Equal(t, "%PDF-1.3", string(contenido))
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(DocumentoDTO{ID: 41, NombreArchivo: "cv-postulante-3", Extension: "pdf", SolicitudID: 12, Categoria: "cv"})
+		}))
+		defer server.Close()
+
+		client := NewDocumentoClient(server.URL)
+
+		// Act
+		documento, err := client.SubirDocumentoCategoria(12, "cv-postulante-3.pdf", "cv", strings.NewReader("%PDF-1.3"))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, uint(41), documento.ID)
+		assert.Equal(t, "cv", documento.Categoria)
+	})
+
+	t.Run("debe incluir el motivo cuando el servicio rechaza el archivo", func(t *testing.T) {
+		// Arrange - Mock server que rechaza la subida
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			json.NewEncoder(w).Encode(map[string]string{"error": "la solicitud superó su cuota"})
+		}))
+		defer server.Close()
+
+		client := NewDocumentoClient(server.URL)
+
+		// Act
+		documento, err := client.SubirDocumento(12, "ficha-solicitud-12.pdf", []byte("%PDF-1.3"))
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, documento)
+		assert.Contains(t, err.Error(), "status 413")
+		assert.Contains(t, err.Error(), "la solicitud superó su cuota")
+	})
+
+	t.Run("debe manejar error de conexión", func(t *testing.T) {
+		// Arrange - URL inválida
+		client := NewDocumentoClient("http://servidor-inexistente:9999")
+
+		// Act
+		documento, err := client.SubirDocumento(12, "ficha.pdf", []byte("%PDF"))
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, documento)
+		assert.Contains(t, err.Error(), "error al conectar con el servicio")
+	})
+}